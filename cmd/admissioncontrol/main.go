@@ -3,8 +3,10 @@ package main
 import (
 	"errors"
 	"os"
+	"strings"
 
 	admissioncontrol "github.com/EdgeNet-project/edgenet/pkg/admissioncontrol"
+	"github.com/EdgeNet-project/edgenet/pkg/bootstrap"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -34,5 +36,15 @@ func main() {
 	webhook.KeyFile = tlsKey
 	webhook.Codecs = serializer.NewCodecFactory(runtime.NewScheme())
 	webhook.Runtime = containerRuntime
+	// The clientset looks up the priority class that the pods inherit from their tenant
+	var authentication string
+	if authentication = strings.TrimSpace(os.Getenv("AUTHENTICATION_STRATEGY")); authentication != "kubeconfig" {
+		authentication = "serviceaccount"
+	}
+	if config, err := bootstrap.GetRestConfig(authentication); err != nil {
+		klog.Infof("Running admission control webhook without tenant priority classes: %s", err.Error())
+	} else if kubeclientset, err := bootstrap.CreateKubeClientset(config); err == nil {
+		webhook.Kubeclientset = kubeclientset
+	}
 	webhook.RunServer()
 }
@@ -28,7 +28,10 @@ import (
 
 	"github.com/EdgeNet-project/edgenet/pkg/bootstrap"
 	"github.com/EdgeNet-project/edgenet/pkg/controller/federation/v1alpha1/clusterlabeler"
+	"github.com/EdgeNet-project/edgenet/pkg/deadline"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	"github.com/EdgeNet-project/edgenet/pkg/signals"
 	"k8s.io/klog"
 )
@@ -36,6 +39,10 @@ import (
 func main() {
 	klog.InitFlags(nil)
 	flag.String("kubeconfig-path", bootstrap.GetDefaultKubeconfigPath(), "Path to the kubeconfig file's directory")
+	paused := flag.Bool("paused", os.Getenv("PAUSED") == "true", "Keep the controller running without reconciling objects, for cluster maintenance")
+	maintenanceConfigMap := flag.String("maintenance-configmap", os.Getenv("MAINTENANCE_CONFIGMAP"), "Config map, as namespace/name, whose paused key pauses and resumes the reconciliation, empty disables it")
+	eventSink := flag.String("event-sink", os.Getenv("EVENT_SINK"), "Sink to stream the recorded events to as JSON lines, stdout or the path of a file, empty disables it")
+	reconcileDeadline := deadline.Flag()
 	flag.Parse()
 
 	stopCh := signals.SetupSignalHandler()
@@ -48,6 +55,7 @@ func main() {
 		log.Println(err.Error())
 		panic(err.Error())
 	}
+	deadline.Bound(config)
 	kubeclientset, err := bootstrap.CreateKubeClientset(config)
 	if err != nil {
 		log.Println(err.Error())
@@ -69,7 +77,7 @@ func main() {
 	// Start the controller to provide the functionalities of clusterlabeler resource
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, time.Hour*1)
 
-	controller := clusterlabeler.NewController(
+	controller, err := clusterlabeler.NewController(
 		kubeclientset,
 		edgenetclientset,
 		edgenetInformerFactory.Federation().V1alpha1().Clusters(),
@@ -77,9 +85,19 @@ func main() {
 		maxmindAccountId,
 		maxmindLicenseKey,
 	)
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	edgenetInformerFactory.Start(stopCh)
 
+	if err := maintenance.Start(kubeclientset, *paused, *maintenanceConfigMap, stopCh); err != nil {
+		klog.Fatalf("Error starting maintenance mode: %s", err.Error())
+	}
+	deadline.Set(*reconcileDeadline)
+	if err := eventsink.Start(*eventSink); err != nil {
+		klog.Fatalf("Error starting event sink: %s", err.Error())
+	}
 	if err = controller.Run(2, stopCh); err != nil {
 		klog.Fatalf("Error running controller: %s", err.Error())
 	}
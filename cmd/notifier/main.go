@@ -21,6 +21,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/EdgeNet-project/edgenet/pkg/bootstrap"
 	"github.com/EdgeNet-project/edgenet/pkg/controller/registration/v1alpha1/notifier"
@@ -37,6 +38,11 @@ func main() {
 	flag.String("slack-token-path", "/edgenet/credentials/slack/token", "Path to the auth token for Slack")
 	flag.String("slack-channel-id-path", "/edgenet/credentials/slack/channelid", "Path to Slack channel ID")
 	flag.String("template-path", "/edgenet/assets/templates/email", "Path to the email templates")
+	var defaultEscalationTimeout time.Duration
+	if timeout, err := time.ParseDuration(os.Getenv("ESCALATION_TIMEOUT")); err == nil {
+		defaultEscalationTimeout = timeout
+	}
+	escalationTimeout := flag.Duration("escalation-timeout", defaultEscalationTimeout, "Time a role request stays pending with only its primary approvers notified before the others are notified, zero notifies all approvers at once")
 	flag.Parse()
 
 	stopCh := signals.SetupSignalHandler()
@@ -63,12 +69,16 @@ func main() {
 	// Start the controller to provide the functionalities of notifier controller
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
 
-	controller := notifier.NewController(
+	controller, err := notifier.NewController(
 		kubeclientset,
 		edgenetclientset,
 		edgenetInformerFactory.Registration().V1alpha1().TenantRequests(),
 		edgenetInformerFactory.Registration().V1alpha1().RoleRequests(),
-		edgenetInformerFactory.Registration().V1alpha1().ClusterRoleRequests())
+		edgenetInformerFactory.Registration().V1alpha1().ClusterRoleRequests(),
+		*escalationTimeout)
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	edgenetInformerFactory.Start(stopCh)
 
@@ -4,12 +4,18 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/EdgeNet-project/edgenet/pkg/bootstrap"
 	"github.com/EdgeNet-project/edgenet/pkg/controller/registration/v1alpha1/rolerequest"
+	"github.com/EdgeNet-project/edgenet/pkg/deadline"
+	"github.com/EdgeNet-project/edgenet/pkg/eventcleanup"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
 	"github.com/EdgeNet-project/edgenet/pkg/signals"
 
 	"k8s.io/klog"
@@ -18,6 +24,38 @@ import (
 func main() {
 	klog.InitFlags(nil)
 	flag.String("kubeconfig-path", bootstrap.GetDefaultKubeconfigPath(), "Path to the kubeconfig file's directory")
+	defaultTenantLabelKeys := strings.Join(multitenancy.DefaultTenantLabelKeys, ",")
+	if labelKeys, ok := os.LookupEnv("TENANT_LABEL_KEYS"); ok {
+		defaultTenantLabelKeys = labelKeys
+	}
+	tenantLabelKeys := flag.String("tenant-label-keys", defaultTenantLabelKeys, "Comma-separated list of tenant labels to copy to the objects generated for the tenant")
+	var defaultReminderInterval time.Duration
+	if interval, err := time.ParseDuration(os.Getenv("REMINDER_INTERVAL")); err == nil {
+		defaultReminderInterval = interval
+	}
+	reminderInterval := flag.Duration("reminder-interval", defaultReminderInterval, "Interval at which the approvers of a role request still pending approval are notified again, zero disables the reminders")
+	defaultMaxReminders := 3
+	if max, err := strconv.Atoi(os.Getenv("MAX_REMINDERS")); err == nil {
+		defaultMaxReminders = max
+	}
+	maxReminders := flag.Int("max-reminders", defaultMaxReminders, "Maximum number of reminders sent for a role request")
+	memberKubeconfigs := flag.String("member-kubeconfigs", os.Getenv("MEMBER_KUBECONFIGS"), "Comma-separated list of name=path of the kubeconfigs of the member clusters to propagate the role bindings to, empty disables the propagation")
+	readHost := flag.String("read-host", os.Getenv("READ_HOST"), "Host of a cache or replica of the API server to serve gets and lists, empty reads from the API server")
+	paused := flag.Bool("paused", os.Getenv("PAUSED") == "true", "Keep the controller running without reconciling objects, for cluster maintenance")
+	maintenanceConfigMap := flag.String("maintenance-configmap", os.Getenv("MAINTENANCE_CONFIGMAP"), "Config map, as namespace/name, whose paused key pauses and resumes the reconciliation, empty disables it")
+	eventSink := flag.String("event-sink", os.Getenv("EVENT_SINK"), "Sink to stream the recorded events to as JSON lines, stdout or the path of a file, empty disables it")
+	var defaultEventTTL time.Duration
+	if eventTTL, err := time.ParseDuration(os.Getenv("EVENT_TTL")); err == nil {
+		defaultEventTTL = eventTTL
+	}
+	eventTTL := flag.Duration("event-ttl", defaultEventTTL, "Age after which the events recorded by the controller are pruned, zero disables it")
+	reconcileDeadline := deadline.Flag()
+	var defaultStuckThreshold int
+	if threshold, err := strconv.Atoi(os.Getenv("STUCK_THRESHOLD")); err == nil {
+		defaultStuckThreshold = threshold
+	}
+	stuckThreshold := flag.Int("stuck-threshold", defaultStuckThreshold, "Number of failed syncs in a row after which a role request is reported as stuck, zero disables it")
+	notifyStuck := flag.Bool("notify-stuck", os.Getenv("NOTIFY_STUCK") == "true", "Notify the cluster administrators of the role requests reported as stuck")
 	flag.Parse()
 
 	stopCh := signals.SetupSignalHandler()
@@ -30,6 +68,7 @@ func main() {
 		log.Println(err.Error())
 		panic(err.Error())
 	}
+	deadline.Bound(config)
 	kubeclientset, err := bootstrap.CreateKubeClientset(config)
 	if err != nil {
 		log.Println(err.Error())
@@ -40,16 +79,46 @@ func main() {
 		log.Println(err.Error())
 		panic(err.Error())
 	}
+	readKubeclientset, readEdgenetclientset, err := bootstrap.CreateReadClientsets(config, *readHost)
+	if err != nil {
+		log.Println(err.Error())
+		panic(err.Error())
+	}
+	memberClientsets, err := bootstrap.CreateMemberClientsets(*memberKubeconfigs)
+	if err != nil {
+		log.Println(err.Error())
+		panic(err.Error())
+	}
 
 	// Start the controller to provide the functionalities of rolerequest resource
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, time.Second*30)
 
-	controller := rolerequest.NewController(kubeclientset,
+	controller, err := rolerequest.NewController(kubeclientset,
 		edgenetclientset,
-		edgenetInformerFactory.Registration().V1alpha1().RoleRequests())
+		edgenetInformerFactory.Registration().V1alpha1().RoleRequests(),
+		strings.Split(*tenantLabelKeys, ","),
+		rolerequest.NeverAutoApprove{},
+		*reminderInterval,
+		*maxReminders,
+		readKubeclientset,
+		readEdgenetclientset,
+		memberClientsets,
+		*stuckThreshold,
+		*notifyStuck)
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	edgenetInformerFactory.Start(stopCh)
 
+	if err := maintenance.Start(kubeclientset, *paused, *maintenanceConfigMap, stopCh); err != nil {
+		klog.Fatalf("Error starting maintenance mode: %s", err.Error())
+	}
+	deadline.Set(*reconcileDeadline)
+	if err := eventsink.Start(*eventSink); err != nil {
+		klog.Fatalf("Error starting event sink: %s", err.Error())
+	}
+	eventcleanup.Start(kubeclientset, "", "rolerequest-controller", *eventTTL, stopCh)
 	if err = controller.Run(2, stopCh); err != nil {
 		klog.Fatalf("Error running controller: %s", err.Error())
 	}
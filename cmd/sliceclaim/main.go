@@ -10,7 +10,11 @@ import (
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
 	"github.com/EdgeNet-project/edgenet/pkg/bootstrap"
 	"github.com/EdgeNet-project/edgenet/pkg/controller/core/v1alpha1/sliceclaim"
+	"github.com/EdgeNet-project/edgenet/pkg/deadline"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
 	"github.com/EdgeNet-project/edgenet/pkg/signals"
 
 	"k8s.io/klog"
@@ -19,7 +23,20 @@ import (
 func main() {
 	klog.InitFlags(nil)
 	flag.String("kubeconfig-path", bootstrap.GetDefaultKubeconfigPath(), "Path to the kubeconfig file's directory")
-	provisioning := flag.String("provisioning", corev1alpha1.DynamicStr, "Working mode to automate slice creation")
+	provisioning := flag.String("provisioning", corev1alpha1.DynamicStr, "Working mode to automate slice creation, either Dynamic or Manual")
+	defaultQuotaNames := multitenancy.DefaultQuotaNames
+	if name, ok := os.LookupEnv("CORE_QUOTA_NAME"); ok {
+		defaultQuotaNames.Core = name
+	}
+	if name, ok := os.LookupEnv("SUB_QUOTA_NAME"); ok {
+		defaultQuotaNames.Sub = name
+	}
+	coreQuotaName := flag.String("core-quota-name", defaultQuotaNames.Core, "Name of the resource quota holding the share of a tenant in its core namespace")
+	subQuotaName := flag.String("sub-quota-name", defaultQuotaNames.Sub, "Name of the resource quota holding the share of a subnamespace in its child namespace")
+	paused := flag.Bool("paused", os.Getenv("PAUSED") == "true", "Keep the controller running without reconciling objects, for cluster maintenance")
+	maintenanceConfigMap := flag.String("maintenance-configmap", os.Getenv("MAINTENANCE_CONFIGMAP"), "Config map, as namespace/name, whose paused key pauses and resumes the reconciliation, empty disables it")
+	eventSink := flag.String("event-sink", os.Getenv("EVENT_SINK"), "Sink to stream the recorded events to as JSON lines, stdout or the path of a file, empty disables it")
+	reconcileDeadline := deadline.Flag()
 	flag.Parse()
 
 	stopCh := signals.SetupSignalHandler()
@@ -32,6 +49,7 @@ func main() {
 		log.Println(err.Error())
 		panic(err.Error())
 	}
+	deadline.Bound(config)
 	kubeclientset, err := bootstrap.CreateKubeClientset(config)
 	if err != nil {
 		log.Println(err.Error())
@@ -46,14 +64,25 @@ func main() {
 	// Start the controller to provide the functionalities of sliceclaim resource
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, time.Second*30)
 
-	controller := sliceclaim.NewController(kubeclientset,
+	controller, err := sliceclaim.NewController(kubeclientset,
 		edgenetclientset,
 		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
 		edgenetInformerFactory.Core().V1alpha1().SliceClaims(),
-		*provisioning)
+		*provisioning,
+		multitenancy.QuotaNames{Core: *coreQuotaName, Sub: *subQuotaName})
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	edgenetInformerFactory.Start(stopCh)
 
+	if err := maintenance.Start(kubeclientset, *paused, *maintenanceConfigMap, stopCh); err != nil {
+		klog.Fatalf("Error starting maintenance mode: %s", err.Error())
+	}
+	deadline.Set(*reconcileDeadline)
+	if err := eventsink.Start(*eventSink); err != nil {
+		klog.Fatalf("Error starting event sink: %s", err.Error())
+	}
 	if err = controller.Run(2, stopCh); err != nil {
 		klog.Fatalf("Error running controller: %s", err.Error())
 	}
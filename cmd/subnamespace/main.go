@@ -9,7 +9,11 @@ import (
 
 	"github.com/EdgeNet-project/edgenet/pkg/bootstrap"
 	"github.com/EdgeNet-project/edgenet/pkg/controller/core/v1alpha1/subnamespace"
+	"github.com/EdgeNet-project/edgenet/pkg/deadline"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
 	"github.com/EdgeNet-project/edgenet/pkg/signals"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,8 +25,52 @@ import (
 func main() {
 	klog.InitFlags(nil)
 	flag.String("kubeconfig-path", bootstrap.GetDefaultKubeconfigPath(), "Path to the kubeconfig file's directory")
+	copyQuotaScopes := flag.Bool("copy-quota-scopes", true, "Set the scopes of the parent quota on the child quota of a workspace, so that both count the same kind of objects")
+	rejectEmpty := flag.Bool("reject-empty", false, "Fail subnamespaces that request neither resources nor inheritance, rather than only warning about them")
+	minimumAllocation := flag.String("minimum-allocation", os.Getenv("MINIMUM_ALLOCATION"), "Comma-separated list of the smallest quantity of each resource a subnamespace can be allocated, such as cpu=100m,memory=64Mi")
+	childOnlyResources := flag.String("child-only-resources", os.Getenv("CHILD_ONLY_RESOURCES"), "Comma-separated list of resources, such as pods, that only limit the child and are not debited from the parent quota, empty disables it")
+	bumpBelowMinimum := flag.Bool("bump-below-minimum", false, "Raise allocations below the minimum to it, rather than failing the subnamespace")
+	repairMissingChild := flag.Bool("repair-missing-child", true, "Re-create child namespaces deleted out-of-band, rather than only reporting them in the subnamespace status")
+	quotaRounding := flag.String("quota-rounding", os.Getenv("QUOTA_ROUNDING"), "Rounding policy for allocations that do not divide evenly into millicores or bytes: floor, ceil, or nearest")
+	defaultTenantLabelKeys := strings.Join(multitenancy.DefaultTenantLabelKeys, ",")
+	if labelKeys, ok := os.LookupEnv("TENANT_LABEL_KEYS"); ok {
+		defaultTenantLabelKeys = labelKeys
+	}
+	tenantLabelKeys := flag.String("tenant-label-keys", defaultTenantLabelKeys, "Comma-separated list of tenant labels to copy to the objects generated for the tenant")
+	var defaultResyncPeriod time.Duration
+	if resyncPeriod, err := time.ParseDuration(os.Getenv("RESYNC_PERIOD")); err == nil {
+		defaultResyncPeriod = resyncPeriod
+	}
+	resyncPeriod := flag.Duration("resync-period", defaultResyncPeriod, "Interval at which subnamespaces are processed again to revert changes made to their generated objects, zero disables it")
+	var defaultDebounceInterval time.Duration
+	if debounceInterval, err := time.ParseDuration(os.Getenv("DEBOUNCE_INTERVAL")); err == nil {
+		defaultDebounceInterval = debounceInterval
+	}
+	debounceInterval := flag.Duration("debounce-interval", defaultDebounceInterval, "Interval within which the spec changes of a subnamespace are coalesced into a single reconcile, zero disables it")
+	defaultQuotaNames := multitenancy.DefaultQuotaNames
+	if name, ok := os.LookupEnv("CORE_QUOTA_NAME"); ok {
+		defaultQuotaNames.Core = name
+	}
+	if name, ok := os.LookupEnv("SUB_QUOTA_NAME"); ok {
+		defaultQuotaNames.Sub = name
+	}
+	coreQuotaName := flag.String("core-quota-name", defaultQuotaNames.Core, "Name of the resource quota holding the share of a tenant in its core namespace")
+	subQuotaName := flag.String("sub-quota-name", defaultQuotaNames.Sub, "Name of the resource quota holding the share of a subnamespace in its child namespace")
+	paused := flag.Bool("paused", os.Getenv("PAUSED") == "true", "Keep the controller running without reconciling objects, for cluster maintenance")
+	maintenanceConfigMap := flag.String("maintenance-configmap", os.Getenv("MAINTENANCE_CONFIGMAP"), "Config map, as namespace/name, whose paused key pauses and resumes the reconciliation, empty disables it")
+	eventSink := flag.String("event-sink", os.Getenv("EVENT_SINK"), "Sink to stream the recorded events to as JSON lines, stdout or the path of a file, empty disables it")
+	reconcileDeadline := deadline.Flag()
 	flag.Parse()
 
+	quotaRoundingPolicy, err := multitenancy.ParseRoundingPolicy(*quotaRounding)
+	if err != nil {
+		klog.Fatalf("Error parsing quota rounding policy: %s", err.Error())
+	}
+	minimumResourceList, err := multitenancy.ParseResourceList(*minimumAllocation)
+	if err != nil {
+		klog.Fatalf("Error parsing minimum allocation: %s", err.Error())
+	}
+
 	stopCh := signals.SetupSignalHandler()
 	var authentication string
 	if authentication = strings.TrimSpace(os.Getenv("AUTHENTICATION_STRATEGY")); authentication != "kubeconfig" {
@@ -33,6 +81,7 @@ func main() {
 		log.Println(err.Error())
 		panic(err.Error())
 	}
+	deadline.Bound(config)
 	kubeclientset, err := bootstrap.CreateKubeClientset(config)
 	if err != nil {
 		log.Println(err.Error())
@@ -53,7 +102,7 @@ func main() {
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(kubeclientset, time.Second*30, informerOption)
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, time.Second*30)
 
-	controller := subnamespace.NewController(kubeclientset,
+	controller, err := subnamespace.NewController(kubeclientset,
 		edgenetclientset,
 		kubeInformerFactory.Rbac().V1().Roles(),
 		kubeInformerFactory.Rbac().V1().RoleBindings(),
@@ -62,11 +111,34 @@ func main() {
 		kubeInformerFactory.Core().V1().Secrets(),
 		kubeInformerFactory.Core().V1().ConfigMaps(),
 		kubeInformerFactory.Core().V1().ServiceAccounts(),
-		edgenetInformerFactory.Core().V1alpha1().SubNamespaces())
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		subnamespace.Options{
+			QuotaRounding:      quotaRoundingPolicy,
+			RepairMissingChild: *repairMissingChild,
+			TenantLabelKeys:    strings.Split(*tenantLabelKeys, ","),
+			ResyncPeriod:       *resyncPeriod,
+			QuotaNames:         multitenancy.QuotaNames{Core: *coreQuotaName, Sub: *subQuotaName},
+			CopyQuotaScopes:    *copyQuotaScopes,
+			RejectEmpty:        *rejectEmpty,
+			MinimumAllocation:  minimumResourceList,
+			BumpBelowMinimum:   *bumpBelowMinimum,
+			ChildOnlyResources: multitenancy.ParseResourceNames(*childOnlyResources),
+			DebounceInterval:   *debounceInterval,
+		})
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	kubeInformerFactory.Start(stopCh)
 	edgenetInformerFactory.Start(stopCh)
 
+	if err := maintenance.Start(kubeclientset, *paused, *maintenanceConfigMap, stopCh); err != nil {
+		klog.Fatalf("Error starting maintenance mode: %s", err.Error())
+	}
+	deadline.Set(*reconcileDeadline)
+	if err := eventsink.Start(*eventSink); err != nil {
+		klog.Fatalf("Error starting event sink: %s", err.Error())
+	}
 	if err = controller.Run(2, stopCh); err != nil {
 		klog.Fatalf("Error running controller: %s", err.Error())
 	}
@@ -5,11 +5,17 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"k8s.io/klog"
 
 	"github.com/EdgeNet-project/edgenet/pkg/bootstrap"
 	"github.com/EdgeNet-project/edgenet/pkg/controller/core/v1alpha1/tenant"
+	"github.com/EdgeNet-project/edgenet/pkg/deadline"
+	"github.com/EdgeNet-project/edgenet/pkg/eventcleanup"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
 	"github.com/EdgeNet-project/edgenet/pkg/signals"
 
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
@@ -18,8 +24,36 @@ import (
 func main() {
 	klog.InitFlags(nil)
 	flag.String("kubeconfig-path", bootstrap.GetDefaultKubeconfigPath(), "Path to the kubeconfig file's directory")
+	allowedEmailDomains := flag.String("allowed-email-domains", os.Getenv("ALLOWED_EMAIL_DOMAINS"), "Comma-separated list of email domains allowed for tenant contacts, empty allows any domain")
+	eventNamespace := flag.String("events-namespace", os.Getenv("EVENTS_NAMESPACE"), "Namespace to record events in, empty records them in the namespace of the involved object")
+	defaultTenantLabelKeys := strings.Join(multitenancy.DefaultTenantLabelKeys, ",")
+	if labelKeys, ok := os.LookupEnv("TENANT_LABEL_KEYS"); ok {
+		defaultTenantLabelKeys = labelKeys
+	}
+	tenantLabelKeys := flag.String("tenant-label-keys", defaultTenantLabelKeys, "Comma-separated list of tenant labels to copy to the objects generated for the tenant")
+	defaultRequiredFields := strings.Join(tenant.DefaultRequiredFields, ",")
+	if fields, ok := os.LookupEnv("REQUIRED_FIELDS"); ok {
+		defaultRequiredFields = fields
+	}
+	requiredFields := flag.String("required-fields", defaultRequiredFields, "Comma-separated list of the address and contact fields a tenant must fill in, such as contact.email or address.zip")
+	defaultRoles := flag.String("default-roles", os.Getenv("DEFAULT_ROLES"), "Comma-separated list of cluster roles, or roles as namespace/name, to instantiate in the core namespace of each tenant, empty disables it")
+	priorityBand := flag.String("priority-band", os.Getenv("PRIORITY_BAND"), "Range, as min-max, of the priority values tenants can request for a priority class of their own, empty disables it")
+	paused := flag.Bool("paused", os.Getenv("PAUSED") == "true", "Keep the controller running without reconciling objects, for cluster maintenance")
+	maintenanceConfigMap := flag.String("maintenance-configmap", os.Getenv("MAINTENANCE_CONFIGMAP"), "Config map, as namespace/name, whose paused key pauses and resumes the reconciliation, empty disables it")
+	eventSink := flag.String("event-sink", os.Getenv("EVENT_SINK"), "Sink to stream the recorded events to as JSON lines, stdout or the path of a file, empty disables it")
+	var defaultEventTTL time.Duration
+	if eventTTL, err := time.ParseDuration(os.Getenv("EVENT_TTL")); err == nil {
+		defaultEventTTL = eventTTL
+	}
+	eventTTL := flag.Duration("event-ttl", defaultEventTTL, "Age after which the events recorded by the controller are pruned, zero disables it")
+	reconcileDeadline := deadline.Flag()
 	flag.Parse()
 
+	tenantPriorityBand, err := tenant.ParsePriorityBand(*priorityBand)
+	if err != nil {
+		klog.Fatalf("Error parsing priority band: %s", err.Error())
+	}
+
 	stopCh := signals.SetupSignalHandler()
 	var authentication string
 	if authentication = strings.TrimSpace(os.Getenv("AUTHENTICATION_STRATEGY")); authentication != "kubeconfig" {
@@ -30,6 +64,7 @@ func main() {
 		log.Println(err.Error())
 		panic(err.Error())
 	}
+	deadline.Bound(config)
 	kubeclientset, err := bootstrap.CreateKubeClientset(config)
 	if err != nil {
 		log.Println(err.Error())
@@ -49,13 +84,31 @@ func main() {
 	// Start the controller to provide the functionalities of tenant resource
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
 
-	controller := tenant.NewController(kubeclientset,
+	controller, err := tenant.NewController(kubeclientset,
 		edgenetclientset,
 		antreaclientset,
-		edgenetInformerFactory.Core().V1alpha1().Tenants())
+		edgenetInformerFactory.Core().V1alpha1().Tenants(),
+		strings.Split(*allowedEmailDomains, ","),
+		*eventNamespace,
+		strings.Split(*tenantLabelKeys, ","),
+		strings.Split(*requiredFields, ","),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		strings.Split(*defaultRoles, ","),
+		tenantPriorityBand)
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	edgenetInformerFactory.Start(stopCh)
 
+	if err := maintenance.Start(kubeclientset, *paused, *maintenanceConfigMap, stopCh); err != nil {
+		klog.Fatalf("Error starting maintenance mode: %s", err.Error())
+	}
+	deadline.Set(*reconcileDeadline)
+	if err := eventsink.Start(*eventSink); err != nil {
+		klog.Fatalf("Error starting event sink: %s", err.Error())
+	}
+	eventcleanup.Start(kubeclientset, *eventNamespace, "tenant-controller", *eventTTL, stopCh)
 	if err = controller.Run(2, stopCh); err != nil {
 		klog.Fatalf("Error running controller: %s", err.Error())
 	}
@@ -3,13 +3,19 @@ package main
 import (
 	"flag"
 	"log"
+	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/EdgeNet-project/edgenet/pkg/bootstrap"
 	"github.com/EdgeNet-project/edgenet/pkg/controller/core/v1alpha1/tenantresourcequota"
+	"github.com/EdgeNet-project/edgenet/pkg/deadline"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
+	"github.com/EdgeNet-project/edgenet/pkg/metrics"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
 	"github.com/EdgeNet-project/edgenet/pkg/signals"
 
 	kubeinformers "k8s.io/client-go/informers"
@@ -19,8 +25,55 @@ import (
 func main() {
 	klog.InitFlags(nil)
 	flag.String("kubeconfig-path", bootstrap.GetDefaultKubeconfigPath(), "Path to the kubeconfig file's directory")
+	flag.String("smtp-path", "/edgenet/credentials/smtp.yaml", "Path to the SMTP credentials to send email")
+	flag.String("template-path", "/edgenet/assets/templates/email", "Path to the email templates")
+	quotaAlertThresholds := flag.String("quota-alert-thresholds", os.Getenv("QUOTA_ALERT_THRESHOLDS"), "Comma-separated list of quota utilization percentages at which tenant owners are alerted, empty disables the alerts")
+	defaultQuotaAlertCooldown := 24 * time.Hour
+	if cooldown, err := time.ParseDuration(os.Getenv("QUOTA_ALERT_COOLDOWN")); err == nil {
+		defaultQuotaAlertCooldown = cooldown
+	}
+	quotaAlertCooldown := flag.Duration("quota-alert-cooldown", defaultQuotaAlertCooldown, "Minimum time between two quota alerts to the same tenant")
+	defaultQuotaNames := multitenancy.DefaultQuotaNames
+	if name, ok := os.LookupEnv("CORE_QUOTA_NAME"); ok {
+		defaultQuotaNames.Core = name
+	}
+	if name, ok := os.LookupEnv("SUB_QUOTA_NAME"); ok {
+		defaultQuotaNames.Sub = name
+	}
+	coreQuotaName := flag.String("core-quota-name", defaultQuotaNames.Core, "Name of the resource quota holding the share of a tenant in its core namespace")
+	subQuotaName := flag.String("sub-quota-name", defaultQuotaNames.Sub, "Name of the resource quota holding the share of a subnamespace in its child namespace")
+	var defaultReapingInterval time.Duration
+	if interval, err := time.ParseDuration(os.Getenv("REAPING_INTERVAL")); err == nil {
+		defaultReapingInterval = interval
+	}
+	reapingInterval := flag.Duration("reaping-interval", defaultReapingInterval, "Window within which the expiries of claims and drops are reaped in a single sweep, zero reaps each expiry on its own")
+	var defaultExpiryNoticeLead time.Duration
+	if lead, err := time.ParseDuration(os.Getenv("EXPIRY_NOTICE_LEAD")); err == nil {
+		defaultExpiryNoticeLead = lead
+	}
+	expiryNoticeLead := flag.Duration("expiry-notice-lead", defaultExpiryNoticeLead, "How long before a claim expires the tenant owner is notified, zero disables the notices")
+	childOnlyResources := flag.String("child-only-resources", os.Getenv("CHILD_ONLY_RESOURCES"), "Comma-separated list of resources, such as pods, that only limit the child and are not debited from the parent quota, to be set as on the subnamespace controller")
+	quotaSnapshotSchedule := flag.String("quota-snapshot-schedule", os.Getenv("QUOTA_SNAPSHOT_SCHEDULE"), "Cron schedule on which the quota allocated to and used by each tenant is snapshotted for chargeback, empty disables the snapshots")
+	quotaSnapshotSink := flag.String("quota-snapshot-sink", os.Getenv("QUOTA_SNAPSHOT_SINK"), "Sink to write the quota snapshots to as JSON lines, stdout or the path of a file, empty defaults to stdout")
+	metricsAddress := flag.String("metrics-address", os.Getenv("METRICS_ADDRESS"), "Address to serve the quota utilization of the tenants on at /metrics, empty disables it")
+	readHost := flag.String("read-host", os.Getenv("READ_HOST"), "Host of a cache or replica of the API server to serve gets and lists, empty reads from the API server")
+	paused := flag.Bool("paused", os.Getenv("PAUSED") == "true", "Keep the controller running without reconciling objects, for cluster maintenance")
+	maintenanceConfigMap := flag.String("maintenance-configmap", os.Getenv("MAINTENANCE_CONFIGMAP"), "Config map, as namespace/name, whose paused key pauses and resumes the reconciliation, empty disables it")
+	eventSink := flag.String("event-sink", os.Getenv("EVENT_SINK"), "Sink to stream the recorded events to as JSON lines, stdout or the path of a file, empty disables it")
+	reconcileDeadline := deadline.Flag()
 	flag.Parse()
 
+	alertThresholds, err := tenantresourcequota.ParseAlertThresholds(*quotaAlertThresholds)
+	if err != nil {
+		klog.Fatalf("Error parsing quota alert thresholds: %s", err.Error())
+	}
+	var snapshotSchedule *multitenancy.Schedule
+	if *quotaSnapshotSchedule != "" {
+		if snapshotSchedule, err = multitenancy.ParseSchedule(*quotaSnapshotSchedule); err != nil {
+			klog.Fatalf("Error parsing quota snapshot schedule: %s", err.Error())
+		}
+	}
+
 	stopCh := signals.SetupSignalHandler()
 	var authentication string
 	if authentication = strings.TrimSpace(os.Getenv("AUTHENTICATION_STRATEGY")); authentication != "kubeconfig" {
@@ -31,6 +84,7 @@ func main() {
 		log.Println(err.Error())
 		panic(err.Error())
 	}
+	deadline.Bound(config)
 	kubeclientset, err := bootstrap.CreateKubeClientset(config)
 	if err != nil {
 		log.Println(err.Error())
@@ -41,19 +95,60 @@ func main() {
 		log.Println(err.Error())
 		panic(err.Error())
 	}
+	readKubeclientset, readEdgenetclientset, err := bootstrap.CreateReadClientsets(config, *readHost)
+	if err != nil {
+		log.Println(err.Error())
+		panic(err.Error())
+	}
 
 	// Start the controller to provide the functionalities of tenantresourcequota resource
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, time.Second*30)
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
 
-	controller := tenantresourcequota.NewController(kubeclientset,
+	controller, err := tenantresourcequota.NewController(kubeclientset,
 		edgenetclientset,
 		kubeInformerFactory.Core().V1().Nodes(),
-		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas())
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		alertThresholds,
+		*quotaAlertCooldown,
+		multitenancy.QuotaNames{Core: *coreQuotaName, Sub: *subQuotaName},
+		*reapingInterval,
+		readKubeclientset,
+		readEdgenetclientset,
+		*expiryNoticeLead,
+		multitenancy.ParseResourceNames(*childOnlyResources))
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	kubeInformerFactory.Start(stopCh)
 	edgenetInformerFactory.Start(stopCh)
 
+	if *metricsAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		go func() {
+			if err := http.ListenAndServe(*metricsAddress, mux); err != nil {
+				klog.Fatalf("Error serving metrics: %s", err.Error())
+			}
+		}()
+	}
+
+	if snapshotSchedule != nil {
+		snapshotSink, err := tenantresourcequota.OpenSnapshotSink(*quotaSnapshotSink)
+		if err != nil {
+			klog.Fatalf("Error opening quota snapshot sink: %s", err.Error())
+		}
+		go controller.RunQuotaSnapshots(snapshotSchedule, snapshotSink, stopCh)
+	}
+
+	if err := maintenance.Start(kubeclientset, *paused, *maintenanceConfigMap, stopCh); err != nil {
+		klog.Fatalf("Error starting maintenance mode: %s", err.Error())
+	}
+	deadline.Set(*reconcileDeadline)
+	if err := eventsink.Start(*eventSink); err != nil {
+		klog.Fatalf("Error starting event sink: %s", err.Error())
+	}
 	if err = controller.Run(2, stopCh); err != nil {
 		klog.Fatalf("Error running controller: %s", err.Error())
 	}
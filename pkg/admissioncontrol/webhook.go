@@ -13,13 +13,16 @@ import (
 
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
 	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 )
 
@@ -34,6 +37,8 @@ type Webhook struct {
 	Codecs   serializer.CodecFactory
 	Runtime  string
 	Port     string
+	// Kubeclientset looks up the priority class of the tenants, none leaves the pods as they are
+	Kubeclientset kubernetes.Interface
 }
 
 func (wh *Webhook) RunServer() {
@@ -136,6 +141,15 @@ func (wh *Webhook) mutatePod(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// The pods of a tenant with a priority class of its own inherit it, unless they name one
+	var priorityClass *schedulingv1.PriorityClass
+	if pod.Spec.PriorityClassName == "" && wh.Kubeclientset != nil {
+		if priorityClass, err = multitenancy.DefaultPriorityClass(wh.Kubeclientset, admissionReviewRequest.Request.Namespace); err != nil {
+			klog.Infoln(err)
+			priorityClass = nil
+		}
+	}
+
 	admissionResponse := new(admissionv1.AdmissionResponse)
 	admissionResponse.Allowed = true
 
@@ -156,6 +170,11 @@ func (wh *Webhook) mutatePod(w http.ResponseWriter, r *http.Request) {
 		runtime := fmt.Sprintf(`{"op":"%s","path":"/spec/runtimeClassName","value":"%s"}`, patchOperation["runtime"], wh.Runtime)
 		patchItems = append(patchItems, runtime)
 	}
+	if priorityClass != nil {
+		// The priority admission has already resolved the priority of the pod without a class, so it is set along
+		priority := fmt.Sprintf(`{"op":"add","path":"/spec/priorityClassName","value":"%s"},{"op":"add","path":"/spec/priority","value":%d}`, priorityClass.GetName(), priorityClass.Value)
+		patchItems = append(patchItems, priority)
+	}
 	patch := fmt.Sprintf(`[%s]`, strings.Join(patchItems, ","))
 	patchType := admissionv1.PatchTypeJSONPatch
 	admissionResponse.PatchType = &patchType
@@ -443,10 +462,10 @@ func (wh *Webhook) validateRoleRequest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if admissionReviewRequest.Request.UserInfo.Username != rolerequest.Spec.Email {
+	if !rolerequest.IsSubject(admissionReviewRequest.Request.UserInfo.Username) {
 		admissionResponse.Allowed = false
 		admissionResponse.Result = &metav1.Status{
-			Message: "username, which is an email address, and email address must be the same",
+			Message: "username must match the username in the request, or the email address in lowercase if no username is given",
 		}
 	}
 
@@ -528,6 +547,12 @@ func (wh *Webhook) validateSubNamespace(w http.ResponseWriter, r *http.Request)
 				Message: "subsidiary namespace mode cannot be changed after creation",
 			}
 		}
+		if subnamespace.Spec.Workspace != nil && oldSubnamespace.Spec.Workspace != nil && oldSubnamespace.Spec.Workspace.Adopt != subnamespace.Spec.Workspace.Adopt {
+			admissionResponse.Allowed = false
+			admissionResponse.Result = &metav1.Status{
+				Message: "subsidiary namespace adoption target cannot be changed after creation",
+			}
+		}
 
 		if oldSubnamespace.GetSliceClaim() != nil && subnamespace.GetSliceClaim() != nil {
 			if *oldSubnamespace.GetSliceClaim() != *subnamespace.GetSliceClaim() {
@@ -547,6 +572,20 @@ func (wh *Webhook) validateSubNamespace(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	if subnamespace.Spec.ResetSchedule != "" {
+		if subnamespace.Spec.Workspace == nil {
+			admissionResponse.Allowed = false
+			admissionResponse.Result = &metav1.Status{
+				Message: "subsidiary namespace reset schedule is only supported for workspaces",
+			}
+		} else if _, err := multitenancy.ParseSchedule(subnamespace.Spec.ResetSchedule); err != nil {
+			admissionResponse.Allowed = false
+			admissionResponse.Result = &metav1.Status{
+				Message: fmt.Sprintf("subsidiary namespace reset schedule is invalid: %s", err),
+			}
+		}
+	}
+
 	var admissionReviewResponse admissionv1.AdmissionReview
 	admissionReviewResponse.Response = admissionResponse
 	admissionReviewResponse.SetGroupVersionKind(admissionReviewRequest.GroupVersionKind())
@@ -32,19 +32,24 @@ const (
 	StatusFailed         = "Failure"
 	StatusReconciliation = "Reconciliation"
 	// Slice claim
-	StatusPending   = "Pending"
-	StatusRequested = "Requested"
-	StatusEmployed  = "Employed"
+	StatusPending       = "Pending"
+	StatusRequested     = "Requested"
+	StatusEmployed      = "Employed"
+	StatusQuotaExceeded = "QuotaExceeded"
 	// Slice
 	StatusBound       = "Bound" // Also used for slice claim
 	StatusReserved    = "Reserved"
 	StatusProvisioned = "Provisioned"
+	StatusDraining    = "Draining"
 	// Subnamespace
 	StatusPartitioned         = "Partitioned"
 	StatusSubnamespaceCreated = "Created"
 	StatusQuotaSet            = "Set"
+	StatusResetting           = "Resetting"
 	// Tenant
 	StatusCoreNamespaceCreated = "Created"
+	StatusEstablishing         = "Establishing"
+	StatusRejected             = "Rejected"
 	StatusEstablished          = "Established" // Also used for subnamespace
 	// Tenant resource quota
 	StatusQuotaCreated = "Created"
@@ -57,6 +62,7 @@ const (
 // Values of string constants subject to repetitive use
 const (
 	DynamicStr                        = "Dynamic"
+	ManualStr                         = "Manual"
 	TenantOwnerClusterRoleName        = "edgenet:tenant-owner"
 	TenantAdminClusterRoleName        = "edgenet:tenant-admin"
 	TenantCollaboratorClusterRoleName = "edgenet:tenant-collaborator"
@@ -97,6 +103,9 @@ type TenantSpec struct {
 	Enabled bool `json:"enabled"`
 	// Description provides additional information about the tenant.
 	Description string `json:"description"`
+	// DeletionProtection blocks the deletion of the tenant, along with its namespaces and workloads,
+	// until it is turned off.
+	DeletionProtection bool `json:"deletionprotection,omitempty"`
 }
 
 // Address describes postal address of tenant
@@ -133,6 +142,9 @@ type TenantStatus struct {
 	Message string `json:"message"`
 	// Failed sets the backoff limit.
 	Failed int `json:"failed"`
+	// ProjectedQuota is the quota of the core namespace resulting from the tenant resource quotas of the tenant,
+	// so that it can be reviewed before the resource quota is applied.
+	ProjectedQuota map[corev1.ResourceName]resource.Quantity `json:"projectedquota,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -178,6 +190,9 @@ type SubNamespaceSpec struct {
 	Subtenant *Subtenant `json:"subtenant"`
 	// Expiration date of the subnamespace.
 	Expiry *metav1.Time `json:"expiry"`
+	// ResetSchedule is a cron expression on which the child namespace of a workspace is torn down
+	// and re-created with the same quota, e.g. "0 2 * * *" to start afresh every night.
+	ResetSchedule string `json:"resetschedule,omitempty"`
 }
 
 // Workspace contains possible resources such as cpu units or memory, which attributes to
@@ -190,6 +205,9 @@ type Workspace struct {
 	// The supported resources are: RBAC, NetworkPolicies, Limit Ranges, Secrets, Config Maps, and
 	// Service Accounts.
 	Inheritance map[string]bool `json:"inheritance"`
+	// InheritanceExclusions lists the names of parent objects, of any inherited kind, that this
+	// workspace opts out of while inheriting the rest.
+	InheritanceExclusions []string `json:"inheritanceexclusions,omitempty"`
 	// Scope can be 'federated', or 'local'. It cannot be changed after creation.
 	Scope string `json:"scope"`
 	// Denote the workspace in sync with its parent.
@@ -198,6 +216,24 @@ type Workspace struct {
 	Owner *Contact `json:"owner"`
 	// SliceClaim is the name of a SliceClaim in the same namespace as the workspace using this slice.
 	SliceClaim *string `json:"sliceclaim"`
+	// Borrow is the amount of resources to draw temporarily from the unused quota of sibling
+	// workspaces, on top of the resource allocation. The loans are returned when a lender runs out
+	// of quota.
+	Borrow map[corev1.ResourceName]resource.Quantity `json:"borrow,omitempty"`
+	// Adopt is the name of an existing namespace to take over as the child of this workspace, rather
+	// than creating a new one. The namespace must carry the "edge-net.io/adopt=true" annotation, and
+	// it is deleted along with the workspace once adopted. It cannot be changed after creation.
+	Adopt string `json:"adopt,omitempty"`
+	// AutoShrink lets the parent reclaim the quota of the workspace once its child namespace has
+	// used none of it for a while. The quota is restored when AutoShrink is removed.
+	AutoShrink *AutoShrink `json:"autoshrink,omitempty"`
+}
+
+// AutoShrink configures the reclamation of the quota of an idle workspace.
+type AutoShrink struct {
+	// IdlePeriod is how long the child namespace must use none of its quota before the quota is
+	// reclaimed by the parent.
+	IdlePeriod metav1.Duration `json:"idleperiod"`
 }
 
 // Subtenant resource represents a tenant under another tenant.
@@ -219,8 +255,33 @@ type SubNamespaceStatus struct {
 	Message string `json:"message"`
 	// Failed sets the backoff limit.
 	Failed int `json:"failed"`
-	// Child is the name of the child namespace.
+	// Child is the name of the child namespace created for the subnamespace, unset while it does not exist.
+	// Clients should read it rather than derive the name from the subnamespace.
 	Child *string `json:"child"`
+	// ResourceAllocation is the allocated resources in canonical form, CPU in decimal
+	// and byte-based resources in binary units.
+	ResourceAllocation map[corev1.ResourceName]resource.Quantity `json:"resourceallocation,omitempty"`
+	// Borrowed lists the quota currently drawn from sibling workspaces.
+	Borrowed []QuotaLoan `json:"borrowed,omitempty"`
+	// LastReset is the time the child namespace was last reset, or the time the reset schedule
+	// started counting from.
+	LastReset *metav1.Time `json:"lastreset,omitempty"`
+	// IdleSince is the time the child namespace was first seen using none of its quota, while
+	// auto shrink is enabled.
+	IdleSince *metav1.Time `json:"idlesince,omitempty"`
+	// Reclaimed is the allocation credited back to the parent by auto shrink, the workspace has no
+	// quota while it is set.
+	Reclaimed map[corev1.ResourceName]resource.Quantity `json:"reclaimed,omitempty"`
+}
+
+// QuotaLoan is an amount of a resource that a workspace draws from the quota of a sibling.
+type QuotaLoan struct {
+	// Lender is the name of the sibling subnamespace lending the quota.
+	Lender string `json:"lender"`
+	// Resource is the name of the lent resource.
+	Resource corev1.ResourceName `json:"resource"`
+	// Quantity is the lent amount of the resource.
+	Quantity resource.Quantity `json:"quantity"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -258,6 +319,10 @@ func (sn SubNamespace) RetrieveQuantity(key corev1.ResourceName) resource.Quanti
 
 // GenerateChildName forms a name for child according to the mode, Workspace or Subtenant.
 func (sn SubNamespace) GenerateChildName(clusterUID string) string {
+	// An adopted namespace keeps its name
+	if sn.Spec.Workspace != nil && sn.Spec.Workspace.Adopt != "" {
+		return sn.Spec.Workspace.Adopt
+	}
 	childName := sn.GetName()
 	if sn.Spec.Workspace != nil && sn.Spec.Workspace.Scope == "federation" {
 		childName = fmt.Sprintf("%s-%s", clusterUID, childName)
@@ -403,8 +468,49 @@ type TenantResourceQuotaSpec struct {
 type ResourceTuning struct {
 	// This denotes which resources to be included.
 	ResourceList map[corev1.ResourceName]resource.Quantity `json:"resourcelist"`
+	// Name of a quota profile, defined cluster-wide, whose resources are included as well.
+	// Quantities in the ResourceList take precedence over those of the profile.
+	Profile string `json:"profile,omitempty"`
+	// Shares of the cluster capacity, in percent, for the resources whose quantity follows the capacity
+	// of the cluster. The quantity is recomputed as nodes join and leave, and it takes precedence over
+	// the quantity in the ResourceList.
+	CapacityPercentage map[corev1.ResourceName]int `json:"capacitypercentage,omitempty"`
+	// Start date of the ResourceTuning, before which it is not counted. This can be nil if it takes effect right away.
+	Start *metav1.Time `json:"start,omitempty"`
 	// Expiration date of the ResourceTuning. This can be nil if no expiration date is specified.
 	Expiry *metav1.Time `json:"expiry"`
+	// Expiration dates of individual resources in the ResourceList. A resource without an
+	// entry here expires along with the ResourceTuning.
+	ResourceExpiry map[corev1.ResourceName]metav1.Time `json:"resourceexpiry,omitempty"`
+	// Labels tag the ResourceTuning for cost attribution, such as with a grant ID or a cost center.
+	// They are carried over as is and reported in the quota snapshots.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// GetResourceExpiry returns the expiration date of the given resource, which is either its own
+// expiration date or that of the ResourceTuning.
+func (rt ResourceTuning) GetResourceExpiry(key corev1.ResourceName) *metav1.Time {
+	if expiry, elementExists := rt.ResourceExpiry[key]; elementExists {
+		return &expiry
+	}
+	return rt.Expiry
+}
+
+// HasStarted tells whether the ResourceTuning has taken effect, which is right away unless it has a start date.
+func (rt ResourceTuning) HasStarted() bool {
+	return rt.Start == nil || time.Until(rt.Start.Time) <= 0
+}
+
+// GetExpiryDates returns all the expiration dates specified in the ResourceTuning.
+func (rt ResourceTuning) GetExpiryDates() []*metav1.Time {
+	var expiryDates []*metav1.Time
+	if rt.Expiry != nil {
+		expiryDates = append(expiryDates, rt.Expiry)
+	}
+	for key := range rt.ResourceExpiry {
+		expiryDates = append(expiryDates, rt.GetResourceExpiry(key))
+	}
+	return expiryDates
 }
 
 // TenantResourceQuotaStatus is the status for a tenant resouce quota resource
@@ -415,6 +521,14 @@ type TenantResourceQuotaStatus struct {
 	Message string `json:"message"`
 	// Failed sets the backoff limit.
 	Failed int `json:"failed"`
+	// AlertedThreshold is the highest utilization threshold, in percent, that the tenant has
+	// been alerted about. It goes back down when the utilization drops below it.
+	AlertedThreshold int `json:"alertedthreshold,omitempty"`
+	// LastAlerted is the time of the last quota alert.
+	LastAlerted *metav1.Time `json:"lastalerted,omitempty"`
+	// ExpiryNotified maps the claims to the latest expiration date the tenant has been notified
+	// about ahead of time, so that each expiry is notified once.
+	ExpiryNotified map[string]metav1.Time `json:"expirynotified,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -430,35 +544,53 @@ type TenantResourceQuotaList struct {
 	Items []TenantResourceQuota `json:"items"`
 }
 
+// Tenant returns the tenant the quota is granted to. A tenant resource quota is named after its tenant, while the
+// additional ones that layer grants from other budget sources on top of it carry the tenant label.
+func (t TenantResourceQuota) Tenant() string {
+	if tenant := t.GetLabels()["edge-net.io/tenant"]; tenant != "" {
+		return tenant
+	}
+	return t.GetName()
+}
+
 // Fetch as its name indicates, it fetches the net value of the resources. For example,
 // 1Gb memory is claimed and 100 milliCPU are dropped. Then the function returns the net resources as '+1Gb', '-100m'.
+// The claims and drops yet to start are left out.
 func (t TenantResourceQuota) Fetch() map[corev1.ResourceName]resource.Quantity {
 	assignedQuota := make(map[corev1.ResourceName]resource.Quantity)
 	if len(t.Spec.Claim) > 0 {
 		for _, claim := range t.Spec.Claim {
-			if claim.Expiry == nil || (claim.Expiry != nil && time.Until(claim.Expiry.Time) >= 0) {
-				for key, value := range claim.ResourceList {
-					if assignedQuantity, elementExists := assignedQuota[key]; elementExists {
-						assignedQuantity.Add(value)
-						assignedQuota[key] = assignedQuantity
-					} else {
-						assignedQuota[key] = value
-					}
+			if !claim.HasStarted() {
+				continue
+			}
+			for key, value := range claim.ResourceList {
+				if expiry := claim.GetResourceExpiry(key); expiry != nil && time.Until(expiry.Time) < 0 {
+					continue
+				}
+				if assignedQuantity, elementExists := assignedQuota[key]; elementExists {
+					assignedQuantity.Add(value)
+					assignedQuota[key] = assignedQuantity
+				} else {
+					assignedQuota[key] = value
 				}
 			}
 		}
 	}
 	if len(t.Spec.Drop) > 0 {
 		for _, drop := range t.Spec.Drop {
-			if drop.Expiry == nil || (drop.Expiry != nil && time.Until(drop.Expiry.Time) >= 0) {
-				for key, value := range drop.ResourceList {
-					if assignedQuantity, elementExists := assignedQuota[key]; elementExists {
-						assignedQuantity.Sub(value)
-						assignedQuota[key] = assignedQuantity
-					} else {
-						value.Neg()
-						assignedQuota[key] = value
-					}
+			if !drop.HasStarted() {
+				continue
+			}
+			for key, value := range drop.ResourceList {
+				if expiry := drop.GetResourceExpiry(key); expiry != nil && time.Until(expiry.Time) < 0 {
+					continue
+				}
+				if assignedQuantity, elementExists := assignedQuota[key]; elementExists {
+					assignedQuantity.Sub(value)
+					assignedQuota[key] = assignedQuantity
+				} else {
+					value.Neg()
+					assignedQuota[key] = value
 				}
 			}
 		}
@@ -466,7 +598,8 @@ func (t TenantResourceQuota) Fetch() map[corev1.ResourceName]resource.Quantity {
 	return assignedQuota
 }
 
-// DropExpiredItems removes the resource tunings if they are expired.
+// DropExpiredItems removes the expired resources from the resource tunings, and the resource tunings
+// themselves once they expire or have no resources left.
 func (t TenantResourceQuota) DropExpiredItems() bool {
 	remove := func(objects ...map[string]ResourceTuning) bool {
 		expired := false
@@ -475,6 +608,17 @@ func (t TenantResourceQuota) DropExpiredItems() bool {
 				if value.Expiry != nil && time.Until(value.Expiry.Time) <= 0 {
 					expired = true
 					delete(obj, key)
+					continue
+				}
+				for resourceName, resourceExpiry := range value.ResourceExpiry {
+					if time.Until(resourceExpiry.Time) <= 0 {
+						expired = true
+						delete(value.ResourceList, resourceName)
+						delete(value.ResourceExpiry, resourceName)
+						if len(value.ResourceList) == 0 {
+							delete(obj, key)
+						}
+					}
 				}
 			}
 		}
@@ -530,6 +674,10 @@ type SliceStatus struct {
 	Failed int `json:"failed"`
 	// Expiration date of the slice.
 	Expiry *metav1.Time `json:"expiry"`
+	// DrainStarted is when the nodes of the expired slice started to be drained.
+	DrainStarted *metav1.Time `json:"drainstarted,omitempty"`
+	// Draining is the number of pods left on the nodes of the slice being drained.
+	Draining int `json:"draining,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
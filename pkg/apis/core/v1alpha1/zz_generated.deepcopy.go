@@ -24,6 +24,7 @@ package v1alpha1
 import (
 	v1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -43,6 +44,23 @@ func (in *Address) DeepCopy() *Address {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoShrink) DeepCopyInto(out *AutoShrink) {
+	*out = *in
+	out.IdlePeriod = in.IdlePeriod
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoShrink.
+func (in *AutoShrink) DeepCopy() *AutoShrink {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoShrink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Contact) DeepCopyInto(out *Contact) {
 	*out = *in
@@ -200,6 +218,23 @@ func (in *NodeSelector) DeepCopy() *NodeSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaLoan) DeepCopyInto(out *QuotaLoan) {
+	*out = *in
+	out.Quantity = in.Quantity.DeepCopy()
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaLoan.
+func (in *QuotaLoan) DeepCopy() *QuotaLoan {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaLoan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceTuning) DeepCopyInto(out *ResourceTuning) {
 	*out = *in
@@ -210,10 +245,35 @@ func (in *ResourceTuning) DeepCopyInto(out *ResourceTuning) {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.CapacityPercentage != nil {
+		in, out := &in.CapacityPercentage, &out.CapacityPercentage
+		*out = make(map[v1.ResourceName]int, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = (*in).DeepCopy()
+	}
 	if in.Expiry != nil {
 		in, out := &in.Expiry, &out.Expiry
 		*out = (*in).DeepCopy()
 	}
+	if in.ResourceExpiry != nil {
+		in, out := &in.ResourceExpiry, &out.ResourceExpiry
+		*out = make(map[v1.ResourceName]metav1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -415,6 +475,10 @@ func (in *SliceStatus) DeepCopyInto(out *SliceStatus) {
 		in, out := &in.Expiry, &out.Expiry
 		*out = (*in).DeepCopy()
 	}
+	if in.DrainStarted != nil {
+		in, out := &in.DrainStarted, &out.DrainStarted
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -527,6 +591,35 @@ func (in *SubNamespaceStatus) DeepCopyInto(out *SubNamespaceStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ResourceAllocation != nil {
+		in, out := &in.ResourceAllocation, &out.ResourceAllocation
+		*out = make(map[v1.ResourceName]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Borrowed != nil {
+		in, out := &in.Borrowed, &out.Borrowed
+		*out = make([]QuotaLoan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastReset != nil {
+		in, out := &in.LastReset, &out.LastReset
+		*out = (*in).DeepCopy()
+	}
+	if in.IdleSince != nil {
+		in, out := &in.IdleSince, &out.IdleSince
+		*out = (*in).DeepCopy()
+	}
+	if in.Reclaimed != nil {
+		in, out := &in.Reclaimed, &out.Reclaimed
+		*out = make(map[v1.ResourceName]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -575,7 +668,7 @@ func (in *Tenant) DeepCopyInto(out *Tenant) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -636,7 +729,7 @@ func (in *TenantResourceQuota) DeepCopyInto(out *TenantResourceQuota) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -724,6 +817,17 @@ func (in *TenantResourceQuotaSpec) DeepCopy() *TenantResourceQuotaSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TenantResourceQuotaStatus) DeepCopyInto(out *TenantResourceQuotaStatus) {
 	*out = *in
+	if in.LastAlerted != nil {
+		in, out := &in.LastAlerted, &out.LastAlerted
+		*out = (*in).DeepCopy()
+	}
+	if in.ExpiryNotified != nil {
+		in, out := &in.ExpiryNotified, &out.ExpiryNotified
+		*out = make(map[string]metav1.Time, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -758,6 +862,13 @@ func (in *TenantSpec) DeepCopy() *TenantSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TenantStatus) DeepCopyInto(out *TenantStatus) {
 	*out = *in
+	if in.ProjectedQuota != nil {
+		in, out := &in.ProjectedQuota, &out.ProjectedQuota
+		*out = make(map[v1.ResourceName]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -788,6 +899,11 @@ func (in *Workspace) DeepCopyInto(out *Workspace) {
 			(*out)[key] = val
 		}
 	}
+	if in.InheritanceExclusions != nil {
+		in, out := &in.InheritanceExclusions, &out.InheritanceExclusions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Owner != nil {
 		in, out := &in.Owner, &out.Owner
 		*out = new(Contact)
@@ -798,6 +914,18 @@ func (in *Workspace) DeepCopyInto(out *Workspace) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Borrow != nil {
+		in, out := &in.Borrow, &out.Borrow
+		*out = make(map[v1.ResourceName]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.AutoShrink != nil {
+		in, out := &in.AutoShrink, &out.AutoShrink
+		*out = new(AutoShrink)
+		**out = **in
+	}
 	return
 }
 
@@ -17,6 +17,8 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -164,6 +166,17 @@ func (crr ClusterRoleRequest) MakeOwnerReference() metav1.OwnerReference {
 	return *metav1.NewControllerRef(&crr.ObjectMeta, SchemeGroupVersion.WithKind("ClusterRoleRequest"))
 }
 
+// GetSubjectName returns the name of the user to bind the cluster role to, which is the email in lowercase.
+func (crr ClusterRoleRequest) GetSubjectName() string {
+	return strings.ToLower(crr.Spec.Email)
+}
+
+// IsSubject tells whether the name, as found in a binding subject, is that of the user of the cluster role request.
+// The email is compared in lowercase, while the name is compared as is since usernames are case-sensitive.
+func (crr ClusterRoleRequest) IsSubject(name string) bool {
+	return name == crr.GetSubjectName()
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -187,10 +200,25 @@ type RoleRequestSpec struct {
 	LastName string `json:"lastname"`
 	// Email of the person requesting the role.
 	Email string `json:"email"`
+	// Username of the person requesting the role as it appears in the credentials, such as the subject
+	// of an OIDC token. When empty, the email is used as the username.
+	Username string `json:"username,omitempty"`
 	// RoleRefSpec indicates the requested Role or ClusterRole
 	RoleRef RoleRefSpec `json:"roleref"`
 	// True if this role request is approved false if not.
 	Approved bool `json:"approved"`
+	// TemporaryAccess makes the role binding time-bound. Once bound, the role is held for the given
+	// duration, after which both the binding subject and the role request are removed.
+	TemporaryAccess *TemporaryAccess `json:"temporaryaccess,omitempty"`
+	// RequiredApprovals is the number of distinct approvers the role request needs before the role is
+	// bound. It defaults to 1, in which case setting Approved is enough.
+	RequiredApprovals int `json:"requiredapprovals,omitempty"`
+}
+
+// TemporaryAccess defines how long a role is granted for
+type TemporaryAccess struct {
+	// Duration of the grant, counting from the time the role is bound.
+	Duration metav1.Duration `json:"duration"`
 }
 
 // RoleRefSpec indicates the requested Role / ClusterRole
@@ -211,6 +239,30 @@ type RoleRequestStatus struct {
 	Message string `json:"message"`
 	// True if the notification send out
 	Notified bool `json:"notified"`
+	// Number of reminders sent to the approvers while the request is pending approval.
+	Reminders int `json:"reminders,omitempty"`
+	// Last time the approvers were notified of the request pending approval.
+	LastNotified *metav1.Time `json:"lastnotified,omitempty"`
+	// Approvals lists the approvers of the role request, by lowercase email.
+	Approvals []string `json:"approvals,omitempty"`
+	// Propagation is the state of the role binding in each member cluster of the federation, by cluster name.
+	// This is either 'Bound' or the reason of the failure.
+	Propagation map[string]string `json:"propagation,omitempty"`
+	// History lists the transitions of the role request from one state to another, oldest first. Only the latest
+	// transitions are kept.
+	History []Transition `json:"history,omitempty"`
+	// True once the request pending approval is escalated from the primary approvers to the others.
+	Escalated bool `json:"escalated,omitempty"`
+}
+
+// Transition is a change in the state of a request, kept in its status as an audit trail
+type Transition struct {
+	// State the request moved to.
+	State string `json:"state"`
+	// Time of the transition.
+	Timestamp metav1.Time `json:"timestamp"`
+	// Description of the transition.
+	Message string `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -229,3 +281,37 @@ type RoleRequestList struct {
 func (rr RoleRequest) MakeOwnerReference() metav1.OwnerReference {
 	return *metav1.NewControllerRef(&rr.ObjectMeta, SchemeGroupVersion.WithKind("RoleRequest"))
 }
+
+// GetSubjectName returns the name of the user to bind the role to, which is the username if
+// present and the email in lowercase otherwise.
+func (rr RoleRequest) GetSubjectName() string {
+	if rr.Spec.Username != "" {
+		return rr.Spec.Username
+	}
+	return strings.ToLower(rr.Spec.Email)
+}
+
+// GetRequiredApprovals returns the number of distinct approvers the role request needs, which is at least 1.
+func (rr RoleRequest) GetRequiredApprovals() int {
+	if rr.Spec.RequiredApprovals < 1 {
+		return 1
+	}
+	return rr.Spec.RequiredApprovals
+}
+
+// HasApproved tells whether the approver is among the approvers of the role request.
+func (rr RoleRequest) HasApproved(approver string) bool {
+	for _, approval := range rr.Status.Approvals {
+		if strings.EqualFold(approval, approver) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSubject tells whether the name, as found in a binding subject or in the user info, is that of the
+// subject of the role request. Only the email is lowercased, while usernames, including the name, are compared
+// as they are since they are case-sensitive.
+func (rr RoleRequest) IsSubject(name string) bool {
+	return name == rr.GetSubjectName()
+}
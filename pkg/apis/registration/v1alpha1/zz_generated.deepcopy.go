@@ -205,6 +205,11 @@ func (in *RoleRequestList) DeepCopyObject() runtime.Object {
 func (in *RoleRequestSpec) DeepCopyInto(out *RoleRequestSpec) {
 	*out = *in
 	out.RoleRef = in.RoleRef
+	if in.TemporaryAccess != nil {
+		in, out := &in.TemporaryAccess, &out.TemporaryAccess
+		*out = new(TemporaryAccess)
+		**out = **in
+	}
 	return
 }
 
@@ -225,6 +230,29 @@ func (in *RoleRequestStatus) DeepCopyInto(out *RoleRequestStatus) {
 		in, out := &in.Expiry, &out.Expiry
 		*out = (*in).DeepCopy()
 	}
+	if in.LastNotified != nil {
+		in, out := &in.LastNotified, &out.LastNotified
+		*out = (*in).DeepCopy()
+	}
+	if in.Approvals != nil {
+		in, out := &in.Approvals, &out.Approvals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Propagation != nil {
+		in, out := &in.Propagation, &out.Propagation
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]Transition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -238,6 +266,23 @@ func (in *RoleRequestStatus) DeepCopy() *RoleRequestStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemporaryAccess) DeepCopyInto(out *TemporaryAccess) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemporaryAccess.
+func (in *TemporaryAccess) DeepCopy() *TemporaryAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(TemporaryAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TenantRequest) DeepCopyInto(out *TenantRequest) {
 	*out = *in
@@ -343,3 +388,20 @@ func (in *TenantRequestStatus) DeepCopy() *TenantRequestStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Transition) DeepCopyInto(out *Transition) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Transition.
+func (in *Transition) DeepCopy() *Transition {
+	if in == nil {
+		return nil
+	}
+	out := new(Transition)
+	in.DeepCopyInto(out)
+	return out
+}
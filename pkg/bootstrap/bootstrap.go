@@ -25,6 +25,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	"gopkg.in/yaml.v2"
@@ -91,6 +92,52 @@ func PrepareRestConfig(server, token string, certificateAuthorityData []byte) *r
 	return config
 }
 
+// CreateReadClientsets generates the clientsets serving the gets and lists of a controller from the given host, such as
+// a cache or a replica of the API server, with the credentials of the config. Both are nil if the host is empty, in
+// which case the controller reads from the clientsets it writes to.
+func CreateReadClientsets(config *rest.Config, host string) (kubernetes.Interface, clientset.Interface, error) {
+	if host == "" {
+		return nil, nil, nil
+	}
+	readConfig := rest.CopyConfig(config)
+	readConfig.Host = host
+	kubeclientset, err := kubernetes.NewForConfig(readConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	edgenetclientset, err := clientset.NewForConfig(readConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kubeclientset, edgenetclientset, nil
+}
+
+// CreateMemberClientsets generates the clientsets of the member clusters of a federation from a comma-separated list
+// of name=path pairs, each path pointing to the kubeconfig of a member cluster. The map is empty if the list is.
+func CreateMemberClientsets(members string) (map[string]kubernetes.Interface, error) {
+	memberClientsets := make(map[string]kubernetes.Interface)
+	for _, member := range strings.Split(members, ",") {
+		if member = strings.TrimSpace(member); member == "" {
+			continue
+		}
+		name, path, found := strings.Cut(member, "=")
+		if name, path = strings.TrimSpace(name), strings.TrimSpace(path); !found || name == "" || path == "" {
+			return nil, fmt.Errorf("member cluster %q is not in the name=path form", member)
+		}
+		if _, exists := memberClientsets[name]; exists {
+			return nil, fmt.Errorf("member cluster %s is listed more than once", name)
+		}
+		config, err := clientcmd.BuildConfigFromFlags("", path)
+		if err != nil {
+			return nil, fmt.Errorf("member cluster %s: %w", name, err)
+		}
+		if memberClientsets[name], err = kubernetes.NewForConfig(config); err != nil {
+			return nil, fmt.Errorf("member cluster %s: %w", name, err)
+		}
+	}
+	return memberClientsets, nil
+}
+
 // CreateEdgeNetClientset generates the clientset to interact with the custom resources
 func CreateEdgeNetClientset(config *rest.Config) (*clientset.Clientset, error) {
 	// Create the clientset
@@ -55,7 +55,7 @@ func TestMain(m *testing.M) {
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, time.Second*30)
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, time.Second*30)
 
-	newController := NewController(kubeclientset,
+	newController, err := NewController(kubeclientset,
 		edgenetclientset,
 		kubeInformerFactory.Core().V1().Nodes(),
 		kubeInformerFactory.Apps().V1().Deployments(),
@@ -64,6 +64,9 @@ func TestMain(m *testing.M) {
 		kubeInformerFactory.Batch().V1().Jobs(),
 		kubeInformerFactory.Batch().V1beta1().CronJobs(),
 		edgenetInformerFactory.Apps().V1alpha1().SelectiveDeployments())
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	kubeInformerFactory.Start(stopCh)
 	edgenetInformerFactory.Start(stopCh)
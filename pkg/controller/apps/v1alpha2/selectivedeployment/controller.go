@@ -24,12 +24,15 @@ import (
 	appsv1alpha2 "github.com/EdgeNet-project/edgenet/pkg/apis/apps/v1alpha2"
 	federationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/federation/v1alpha1"
 	"github.com/EdgeNet-project/edgenet/pkg/bootstrap"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	edgenetscheme "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/apps/v1alpha2"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/apps/v1alpha2"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	"github.com/EdgeNet-project/edgenet/pkg/multiprovider"
 	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -124,13 +127,27 @@ func NewController(
 	statefulsetInformer appsinformers.StatefulSetInformer,
 	jobInformer batchinformers.JobInformer,
 	cronjobInformer batchinformers.CronJobInformer,
-	selectivedeploymentInformer informers.SelectiveDeploymentInformer) *Controller {
+	selectivedeploymentInformer informers.SelectiveDeploymentInformer) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("nodeInformer", nodeInformer)
+	validator.NotNil("deploymentInformer", deploymentInformer)
+	validator.NotNil("daemonsetInformer", daemonsetInformer)
+	validator.NotNil("statefulsetInformer", statefulsetInformer)
+	validator.NotNil("jobInformer", jobInformer)
+	validator.NotNil("cronjobInformer", cronjobInformer)
+	validator.NotNil("selectivedeploymentInformer", selectivedeploymentInformer)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
 
 	utilruntime.Must(edgenetscheme.AddToScheme(scheme.Scheme))
 	klog.Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events(metav1.NamespaceAll)})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
 	controller := &Controller{
@@ -229,7 +246,7 @@ func NewController(
 		DeleteFunc: controller.handleObject,
 	})*/
 
-	return controller
+	return controller, nil
 }
 
 // Run will set up the event handlers for the types of selective deployment and node, as well
@@ -293,12 +310,13 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/EdgeNet-project/edgenet/pkg/multiprovider"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 	"k8s.io/apimachinery/pkg/api/errors"
 
 	corev1 "k8s.io/api/core/v1"
@@ -58,13 +61,22 @@ func NewController(
 	maxmindURL string,
 	maxmindAccountID string,
 	maxmindLicenseKey string,
-) *Controller {
+) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("informer", informer)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
+
 	// Create event broadcaster
 	utilruntime.Must(scheme.AddToScheme(scheme.Scheme))
 	klog.V(4).Infoln("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
 	controller := &Controller{
@@ -92,7 +104,7 @@ func NewController(
 		},
 	})
 
-	return controller
+	return controller, nil
 }
 
 // Run will set up the event handlers for the types of node, as well
@@ -146,12 +158,13 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.V(4).Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.V(4).Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
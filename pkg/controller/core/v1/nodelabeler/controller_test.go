@@ -64,7 +64,7 @@ func TestMain(m *testing.M) {
 	go func() {
 		kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, time.Second*30)
 
-		newController := NewController(
+		newController, err := NewController(
 			kubeclientset,
 			edgenetclientset,
 			kubeInformerFactory.Core().V1().Nodes(),
@@ -72,6 +72,9 @@ func TestMain(m *testing.M) {
 			"null-account-id",
 			"null-license-key",
 		)
+		if err != nil {
+			klog.Fatalf("Error creating controller: %s", err.Error())
+		}
 
 		kubeInformerFactory.Start(stopCh)
 		controller = newController
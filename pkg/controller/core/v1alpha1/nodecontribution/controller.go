@@ -27,11 +27,14 @@ import (
 	"golang.org/x/crypto/ssh"
 
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	edgenetscheme "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/core/v1alpha1"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	multiprovider "github.com/EdgeNet-project/edgenet/pkg/multiprovider"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -111,13 +114,22 @@ func NewController(
 	edgenetclientset clientset.Interface,
 	nodeInformer coreinformers.NodeInformer,
 	nodecontributionInformer informers.NodeContributionInformer,
-	hostedZone, domain string) *Controller {
+	hostedZone, domain string) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("nodeInformer", nodeInformer)
+	validator.NotNil("nodecontributionInformer", nodecontributionInformer)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
 
 	utilruntime.Must(edgenetscheme.AddToScheme(scheme.Scheme))
 	klog.Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
 	multiproviderManager := multiprovider.NewManager(kubeclientset, nil, nil, nil)
@@ -229,7 +241,7 @@ func NewController(
 		},
 	})
 
-	return controller
+	return controller, nil
 }
 
 // Run will set up the event handlers for the types of node contribution and node, as well
@@ -288,12 +300,13 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
@@ -25,13 +25,17 @@ import (
 	"time"
 
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	edgenetscheme "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/core/v1alpha1"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -58,6 +62,7 @@ const (
 	failureBound       = "Bound Failed"
 	failureSlice       = "Slice Failed"
 	failurePatch       = "Patch Failed"
+	failureDrain       = "Drain Timed Out"
 
 	messageResourceSynced = "Slice synced successfully"
 	messageProvisioned    = "Desired resources are provisioned"
@@ -68,8 +73,15 @@ const (
 	messageSliceFailed    = "There are no adequate resources to slice"
 	messagePatchFailed    = "Node patch operation has failed"
 	messageReconciliation = "Reconciliation in progress"
+	messageDrainStarted   = "Nodes are cordoned to drain the workloads before reclamation"
+	messageDraining       = "Draining %d pods from the nodes before reclamation"
+	messageDrained        = "Nodes are drained"
+	messageDrainTimeout   = "Drain timed out, %d pods are force-deleted"
 )
 
+// drainPollInterval is how often the nodes of a slice being drained are checked
+var drainPollInterval = 10 * time.Second
+
 // Controller is the controller implementation for Slice resources
 type Controller struct {
 	// kubeclientset is a standard kubernetes clientset
@@ -92,6 +104,12 @@ type Controller struct {
 	// recorder is an event recorder for recording Event resources to the
 	// Kubernetes API.
 	recorder record.EventRecorder
+
+	// drainTimeout is how long the workloads on the nodes of an expired slice are given to leave
+	// before they are force-deleted
+	drainTimeout time.Duration
+	// skipTaintedNodes keeps the nodes tainted with NoSchedule or NoExecute out of the slices
+	skipTaintedNodes bool
 }
 
 // NewController returns a new controller
@@ -99,13 +117,24 @@ func NewController(
 	kubeclientset kubernetes.Interface,
 	edgenetclientset clientset.Interface,
 	sliceClaimInformer informers.SliceClaimInformer,
-	sliceInformer informers.SliceInformer) *Controller {
+	sliceInformer informers.SliceInformer,
+	drainTimeout time.Duration,
+	skipTaintedNodes bool) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("sliceClaimInformer", sliceClaimInformer)
+	validator.NotNil("sliceInformer", sliceInformer)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
 
 	utilruntime.Must(edgenetscheme.AddToScheme(scheme.Scheme))
 	klog.Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
 	controller := &Controller{
@@ -117,6 +146,8 @@ func NewController(
 		slicesSynced:      sliceInformer.Informer().HasSynced,
 		workqueue:         workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Slices"),
 		recorder:          recorder,
+		drainTimeout:      drainTimeout,
+		skipTaintedNodes:  skipTaintedNodes,
 	}
 
 	klog.Infoln("Setting up event handlers")
@@ -134,7 +165,8 @@ func NewController(
 		},
 		DeleteFunc: func(obj interface{}) {
 			sliceCopy := obj.(*corev1alpha1.Slice).DeepCopy()
-			controller.returnNodes(sliceCopy)
+			// The deleted slice cannot be requeued, so its nodes are returned at the end of the maintenance
+			maintenance.WhenResumed(func() { controller.returnNodes(sliceCopy) })
 		},
 	})
 
@@ -150,7 +182,7 @@ func NewController(
 		DeleteFunc: controller.handleObject,
 	})
 
-	return controller
+	return controller, nil
 }
 
 // Run will set up the event handlers for the types of slice and node, as well
@@ -209,12 +241,13 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
@@ -317,6 +350,12 @@ func (c *Controller) handleObject(obj interface{}) {
 
 func (c *Controller) processSlice(sliceCopy *corev1alpha1.Slice) {
 	if sliceCopy.Status.Expiry != nil && time.Until(sliceCopy.Status.Expiry.Time) <= 0 {
+		// The workloads leave the nodes before the namespaces of the slice are removed along with it
+		if sliceCopy.Status.State == corev1alpha1.StatusProvisioned || sliceCopy.Status.State == corev1alpha1.StatusDraining {
+			if drained := c.drainSlice(sliceCopy); !drained {
+				return
+			}
+		}
 		c.recorder.Event(sliceCopy, corev1.EventTypeWarning, successExpired, messageExpired)
 		c.edgenetclientset.CoreV1alpha1().Slices().Delete(context.TODO(), sliceCopy.GetName(), metav1.DeleteOptions{})
 		return
@@ -327,6 +366,15 @@ func (c *Controller) processSlice(sliceCopy *corev1alpha1.Slice) {
 	}
 
 	switch sliceCopy.Status.State {
+	case corev1alpha1.StatusDraining:
+		// The expiry date is extended while draining, the nodes take workloads again
+		c.uncordonNodes(sliceCopy)
+		c.recorder.Event(sliceCopy, corev1.EventTypeNormal, corev1alpha1.StatusReconciliation, messageReconciliation)
+		sliceCopy.Status.State = corev1alpha1.StatusReconciliation
+		sliceCopy.Status.Message = messageReconciliation
+		sliceCopy.Status.DrainStarted = nil
+		sliceCopy.Status.Draining = 0
+		c.updateStatus(context.TODO(), sliceCopy)
 	case corev1alpha1.StatusProvisioned:
 		if ok := c.checkSliceStatus(sliceCopy, "slice"); ok {
 			if sliceCopy.Spec.ClaimRef != nil {
@@ -467,6 +515,95 @@ func (c *Controller) provisionSlice(sliceCopy *corev1alpha1.Slice) (bool, bool)
 	return false, false
 }
 
+// drainSlice cordons the nodes of an expired slice and evicts the workloads running on them. It returns true once
+// the nodes are drained, or once the drain timeout elapses, in which case the remaining pods are force-deleted.
+// Otherwise, the slice is enqueued to check the progress again.
+func (c *Controller) drainSlice(sliceCopy *corev1alpha1.Slice) bool {
+	if sliceCopy.Status.State != corev1alpha1.StatusDraining || sliceCopy.Status.DrainStarted == nil {
+		c.recorder.Event(sliceCopy, corev1.EventTypeNormal, corev1alpha1.StatusDraining, messageDrainStarted)
+		drainStarted := metav1.Now()
+		sliceCopy.Status.State = corev1alpha1.StatusDraining
+		sliceCopy.Status.DrainStarted = &drainStarted
+	}
+	elapsed := time.Since(sliceCopy.Status.DrainStarted.Time)
+	force := elapsed >= c.drainTimeout
+
+	nodeRaw, err := c.kubeclientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/slice=%s", sliceCopy.GetName())})
+	if err != nil {
+		klog.Infoln(err)
+		c.enqueueSliceAfter(sliceCopy, drainPollInterval)
+		return false
+	}
+	pending, isListed := 0, true
+	for _, nodeRow := range nodeRaw.Items {
+		if !nodeRow.Spec.Unschedulable {
+			if err := c.cordonNode(nodeRow.GetName(), true); err != nil {
+				c.recorder.Event(sliceCopy, corev1.EventTypeWarning, failurePatch, messagePatchFailed)
+			}
+		}
+		podCount, err := c.evictPods(nodeRow.GetName(), force)
+		if err != nil {
+			klog.Infoln(err)
+			isListed = false
+		}
+		pending += podCount
+	}
+
+	if isListed && (pending == 0 || force) {
+		sliceCopy.Status.Draining = 0
+		sliceCopy.Status.Message = messageDrained
+		if pending != 0 {
+			c.recorder.Event(sliceCopy, corev1.EventTypeWarning, failureDrain, fmt.Sprintf(messageDrainTimeout, pending))
+			sliceCopy.Status.Message = fmt.Sprintf(messageDrainTimeout, pending)
+		}
+		c.updateStatus(context.TODO(), sliceCopy)
+		return true
+	}
+	if message := fmt.Sprintf(messageDraining, pending); sliceCopy.Status.Message != message || sliceCopy.Status.Draining != pending {
+		sliceCopy.Status.Draining = pending
+		sliceCopy.Status.Message = message
+		c.updateStatus(context.TODO(), sliceCopy)
+	}
+	after := drainPollInterval
+	if remaining := c.drainTimeout - elapsed; remaining > 0 && remaining < after {
+		after = remaining
+	}
+	c.enqueueSliceAfter(sliceCopy, after)
+	return false
+}
+
+// evictPods evicts the workloads running on the node, or force-deletes them once the drain times out, and returns
+// the number of pods found. The pods of daemon sets are left as they are, they would be recreated anyway.
+func (c *Controller) evictPods(node string, force bool) (int, error) {
+	podRaw, err := c.kubeclientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{FieldSelector: fmt.Sprintf("metadata.namespace!=kube-system,metadata.namespace!=edgenet,spec.nodeName=%s", node)})
+	if err != nil {
+		return 0, err
+	}
+	podCount := 0
+	for _, podRow := range podRaw.Items {
+		if ownerRef := metav1.GetControllerOf(&podRow); ownerRef != nil && ownerRef.Kind == "DaemonSet" {
+			continue
+		}
+		podCount++
+		if force {
+			var gracePeriod int64 = 0
+			if err := c.kubeclientset.CoreV1().Pods(podRow.GetNamespace()).Delete(context.TODO(), podRow.GetName(), metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod}); err != nil && !errors.IsNotFound(err) {
+				klog.Infoln(err)
+			}
+			continue
+		}
+		if podRow.GetDeletionTimestamp() != nil {
+			continue
+		}
+		eviction := &policyv1beta1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: podRow.GetName(), Namespace: podRow.GetNamespace()}}
+		// A disruption budget may refuse the eviction, which is retried until the drain times out
+		if err := c.kubeclientset.CoreV1().Pods(podRow.GetNamespace()).Evict(context.TODO(), eviction); err != nil && !errors.IsNotFound(err) {
+			klog.Infoln(err)
+		}
+	}
+	return podCount, nil
+}
+
 func (c *Controller) preReserveNodes(sliceCopy *corev1alpha1.Slice) bool {
 	for _, nodeSelectorTerm := range sliceCopy.Spec.NodeSelector.Selector.NodeSelectorTerms {
 		var labelSelector string
@@ -560,6 +697,10 @@ nodeLoop:
 			}
 			continue nodeLoop
 		}
+		// Workloads cannot land on a node that is not ready, nor on a tainted one unless they tolerate the taint
+		if !isNodeReady(nodeRow) || (c.skipTaintedNodes && hasNoScheduleTaint(nodeRow)) {
+			continue nodeLoop
+		}
 	limitLoop:
 		for limitKey, limitValue := range sliceCopy.Spec.NodeSelector.Resources.Limits {
 			if limitValue.Cmp(nodeRow.Status.Capacity[limitKey]) == -1 {
@@ -576,6 +717,26 @@ nodeLoop:
 	return associatedNodeList, nodeList
 }
 
+// isNodeReady tells whether the Ready condition of the node is true
+func isNodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// hasNoScheduleTaint tells whether the node is tainted to keep new pods away
+func hasNoScheduleTaint(node corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Controller) checkSliceStatus(sliceCopy *corev1alpha1.Slice, phase string) bool {
 	if nodeRaw, err := c.kubeclientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/%s=%s", phase, sliceCopy.GetName())}); err == nil {
 		associatedNodeList, _ := c.getFeasibleNodes(sliceCopy, nodeRaw)
@@ -594,15 +755,44 @@ func (c *Controller) returnNodes(sliceCopy *corev1alpha1.Slice) {
 			}
 		}
 	}
-	if sliceCopy.Status.State == corev1alpha1.StatusProvisioned {
+	if sliceCopy.Status.State == corev1alpha1.StatusProvisioned || sliceCopy.Status.State == corev1alpha1.StatusDraining {
 		if nodeRaw, err := c.kubeclientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/slice=%s", sliceCopy.GetName())}); err == nil {
 			for _, nodeRow := range nodeRaw.Items {
 				c.patchNode("return", "", nodeRow.GetName())
+				if sliceCopy.Status.State == corev1alpha1.StatusDraining {
+					c.cordonNode(nodeRow.GetName(), false)
+				}
+			}
+		}
+	}
+}
+
+// uncordonNodes makes the nodes of a slice whose drain is called off schedulable again
+func (c *Controller) uncordonNodes(sliceCopy *corev1alpha1.Slice) {
+	if nodeRaw, err := c.kubeclientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/slice=%s", sliceCopy.GetName())}); err == nil {
+		for _, nodeRow := range nodeRaw.Items {
+			if nodeRow.Spec.Unschedulable {
+				c.cordonNode(nodeRow.GetName(), false)
 			}
 		}
 	}
 }
 
+// cordonNode marks the node unschedulable, or schedulable again
+func (c *Controller) cordonNode(node string, unschedulable bool) error {
+	type patchBoolValue struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value bool   `json:"value"`
+	}
+	bytes, _ := json.Marshal([]patchBoolValue{{Op: "add", Path: "/spec/unschedulable", Value: unschedulable}})
+	_, err := c.kubeclientset.CoreV1().Nodes().Patch(context.TODO(), node, types.JSONPatchType, bytes, metav1.PatchOptions{})
+	if err != nil {
+		klog.Infoln(err.Error())
+	}
+	return err
+}
+
 func (c *Controller) patchNode(phase, slice, node string) error {
 	var err error
 	type patchStringValue struct {
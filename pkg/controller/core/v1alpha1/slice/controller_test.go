@@ -0,0 +1,257 @@
+package slice
+
+import (
+	"context"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	edgenettestclient "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/fake"
+	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+func init() {
+	klog.SetOutput(ioutil.Discard)
+}
+
+func TestDrainBeforeReclamation(t *testing.T) {
+	podsResource := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	// setup returns a controller whose slice is expired on a node running two workloads and a daemon set pod,
+	// the evictions are refused as long as refuse returns true
+	setup := func(refuse func(name string) bool) (*Controller, *testclient.Clientset, *edgenettestclient.Clientset, *record.FakeRecorder, *int) {
+		kubeclientset := testclient.NewSimpleClientset()
+		edgenetclientset := edgenettestclient.NewSimpleClientset()
+		edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+		controller, err := NewController(kubeclientset,
+			edgenetclientset,
+			edgenetInformerFactory.Core().V1alpha1().SliceClaims(),
+			edgenetInformerFactory.Core().V1alpha1().Slices(),
+			time.Minute,
+			false)
+		util.OK(t, err)
+		recorder := record.NewFakeRecorder(100)
+		controller.recorder = recorder
+
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{
+			"edge-net.io/access":          "private",
+			"edge-net.io/slice":           "slice",
+			"edge-net.io/pre-reservation": "slice",
+		}}}
+		kubeclientset.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+		for _, name := range []string{"web-1", "web-2", "agent"} {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "lab"}, Spec: corev1.PodSpec{NodeName: "node-1"}}
+			if name == "agent" {
+				isController := true
+				pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "agent", UID: "agent", Controller: &isController}}
+			}
+			kubeclientset.CoreV1().Pods("lab").Create(context.TODO(), pod, metav1.CreateOptions{})
+		}
+		kubeclientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetSubresource() != "eviction" {
+				return false, nil, nil
+			}
+			eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1beta1.Eviction)
+			if refuse(eviction.GetName()) {
+				return true, nil, errors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget", 10)
+			}
+			return true, nil, kubeclientset.Tracker().Delete(podsResource, eviction.GetNamespace(), eviction.GetName())
+		})
+
+		// Count the workloads left on the node when the slice, and its namespaces along with it, goes away
+		podsAtReclamation := -1
+		edgenetclientset.PrependReactor("delete", "slices", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			podRaw, _ := kubeclientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{})
+			podsAtReclamation = len(podRaw.Items) - 1
+			return false, nil, nil
+		})
+
+		expiry := metav1.NewTime(time.Now().Add(-time.Minute))
+		slice := &corev1alpha1.Slice{ObjectMeta: metav1.ObjectMeta{Name: "slice"}}
+		slice.Spec.NodeSelector.Count = 1
+		slice.Status.State = corev1alpha1.StatusProvisioned
+		slice.Status.Expiry = &expiry
+		edgenetclientset.CoreV1alpha1().Slices().Create(context.TODO(), slice, metav1.CreateOptions{})
+		return controller, kubeclientset, edgenetclientset, recorder, &podsAtReclamation
+	}
+	process := func(controller *Controller, edgenetclientset *edgenettestclient.Clientset) (*corev1alpha1.Slice, bool) {
+		slice, err := edgenetclientset.CoreV1alpha1().Slices().Get(context.TODO(), "slice", metav1.GetOptions{})
+		util.OK(t, err)
+		controller.processSlice(slice.DeepCopy())
+		slice, err = edgenetclientset.CoreV1alpha1().Slices().Get(context.TODO(), "slice", metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil, true
+		}
+		util.OK(t, err)
+		return slice, false
+	}
+	drainEvents := func(recorder *record.FakeRecorder) []string {
+		var events []string
+		for {
+			select {
+			case event := <-recorder.Events:
+				if strings.Contains(event, corev1alpha1.StatusDraining) || strings.Contains(event, failureDrain) || strings.Contains(event, successExpired) {
+					events = append(events, event)
+				}
+			default:
+				return events
+			}
+		}
+	}
+
+	t.Run("drained", func(t *testing.T) {
+		refused := false
+		controller, kubeclientset, edgenetclientset, recorder, podsAtReclamation := setup(func(name string) bool {
+			// The disruption budget of web-2 refuses the first eviction
+			if name == "web-2" && !refused {
+				refused = true
+				return true
+			}
+			return false
+		})
+
+		slice, deleted := process(controller, edgenetclientset)
+		util.Equals(t, false, deleted)
+		util.Equals(t, corev1alpha1.StatusDraining, slice.Status.State)
+		util.Equals(t, 2, slice.Status.Draining)
+		util.Equals(t, true, slice.Status.DrainStarted != nil)
+		node, err := kubeclientset.CoreV1().Nodes().Get(context.TODO(), "node-1", metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, true, node.Spec.Unschedulable)
+		_, err = kubeclientset.CoreV1().Pods("lab").Get(context.TODO(), "web-1", metav1.GetOptions{})
+		util.Equals(t, true, errors.IsNotFound(err))
+		_, err = kubeclientset.CoreV1().Pods("lab").Get(context.TODO(), "agent", metav1.GetOptions{})
+		util.OK(t, err)
+
+		slice, deleted = process(controller, edgenetclientset)
+		util.Equals(t, false, deleted)
+		util.Equals(t, 1, slice.Status.Draining)
+
+		_, deleted = process(controller, edgenetclientset)
+		util.Equals(t, true, deleted)
+		util.Equals(t, 0, *podsAtReclamation)
+		events := drainEvents(recorder)
+		util.Equals(t, 2, len(events))
+		util.Equals(t, true, strings.Contains(events[0], corev1alpha1.StatusDraining))
+		util.Equals(t, true, strings.Contains(events[1], successExpired))
+	})
+
+	t.Run("timed out", func(t *testing.T) {
+		controller, kubeclientset, edgenetclientset, recorder, podsAtReclamation := setup(func(name string) bool { return true })
+
+		slice, deleted := process(controller, edgenetclientset)
+		util.Equals(t, false, deleted)
+		util.Equals(t, 2, slice.Status.Draining)
+		_, deleted = process(controller, edgenetclientset)
+		util.Equals(t, false, deleted)
+
+		drainStarted := metav1.NewTime(slice.Status.DrainStarted.Add(-2 * time.Minute))
+		slice.Status.DrainStarted = &drainStarted
+		_, err := edgenetclientset.CoreV1alpha1().Slices().UpdateStatus(context.TODO(), slice, metav1.UpdateOptions{})
+		util.OK(t, err)
+		_, deleted = process(controller, edgenetclientset)
+		util.Equals(t, true, deleted)
+		util.Equals(t, 0, *podsAtReclamation)
+		podRaw, err := kubeclientset.CoreV1().Pods("lab").List(context.TODO(), metav1.ListOptions{})
+		util.OK(t, err)
+		util.Equals(t, 1, len(podRaw.Items))
+		events := drainEvents(recorder)
+		util.Equals(t, 3, len(events))
+		util.Equals(t, true, strings.Contains(events[1], failureDrain))
+	})
+
+	t.Run("extended", func(t *testing.T) {
+		controller, kubeclientset, edgenetclientset, _, _ := setup(func(name string) bool { return true })
+
+		slice, _ := process(controller, edgenetclientset)
+		expiry := metav1.NewTime(time.Now().Add(time.Hour))
+		slice.Status.Expiry = &expiry
+		_, err := edgenetclientset.CoreV1alpha1().Slices().UpdateStatus(context.TODO(), slice, metav1.UpdateOptions{})
+		util.OK(t, err)
+		slice, deleted := process(controller, edgenetclientset)
+		util.Equals(t, false, deleted)
+		util.Equals(t, corev1alpha1.StatusReconciliation, slice.Status.State)
+		util.Equals(t, true, slice.Status.DrainStarted == nil)
+		node, err := kubeclientset.CoreV1().Nodes().Get(context.TODO(), "node-1", metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, false, node.Spec.Unschedulable)
+	})
+}
+
+func TestNodeReadiness(t *testing.T) {
+	cases := map[string]struct {
+		count            int
+		skipTaintedNodes bool
+		expected         []string
+	}{
+		"ready nodes":               {2, false, []string{"ready", "tainted"}},
+		"not ready nodes left out":  {3, false, nil},
+		"tainted nodes skipped":     {1, true, []string{"ready"}},
+		"not enough feasible nodes": {2, true, nil},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			kubeclientset := testclient.NewSimpleClientset()
+			edgenetclientset := edgenettestclient.NewSimpleClientset()
+			edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+			controller, err := NewController(kubeclientset,
+				edgenetclientset,
+				edgenetInformerFactory.Core().V1alpha1().SliceClaims(),
+				edgenetInformerFactory.Core().V1alpha1().Slices(),
+				time.Minute,
+				tc.skipTaintedNodes)
+			util.OK(t, err)
+			controller.recorder = record.NewFakeRecorder(100)
+
+			conditions := map[string]corev1.ConditionStatus{"ready": corev1.ConditionTrue, "not-ready": corev1.ConditionFalse, "unknown": corev1.ConditionUnknown, "tainted": corev1.ConditionTrue, "no-condition": ""}
+			for name, status := range conditions {
+				node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+					"edge-net.io/access":          "public",
+					"edge-net.io/slice":           "none",
+					"edge-net.io/pre-reservation": "none",
+				}}}
+				node.Status.Capacity = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+				if status != "" {
+					node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}
+				}
+				if name == "tainted" {
+					node.Spec.Taints = []corev1.Taint{{Key: "node.kubernetes.io/maintenance", Effect: corev1.TaintEffectNoSchedule}}
+				}
+				_, err := kubeclientset.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+				util.OK(t, err)
+			}
+
+			slice := &corev1alpha1.Slice{ObjectMeta: metav1.ObjectMeta{Name: "slice"}}
+			slice.Spec.NodeSelector.Count = tc.count
+			slice.Spec.NodeSelector.Selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "edge-net.io/access", Operator: corev1.NodeSelectorOpIn, Values: []string{"public"}}}}}
+			slice.Spec.NodeSelector.Resources.Limits = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+			slice.Spec.NodeSelector.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+			util.Equals(t, tc.expected != nil, controller.preReserveNodes(slice))
+
+			nodeRaw, err := kubeclientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/pre-reservation=slice"})
+			util.OK(t, err)
+			var reserved []string
+			for _, nodeRow := range nodeRaw.Items {
+				reserved = append(reserved, nodeRow.GetName())
+			}
+			sort.Strings(reserved)
+			util.Equals(t, tc.expected, reserved)
+		})
+	}
+}
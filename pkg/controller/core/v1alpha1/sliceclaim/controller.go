@@ -24,12 +24,15 @@ import (
 	"time"
 
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	edgenetscheme "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/core/v1alpha1"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -56,6 +59,7 @@ const (
 	successQuotaCheck    = "Checked"
 	successBound         = "Bound"
 	failureQuotaShortage = "Shortage"
+	failureQuotaExceeded = "Quota Exceeded"
 	failureBound         = "Already Bound"
 	failureBinding       = "Binding Failed"
 	failureCreation      = "Creation Failed"
@@ -67,6 +71,7 @@ const (
 	messageQuotaCheck     = "The parent has sufficient quota"
 	messageBound          = "Slice is bound successfully"
 	messageQuotaShortage  = "Insufficient quota at the parent"
+	messageQuotaExceeded  = "Insufficient %s left in the tenant resource quota"
 	messageBoundAlready   = "Slice is bound to another claim already"
 	messageBindingFailed  = "Slice binding failed"
 	messageCreationFailed = "Slice creation failed"
@@ -88,6 +93,8 @@ type Controller struct {
 	subnamespacesSynced cache.InformerSynced
 
 	provisioning string
+	// quotaNames are the names of the resource quotas in the core and child namespaces
+	quotaNames multitenancy.QuotaNames
 
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
@@ -106,13 +113,24 @@ func NewController(
 	edgenetclientset clientset.Interface,
 	subnamespaceInformer informers.SubNamespaceInformer,
 	sliceclaimInformer informers.SliceClaimInformer,
-	provisioning string) *Controller {
+	provisioning string,
+	quotaNames multitenancy.QuotaNames) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("subnamespaceInformer", subnamespaceInformer)
+	validator.NotNil("sliceclaimInformer", sliceclaimInformer)
+	validator.OneOf("provisioning", provisioning, corev1alpha1.DynamicStr, corev1alpha1.ManualStr)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
 
 	utilruntime.Must(edgenetscheme.AddToScheme(scheme.Scheme))
 	klog.Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
 	controller := &Controller{
@@ -125,6 +143,7 @@ func NewController(
 		workqueue:           workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "SliceClaims"),
 		recorder:            recorder,
 		provisioning:        provisioning,
+		quotaNames:          quotaNames,
 	}
 
 	klog.Infoln("Setting up event handlers")
@@ -144,7 +163,7 @@ func NewController(
 		DeleteFunc: controller.handleSubNamespace,
 	})
 
-	return controller
+	return controller, nil
 }
 
 // Run will set up the event handlers for the types of slice claim and node, as well
@@ -203,12 +222,13 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
@@ -315,7 +335,7 @@ func (c *Controller) processSliceClaim(sliceclaimCopy *corev1alpha1.SliceClaim)
 				c.updateStatus(context.TODO(), sliceclaimCopy)
 				return
 			}
-			if isAllocated, isSufficient := c.checkResourceAllocation(sliceclaimCopy, fmt.Sprintf("%s-quota", namespaceLabels["edge-net.io/kind"])); !isSufficient || !isAllocated {
+			if isAllocated, isSufficient := c.checkResourceAllocation(sliceclaimCopy, c.quotaNames.ForKind(namespaceLabels["edge-net.io/kind"])); !isSufficient || !isAllocated {
 				c.recorder.Event(sliceclaimCopy, corev1.EventTypeNormal, corev1alpha1.StatusReconciliation, messageReconciliation)
 				sliceclaimCopy.Status.State = corev1alpha1.StatusReconciliation
 				sliceclaimCopy.Status.Message = messageReconciliation
@@ -323,7 +343,7 @@ func (c *Controller) processSliceClaim(sliceclaimCopy *corev1alpha1.SliceClaim)
 				return
 			}
 		case corev1alpha1.StatusBound:
-			isAllocated, isSufficient := c.checkResourceAllocation(sliceclaimCopy, fmt.Sprintf("%s-quota", namespaceLabels["edge-net.io/kind"]))
+			isAllocated, isSufficient := c.checkResourceAllocation(sliceclaimCopy, c.quotaNames.ForKind(namespaceLabels["edge-net.io/kind"]))
 			if !isSufficient {
 				return
 			}
@@ -335,7 +355,7 @@ func (c *Controller) processSliceClaim(sliceclaimCopy *corev1alpha1.SliceClaim)
 				return
 			}
 		case corev1alpha1.StatusRequested:
-			if _, isSufficient := c.checkResourceAllocation(sliceclaimCopy, fmt.Sprintf("%s-quota", namespaceLabels["edge-net.io/kind"])); !isSufficient {
+			if _, isSufficient := c.checkResourceAllocation(sliceclaimCopy, c.quotaNames.ForKind(namespaceLabels["edge-net.io/kind"])); !isSufficient {
 				return
 			}
 			if slice, err := c.edgenetclientset.CoreV1alpha1().Slices().Get(context.TODO(), sliceclaimCopy.Spec.SliceName, metav1.GetOptions{}); err == nil && slice.Spec.ClaimRef != nil && slice.Spec.ClaimRef.UID == sliceclaimCopy.GetUID() {
@@ -352,7 +372,7 @@ func (c *Controller) processSliceClaim(sliceclaimCopy *corev1alpha1.SliceClaim)
 			sliceclaimCopy.Status.Message = messageBindingFailed
 			c.updateStatus(context.TODO(), sliceclaimCopy)
 		case corev1alpha1.StatusPending:
-			if _, isSufficient := c.checkResourceAllocation(sliceclaimCopy, fmt.Sprintf("%s-quota", namespaceLabels["edge-net.io/kind"])); !isSufficient {
+			if _, isSufficient := c.checkResourceAllocation(sliceclaimCopy, c.quotaNames.ForKind(namespaceLabels["edge-net.io/kind"])); !isSufficient {
 				return
 			}
 			if slice, err := c.edgenetclientset.CoreV1alpha1().Slices().Get(context.TODO(), sliceclaimCopy.Spec.SliceName, metav1.GetOptions{}); err == nil {
@@ -371,6 +391,13 @@ func (c *Controller) processSliceClaim(sliceclaimCopy *corev1alpha1.SliceClaim)
 			}
 
 			if strings.EqualFold(c.provisioning, corev1alpha1.DynamicStr) {
+				if resourceName, isSufficient := c.checkTenantQuota(sliceclaimCopy, namespaceLabels["edge-net.io/tenant"]); !isSufficient {
+					c.recorder.Event(sliceclaimCopy, corev1.EventTypeWarning, failureQuotaExceeded, fmt.Sprintf(messageQuotaExceeded, resourceName))
+					sliceclaimCopy.Status.State = corev1alpha1.StatusQuotaExceeded
+					sliceclaimCopy.Status.Message = fmt.Sprintf(messageQuotaExceeded, resourceName)
+					c.updateStatus(context.TODO(), sliceclaimCopy)
+					return
+				}
 				if isCreated := c.createSlice(sliceclaimCopy.Spec.SliceName, sliceclaimCopy.Spec.SliceClassName, sliceclaimCopy.Spec.NodeSelector, sliceclaimCopy.MakeObjectReference(), sliceclaimCopy.Spec.SliceExpiry); isCreated {
 					c.recorder.Event(sliceclaimCopy, corev1.EventTypeNormal, successClaimed, messageClaimed)
 					sliceclaimCopy.Status.State = corev1alpha1.StatusRequested
@@ -383,8 +410,17 @@ func (c *Controller) processSliceClaim(sliceclaimCopy *corev1alpha1.SliceClaim)
 				sliceclaimCopy.Status.Message = messageCreationFailed
 				c.updateStatus(context.TODO(), sliceclaimCopy)
 			}
+		case corev1alpha1.StatusQuotaExceeded:
+			// The claim is held until the tenant has the quota to provision it
+			if _, isSufficient := c.checkTenantQuota(sliceclaimCopy, namespaceLabels["edge-net.io/tenant"]); !isSufficient {
+				return
+			}
+			c.recorder.Event(sliceclaimCopy, corev1.EventTypeNormal, pendingSlice, messageWaiting)
+			sliceclaimCopy.Status.State = corev1alpha1.StatusPending
+			sliceclaimCopy.Status.Message = messageWaiting
+			c.updateStatus(context.TODO(), sliceclaimCopy)
 		default:
-			if _, isSufficient := c.checkResourceAllocation(sliceclaimCopy, fmt.Sprintf("%s-quota", namespaceLabels["edge-net.io/kind"])); !isSufficient {
+			if _, isSufficient := c.checkResourceAllocation(sliceclaimCopy, c.quotaNames.ForKind(namespaceLabels["edge-net.io/kind"])); !isSufficient {
 				return
 			}
 			c.recorder.Event(sliceclaimCopy, corev1.EventTypeWarning, pendingSlice, messageWaiting)
@@ -455,18 +491,7 @@ func (c *Controller) bindSlice(sliceCopy *corev1alpha1.Slice, sliceclaimClass st
 
 func (c *Controller) checkResourceQuota(sliceclaimResourceLimits corev1.ResourceList, nodeCount int, parentNamespace, parentQuotaName string) bool {
 	if parentResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(parentNamespace).Get(context.TODO(), parentQuotaName, metav1.GetOptions{}); err == nil {
-		var resourceDemandList = make(corev1.ResourceList)
-		for key, value := range sliceclaimResourceLimits {
-			resourceDemand := value.DeepCopy()
-			for i := 0; i < nodeCount; i++ {
-				if _, elementExists := resourceDemandList[key]; elementExists {
-					resourceDemand.Add(value)
-					resourceDemandList[key] = resourceDemand
-				} else {
-					resourceDemandList[key] = resourceDemand
-				}
-			}
-		}
+		resourceDemandList := getResourceDemand(sliceclaimResourceLimits, nodeCount)
 		for key, value := range parentResourceQuota.Spec.Hard {
 			availableQuota := value.DeepCopy()
 			if _, elementExists := resourceDemandList[key]; elementExists {
@@ -480,6 +505,54 @@ func (c *Controller) checkResourceQuota(sliceclaimResourceLimits corev1.Resource
 	return true
 }
 
+// checkTenantQuota tells whether the tenant resource quota of the tenant, aggregated as by the tenant resource quota
+// controller, has what the slice claim demands left after the usage in the tenant's namespaces. It returns the first
+// resource found to be short otherwise. A tenant without a tenant resource quota, or a resource the quota does not
+// limit, is not held back.
+func (c *Controller) checkTenantQuota(sliceclaimCopy *corev1alpha1.SliceClaim, tenant string) (corev1.ResourceName, bool) {
+	if tenant == "" {
+		return "", true
+	}
+	tenantQuota, err := multitenancy.TenantQuota(c.edgenetclientset, tenant)
+	if err != nil {
+		return "", true
+	}
+	tenantQuotaResourceList, err := multitenancy.QuotaResourceList(tenantQuota)
+	if err != nil {
+		return "", true
+	}
+	usedResourceList := multitenancy.TenantQuotaUsage(c.kubeclientset, tenant)
+	for key, demand := range getResourceDemand(sliceclaimCopy.Spec.NodeSelector.Resources.Limits, sliceclaimCopy.Spec.NodeSelector.Count) {
+		key = multitenancy.QuotaResourceName(key)
+		quotaQuantity, elementExists := tenantQuotaResourceList[key]
+		if !elementExists {
+			continue
+		}
+		availableQuota := quotaQuantity.DeepCopy()
+		availableQuota.Sub(usedResourceList[key])
+		if availableQuota.Cmp(demand) == -1 {
+			return key, false
+		}
+	}
+	return "", true
+}
+
+// getResourceDemand returns the resources the slice claim demands, that is, the limits of a node times the node count
+func getResourceDemand(sliceclaimResourceLimits corev1.ResourceList, nodeCount int) corev1.ResourceList {
+	resourceDemandList := make(corev1.ResourceList)
+	if nodeCount < 1 {
+		return resourceDemandList
+	}
+	for key, value := range sliceclaimResourceLimits {
+		resourceDemand := value.DeepCopy()
+		for i := 1; i < nodeCount; i++ {
+			resourceDemand.Add(value)
+		}
+		resourceDemandList[key] = resourceDemand
+	}
+	return resourceDemandList
+}
+
 // updateStatus calls the API to update the slice claim status.
 func (c *Controller) updateStatus(ctx context.Context, sliceclaimCopy *corev1alpha1.SliceClaim) {
 	if sliceclaimCopy.Status.State == corev1alpha1.StatusFailed {
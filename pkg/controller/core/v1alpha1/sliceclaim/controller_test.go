@@ -0,0 +1,128 @@
+package sliceclaim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	edgenettestclient "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/fake"
+	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/klog"
+)
+
+func init() {
+	klog.SetOutput(ioutil.Discard)
+}
+
+func TestNewController(t *testing.T) {
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+
+	t.Run("valid", func(t *testing.T) {
+		for _, provisioning := range []string{corev1alpha1.DynamicStr, corev1alpha1.ManualStr, "dynamic"} {
+			controller, err := NewController(kubeclientset,
+				edgenetclientset,
+				edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+				edgenetInformerFactory.Core().V1alpha1().SliceClaims(),
+				provisioning,
+				multitenancy.DefaultQuotaNames)
+			util.OK(t, err)
+			util.Equals(t, true, controller != nil)
+		}
+	})
+	t.Run("nil dependencies", func(t *testing.T) {
+		var edgenetclientset *edgenettestclient.Clientset
+		controller, err := NewController(nil,
+			edgenetclientset,
+			nil,
+			edgenetInformerFactory.Core().V1alpha1().SliceClaims(),
+			"Automatic",
+			multitenancy.DefaultQuotaNames)
+		util.Equals(t, true, controller == nil)
+		util.Equals(t, "sliceclaim-controller: kubeclientset must not be nil; "+
+			"sliceclaim-controller: edgenetclientset must not be nil; "+
+			"sliceclaim-controller: subnamespaceInformer must not be nil; "+
+			"sliceclaim-controller: provisioning must be one of Dynamic, Manual, got \"Automatic\"", err.Error())
+		var validationErrors validation.Errors
+		util.Equals(t, true, errors.As(err, &validationErrors))
+		util.Equals(t, 4, len(validationErrors))
+	})
+}
+
+func TestTenantQuotaAdmission(t *testing.T) {
+	cases := map[string]struct {
+		used     string
+		expected string
+	}{
+		"quota left":     {"2", corev1alpha1.StatusRequested},
+		"quota exceeded": {"6", corev1alpha1.StatusQuotaExceeded},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "edgenet", Labels: map[string]string{"edge-net.io/tenant": "edgenet", "edge-net.io/kind": "core"}}}
+			resourceQuota := &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: multitenancy.DefaultQuotaNames.Core, Namespace: "edgenet"},
+				Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")}},
+				Status:     corev1.ResourceQuotaStatus{Used: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(tc.used)}},
+			}
+			kubeclientset := testclient.NewSimpleClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, namespace, resourceQuota)
+
+			tenantResourceQuota := &corev1alpha1.TenantResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "edgenet"},
+				Spec: corev1alpha1.TenantResourceQuotaSpec{
+					Claim: map[string]corev1alpha1.ResourceTuning{"initial": {ResourceList: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("8")}}},
+				},
+			}
+			sliceclaim := &corev1alpha1.SliceClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "edgenet-slice", Namespace: "edgenet"},
+				Spec: corev1alpha1.SliceClaimSpec{
+					SliceClassName: "Node",
+					SliceName:      "edgenet-slice",
+					NodeSelector: corev1alpha1.NodeSelector{
+						Count:     2,
+						Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}},
+					},
+				},
+				Status: corev1alpha1.SliceClaimStatus{State: corev1alpha1.StatusPending},
+			}
+			edgenetclientset := edgenettestclient.NewSimpleClientset(tenantResourceQuota, sliceclaim)
+			edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+			controller, err := NewController(kubeclientset,
+				edgenetclientset,
+				edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+				edgenetInformerFactory.Core().V1alpha1().SliceClaims(),
+				corev1alpha1.DynamicStr,
+				multitenancy.DefaultQuotaNames)
+			util.OK(t, err)
+
+			controller.processSliceClaim(sliceclaim.DeepCopy())
+			sliceclaimCopy, err := edgenetclientset.CoreV1alpha1().SliceClaims("edgenet").Get(context.TODO(), sliceclaim.GetName(), metav1.GetOptions{})
+			util.OK(t, err)
+			util.Equals(t, tc.expected, sliceclaimCopy.Status.State)
+			_, err = edgenetclientset.CoreV1alpha1().Slices().Get(context.TODO(), sliceclaim.Spec.SliceName, metav1.GetOptions{})
+			util.Equals(t, tc.expected == corev1alpha1.StatusRequested, err == nil)
+			if tc.expected == corev1alpha1.StatusQuotaExceeded {
+				util.Equals(t, fmt.Sprintf(messageQuotaExceeded, corev1.ResourceCPU), sliceclaimCopy.Status.Message)
+				util.Equals(t, 0, sliceclaimCopy.Status.Failed)
+
+				// The held claim stays held until the quota frees up
+				controller.processSliceClaim(sliceclaimCopy.DeepCopy())
+				sliceclaimCopy, err = edgenetclientset.CoreV1alpha1().SliceClaims("edgenet").Get(context.TODO(), sliceclaim.GetName(), metav1.GetOptions{})
+				util.OK(t, err)
+				util.Equals(t, corev1alpha1.StatusQuotaExceeded, sliceclaimCopy.Status.State)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subnamespace
+
+import (
+	"context"
+	"fmt"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Clone creates a subnamespace named newName next to the source subnamespace, with the same spec, labels, and
+// annotations. The status is left for the controller to fill in, and an adopted namespace is not carried over, as the
+// clone gets a namespace of its own. It fails without creating anything if the parent lacks the quota to allocate
+// the resources of the source once more.
+func Clone(edgenetclientset clientset.Interface, srcNamespace, srcName, newName string) (*corev1alpha1.SubNamespace, error) {
+	source, err := edgenetclientset.CoreV1alpha1().SubNamespaces(srcNamespace).Get(context.TODO(), srcName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if resourceName, isSufficient := isCloneQuotaSufficient(edgenetclientset, source); !isSufficient {
+		return nil, fmt.Errorf("namespace %s lacks the %s to clone subnamespace %s", srcNamespace, resourceName, srcName)
+	}
+
+	clone := &corev1alpha1.SubNamespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        newName,
+			Namespace:   srcNamespace,
+			Labels:      source.DeepCopy().GetLabels(),
+			Annotations: source.DeepCopy().GetAnnotations(),
+		},
+		Spec: *source.Spec.DeepCopy(),
+	}
+	if clone.Spec.Workspace != nil {
+		clone.Spec.Workspace.Adopt = ""
+	}
+	return edgenetclientset.CoreV1alpha1().SubNamespaces(srcNamespace).Create(context.TODO(), clone, metav1.CreateOptions{})
+}
+
+// isCloneQuotaSufficient tells whether the quota of the parent covers the subnamespaces already carved out of it along
+// with a copy of the source, and returns the first resource found to be short otherwise. The parent quota is that of
+// the subnamespace owning the namespace or, for the core namespace of a tenant, the tenant quota.
+func isCloneQuotaSufficient(edgenetclientset clientset.Interface, source *corev1alpha1.SubNamespace) (corev1.ResourceName, bool) {
+	resourceAllocation := source.GetResourceAllocation()
+	if resourceAllocation == nil {
+		return "", true
+	}
+	parentQuotaResourceList, err := parentQuota(edgenetclientset, source.GetNamespace())
+	if err != nil {
+		return "", false
+	}
+	subnamespaceRaw, err := edgenetclientset.CoreV1alpha1().SubNamespaces(source.GetNamespace()).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return "", false
+	}
+	allocatedQuotaResourceList := make(map[corev1.ResourceName]resource.Quantity)
+	for key, quantity := range resourceAllocation {
+		allocatedQuotaResourceList[multitenancy.QuotaResourceName(key)] = quantity.DeepCopy()
+	}
+	for _, subnamespaceRow := range subnamespaceRaw.Items {
+		if subnamespaceRow.Status.State == corev1alpha1.StatusFailed || len(subnamespaceRow.Status.Reclaimed) != 0 {
+			continue
+		}
+		for key, quantity := range subnamespaceRow.GetResourceAllocation() {
+			allocatedQuantity := allocatedQuotaResourceList[multitenancy.QuotaResourceName(key)]
+			allocatedQuantity.Add(quantity)
+			allocatedQuotaResourceList[multitenancy.QuotaResourceName(key)] = allocatedQuantity
+		}
+	}
+	for key, allocatedQuantity := range allocatedQuotaResourceList {
+		if quotaQuantity, elementExists := parentQuotaResourceList[key]; !elementExists || quotaQuantity.Cmp(allocatedQuantity) == -1 {
+			return key, false
+		}
+	}
+	return "", true
+}
+
+// parentQuota returns the quota of the namespace out of which subnamespaces are carved, named as in a resource quota.
+func parentQuota(edgenetclientset clientset.Interface, namespace string) (map[corev1.ResourceName]resource.Quantity, error) {
+	subnamespaceRaw, err := edgenetclientset.CoreV1alpha1().SubNamespaces(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, subnamespaceRow := range subnamespaceRaw.Items {
+		if subnamespaceRow.Status.Child != nil && *subnamespaceRow.Status.Child == namespace {
+			parentQuotaResourceList := make(map[corev1.ResourceName]resource.Quantity)
+			for key, quantity := range subnamespaceRow.GetResourceAllocation() {
+				parentQuotaResourceList[multitenancy.QuotaResourceName(key)] = quantity
+			}
+			return parentQuotaResourceList, nil
+		}
+	}
+	tenantQuota, err := multitenancy.TenantQuota(edgenetclientset, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return multitenancy.QuotaResourceList(tenantQuota)
+}
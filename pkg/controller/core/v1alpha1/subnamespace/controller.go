@@ -20,17 +20,22 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
 	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	edgenetscheme "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/core/v1alpha1"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
 	"github.com/google/uuid"
 
@@ -42,6 +47,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformers "k8s.io/client-go/informers/core/v1"
@@ -54,19 +60,30 @@ import (
 	rbaclisters "k8s.io/client-go/listers/rbac/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 )
 
 const controllerAgentName = "subnamespace-controller"
 
+// QuotaUpdatedAnnotation is stamped on the child namespace of a workspace with the time its quota changed, so that
+// workload operators can watch it and react, for example by scaling down once the quota is reduced
+const QuotaUpdatedAnnotation = "edge-net.io/quota-updated-at"
+
 // Definitions of the state of the subnamespace resource
 const (
 	backoffLimit = 3
+	// inheritanceWorkers bounds the number of objects copied to a child at once
+	inheritanceWorkers = 10
 
 	successSynced        = "Synced"
 	successExpired       = "Expired"
 	successSlice         = "Slice Ready"
+	successReset         = "Reset"
+	successAdopted       = "Adopted"
+	successShrunk        = "Shrunk"
+	successRestored      = "Restored"
 	failureQuotaShortage = "Shortage"
 	failureUpdate        = "Not Updated"
 	failureApplied       = "Not Applied"
@@ -74,18 +91,29 @@ const (
 	failureInheritance   = "Not Inherited"
 	failureBinding       = "Binding Failed"
 	failureCollision     = "Name Collision"
+	failureDuplicateName = "DuplicateChildName"
 	failureSlice         = "Slice Unready"
+	failureAllocation    = "Invalid Allocation"
+	failureChildMissing  = "Child Missing"
+	failureReset         = "Not Reset"
+	failureSchedule      = "Invalid Schedule"
+	failureOverallocated = "Overallocated"
+	failureBelowMinimum  = "Below Minimum"
+	successBumped        = "Bumped"
+	warningEmpty         = "Empty"
 
 	messageResourceSynced      = "Subsidiary namespace synced successfully"
 	messageEstablished         = "Subsidiary namespace established"
 	messageExpired             = "Subsidiary namespace deleted"
 	messageQuotaCheck          = "The parent has sufficient quota"
 	messageApplyFail           = "Child quota cannot be applied"
+	messageRolledBack          = "Child namespace rolled back as its quota cannot be applied"
 	messageCreation            = "Subsidiary namespace created"
 	messageCreationFail        = "Subsidiary namespace cannot be created"
 	messageNSUpdateFail        = "Subsidiary namespace cannot be updated"
 	messageInheritanceFail     = "Inheritance from parent to child failed"
 	messageCollision           = "Name is not available. Please choose another one."
+	messageDuplicateName       = "Child name %s is already taken by subnamespace %s/%s"
 	messageSubnamespaceDeleted = "Last created child subnamespace has been deleted due to insufficient quota "
 	messageParentQuotaShortage = "Insufficient quota at the parent"
 	messageUpdateFail          = "Quota cannot be updated"
@@ -95,6 +123,18 @@ const (
 	messagePartitioned         = "Parent resource quota has been partitioned among its children and itself"
 	messageApplied             = "Child quota applied successfully"
 	messageReconciliation      = "Reconciliation in progress"
+	messageAllocationFail      = "Resource allocation is invalid"
+	messageChildMissing        = "Child namespace missing"
+	messageReset               = "Child namespace is being reset on schedule"
+	messageResetFail           = "Child namespace cannot be reset"
+	messageScheduleInvalid     = "Reset schedule is invalid"
+	messageAdopted             = "Existing namespace adopted as the child"
+	messageShrunk              = "Idle workspace quota reclaimed by the parent"
+	messageRestored            = "Reclaimed workspace quota restored"
+	messageEmpty               = "Subsidiary namespace requests neither resources nor inheritance"
+	messageOverallocated       = "Tenant subnamespaces already take up the whole tenant quota of %s"
+	messageBelowMinimum        = "Resource allocation of %s is below the minimum of %s"
+	messageBumped              = "Resource allocation of %s raised to the minimum of %s"
 )
 
 // Controller is the controller implementation for Subsidiary Namespace resources
@@ -124,6 +164,44 @@ type Controller struct {
 
 	multitenancyManager *multitenancy.Manager
 
+	// quotaRounding determines how allocations that do not divide evenly into millicores or bytes
+	// are rounded before they are debited from the parent quota
+	quotaRounding multitenancy.RoundingPolicy
+	// repairMissingChild determines whether a child deleted out-of-band is re-created, or only
+	// reported in the status of its subnamespace
+	repairMissingChild bool
+	// tenantLabelKeys lists the tenant labels copied to the objects generated for the tenant
+	tenantLabelKeys []string
+	// resyncPeriod is the interval at which a successfully synced subnamespace is processed again,
+	// so that changes made to its generated objects by others are reverted; zero disables it
+	resyncPeriod time.Duration
+	// quotaNames are the names of the resource quotas in the core and child namespaces
+	quotaNames multitenancy.QuotaNames
+	// copyQuotaScopes determines whether the child quota of a workspace takes over the scopes of the parent quota,
+	// so that both count the same kind of objects
+	copyQuotaScopes bool
+	// rejectEmpty determines whether a subnamespace requesting neither resources nor inheritance fails,
+	// rather than only being warned about
+	rejectEmpty bool
+	// minimumAllocation holds the smallest quantity of each resource a subnamespace can be allocated, so that
+	// none is too small to schedule anything
+	minimumAllocation map[corev1.ResourceName]resource.Quantity
+	// bumpBelowMinimum determines whether an allocation below the minimum is raised to it, rather than failing
+	bumpBelowMinimum bool
+	// childOnlyResources lists the resources that only limit the child, such as pods, which are not debited from
+	// the parent quota so that a child can be capped on them without taking from the budget of its parent
+	childOnlyResources multitenancy.ChildOnlyResources
+	// debounceInterval postpones the reconcile of a subnamespace whose spec changes, so that the changes arriving
+	// within the interval are coalesced into a single reconcile of the latest spec; zero disables it
+	debounceInterval time.Duration
+
+	// inheritanceResyncDelay postpones the resync of children after a change in their parent
+	// so that a burst of changes results in a single resync
+	inheritanceResyncDelay time.Duration
+	// resetPollDelay is the interval at which a child being reset is checked for the completion
+	// of its deletion, before it is re-created
+	resetPollDelay time.Duration
+
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
@@ -135,6 +213,45 @@ type Controller struct {
 	recorder record.EventRecorder
 }
 
+// Options holds the settings that tune the behavior of the controller
+type Options struct {
+	// QuotaRounding determines how allocations that do not divide evenly into millicores or bytes
+	// are rounded before they are debited from the parent quota
+	QuotaRounding multitenancy.RoundingPolicy
+	// RepairMissingChild determines whether a child deleted out-of-band is re-created, or only
+	// reported in the status of its subnamespace
+	RepairMissingChild bool
+	// TenantLabelKeys lists the tenant labels copied to the objects generated for the tenant
+	TenantLabelKeys []string
+	// ResyncPeriod is the interval at which a successfully synced subnamespace is processed again; zero disables it
+	ResyncPeriod time.Duration
+	// QuotaNames are the names of the resource quotas in the core and child namespaces
+	QuotaNames multitenancy.QuotaNames
+	// CopyQuotaScopes determines whether the child quota of a workspace takes over the scopes of the parent quota
+	CopyQuotaScopes bool
+	// RejectEmpty determines whether a subnamespace requesting neither resources nor inheritance fails
+	RejectEmpty bool
+	// MinimumAllocation holds the smallest quantity of each resource a subnamespace can be allocated
+	MinimumAllocation map[corev1.ResourceName]resource.Quantity
+	// BumpBelowMinimum determines whether an allocation below the minimum is raised to it, rather than failing
+	BumpBelowMinimum bool
+	// ChildOnlyResources lists the resources that only limit the child and are not debited from the parent quota
+	ChildOnlyResources multitenancy.ChildOnlyResources
+	// DebounceInterval postpones the reconcile of a subnamespace whose spec changes; zero disables it
+	DebounceInterval time.Duration
+}
+
+// DefaultOptions returns the options the controller runs with unless configured otherwise
+func DefaultOptions() Options {
+	return Options{
+		QuotaRounding:      multitenancy.RoundFloor,
+		RepairMissingChild: true,
+		TenantLabelKeys:    multitenancy.DefaultTenantLabelKeys,
+		QuotaNames:         multitenancy.DefaultQuotaNames,
+		CopyQuotaScopes:    true,
+	}
+}
+
 // NewController returns a new controller
 func NewController(
 	kubeclientset kubernetes.Interface,
@@ -146,39 +263,68 @@ func NewController(
 	secretInformer coreinformers.SecretInformer,
 	configmapInformer coreinformers.ConfigMapInformer,
 	serviceaccountInformer coreinformers.ServiceAccountInformer,
-	subnamespaceInformer informers.SubNamespaceInformer) *Controller {
+	subnamespaceInformer informers.SubNamespaceInformer,
+	options Options) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("roleInformer", roleInformer)
+	validator.NotNil("rolebindingInformer", rolebindingInformer)
+	validator.NotNil("networkpolicyInformer", networkpolicyInformer)
+	validator.NotNil("limitrangeInformer", limitrangeInformer)
+	validator.NotNil("secretInformer", secretInformer)
+	validator.NotNil("configmapInformer", configmapInformer)
+	validator.NotNil("serviceaccountInformer", serviceaccountInformer)
+	validator.NotNil("subnamespaceInformer", subnamespaceInformer)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
 
 	utilruntime.Must(edgenetscheme.AddToScheme(scheme.Scheme))
 	klog.Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
 	multitenancyManager := multitenancy.NewManager(kubeclientset, edgenetclientset)
 
 	controller := &Controller{
-		kubeclientset:         kubeclientset,
-		edgenetclientset:      edgenetclientset,
-		rolesLister:           roleInformer.Lister(),
-		rolesSynced:           roleInformer.Informer().HasSynced,
-		rolebindingsLister:    rolebindingInformer.Lister(),
-		rolebindingsSynced:    roleInformer.Informer().HasSynced,
-		networkpoliciesLister: networkpolicyInformer.Lister(),
-		networkpoliciesSynced: networkpolicyInformer.Informer().HasSynced,
-		limitrangesLister:     limitrangeInformer.Lister(),
-		limitrangesSynced:     limitrangeInformer.Informer().HasSynced,
-		secretsLister:         secretInformer.Lister(),
-		secretsSynced:         secretInformer.Informer().HasSynced,
-		configmapsLister:      configmapInformer.Lister(),
-		configmapsSynced:      configmapInformer.Informer().HasSynced,
-		serviceaccountsLister: serviceaccountInformer.Lister(),
-		serviceaccountsSynced: serviceaccountInformer.Informer().HasSynced,
-		subnamespacesLister:   subnamespaceInformer.Lister(),
-		subnamespacesSynced:   subnamespaceInformer.Informer().HasSynced,
-		workqueue:             workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "SubNamespaces"),
-		recorder:              recorder,
-		multitenancyManager:   multitenancyManager,
+		kubeclientset:          kubeclientset,
+		edgenetclientset:       edgenetclientset,
+		rolesLister:            roleInformer.Lister(),
+		rolesSynced:            roleInformer.Informer().HasSynced,
+		rolebindingsLister:     rolebindingInformer.Lister(),
+		rolebindingsSynced:     rolebindingInformer.Informer().HasSynced,
+		networkpoliciesLister:  networkpolicyInformer.Lister(),
+		networkpoliciesSynced:  networkpolicyInformer.Informer().HasSynced,
+		limitrangesLister:      limitrangeInformer.Lister(),
+		limitrangesSynced:      limitrangeInformer.Informer().HasSynced,
+		secretsLister:          secretInformer.Lister(),
+		secretsSynced:          secretInformer.Informer().HasSynced,
+		configmapsLister:       configmapInformer.Lister(),
+		configmapsSynced:       configmapInformer.Informer().HasSynced,
+		serviceaccountsLister:  serviceaccountInformer.Lister(),
+		serviceaccountsSynced:  serviceaccountInformer.Informer().HasSynced,
+		subnamespacesLister:    subnamespaceInformer.Lister(),
+		subnamespacesSynced:    subnamespaceInformer.Informer().HasSynced,
+		workqueue:              workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "SubNamespaces"),
+		recorder:               recorder,
+		multitenancyManager:    multitenancyManager,
+		quotaRounding:          options.QuotaRounding,
+		repairMissingChild:     options.RepairMissingChild,
+		tenantLabelKeys:        options.TenantLabelKeys,
+		resyncPeriod:           options.ResyncPeriod,
+		quotaNames:             options.QuotaNames,
+		copyQuotaScopes:        options.CopyQuotaScopes,
+		rejectEmpty:            options.RejectEmpty,
+		minimumAllocation:      options.MinimumAllocation,
+		bumpBelowMinimum:       options.BumpBelowMinimum,
+		childOnlyResources:     options.ChildOnlyResources,
+		debounceInterval:       options.DebounceInterval,
+		inheritanceResyncDelay: 30 * time.Second,
+		resetPollDelay:         5 * time.Second,
 	}
 
 	klog.Infoln("Setting up event handlers")
@@ -191,17 +337,11 @@ func NewController(
 			}
 			controller.enqueueSubNamespace(obj)
 		},
-		UpdateFunc: func(old, new interface{}) {
-			newSubnamespace := new.(*corev1alpha1.SubNamespace)
-			oldSubnamespace := old.(*corev1alpha1.SubNamespace)
-			controller.enqueueSubNamespace(new)
-			if (oldSubnamespace.Spec.Expiry == nil && newSubnamespace.Spec.Expiry != nil) ||
-				(oldSubnamespace.Spec.Expiry != nil && newSubnamespace.Spec.Expiry != nil && !oldSubnamespace.Spec.Expiry.Time.Equal(newSubnamespace.Spec.Expiry.Time) && time.Until(newSubnamespace.Spec.Expiry.Time) > 0) {
-				controller.enqueueSubNamespaceAfter(new, time.Until(newSubnamespace.Spec.Expiry.Time))
-			}
-		}, DeleteFunc: func(obj interface{}) {
-			subnamespace := obj.(*corev1alpha1.SubNamespace)
-			controller.cleanup(subnamespace)
+		UpdateFunc: controller.handleSubNamespaceUpdate,
+		DeleteFunc: func(obj interface{}) {
+			subnamespaceCopy := obj.(*corev1alpha1.SubNamespace).DeepCopy()
+			// The deleted subnamespace cannot be requeued, so its cleanup waits for the end of the maintenance
+			maintenance.WhenResumed(func() { controller.cleanup(subnamespaceCopy) })
 		},
 	})
 
@@ -290,7 +430,7 @@ func NewController(
 		DeleteFunc: controller.handleObject,
 	})
 
-	return controller
+	return controller, nil
 }
 
 // Run will set up the event handlers for the types of subsidiary namespace and node, as well
@@ -348,12 +488,13 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
@@ -387,6 +528,9 @@ func (c *Controller) syncHandler(key string) error {
 
 	c.processSubNamespace(subnamespace.DeepCopy())
 	c.recorder.Event(subnamespace, corev1.EventTypeNormal, successSynced, messageResourceSynced)
+	if c.resyncPeriod > 0 {
+		c.enqueueSubNamespaceAfter(subnamespace, c.resyncPeriod)
+	}
 	return nil
 }
 
@@ -416,6 +560,23 @@ func (c *Controller) enqueueSubNamespaceAfter(obj interface{}, after time.Durati
 	c.workqueue.AddAfter(key, after)
 }
 
+// handleSubNamespaceUpdate enqueues the updated Subsidiary Namespace resource, after the debounce interval if
+// its spec has changed, and once more at its expiry date if that has changed.
+func (c *Controller) handleSubNamespaceUpdate(old, new interface{}) {
+	newSubnamespace := new.(*corev1alpha1.SubNamespace)
+	oldSubnamespace := old.(*corev1alpha1.SubNamespace)
+	// The status updates made by the controller itself are not held back, as they move the reconcile along
+	if c.debounceInterval > 0 && !reflect.DeepEqual(oldSubnamespace.Spec, newSubnamespace.Spec) {
+		c.enqueueSubNamespaceAfter(new, c.debounceInterval)
+	} else {
+		c.enqueueSubNamespace(new)
+	}
+	if (oldSubnamespace.Spec.Expiry == nil && newSubnamespace.Spec.Expiry != nil) ||
+		(oldSubnamespace.Spec.Expiry != nil && newSubnamespace.Spec.Expiry != nil && !oldSubnamespace.Spec.Expiry.Time.Equal(newSubnamespace.Spec.Expiry.Time) && time.Until(newSubnamespace.Spec.Expiry.Time) > 0) {
+		c.enqueueSubNamespaceAfter(new, time.Until(newSubnamespace.Spec.Expiry.Time))
+	}
+}
+
 // handleObject will take any resource implementing metav1.Object and attempt
 // to find the SubNamespace resource that 'owns' its namespace. It does this by
 // looking at the objects metadata.ownerReferences field for an appropriate OwnerReference.
@@ -439,22 +600,27 @@ func (c *Controller) handleObject(obj interface{}) {
 	}
 	klog.Infof("Processing object: %s", object.GetName())
 
-	namespace, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), object.GetNamespace(), metav1.GetOptions{})
-	if err != nil {
-		return
-	}
-
-	subnamespaceRaw, err := c.subnamespacesLister.SubNamespaces(object.GetNamespace()).List(labels.Everything())
-	if err == nil {
+	// A change in a parent namespace is inherited by the children that sync this kind of object.
+	// The lookup is served from the cache, so that the namespaces without subnamespaces cause no churn.
+	kind := inheritanceKindOf(object)
+	if subnamespaceRaw, err := c.subnamespacesLister.SubNamespaces(object.GetNamespace()).List(labels.Everything()); err == nil {
 		for _, subnamespaceRow := range subnamespaceRaw {
-			if subnamespaceRow.Spec.Workspace != nil && subnamespaceRow.Spec.Workspace.Sync {
-				c.enqueueSubNamespaceAfter(subnamespaceRow, 30*time.Second)
+			if subnamespaceRow.Spec.Workspace != nil && subnamespaceRow.Spec.Workspace.Sync && subnamespaceRow.Spec.Workspace.Inheritance[kind] {
+				c.enqueueSubNamespaceAfter(subnamespaceRow, c.inheritanceResyncDelay)
 			}
 		}
 	}
 
-	objectLabels := object.GetLabels()
-	if ownerRef := metav1.GetControllerOf(namespace); ownerRef != nil && objectLabels["edge-net.io/generated"] == "true" {
+	// A change in an inherited copy within a child namespace gets reverted by its subnamespace
+	if object.GetLabels()["edge-net.io/generated"] != "true" {
+		return
+	}
+	namespace, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), object.GetNamespace(), metav1.GetOptions{})
+	if err != nil {
+		return
+	}
+
+	if ownerRef := metav1.GetControllerOf(namespace); ownerRef != nil {
 		if ownerRef.Kind != "Namespace" {
 			return
 		}
@@ -465,7 +631,7 @@ func (c *Controller) handleObject(obj interface{}) {
 		}
 		parentnamespaceLabels := parentnamespace.GetLabels()
 
-		subnamespaceRaw, err = c.subnamespacesLister.SubNamespaces(ownerRef.Name).List(labels.Everything())
+		subnamespaceRaw, err := c.subnamespacesLister.SubNamespaces(ownerRef.Name).List(labels.Everything())
 		if err != nil {
 			klog.Infof("ignoring orphaned object '%s' of subnamespace '%s'", object.GetSelfLink(), ownerRef.Name)
 		} else {
@@ -482,6 +648,25 @@ func (c *Controller) handleObject(obj interface{}) {
 	}
 }
 
+// inheritanceKindOf returns the key of the workspace inheritance map that covers the object
+func inheritanceKindOf(object metav1.Object) string {
+	switch object.(type) {
+	case *rbacv1.Role, *rbacv1.RoleBinding:
+		return "rbac"
+	case *networkingv1.NetworkPolicy:
+		return "networkpolicy"
+	case *corev1.LimitRange:
+		return "limitrange"
+	case *corev1.Secret:
+		return "secret"
+	case *corev1.ConfigMap:
+		return "configmap"
+	case *corev1.ServiceAccount:
+		return "serviceaccount"
+	}
+	return ""
+}
+
 func (c *Controller) processSubNamespace(subnamespaceCopy *corev1alpha1.SubNamespace) {
 	if subnamespaceCopy.Spec.Expiry != nil && time.Until(subnamespaceCopy.Spec.Expiry.Time) <= 0 {
 		c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, successExpired, messageExpired)
@@ -492,6 +677,34 @@ func (c *Controller) processSubNamespace(subnamespaceCopy *corev1alpha1.SubNames
 		c.cleanup(subnamespaceCopy)
 		return
 	}
+	resourceAllocation, err := multitenancy.RoundResourceList(subnamespaceCopy.GetResourceAllocation(), c.quotaRounding)
+	if err == nil {
+		_, err = multitenancy.QuotaResourceList(resourceAllocation)
+	}
+	if err != nil {
+		c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureAllocation, err.Error())
+		subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
+		subnamespaceCopy.Status.Message = fmt.Sprintf("%s: %s", messageAllocationFail, err)
+		c.updateStatus(context.TODO(), subnamespaceCopy)
+		return
+	}
+	// The minimum applies to new allocations only, so that raising it does not fail the subnamespaces already established
+	if allocationChanged(subnamespaceCopy.Status.ResourceAllocation, resourceAllocation) {
+		if name, minimum, isBelowMinimum := c.belowMinimum(resourceAllocation); isBelowMinimum {
+			if c.bumpBelowMinimum {
+				// The spec is updated to the minimum, so that the quota debited from the parent matches the allocation
+				c.raiseToMinimum(subnamespaceCopy)
+				return
+			}
+			message := fmt.Sprintf(messageBelowMinimum, name, minimum.String())
+			c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureBelowMinimum, message)
+			subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
+			subnamespaceCopy.Status.Message = message
+			c.updateStatus(context.TODO(), subnamespaceCopy)
+			return
+		}
+	}
+	subnamespaceCopy.Status.ResourceAllocation = resourceAllocation
 
 	permitted, parentNamespace, parentNamespaceLabels := c.multitenancyManager.EligibilityCheck(subnamespaceCopy.GetNamespace())
 	if permitted {
@@ -500,13 +713,43 @@ func (c *Controller) processSubNamespace(subnamespaceCopy *corev1alpha1.SubNames
 			childNameHashed = *subnamespaceCopy.Status.Child
 		} else {
 			childNameHashed = subnamespaceCopy.GenerateChildName(parentNamespaceLabels["edge-net.io/cluster-uid"])
+		}
+		// Quota debits and credits on the parent and the child are serialized with the other reconciles
+		// touching them, and with the tenant resource quota reconciliation running in this process
+		unlock := multitenancy.LockNamespaces(parentNamespaceLabels["edge-net.io/tenant"], parentNamespace.GetName(), childNameHashed)
+		defer unlock()
+
+		if subnamespaceCopy.Status.Child == nil {
+			if isDuplicate := c.checkDuplicateChildName(subnamespaceCopy, parentNamespaceLabels["edge-net.io/cluster-uid"], childNameHashed); isDuplicate {
+				return
+			}
 			if hasConflict := c.checkNamespaceCollision(subnamespaceCopy, parentNamespace, childNameHashed); hasConflict {
 				return
 			}
 		}
 
+		if isChildMissing := c.isChildMissing(subnamespaceCopy, childNameHashed); isChildMissing {
+			c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureChildMissing, messageChildMissing)
+			subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
+			subnamespaceCopy.Status.Message = messageChildMissing
+			subnamespaceCopy.Status.Child = nil
+			// The status is updated without counting a failure, as a child deleted out-of-band is repaired rather
+			// than retried, and repairs must not exhaust the backoff limit
+			if _, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespaceCopy.GetNamespace()).UpdateStatus(context.TODO(), subnamespaceCopy, metav1.UpdateOptions{}); err != nil {
+				klog.Infoln(err)
+			}
+			return
+		}
+		// A subnamespace failed on a missing child goes through partitioning again, which re-creates the child
+		if subnamespaceCopy.Status.State == corev1alpha1.StatusFailed && subnamespaceCopy.Status.Message == messageChildMissing && !c.repairMissingChild {
+			return
+		}
+
 		switch subnamespaceCopy.Status.State {
 		case corev1alpha1.StatusEstablished:
+			if isReset := c.resetOnSchedule(subnamespaceCopy, childNameHashed); isReset {
+				return
+			}
 			if sliceclaimName := subnamespaceCopy.GetSliceClaim(); sliceclaimName != nil {
 				if sliceclaimCopy, ok := c.checkSliceClaim(subnamespaceCopy.GetNamespace(), *sliceclaimName); sliceclaimCopy == nil || !ok {
 					c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureSlice, messageSliceFailure)
@@ -537,22 +780,29 @@ func (c *Controller) processSubNamespace(subnamespaceCopy *corev1alpha1.SubNames
 					switch subnamespaceCopy.GetMode() {
 					case "workspace":
 						resourceQuota := corev1.ResourceQuota{}
-						resourceQuota.SetName("sub-quota")
+						resourceQuota.SetName(c.quotaNames.Sub)
+						resourceQuota.SetLabels(c.tenantLabels(parentNamespaceLabels["edge-net.io/tenant"]))
 						resourceQuota.Spec = corev1.ResourceQuotaSpec{
 							Hard: remainingQuotaResourceList,
 						}
+						resourceQuota.Spec.Scopes, resourceQuota.Spec.ScopeSelector = c.parentQuotaScopes(parentNamespace)
 						if _, err := c.kubeclientset.CoreV1().ResourceQuotas(childNameHashed).Create(context.TODO(), resourceQuota.DeepCopy(), metav1.CreateOptions{}); err != nil {
 							if errors.IsAlreadyExists(err) {
-								remainingChildResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(childNameHashed).Get(context.TODO(), resourceQuota.GetName(), metav1.GetOptions{})
+								// The tenant resource quota controller, which runs in a process of its own, tunes the
+								// child quota too, so a conflicting update is made again on the current quota
+								err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+									remainingChildResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(childNameHashed).Get(context.TODO(), resourceQuota.GetName(), metav1.GetOptions{})
+									if err != nil {
+										return err
+									}
+									remainingChildResourceQuota.Spec.Hard = remainingQuotaResourceList
+									remainingChildResourceQuota.Spec.Scopes = resourceQuota.Spec.Scopes
+									remainingChildResourceQuota.Spec.ScopeSelector = resourceQuota.Spec.ScopeSelector
+									multitenancy.StampLabels(remainingChildResourceQuota, resourceQuota.GetLabels())
+									_, err = c.kubeclientset.CoreV1().ResourceQuotas(childNameHashed).Update(context.TODO(), remainingChildResourceQuota, metav1.UpdateOptions{})
+									return err
+								})
 								if err != nil {
-									c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureApplied, messageApplyFail)
-									subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
-									subnamespaceCopy.Status.Message = failureApplied
-									c.updateStatus(context.TODO(), subnamespaceCopy)
-									return
-								}
-								remainingChildResourceQuota.Spec.Hard = remainingQuotaResourceList
-								if _, err := c.kubeclientset.CoreV1().ResourceQuotas(childNameHashed).Update(context.TODO(), remainingChildResourceQuota, metav1.UpdateOptions{}); err != nil {
 									c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureApplied, messageApplyFail)
 									subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
 									subnamespaceCopy.Status.Message = failureApplied
@@ -561,14 +811,12 @@ func (c *Controller) processSubNamespace(subnamespaceCopy *corev1alpha1.SubNames
 									return
 								}
 							} else {
-								c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureApplied, messageApplyFail)
-								subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
-								subnamespaceCopy.Status.Message = failureApplied
-								c.updateStatus(context.TODO(), subnamespaceCopy)
 								klog.Infoln(err)
+								c.rollbackChild(subnamespaceCopy, childNameHashed)
 								return
 							}
 						}
+						c.stampQuotaUpdate(childNameHashed)
 					case "subtenant":
 						if subtenant, err := c.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), childNameHashed, metav1.GetOptions{}); err == nil {
 							claim := corev1alpha1.ResourceTuning{
@@ -599,6 +847,18 @@ func (c *Controller) processSubNamespace(subnamespaceCopy *corev1alpha1.SubNames
 			subnamespaceCopy.Status.State = corev1alpha1.StatusQuotaSet
 			subnamespaceCopy.Status.Message = messageApplied
 			c.updateStatus(context.TODO(), subnamespaceCopy)
+		case corev1alpha1.StatusResetting:
+			// The parent quota stays partitioned, so the child is re-created with the same grant once its deletion completes
+			if _, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childNameHashed, metav1.GetOptions{}); err == nil {
+				c.enqueueSubNamespaceAfter(subnamespaceCopy, c.resetPollDelay)
+				return
+			} else if !errors.IsNotFound(err) {
+				klog.Infoln(err)
+				return
+			}
+			subnamespaceCopy.Status.State = corev1alpha1.StatusPartitioned
+			subnamespaceCopy.Status.Message = messagePartitioned
+			c.updateStatus(context.TODO(), subnamespaceCopy)
 		case corev1alpha1.StatusPartitioned:
 			ownerReferences := []metav1.OwnerReference{multitenancy.MakeOwnerReferenceForNamespace(parentNamespace)}
 			if isCreated := c.makeSubsidiaryNamespace(subnamespaceCopy, parentNamespaceLabels["edge-net.io/tenant"], childNameHashed, parentNamespace.GetAnnotations(), ownerReferences); !isCreated {
@@ -610,6 +870,20 @@ func (c *Controller) processSubNamespace(subnamespaceCopy *corev1alpha1.SubNames
 			subnamespaceCopy.Status.Message = messageCreation
 			c.updateStatus(context.TODO(), subnamespaceCopy)
 		default:
+			if isEmpty(subnamespaceCopy) {
+				if c.rejectEmpty {
+					if subnamespaceCopy.Status.State != corev1alpha1.StatusFailed || subnamespaceCopy.Status.Message != messageEmpty {
+						c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, warningEmpty, messageEmpty)
+						subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
+						subnamespaceCopy.Status.Message = messageEmpty
+						c.updateStatus(context.TODO(), subnamespaceCopy)
+					}
+					return
+				}
+				if subnamespaceCopy.Status.State == "" {
+					c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, warningEmpty, messageEmpty)
+				}
+			}
 			if sliceclaimName := subnamespaceCopy.GetSliceClaim(); sliceclaimName != nil {
 				sliceclaimCopy, ok := c.checkSliceClaim(subnamespaceCopy.GetNamespace(), *sliceclaimName)
 				if !ok {
@@ -661,6 +935,14 @@ func (c *Controller) processSubNamespace(subnamespaceCopy *corev1alpha1.SubNames
 					return
 				}
 			}
+			if resourceName, isOverallocated := c.isTenantOverallocated(subnamespaceCopy, parentNamespace); isOverallocated {
+				message := fmt.Sprintf(messageOverallocated, resourceName)
+				c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureOverallocated, message)
+				subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
+				subnamespaceCopy.Status.Message = message
+				c.updateStatus(context.TODO(), subnamespaceCopy)
+				return
+			}
 			if isPartitioned := c.partitionParentQuota(subnamespaceCopy, parentNamespace); !isPartitioned {
 				return
 			}
@@ -673,6 +955,8 @@ func (c *Controller) processSubNamespace(subnamespaceCopy *corev1alpha1.SubNames
 }
 
 func (c *Controller) reconcile(subnamespaceCopy *corev1alpha1.SubNamespace, parentNamespace *corev1.Namespace, childNameHashed string) {
+	loansChanged, lenders := c.reconcileLoans(subnamespaceCopy)
+	autoShrinkChanged := c.reconcileAutoShrink(subnamespaceCopy, childNameHashed)
 	if subnamespaceCopy.GetResourceAllocation() != nil {
 		if _, isQuotaSufficient, isReconciled := c.reconcileWithChildQuota(subnamespaceCopy, childNameHashed); !isReconciled || !isQuotaSufficient {
 			subnamespaceCopy.Status.State = corev1alpha1.StatusSubnamespaceCreated
@@ -687,18 +971,36 @@ func (c *Controller) reconcile(subnamespaceCopy *corev1alpha1.SubNamespace, pare
 		subnamespaceCopy.Status.State = corev1alpha1.StatusReconciliation
 		subnamespaceCopy.Status.Message = messageReconciliation
 	}
-	if subnamespaceCopy.Status.State != corev1alpha1.StatusEstablished {
+	if subnamespaceCopy.Status.State != corev1alpha1.StatusEstablished || loansChanged || autoShrinkChanged {
 		c.updateStatus(context.TODO(), subnamespaceCopy)
+		// Lenders adjust their own quota once the loans are recorded
+		for _, lender := range lenders {
+			c.enqueueSubNamespace(lender)
+		}
 		return
 	}
 	if subnamespaceCopy.Spec.Workspace != nil && subnamespaceCopy.Spec.Workspace.Sync {
-		klog.Infoln("SYNCING")
 		c.handleInheritance(subnamespaceCopy, childNameHashed)
 	}
 }
 
+// parentQuotaScopes returns the scopes and the scope selector of the parent quota to be set on a child quota,
+// none if copying them is disabled or the parent quota is not found
+func (c *Controller) parentQuotaScopes(parentNamespace *corev1.Namespace) ([]corev1.ResourceQuotaScope, *corev1.ScopeSelector) {
+	if !c.copyQuotaScopes {
+		return nil, nil
+	}
+	parentResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(parentNamespace.GetName()).Get(context.TODO(), c.quotaNames.ForKind(parentNamespace.GetLabels()["edge-net.io/kind"]), metav1.GetOptions{})
+	if err != nil {
+		klog.Infoln(err)
+		return nil, nil
+	}
+	parentResourceQuotaCopy := parentResourceQuota.DeepCopy()
+	return parentResourceQuotaCopy.Spec.Scopes, parentResourceQuotaCopy.Spec.ScopeSelector
+}
+
 func (c *Controller) reconcileWithChildQuota(subnamespaceCopy *corev1alpha1.SubNamespace, childNameHashed string) (map[corev1.ResourceName]resource.Quantity, bool, bool) {
-	remainingQuotaResourceList, lastInSubnamespace, isQuotaSufficient := c.subtractSubnamespaceQuotas(subnamespaceCopy, childNameHashed, subnamespaceCopy.GetResourceAllocation())
+	remainingQuotaResourceList, lastInSubnamespace, isQuotaSufficient := c.subtractSubnamespaceQuotas(subnamespaceCopy, childNameHashed, c.effectiveResourceAllocation(subnamespaceCopy))
 	if !isQuotaSufficient {
 		c.edgenetclientset.CoreV1alpha1().SubNamespaces(childNameHashed).Delete(context.TODO(), lastInSubnamespace, metav1.DeleteOptions{})
 		c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, corev1alpha1.StatusFailed, messageSubnamespaceDeleted)
@@ -715,7 +1017,7 @@ func (c *Controller) reconcileWithChildQuota(subnamespaceCopy *corev1alpha1.SubN
 		}
 		childQuotaResourceList = currentChildResourceQuota.Fetch()
 	default:
-		currentChildResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(childNameHashed).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+		currentChildResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(childNameHashed).Get(context.TODO(), c.quotaNames.Sub, metav1.GetOptions{})
 		if err != nil {
 			return remainingQuotaResourceList, true, false
 		}
@@ -769,7 +1071,7 @@ func (c *Controller) reconcileWithOwnerPermissions(subnamespaceCopy *corev1alpha
 
 func (c *Controller) reconcileWithParentQuota(subnamespaceCopy *corev1alpha1.SubNamespace, parentNamespace *corev1.Namespace) (*corev1.ResourceQuota, bool) {
 	parentNamespaceLabels := parentNamespace.GetLabels()
-	currentParentResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(parentNamespace.GetName()).Get(context.TODO(), fmt.Sprintf("%s-quota", parentNamespaceLabels["edge-net.io/kind"]), metav1.GetOptions{})
+	currentParentResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(parentNamespace.GetName()).Get(context.TODO(), c.quotaNames.ForKind(parentNamespaceLabels["edge-net.io/kind"]), metav1.GetOptions{})
 	if err != nil {
 		return nil, true
 	}
@@ -778,12 +1080,14 @@ func (c *Controller) reconcileWithParentQuota(subnamespaceCopy *corev1alpha1.Sub
 	}
 	var parentQuotaResourceList = make(corev1.ResourceList)
 	if strings.ToLower(parentNamespaceLabels["edge-net.io/kind"]) == "core" {
-		if parentResourceQuota, err := c.edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), parentNamespace.GetName(), metav1.GetOptions{}); err == nil {
-			parentQuotaResourceList = parentResourceQuota.Fetch()
+		if tenantQuota, err := multitenancy.TenantQuota(c.edgenetclientset, parentNamespace.GetName()); err == nil {
+			if quotaResourceList, err := multitenancy.QuotaResourceList(tenantQuota); err == nil {
+				parentQuotaResourceList = quotaResourceList
+			}
 		}
 	} else {
 		if parentNamespaceOwner, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(parentNamespaceLabels["edge-net.io/parent-namespace"]).Get(context.TODO(), parentNamespaceLabels["edge-net.io/owner"], metav1.GetOptions{}); err == nil {
-			parentQuotaResourceList = parentNamespaceOwner.GetResourceAllocation()
+			parentQuotaResourceList = c.allocatedResourceList(*parentNamespaceOwner)
 		}
 	}
 	remainingQuotaResourceList, _, isQuotaSufficient := c.subtractSubnamespaceQuotas(subnamespaceCopy, parentNamespace.GetName(), parentQuotaResourceList)
@@ -810,31 +1114,91 @@ func (c *Controller) reconcileWithParentQuota(subnamespaceCopy *corev1alpha1.Sub
 }
 
 func (c *Controller) partitionParentQuota(subnamespaceCopy *corev1alpha1.SubNamespace, parentNamespace *corev1.Namespace) bool {
-	if currentParentResourceQuota, isReconciled := c.reconcileWithParentQuota(subnamespaceCopy, parentNamespace); !isReconciled {
-		if currentParentResourceQuota != nil {
-			if _, err := c.kubeclientset.CoreV1().ResourceQuotas(parentNamespace.GetName()).Update(context.TODO(), currentParentResourceQuota, metav1.UpdateOptions{}); err != nil {
-				c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureSlice, messageUpdateFail)
-				subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
-				subnamespaceCopy.Status.Message = messageUpdateFail
-				c.updateStatus(context.TODO(), subnamespaceCopy)
-				return false
-			}
-		} else {
-			c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureQuotaShortage, messageParentQuotaShortage)
-			subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
-			subnamespaceCopy.Status.Message = messageParentQuotaShortage
-			c.updateStatus(context.TODO(), subnamespaceCopy)
-			return false
+	isShort := false
+	// The tenant resource quota controller, which runs in a process of its own, tunes the parent quota too, so a
+	// conflicting update is partitioned again from the current quota
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		currentParentResourceQuota, isReconciled := c.reconcileWithParentQuota(subnamespaceCopy, parentNamespace)
+		if isShort = !isReconciled && currentParentResourceQuota == nil; isReconciled || isShort {
+			return nil
 		}
+		_, err := c.kubeclientset.CoreV1().ResourceQuotas(parentNamespace.GetName()).Update(context.TODO(), currentParentResourceQuota, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureSlice, messageUpdateFail)
+		subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
+		subnamespaceCopy.Status.Message = messageUpdateFail
+		c.updateStatus(context.TODO(), subnamespaceCopy)
+		return false
+	}
+	if isShort {
+		c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureQuotaShortage, messageParentQuotaShortage)
+		subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
+		subnamespaceCopy.Status.Message = messageParentQuotaShortage
+		c.updateStatus(context.TODO(), subnamespaceCopy)
+		return false
 	}
 	return true
 }
 
+// isTenantOverallocated tells whether the subnamespaces carved out of the core namespace of the tenant already take up
+// the tenant quota, aggregated over its tenant resource quotas, and returns the first resource found to be so. Races and
+// manual edits can push them over the quota, in which case no new carve-out is made until the allocation goes back
+// down. A carve-out from the core namespace is refused once the allocation of a resource meets the quota, a nested one
+// only once it exceeds it, since the quota of a nested subnamespace is taken from its parent and not from the tenant.
+func (c *Controller) isTenantOverallocated(subnamespaceCopy *corev1alpha1.SubNamespace, parentNamespace *corev1.Namespace) (corev1.ResourceName, bool) {
+	if subnamespaceCopy.GetResourceAllocation() == nil {
+		return "", false
+	}
+	tenant := parentNamespace.GetLabels()["edge-net.io/tenant"]
+	tenantQuota, err := multitenancy.TenantQuota(c.edgenetclientset, tenant)
+	if err != nil {
+		return "", false
+	}
+	tenantQuotaResourceList, err := multitenancy.QuotaResourceList(tenantQuota)
+	if err != nil {
+		return "", false
+	}
+	subnamespaceRaw, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(tenant).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.Infoln(err)
+		return "", false
+	}
+	allocatedQuotaResourceList := make(map[corev1.ResourceName]resource.Quantity)
+	for _, subnamespaceRow := range subnamespaceRaw.Items {
+		if subnamespaceRow.GetUID() == subnamespaceCopy.GetUID() || multitenancy.IsSubNamespaceQuotaExempt(c.kubeclientset, subnamespaceRow) {
+			continue
+		}
+		if subnamespaceRow.Status.State == corev1alpha1.StatusEstablished || subnamespaceRow.Status.State == corev1alpha1.StatusQuotaSet || subnamespaceRow.Status.State == corev1alpha1.StatusSubnamespaceCreated || subnamespaceRow.Status.State == corev1alpha1.StatusPartitioned {
+			for resourceName, quantity := range c.allocatedResourceList(subnamespaceRow) {
+				allocatedQuantity := allocatedQuotaResourceList[resourceName]
+				allocatedQuantity.Add(quantity)
+				allocatedQuotaResourceList[resourceName] = allocatedQuantity
+			}
+		}
+	}
+	isCore := strings.ToLower(parentNamespace.GetLabels()["edge-net.io/kind"]) == "core"
+	for resourceName, quotaQuantity := range tenantQuotaResourceList {
+		allocatedQuantity, elementExists := allocatedQuotaResourceList[resourceName]
+		if !elementExists || c.childOnlyResources.Contains(resourceName) {
+			continue
+		}
+		if comparison := allocatedQuantity.Cmp(quotaQuantity); comparison == 1 || (comparison == 0 && isCore) {
+			return resourceName, true
+		}
+	}
+	return "", false
+}
+
 func (c *Controller) subtractSubnamespaceQuotas(subnamespaceCopy *corev1alpha1.SubNamespace, namespace string, remainingQuotaResourceList map[corev1.ResourceName]resource.Quantity) (map[corev1.ResourceName]resource.Quantity, string, bool) {
 	var lastInDate metav1.Time
 	var lastInSubnamespace string
 	if subnamespaceRaw, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(namespace).List(context.TODO(), metav1.ListOptions{}); err == nil {
 		for _, subnamespaceRow := range subnamespaceRaw.Items {
+			if multitenancy.IsSubNamespaceQuotaExempt(c.kubeclientset, subnamespaceRow) {
+				continue
+			}
 			if (subnamespaceRow.GetUID() == subnamespaceCopy.GetUID() && !(subnamespaceCopy.Status.Failed >= backoffLimit && subnamespaceCopy.Status.State == corev1alpha1.StatusFailed)) ||
 				subnamespaceRow.Status.State == corev1alpha1.StatusEstablished || subnamespaceRow.Status.State == corev1alpha1.StatusQuotaSet || subnamespaceRow.Status.State == corev1alpha1.StatusSubnamespaceCreated || subnamespaceRow.Status.State == corev1alpha1.StatusPartitioned {
 				if lastInDate.IsZero() || subnamespaceRow.GetCreationTimestamp().After(lastInDate.Time) {
@@ -842,9 +1206,9 @@ func (c *Controller) subtractSubnamespaceQuotas(subnamespaceCopy *corev1alpha1.S
 					lastInDate = subnamespaceRow.GetCreationTimestamp()
 				}
 				for remainingQuotaResource, remainingQuotaQuantity := range remainingQuotaResourceList {
-					childQuota := subnamespaceRow.RetrieveQuantity(remainingQuotaResource)
+					childQuota := c.debitedQuantity(subnamespaceRow, remainingQuotaResource)
 					if subnamespaceRow.GetUID() == subnamespaceCopy.GetUID() {
-						childQuota = subnamespaceCopy.RetrieveQuantity(remainingQuotaResource)
+						childQuota = c.debitedQuantity(*subnamespaceCopy, remainingQuotaResource)
 					}
 					if remainingQuotaQuantity.Cmp(childQuota) == -1 {
 						return remainingQuotaResourceList, lastInSubnamespace, false
@@ -858,6 +1222,430 @@ func (c *Controller) subtractSubnamespaceQuotas(subnamespaceCopy *corev1alpha1.S
 	return remainingQuotaResourceList, lastInSubnamespace, true
 }
 
+// allocatedQuantity returns the quantity of the resource allocated to the subnamespace, rounded
+// according to the quota rounding policy so that debits and credits match exactly. An extended
+// resource matches whether or not it is prefixed with requests.
+func (c *Controller) allocatedQuantity(subnamespace corev1alpha1.SubNamespace, key corev1.ResourceName) resource.Quantity {
+	quantity := subnamespace.RetrieveQuantity(key)
+	if len(subnamespace.Status.Reclaimed) != 0 {
+		// The allocation of a shrunk workspace is credited back to the parent
+		return *resource.NewQuantity(0, quantity.Format)
+	}
+	for name, allocatedQuantity := range subnamespace.GetResourceAllocation() {
+		if name != key && multitenancy.QuotaResourceName(name) == multitenancy.QuotaResourceName(key) {
+			quantity = allocatedQuantity
+		}
+	}
+	if roundedQuantity, err := multitenancy.RoundResourceQuantity(key, quantity, c.quotaRounding); err == nil {
+		return roundedQuantity
+	}
+	return quantity
+}
+
+// debitedQuantity returns the quantity of the resource debited from the parent for the subnamespace, which is
+// its allocation unless the resource only limits the child.
+func (c *Controller) debitedQuantity(subnamespace corev1alpha1.SubNamespace, key corev1.ResourceName) resource.Quantity {
+	quantity := c.allocatedQuantity(subnamespace, key)
+	if c.childOnlyResources.Contains(key) {
+		return *resource.NewQuantity(0, quantity.Format)
+	}
+	return quantity
+}
+
+// allocatedResourceList returns the resources allocated to the subnamespace, named as in a resource quota.
+func (c *Controller) allocatedResourceList(subnamespace corev1alpha1.SubNamespace) map[corev1.ResourceName]resource.Quantity {
+	resourceList := subnamespace.GetResourceAllocation()
+	if resourceList == nil {
+		return nil
+	}
+	allocatedResourceList := make(map[corev1.ResourceName]resource.Quantity, len(resourceList))
+	for key := range resourceList {
+		allocatedResourceList[multitenancy.QuotaResourceName(key)] = c.allocatedQuantity(subnamespace, key)
+	}
+	return allocatedResourceList
+}
+
+// belowMinimum returns the first resource of the allocation found below its minimum, along with the minimum.
+// Resources that are not allocated are not checked.
+func (c *Controller) belowMinimum(resourceAllocation map[corev1.ResourceName]resource.Quantity) (corev1.ResourceName, resource.Quantity, bool) {
+	for name, quantity := range resourceAllocation {
+		for minimumName, minimum := range c.minimumAllocation {
+			if multitenancy.QuotaResourceName(name) == multitenancy.QuotaResourceName(minimumName) && quantity.Cmp(minimum) == -1 {
+				return name, minimum, true
+			}
+		}
+	}
+	return "", resource.Quantity{}, false
+}
+
+// allocationChanged tells whether the allocation differs from the one previously applied. A subnamespace that has
+// not been applied yet has no previous allocation, and its allocation is then considered changed.
+func allocationChanged(previous, current map[corev1.ResourceName]resource.Quantity) bool {
+	if previous == nil || len(previous) != len(current) {
+		return true
+	}
+	for name, quantity := range current {
+		if previousQuantity, ok := previous[name]; !ok || quantity.Cmp(previousQuantity) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// raiseToMinimum raises each resource of the allocation below its minimum to the minimum. The update of the spec
+// brings the subnamespace back to the queue, to be processed with its new allocation.
+func (c *Controller) raiseToMinimum(subnamespaceCopy *corev1alpha1.SubNamespace) {
+	resourceAllocation := subnamespaceCopy.GetResourceAllocation()
+	var raised []string
+	for name, quantity := range resourceAllocation {
+		for minimumName, minimum := range c.minimumAllocation {
+			if multitenancy.QuotaResourceName(name) == multitenancy.QuotaResourceName(minimumName) && quantity.Cmp(minimum) == -1 {
+				resourceAllocation[name] = minimum.DeepCopy()
+				raised = append(raised, fmt.Sprintf(messageBumped, name, minimum.String()))
+			}
+		}
+	}
+	sort.Strings(raised)
+	subnamespaceCopy.SetResourceAllocation(resourceAllocation)
+	if _, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespaceCopy.GetNamespace()).Update(context.TODO(), subnamespaceCopy, metav1.UpdateOptions{}); err != nil {
+		c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureUpdate, err.Error())
+		return
+	}
+	for _, message := range raised {
+		c.recorder.Event(subnamespaceCopy, corev1.EventTypeNormal, successBumped, message)
+	}
+}
+
+// effectiveResourceAllocation returns the allocation of the subnamespace, increased by the quota it borrows
+// from its siblings and decreased by the quota it lends to them. Loans do not change the quota debited from
+// the parent, they only move quota between siblings.
+func (c *Controller) effectiveResourceAllocation(subnamespaceCopy *corev1alpha1.SubNamespace) map[corev1.ResourceName]resource.Quantity {
+	resourceList := c.allocatedResourceList(*subnamespaceCopy)
+	if subnamespaceCopy.GetMode() != "workspace" || resourceList == nil {
+		return resourceList
+	}
+	for _, loan := range subnamespaceCopy.Status.Borrowed {
+		resourceName := multitenancy.QuotaResourceName(loan.Resource)
+		if quantity, elementExists := resourceList[resourceName]; elementExists {
+			quantity.Add(loan.Quantity)
+			resourceList[resourceName] = quantity
+		}
+	}
+	if subnamespaceRaw, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespaceCopy.GetNamespace()).List(context.TODO(), metav1.ListOptions{}); err == nil {
+		for _, subnamespaceRow := range subnamespaceRaw.Items {
+			for _, loan := range subnamespaceRow.Status.Borrowed {
+				if loan.Lender != subnamespaceCopy.GetName() {
+					continue
+				}
+				resourceName := multitenancy.QuotaResourceName(loan.Resource)
+				if quantity, elementExists := resourceList[resourceName]; elementExists {
+					quantity.Sub(loan.Quantity)
+					resourceList[resourceName] = quantity
+				}
+			}
+		}
+	}
+	return resourceList
+}
+
+// reconcileLoans returns the quota borrowed from siblings that are short of it, and borrows what is still
+// missing from the unused quota of the other siblings. It reports whether the loans have changed, along
+// with the lenders affected by the change.
+func (c *Controller) reconcileLoans(subnamespaceCopy *corev1alpha1.SubNamespace) (bool, []*corev1alpha1.SubNamespace) {
+	if subnamespaceCopy.GetMode() != "workspace" || (len(subnamespaceCopy.Spec.Workspace.Borrow) == 0 && len(subnamespaceCopy.Status.Borrowed) == 0) {
+		return false, nil
+	}
+	siblings := make(map[string]*corev1alpha1.SubNamespace)
+	siblingNames := []string{}
+	if subnamespaceRaw, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespaceCopy.GetNamespace()).List(context.TODO(), metav1.ListOptions{}); err == nil {
+		for _, subnamespaceRow := range subnamespaceRaw.Items {
+			if subnamespaceRow.GetUID() == subnamespaceCopy.GetUID() || subnamespaceRow.GetMode() != "workspace" ||
+				subnamespaceRow.Status.State != corev1alpha1.StatusEstablished || subnamespaceRow.Status.Child == nil {
+				continue
+			}
+			siblings[subnamespaceRow.GetName()] = subnamespaceRow.DeepCopy()
+			siblingNames = append(siblingNames, subnamespaceRow.GetName())
+		}
+	}
+	sort.Strings(siblingNames)
+
+	changed := false
+	affectedLenders := make(map[string]*corev1alpha1.SubNamespace)
+	borrowed := []corev1alpha1.QuotaLoan{}
+	borrowedTotal := make(map[corev1.ResourceName]resource.Quantity)
+	for _, loan := range subnamespaceCopy.Status.Borrowed {
+		demand, isDemanded := subnamespaceCopy.Spec.Workspace.Borrow[loan.Resource]
+		total := borrowedTotal[loan.Resource]
+		total.Add(loan.Quantity)
+		lender, lenderExists := siblings[loan.Lender]
+		if !isDemanded || total.Cmp(demand) == 1 || !lenderExists || c.isLenderShort(lender, loan.Resource) {
+			changed = true
+			if lenderExists {
+				affectedLenders[lender.GetName()] = lender
+			}
+			continue
+		}
+		borrowedTotal[loan.Resource] = total
+		borrowed = append(borrowed, loan)
+	}
+
+	for resourceName, demand := range subnamespaceCopy.Spec.Workspace.Borrow {
+		missing := demand.DeepCopy()
+		missing.Sub(borrowedTotal[resourceName])
+		for _, siblingName := range siblingNames {
+			if missing.Sign() <= 0 {
+				break
+			}
+			lendable := c.lendableQuantity(siblings[siblingName], resourceName)
+			if lendable.Sign() <= 0 {
+				continue
+			}
+			if lendable.Cmp(missing) == 1 {
+				lendable = missing.DeepCopy()
+			}
+			borrowed = append(borrowed, corev1alpha1.QuotaLoan{Lender: siblingName, Resource: resourceName, Quantity: lendable})
+			missing.Sub(lendable)
+			changed = true
+			affectedLenders[siblingName] = siblings[siblingName]
+		}
+	}
+	sort.SliceStable(borrowed, func(i, j int) bool {
+		if borrowed[i].Lender != borrowed[j].Lender {
+			return borrowed[i].Lender < borrowed[j].Lender
+		}
+		return borrowed[i].Resource < borrowed[j].Resource
+	})
+	if len(borrowed) == 0 {
+		borrowed = nil
+	}
+	subnamespaceCopy.Status.Borrowed = borrowed
+
+	lenders := []*corev1alpha1.SubNamespace{}
+	for _, lender := range affectedLenders {
+		lenders = append(lenders, lender)
+	}
+	return changed, lenders
+}
+
+// lendableQuantity returns how much of a resource the sibling can lend, which is half of its unused quota
+// so that the sibling keeps some headroom and loans do not bounce back and forth.
+func (c *Controller) lendableQuantity(sibling *corev1alpha1.SubNamespace, resourceName corev1.ResourceName) resource.Quantity {
+	siblingResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(*sibling.Status.Child).Get(context.TODO(), c.quotaNames.Sub, metav1.GetOptions{})
+	if err != nil {
+		return resource.Quantity{}
+	}
+	quotaResourceName := multitenancy.QuotaResourceName(resourceName)
+	unused, elementExists := siblingResourceQuota.Spec.Hard[quotaResourceName]
+	if !elementExists {
+		return resource.Quantity{}
+	}
+	unused.Sub(siblingResourceQuota.Status.Used[quotaResourceName])
+	if unused.Sign() <= 0 {
+		return resource.Quantity{}
+	}
+	lendableMilli := unused.MilliValue() / 2
+	if resourceName != corev1.ResourceCPU && resourceName != corev1.ResourceRequestsCPU && resourceName != corev1.ResourceLimitsCPU {
+		// Lend whole units only, such as bytes or pods
+		lendableMilli -= lendableMilli % 1000
+	}
+	lendable, err := multitenancy.NormalizeResourceQuantity(resourceName, *resource.NewMilliQuantity(lendableMilli, unused.Format))
+	if err != nil {
+		return resource.Quantity{}
+	}
+	return lendable
+}
+
+// isLenderShort reports whether the sibling has used up its quota of the resource, so it needs its loans back.
+func (c *Controller) isLenderShort(lender *corev1alpha1.SubNamespace, resourceName corev1.ResourceName) bool {
+	lenderResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(*lender.Status.Child).Get(context.TODO(), c.quotaNames.Sub, metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+	quotaResourceName := multitenancy.QuotaResourceName(resourceName)
+	hard, elementExists := lenderResourceQuota.Spec.Hard[quotaResourceName]
+	if !elementExists {
+		return false
+	}
+	used := lenderResourceQuota.Status.Used[quotaResourceName]
+	return used.Cmp(hard) >= 0
+}
+
+// reconcileAutoShrink keeps track of how long the child namespace of a workspace with auto shrink has used
+// none of its quota, and reclaims the allocation for the parent once the idle period elapses. The allocation
+// is restored when auto shrink is disabled. It returns true if the status has changed.
+func (c *Controller) reconcileAutoShrink(subnamespaceCopy *corev1alpha1.SubNamespace, childNameHashed string) bool {
+	if subnamespaceCopy.GetMode() != "workspace" {
+		return false
+	}
+	if subnamespaceCopy.Spec.Workspace.AutoShrink == nil {
+		if subnamespaceCopy.Status.IdleSince == nil && subnamespaceCopy.Status.Reclaimed == nil {
+			return false
+		}
+		if subnamespaceCopy.Status.Reclaimed != nil {
+			c.recorder.Event(subnamespaceCopy, corev1.EventTypeNormal, successRestored, messageRestored)
+		}
+		subnamespaceCopy.Status.IdleSince = nil
+		subnamespaceCopy.Status.Reclaimed = nil
+		return true
+	}
+	if subnamespaceCopy.Status.Reclaimed != nil {
+		return false
+	}
+	if !c.isChildIdle(childNameHashed) {
+		if subnamespaceCopy.Status.IdleSince == nil {
+			return false
+		}
+		subnamespaceCopy.Status.IdleSince = nil
+		return true
+	}
+	if subnamespaceCopy.Status.IdleSince == nil {
+		now := metav1.Now()
+		subnamespaceCopy.Status.IdleSince = &now
+		c.enqueueSubNamespaceAfter(subnamespaceCopy, subnamespaceCopy.Spec.Workspace.AutoShrink.IdlePeriod.Duration)
+		return true
+	}
+	if wait := time.Until(subnamespaceCopy.Status.IdleSince.Add(subnamespaceCopy.Spec.Workspace.AutoShrink.IdlePeriod.Duration)); wait > 0 {
+		c.enqueueSubNamespaceAfter(subnamespaceCopy, wait)
+		return false
+	}
+	// The quota of the nested subnamespaces is drawn from the allocation, which cannot be reclaimed under them
+	if subnamespaceRaw, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(childNameHashed).List(context.TODO(), metav1.ListOptions{}); err != nil || len(subnamespaceRaw.Items) != 0 {
+		return false
+	}
+	subnamespaceCopy.Status.Reclaimed = c.allocatedResourceList(*subnamespaceCopy)
+	c.recorder.Event(subnamespaceCopy, corev1.EventTypeNormal, successShrunk, messageShrunk)
+	return true
+}
+
+// isChildIdle reports whether the child namespace uses none of the resources of its quota.
+func (c *Controller) isChildIdle(childNameHashed string) bool {
+	childResourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(childNameHashed).Get(context.TODO(), c.quotaNames.Sub, metav1.GetOptions{})
+	if err != nil || len(childResourceQuota.Status.Used) == 0 {
+		return false
+	}
+	for _, used := range childResourceQuota.Status.Used {
+		if !used.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmpty reports whether the subnamespace requests neither any resources nor any inheritance, which
+// results in a child that cannot be used. The allocation of a subnamespace bound to a slice claim is
+// only known once the slice is ready, so it is not considered empty.
+func isEmpty(subnamespace *corev1alpha1.SubNamespace) bool {
+	if subnamespace.GetSliceClaim() != nil {
+		return false
+	}
+	for _, quantity := range subnamespace.GetResourceAllocation() {
+		if !quantity.IsZero() {
+			return false
+		}
+	}
+	if subnamespace.Spec.Workspace != nil {
+		for _, inherited := range subnamespace.Spec.Workspace.Inheritance {
+			if inherited {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isChildMissing reports whether the child of an already created subnamespace no longer exists,
+// for example because the child namespace has been deleted out-of-band.
+func (c *Controller) isChildMissing(subnamespaceCopy *corev1alpha1.SubNamespace, childNameHashed string) bool {
+	if subnamespaceCopy.Status.Child == nil {
+		return false
+	}
+	switch subnamespaceCopy.Status.State {
+	case corev1alpha1.StatusEstablished, corev1alpha1.StatusQuotaSet, corev1alpha1.StatusSubnamespaceCreated:
+	default:
+		return false
+	}
+	var err error
+	if subnamespaceCopy.GetMode() == "workspace" {
+		_, err = c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childNameHashed, metav1.GetOptions{})
+	} else {
+		_, err = c.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), childNameHashed, metav1.GetOptions{})
+	}
+	return errors.IsNotFound(err)
+}
+
+// rollbackChild deletes the child namespace of a workspace whose quota cannot be created, so that no namespace is
+// left without limits, and marks the subnamespace failed to go through partitioning again. Only a namespace created for
+// this provisioning is rolled back, as an adopted one or one where workloads already run is not the controller's to drop.
+func (c *Controller) rollbackChild(subnamespaceCopy *corev1alpha1.SubNamespace, childNameHashed string) {
+	message := messageApplyFail
+	if childNamespace, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childNameHashed, metav1.GetOptions{}); err == nil && c.isFreshChild(childNamespace) {
+		if err := c.kubeclientset.CoreV1().Namespaces().Delete(context.TODO(), childNameHashed, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			klog.Infoln(err)
+		} else {
+			message = messageRolledBack
+			subnamespaceCopy.Status.Child = nil
+		}
+	}
+	c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureApplied, message)
+	subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
+	subnamespaceCopy.Status.Message = message
+	c.updateStatus(context.TODO(), subnamespaceCopy)
+}
+
+// isFreshChild tells whether the child namespace has been created for this provisioning of the subnamespace, that is,
+// it is not adopted, it has never received its quota, and it holds no pods
+func (c *Controller) isFreshChild(childNamespace *corev1.Namespace) bool {
+	if isAdoptable(childNamespace) {
+		return false
+	}
+	if _, elementExists := childNamespace.GetAnnotations()[QuotaUpdatedAnnotation]; elementExists {
+		return false
+	}
+	pods, err := c.kubeclientset.CoreV1().Pods(childNamespace.GetName()).List(context.TODO(), metav1.ListOptions{Limit: 1})
+	return err == nil && len(pods.Items) == 0
+}
+
+// resetOnSchedule deletes the child namespace of a workspace when its reset schedule triggers,
+// and queues the subnamespace for the next trigger otherwise. It returns true if the status is updated.
+func (c *Controller) resetOnSchedule(subnamespaceCopy *corev1alpha1.SubNamespace, childNameHashed string) bool {
+	if subnamespaceCopy.Spec.Workspace == nil || subnamespaceCopy.Spec.ResetSchedule == "" {
+		return false
+	}
+	schedule, err := multitenancy.ParseSchedule(subnamespaceCopy.Spec.ResetSchedule)
+	if err != nil {
+		c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureSchedule, messageScheduleInvalid)
+		return false
+	}
+	if subnamespaceCopy.Status.LastReset == nil {
+		// The schedule counts from the first time the workspace is established
+		now := metav1.Now()
+		subnamespaceCopy.Status.LastReset = &now
+		c.updateStatus(context.TODO(), subnamespaceCopy)
+		return true
+	}
+	next := schedule.Next(subnamespaceCopy.Status.LastReset.Time)
+	if next.IsZero() {
+		return false
+	}
+	if wait := time.Until(next); wait > 0 {
+		c.enqueueSubNamespaceAfter(subnamespaceCopy, wait)
+		return false
+	}
+	if err := c.kubeclientset.CoreV1().Namespaces().Delete(context.TODO(), childNameHashed, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureReset, messageResetFail)
+		klog.Infoln(err)
+		return false
+	}
+	c.recorder.Event(subnamespaceCopy, corev1.EventTypeNormal, successReset, messageReset)
+	now := metav1.Now()
+	subnamespaceCopy.Status.LastReset = &now
+	subnamespaceCopy.Status.Child = nil
+	subnamespaceCopy.Status.State = corev1alpha1.StatusResetting
+	subnamespaceCopy.Status.Message = messageReset
+	c.updateStatus(context.TODO(), subnamespaceCopy)
+	return true
+}
+
 func (c *Controller) checkSliceClaim(namespace, name string) (*corev1alpha1.SliceClaim, bool) {
 	if sliceclaimCopy, err := c.edgenetclientset.CoreV1alpha1().SliceClaims(namespace).Get(context.TODO(), name, metav1.GetOptions{}); err == nil {
 		if sliceclaimCopy.Status.State == corev1alpha1.StatusBound || sliceclaimCopy.Status.State == corev1alpha1.StatusEmployed {
@@ -884,6 +1672,9 @@ func (c *Controller) checkNamespaceCollision(subnamespaceCopy *corev1alpha1.SubN
 	}
 	if subnamespaceCopy.GetMode() == "workspace" {
 		if childNamespace, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childNameHashed, metav1.GetOptions{}); err == nil {
+			if isAdoptable(childNamespace) && !hasParentNamespace(childNamespace) {
+				return false
+			}
 			return checkOwnerReferences(childNamespace.GetOwnerReferences())
 		}
 	} else {
@@ -894,6 +1685,83 @@ func (c *Controller) checkNamespaceCollision(subnamespaceCopy *corev1alpha1.SubN
 	return false
 }
 
+// checkDuplicateChildName fails the subnamespace if another one, anywhere in the cluster, already has or generates the
+// same child name, which the same name under different parents can do as the parent only goes into a short hash. The
+// first created keeps the name, and it is caught before either child exists, unlike a collision on an existing one.
+func (c *Controller) checkDuplicateChildName(subnamespaceCopy *corev1alpha1.SubNamespace, clusterUID, childNameHashed string) bool {
+	subnamespaceRaw, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		klog.Infoln(err)
+		return false
+	}
+	for _, subnamespaceRow := range subnamespaceRaw.Items {
+		if subnamespaceRow.GetUID() == subnamespaceCopy.GetUID() || subnamespaceRow.Status.State == corev1alpha1.StatusFailed {
+			continue
+		}
+		if subnamespaceRow.Status.Child != nil {
+			if *subnamespaceRow.Status.Child != childNameHashed {
+				continue
+			}
+		} else if subnamespaceRow.GenerateChildName(clusterUID) != childNameHashed || !isCreatedBefore(subnamespaceRow, *subnamespaceCopy) {
+			continue
+		}
+		message := fmt.Sprintf(messageDuplicateName, childNameHashed, subnamespaceRow.GetNamespace(), subnamespaceRow.GetName())
+		c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureDuplicateName, message)
+		subnamespaceCopy.Status.Failed = backoffLimit - 1
+		subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
+		subnamespaceCopy.Status.Message = message
+		c.updateStatus(context.TODO(), subnamespaceCopy)
+		return true
+	}
+	return false
+}
+
+// isCreatedBefore tells whether the first subnamespace was created before the second, breaking ties on the namespace
+// and the name so that exactly one of two pending subnamespaces claiming the same child name keeps it
+func isCreatedBefore(first, second corev1alpha1.SubNamespace) bool {
+	firstCreation, secondCreation := first.GetCreationTimestamp(), second.GetCreationTimestamp()
+	if !firstCreation.Equal(&secondCreation) {
+		return firstCreation.Before(&secondCreation)
+	}
+	if first.GetNamespace() != second.GetNamespace() {
+		return first.GetNamespace() < second.GetNamespace()
+	}
+	return first.GetName() < second.GetName()
+}
+
+// isAdoptable reports whether the namespace is marked by the cluster admins to be taken over as the child of a workspace
+func isAdoptable(namespace *corev1.Namespace) bool {
+	return namespace.GetAnnotations()["edge-net.io/adopt"] == "true"
+}
+
+// stampQuotaUpdate annotates the child namespace with the time its quota has been applied
+func (c *Controller) stampQuotaUpdate(childNameHashed string) {
+	childNamespace, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childNameHashed, metav1.GetOptions{})
+	if err != nil {
+		klog.Infoln(err)
+		return
+	}
+	annotations := childNamespace.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[QuotaUpdatedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	childNamespace.SetAnnotations(annotations)
+	if _, err := c.kubeclientset.CoreV1().Namespaces().Update(context.TODO(), childNamespace, metav1.UpdateOptions{}); err != nil {
+		klog.Infoln(err)
+	}
+}
+
+// hasParentNamespace reports whether the namespace is already owned by a parent namespace
+func hasParentNamespace(namespace *corev1.Namespace) bool {
+	for _, ownerReference := range namespace.GetOwnerReferences() {
+		if ownerReference.Kind == "Namespace" {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Controller) validateChildOwnership(parentNamespace *corev1.Namespace, mode, childNameHashed string) (bool, bool) {
 	var checkOwnerReferences = func(ownerReferences []metav1.OwnerReference) (bool, bool) {
 		for _, ownerReference := range ownerReferences {
@@ -919,7 +1787,7 @@ func (c *Controller) returnParentResourceQuota(subnamespaceCopy *corev1alpha1.Su
 	returnedQuota := make(map[corev1.ResourceName]resource.Quantity)
 	for key, value := range parentResourceQuota.Spec.Hard {
 		remainingQuota := value.DeepCopy()
-		remainingQuota.Add(subnamespaceCopy.RetrieveQuantity(key))
+		remainingQuota.Add(c.debitedQuantity(*subnamespaceCopy, key))
 		returnedQuota[key] = remainingQuota
 	}
 
@@ -958,11 +1826,39 @@ func (c *Controller) makeSubsidiaryNamespace(subnamespaceCopy *corev1alpha1.SubN
 		childNamespaceObj.SetName(childNameHashed)
 		childNamespaceObj.SetAnnotations(annotations)
 		childNamespaceObj.SetLabels(labels)
+		multitenancy.StampLabels(childNamespaceObj, c.tenantLabels(tenant))
+		labels = childNamespaceObj.GetLabels()
 		if _, err := c.kubeclientset.CoreV1().Namespaces().Create(context.TODO(), childNamespaceObj, metav1.CreateOptions{}); err != nil {
 			if errors.IsAlreadyExists(err) {
 				childNamespace, _ := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childNamespaceObj.GetName(), metav1.GetOptions{})
-				childNamespace.SetAnnotations(annotations)
-				childNamespace.SetLabels(labels)
+				if isAdoptable(childNamespace) {
+					// An adopted namespace keeps its own labels and annotations
+					childLabels := childNamespace.GetLabels()
+					if childLabels == nil {
+						childLabels = make(map[string]string)
+					}
+					for key, value := range labels {
+						childLabels[key] = value
+					}
+					childNamespace.SetLabels(childLabels)
+					for key, value := range annotations {
+						childNamespace.Annotations[key] = value
+					}
+					if !hasParentNamespace(childNamespace) {
+						childNamespace.SetOwnerReferences(append(childNamespace.GetOwnerReferences(), ownerReferences...))
+						c.recorder.Event(subnamespaceCopy, corev1.EventTypeNormal, successAdopted, messageAdopted)
+					}
+				} else {
+					// The time of the last quota change outlives the annotations being reset
+					if quotaUpdatedAt, elementExists := childNamespace.GetAnnotations()[QuotaUpdatedAnnotation]; elementExists {
+						if annotations == nil {
+							annotations = make(map[string]string)
+						}
+						annotations[QuotaUpdatedAnnotation] = quotaUpdatedAt
+					}
+					childNamespace.SetAnnotations(annotations)
+					childNamespace.SetLabels(labels)
+				}
 				if _, err := c.kubeclientset.CoreV1().Namespaces().Update(context.TODO(), childNamespace, metav1.UpdateOptions{}); err != nil {
 					c.recorder.Event(subnamespaceCopy, corev1.EventTypeWarning, failureUpdate, messageNSUpdateFail)
 					subnamespaceCopy.Status.State = corev1alpha1.StatusFailed
@@ -984,6 +1880,7 @@ func (c *Controller) makeSubsidiaryNamespace(subnamespaceCopy *corev1alpha1.SubN
 			rbSubjects := []rbacv1.Subject{{Kind: "User", Name: subnamespaceCopy.Spec.Workspace.Owner.Email, APIGroup: "rbac.authorization.k8s.io"}}
 			roleBind := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: objectName, Namespace: childNameHashed},
 				Subjects: rbSubjects, RoleRef: roleRef}
+			roleBind.SetLabels(c.tenantLabels(tenant))
 			if roleBinding, err := c.kubeclientset.RbacV1().RoleBindings(childNameHashed).Create(context.TODO(), roleBind, metav1.CreateOptions{}); err != nil {
 				if errors.IsAlreadyExists(err) {
 					roleBindingCopy := roleBinding.DeepCopy()
@@ -1044,15 +1941,26 @@ func (c *Controller) makeSubsidiaryNamespace(subnamespaceCopy *corev1alpha1.SubN
 	return true
 }
 
+// tenantLabels returns the labels to put on the objects generated for the tenant, or only the tenant name
+// if the tenant cannot be retrieved
+func (c *Controller) tenantLabels(tenant string) map[string]string {
+	tenantObj, err := c.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant, metav1.GetOptions{})
+	if err != nil {
+		klog.Infoln(err)
+		return map[string]string{"edge-net.io/tenant": tenant}
+	}
+	return multitenancy.TenantLabels(tenantObj, c.tenantLabelKeys)
+}
+
 func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespace, childNamespace string) bool {
 	done := true
 	if subnamespaceCopy.Spec.Workspace.Inheritance["rbac"] {
 		if parentRaw, err := c.kubeclientset.RbacV1().Roles(subnamespaceCopy.GetNamespace()).List(context.TODO(), metav1.ListOptions{}); err == nil {
 			var childItems []rbacv1.Role
-			if childRaw, err := c.kubeclientset.RbacV1().Roles(subnamespaceCopy.GetNamespace()).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/generated=true"}); err == nil {
+			if childRaw, err := c.kubeclientset.RbacV1().Roles(childNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/generated=true"}); err == nil {
 				childItems = childRaw.Items
 			}
-			inheritance := Inheritance{}
+			inheritance := Inheritance{Exclusions: subnamespaceCopy.Spec.Workspace.InheritanceExclusions}
 			inheritance.Child = make([]interface{}, len(childItems))
 			for k, v := range childItems {
 				inheritance.Child[k] = v.DeepCopy()
@@ -1062,35 +1970,36 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 				inheritance.Parent[k] = v.DeepCopy()
 			}
 			createList, updateList, deleteList := inheritance.GetOperationList()
-			if len(createList) > 0 {
-				for _, obj := range createList {
-					role := obj.(*rbacv1.Role)
+			operations := []func() error{}
+			for _, obj := range createList {
+				role := obj.(*rbacv1.Role)
+				operations = append(operations, func() error {
 					if _, err := c.kubeclientset.RbacV1().Roles(childNamespace).Create(context.TODO(), role, metav1.CreateOptions{}); err != nil {
 						if !errors.IsAlreadyExists(err) {
-							done = false
-							klog.Infoln(err)
-						} else {
-							// TODO: Warning
+							return err
+						} else if err := c.takeOverInheritedObject(inheritance, childNamespace, role); err != nil {
+							return err
 						}
 					}
-				}
+					return nil
+				})
 			}
-			if len(updateList) > 0 {
-				for _, obj := range updateList {
-					childRole := obj.(*rbacv1.Role)
-					if _, err := c.kubeclientset.RbacV1().Roles(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
+			for _, obj := range updateList {
+				childRole := obj.(*rbacv1.Role)
+				operations = append(operations, func() error {
+					_, err := c.kubeclientset.RbacV1().Roles(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{})
+					return err
+				})
 			}
-			if len(deleteList) > 0 {
-				for objName := range deleteList {
-					if err := c.kubeclientset.RbacV1().Roles(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
+			for objName := range deleteList {
+				objName := objName
+				operations = append(operations, func() error {
+					return c.kubeclientset.RbacV1().Roles(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{})
+				})
+			}
+			if err := runInheritanceOperations(operations); err != nil {
+				done = false
+				klog.Infoln(err)
 			}
 
 		}
@@ -1099,7 +2008,7 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 			if childRaw, err := c.kubeclientset.RbacV1().RoleBindings(childNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/generated=true"}); err == nil {
 				childItems = childRaw.Items
 			}
-			inheritance := Inheritance{}
+			inheritance := Inheritance{Exclusions: subnamespaceCopy.Spec.Workspace.InheritanceExclusions}
 			inheritance.Child = make([]interface{}, len(childItems))
 			for k, v := range childItems {
 				inheritance.Child[k] = v.DeepCopy()
@@ -1109,35 +2018,36 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 				inheritance.Parent[k] = v.DeepCopy()
 			}
 			createList, updateList, deleteList := inheritance.GetOperationList()
-			if len(createList) > 0 {
-				for _, obj := range createList {
-					role := obj.(*rbacv1.RoleBinding)
+			operations := []func() error{}
+			for _, obj := range createList {
+				role := obj.(*rbacv1.RoleBinding)
+				operations = append(operations, func() error {
 					if _, err := c.kubeclientset.RbacV1().RoleBindings(childNamespace).Create(context.TODO(), role, metav1.CreateOptions{}); err != nil {
 						if !errors.IsAlreadyExists(err) {
-							done = false
-							klog.Infoln(err)
-						} else {
-							// TODO: Warning
+							return err
+						} else if err := c.takeOverInheritedObject(inheritance, childNamespace, role); err != nil {
+							return err
 						}
 					}
-				}
-			}
-			if len(updateList) > 0 {
-				for _, obj := range updateList {
-					childRole := obj.(*rbacv1.RoleBinding)
-					if _, err := c.kubeclientset.RbacV1().RoleBindings(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
-			}
-			if len(deleteList) > 0 {
-				for objName := range deleteList {
-					if err := c.kubeclientset.RbacV1().RoleBindings(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
+					return nil
+				})
+			}
+			for _, obj := range updateList {
+				childRole := obj.(*rbacv1.RoleBinding)
+				operations = append(operations, func() error {
+					_, err := c.kubeclientset.RbacV1().RoleBindings(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{})
+					return err
+				})
+			}
+			for objName := range deleteList {
+				objName := objName
+				operations = append(operations, func() error {
+					return c.kubeclientset.RbacV1().RoleBindings(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{})
+				})
+			}
+			if err := runInheritanceOperations(operations); err != nil {
+				done = false
+				klog.Infoln(err)
 			}
 		}
 	} else {
@@ -1150,7 +2060,7 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 			if childRaw, err := c.kubeclientset.NetworkingV1().NetworkPolicies(childNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/generated=true"}); err == nil {
 				childItems = childRaw.Items
 			}
-			inheritance := Inheritance{}
+			inheritance := Inheritance{Exclusions: subnamespaceCopy.Spec.Workspace.InheritanceExclusions}
 			inheritance.Child = make([]interface{}, len(childItems))
 			for k, v := range childItems {
 				inheritance.Child[k] = v.DeepCopy()
@@ -1160,35 +2070,36 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 				inheritance.Parent[k] = v.DeepCopy()
 			}
 			createList, updateList, deleteList := inheritance.GetOperationList()
-			if len(createList) > 0 {
-				for _, obj := range createList {
-					role := obj.(*networkingv1.NetworkPolicy)
+			operations := []func() error{}
+			for _, obj := range createList {
+				role := obj.(*networkingv1.NetworkPolicy)
+				operations = append(operations, func() error {
 					if _, err := c.kubeclientset.NetworkingV1().NetworkPolicies(childNamespace).Create(context.TODO(), role, metav1.CreateOptions{}); err != nil {
 						if !errors.IsAlreadyExists(err) {
-							done = false
-							klog.Infoln(err)
-						} else {
-							// TODO: Warning
+							return err
+						} else if err := c.takeOverInheritedObject(inheritance, childNamespace, role); err != nil {
+							return err
 						}
 					}
-				}
-			}
-			if len(updateList) > 0 {
-				for _, obj := range updateList {
-					childRole := obj.(*networkingv1.NetworkPolicy)
-					if _, err := c.kubeclientset.NetworkingV1().NetworkPolicies(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
-			}
-			if len(deleteList) > 0 {
-				for objName := range deleteList {
-					if err := c.kubeclientset.NetworkingV1().NetworkPolicies(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
+					return nil
+				})
+			}
+			for _, obj := range updateList {
+				childRole := obj.(*networkingv1.NetworkPolicy)
+				operations = append(operations, func() error {
+					_, err := c.kubeclientset.NetworkingV1().NetworkPolicies(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{})
+					return err
+				})
+			}
+			for objName := range deleteList {
+				objName := objName
+				operations = append(operations, func() error {
+					return c.kubeclientset.NetworkingV1().NetworkPolicies(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{})
+				})
+			}
+			if err := runInheritanceOperations(operations); err != nil {
+				done = false
+				klog.Infoln(err)
 			}
 		}
 	} else {
@@ -1200,7 +2111,7 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 			if childRaw, err := c.kubeclientset.CoreV1().LimitRanges(childNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/generated=true"}); err == nil {
 				childItems = childRaw.Items
 			}
-			inheritance := Inheritance{}
+			inheritance := Inheritance{Exclusions: subnamespaceCopy.Spec.Workspace.InheritanceExclusions}
 			inheritance.Child = make([]interface{}, len(childItems))
 			for k, v := range childItems {
 				inheritance.Child[k] = v.DeepCopy()
@@ -1210,35 +2121,36 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 				inheritance.Parent[k] = v.DeepCopy()
 			}
 			createList, updateList, deleteList := inheritance.GetOperationList()
-			if len(createList) > 0 {
-				for _, obj := range createList {
-					role := obj.(*corev1.LimitRange)
+			operations := []func() error{}
+			for _, obj := range createList {
+				role := obj.(*corev1.LimitRange)
+				operations = append(operations, func() error {
 					if _, err := c.kubeclientset.CoreV1().LimitRanges(childNamespace).Create(context.TODO(), role, metav1.CreateOptions{}); err != nil {
 						if !errors.IsAlreadyExists(err) {
-							done = false
-							klog.Infoln(err)
+							return err
 						} else {
 							// TODO: Warning
 						}
 					}
-				}
-			}
-			if len(updateList) > 0 {
-				for _, obj := range updateList {
-					childRole := obj.(*corev1.LimitRange)
-					if _, err := c.kubeclientset.CoreV1().LimitRanges(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
-			}
-			if len(deleteList) > 0 {
-				for objName := range deleteList {
-					if err := c.kubeclientset.CoreV1().LimitRanges(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
+					return nil
+				})
+			}
+			for _, obj := range updateList {
+				childRole := obj.(*corev1.LimitRange)
+				operations = append(operations, func() error {
+					_, err := c.kubeclientset.CoreV1().LimitRanges(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{})
+					return err
+				})
+			}
+			for objName := range deleteList {
+				objName := objName
+				operations = append(operations, func() error {
+					return c.kubeclientset.CoreV1().LimitRanges(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{})
+				})
+			}
+			if err := runInheritanceOperations(operations); err != nil {
+				done = false
+				klog.Infoln(err)
 			}
 		}
 	} else {
@@ -1250,7 +2162,7 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 			if childRaw, err := c.kubeclientset.CoreV1().Secrets(childNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/generated=true"}); err == nil {
 				childItems = childRaw.Items
 			}
-			inheritance := Inheritance{}
+			inheritance := Inheritance{Exclusions: subnamespaceCopy.Spec.Workspace.InheritanceExclusions}
 			inheritance.Child = make([]interface{}, len(childItems))
 			for k, v := range childItems {
 				inheritance.Child[k] = v.DeepCopy()
@@ -1260,35 +2172,36 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 				inheritance.Parent[k] = v.DeepCopy()
 			}
 			createList, updateList, deleteList := inheritance.GetOperationList()
-			if len(createList) > 0 {
-				for _, obj := range createList {
-					role := obj.(*corev1.Secret)
+			operations := []func() error{}
+			for _, obj := range createList {
+				role := obj.(*corev1.Secret)
+				operations = append(operations, func() error {
 					if _, err := c.kubeclientset.CoreV1().Secrets(childNamespace).Create(context.TODO(), role, metav1.CreateOptions{}); err != nil {
 						if !errors.IsAlreadyExists(err) {
-							done = false
-							klog.Infoln(err)
+							return err
 						} else {
 							// TODO: Warning
 						}
 					}
-				}
-			}
-			if len(updateList) > 0 {
-				for _, obj := range updateList {
-					childRole := obj.(*corev1.Secret)
-					if _, err := c.kubeclientset.CoreV1().Secrets(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
-			}
-			if len(deleteList) > 0 {
-				for objName := range deleteList {
-					if err := c.kubeclientset.CoreV1().Secrets(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
+					return nil
+				})
+			}
+			for _, obj := range updateList {
+				childRole := obj.(*corev1.Secret)
+				operations = append(operations, func() error {
+					_, err := c.kubeclientset.CoreV1().Secrets(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{})
+					return err
+				})
+			}
+			for objName := range deleteList {
+				objName := objName
+				operations = append(operations, func() error {
+					return c.kubeclientset.CoreV1().Secrets(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{})
+				})
+			}
+			if err := runInheritanceOperations(operations); err != nil {
+				done = false
+				klog.Infoln(err)
 			}
 		}
 	} else {
@@ -1300,7 +2213,7 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 			if childRaw, err := c.kubeclientset.CoreV1().ConfigMaps(childNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/generated=true"}); err == nil {
 				childItems = childRaw.Items
 			}
-			inheritance := Inheritance{}
+			inheritance := Inheritance{Exclusions: subnamespaceCopy.Spec.Workspace.InheritanceExclusions}
 			inheritance.Child = make([]interface{}, len(childItems))
 			for k, v := range childItems {
 				inheritance.Child[k] = v.DeepCopy()
@@ -1310,35 +2223,36 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 				inheritance.Parent[k] = v.DeepCopy()
 			}
 			createList, updateList, deleteList := inheritance.GetOperationList()
-			if len(createList) > 0 {
-				for _, obj := range createList {
-					role := obj.(*corev1.ConfigMap)
+			operations := []func() error{}
+			for _, obj := range createList {
+				role := obj.(*corev1.ConfigMap)
+				operations = append(operations, func() error {
 					if _, err := c.kubeclientset.CoreV1().ConfigMaps(childNamespace).Create(context.TODO(), role, metav1.CreateOptions{}); err != nil {
 						if !errors.IsAlreadyExists(err) {
-							done = false
-							klog.Infoln(err)
+							return err
 						} else {
 							// TODO: Warning
 						}
 					}
-				}
-			}
-			if len(updateList) > 0 {
-				for _, obj := range updateList {
-					childRole := obj.(*corev1.ConfigMap)
-					if _, err := c.kubeclientset.CoreV1().ConfigMaps(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
-			}
-			if len(deleteList) > 0 {
-				for objName := range deleteList {
-					if err := c.kubeclientset.CoreV1().ConfigMaps(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
+					return nil
+				})
+			}
+			for _, obj := range updateList {
+				childRole := obj.(*corev1.ConfigMap)
+				operations = append(operations, func() error {
+					_, err := c.kubeclientset.CoreV1().ConfigMaps(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{})
+					return err
+				})
+			}
+			for objName := range deleteList {
+				objName := objName
+				operations = append(operations, func() error {
+					return c.kubeclientset.CoreV1().ConfigMaps(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{})
+				})
+			}
+			if err := runInheritanceOperations(operations); err != nil {
+				done = false
+				klog.Infoln(err)
 			}
 		}
 	} else {
@@ -1350,7 +2264,7 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 			if childRaw, err := c.kubeclientset.CoreV1().ServiceAccounts(childNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/generated=true"}); err == nil {
 				childItems = childRaw.Items
 			}
-			inheritance := Inheritance{}
+			inheritance := Inheritance{Exclusions: subnamespaceCopy.Spec.Workspace.InheritanceExclusions}
 			inheritance.Child = make([]interface{}, len(childItems))
 			for k, v := range childItems {
 				inheritance.Child[k] = v.DeepCopy()
@@ -1360,35 +2274,36 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 				inheritance.Parent[k] = v.DeepCopy()
 			}
 			createList, updateList, deleteList := inheritance.GetOperationList()
-			if len(createList) > 0 {
-				for _, obj := range createList {
-					role := obj.(*corev1.ServiceAccount)
+			operations := []func() error{}
+			for _, obj := range createList {
+				role := obj.(*corev1.ServiceAccount)
+				operations = append(operations, func() error {
 					if _, err := c.kubeclientset.CoreV1().ServiceAccounts(childNamespace).Create(context.TODO(), role, metav1.CreateOptions{}); err != nil {
 						if !errors.IsAlreadyExists(err) {
-							done = false
-							klog.Infoln(err)
+							return err
 						} else {
 							// TODO: Warning
 						}
 					}
-				}
-			}
-			if len(updateList) > 0 {
-				for _, obj := range updateList {
-					childRole := obj.(*corev1.ServiceAccount)
-					if _, err := c.kubeclientset.CoreV1().ServiceAccounts(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
-			}
-			if len(deleteList) > 0 {
-				for objName := range deleteList {
-					if err := c.kubeclientset.CoreV1().ServiceAccounts(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{}); err != nil {
-						done = false
-						klog.Infoln(err)
-					}
-				}
+					return nil
+				})
+			}
+			for _, obj := range updateList {
+				childRole := obj.(*corev1.ServiceAccount)
+				operations = append(operations, func() error {
+					_, err := c.kubeclientset.CoreV1().ServiceAccounts(childNamespace).Update(context.TODO(), childRole, metav1.UpdateOptions{})
+					return err
+				})
+			}
+			for objName := range deleteList {
+				objName := objName
+				operations = append(operations, func() error {
+					return c.kubeclientset.CoreV1().ServiceAccounts(childNamespace).Delete(context.TODO(), objName, metav1.DeleteOptions{})
+				})
+			}
+			if err := runInheritanceOperations(operations); err != nil {
+				done = false
+				klog.Infoln(err)
 			}
 		}
 	} else {
@@ -1404,11 +2319,68 @@ func (c *Controller) handleInheritance(subnamespaceCopy *corev1alpha1.SubNamespa
 	return done
 }
 
+// runInheritanceOperations runs the operations that copy the parent's objects to the child on a bounded pool of
+// workers, as a parent with many objects would otherwise leave the child partially configured for long.
+// The errors of the operations that fail are aggregated, without stopping the others.
+func runInheritanceOperations(operations []func() error) error {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var errs []error
+	workers := make(chan struct{}, inheritanceWorkers)
+	for _, operation := range operations {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(operation func() error) {
+			defer wg.Done()
+			defer func() { <-workers }()
+			if err := operation(); err != nil {
+				mutex.Lock()
+				errs = append(errs, err)
+				mutex.Unlock()
+			}
+		}(operation)
+	}
+	wg.Wait()
+	return utilerrors.NewAggregate(errs)
+}
+
+// takeOverInheritedObject brings a child object that has the same name as an inherited one, but is not
+// managed by inheritance yet, in line with the parent's object, instead of leaving it as it is
+func (c *Controller) takeOverInheritedObject(inheritance Inheritance, childNamespace string, obj interface{}) error {
+	var err error
+	switch parentObj := obj.(type) {
+	case *rbacv1.Role:
+		var childRole *rbacv1.Role
+		if childRole, err = c.kubeclientset.RbacV1().Roles(childNamespace).Get(context.TODO(), parentObj.GetName(), metav1.GetOptions{}); err == nil {
+			if childObj := inheritance.prepareForUpdate(childRole, parentObj); childObj != nil {
+				_, err = c.kubeclientset.RbacV1().Roles(childNamespace).Update(context.TODO(), childObj.(*rbacv1.Role), metav1.UpdateOptions{})
+			}
+		}
+	case *rbacv1.RoleBinding:
+		var childRoleBinding *rbacv1.RoleBinding
+		if childRoleBinding, err = c.kubeclientset.RbacV1().RoleBindings(childNamespace).Get(context.TODO(), parentObj.GetName(), metav1.GetOptions{}); err == nil {
+			if childObj := inheritance.prepareForUpdate(childRoleBinding, parentObj); childObj != nil {
+				_, err = c.kubeclientset.RbacV1().RoleBindings(childNamespace).Update(context.TODO(), childObj.(*rbacv1.RoleBinding), metav1.UpdateOptions{})
+			}
+		}
+	case *networkingv1.NetworkPolicy:
+		var childNetworkPolicy *networkingv1.NetworkPolicy
+		if childNetworkPolicy, err = c.kubeclientset.NetworkingV1().NetworkPolicies(childNamespace).Get(context.TODO(), parentObj.GetName(), metav1.GetOptions{}); err == nil {
+			if childObj := inheritance.prepareForUpdate(childNetworkPolicy, parentObj); childObj != nil {
+				_, err = c.kubeclientset.NetworkingV1().NetworkPolicies(childNamespace).Update(context.TODO(), childObj.(*networkingv1.NetworkPolicy), metav1.UpdateOptions{})
+			}
+		}
+	}
+	return err
+}
+
 // Inheritance is a struct to manage inheritance between parent and child
 type Inheritance struct {
 	Child          []interface{}
 	Parent         []interface{}
 	ChildNamespace string
+	// Exclusions lists the names of parent objects not to copy to the child
+	Exclusions []string
 }
 
 // GetOperationList returns the list of objects to create, update and delete
@@ -1420,6 +2392,10 @@ func (i Inheritance) GetOperationList() ([]interface{}, []interface{}, map[strin
 		comparisonSlice[childObj.(metav1.Object).GetName()] = childObj
 	}
 	for _, parentObj := range i.Parent {
+		if i.isExcluded(parentObj.(metav1.Object).GetName()) {
+			// A copy inherited before the exclusion stays in the comparison slice, so it gets deleted
+			continue
+		}
 		if _, ok := comparisonSlice[parentObj.(metav1.Object).GetName()]; ok {
 			childObj := i.prepareForUpdate(comparisonSlice[parentObj.(metav1.Object).GetName()], parentObj)
 			if childObj != nil {
@@ -1434,6 +2410,15 @@ func (i Inheritance) GetOperationList() ([]interface{}, []interface{}, map[strin
 	return createList, updateList, comparisonSlice
 }
 
+func (i Inheritance) isExcluded(name string) bool {
+	for _, exclusion := range i.Exclusions {
+		if exclusion == name {
+			return true
+		}
+	}
+	return false
+}
+
 func (i Inheritance) prepareForCreate(obj interface{}) interface{} {
 	obj.(metav1.Object).SetNamespace(i.ChildNamespace)
 	obj.(metav1.Object).SetUID(types.UID(uuid.New().String()))
@@ -1563,7 +2548,13 @@ func (c *Controller) cleanup(subnamespaceCopy *corev1alpha1.SubNamespace) {
 	} else {
 		return
 	}
-	c.partitionParentQuota(subnamespaceCopy, parentNamespace)
+	childRecorded := subnamespaceCopy.Status.Child != nil
+	subnamespaceCopy.Status.Child = nil
+	unlock := multitenancy.LockNamespaces(parentNamespaceLabels["edge-net.io/tenant"], parentNamespace.GetName(), childNameHashed)
+	defer unlock()
+	if isPartitioned := c.partitionParentQuota(subnamespaceCopy, parentNamespace); isPartitioned && childRecorded {
+		c.updateStatus(context.TODO(), subnamespaceCopy)
+	}
 }
 
 // updateStatus calls the API to update the subnamespace status.
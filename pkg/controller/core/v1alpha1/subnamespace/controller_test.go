@@ -7,10 +7,16 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	corev1alpha "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/controller/core/v1alpha1/tenantresourcequota"
+	"github.com/EdgeNet-project/edgenet/pkg/deadline"
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	edgenettestclient "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/fake"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
@@ -25,9 +31,16 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 )
 
@@ -55,7 +68,7 @@ func TestMain(m *testing.M) {
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
 
-	controller := NewController(kubeclientset,
+	controller, err := NewController(kubeclientset,
 		edgenetclientset,
 		kubeInformerFactory.Rbac().V1().Roles(),
 		kubeInformerFactory.Rbac().V1().RoleBindings(),
@@ -64,8 +77,15 @@ func TestMain(m *testing.M) {
 		kubeInformerFactory.Core().V1().Secrets(),
 		kubeInformerFactory.Core().V1().ConfigMaps(),
 		kubeInformerFactory.Core().V1().ServiceAccounts(),
-		edgenetInformerFactory.Core().V1alpha1().SubNamespaces())
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
+	controller.inheritanceResyncDelay = 100 * time.Millisecond
+	controller.resetPollDelay = 100 * time.Millisecond
 
+	kubeInformerFactory.Start(stopCh)
 	edgenetInformerFactory.Start(stopCh)
 
 	go func() {
@@ -483,3 +503,2006 @@ func TestQuota(t *testing.T) {
 	_, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName3, metav1.GetOptions{})
 	util.Equals(t, true, errors.IsNotFound(err))
 }
+
+func TestResourceAllocationUnits(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	subnamespaceSI := g.subNamespaceObj.DeepCopy()
+	subnamespaceSI.SetName("si-units")
+	subnamespaceSI.SetUID("si-units")
+	subnamespaceSI.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1")
+	subnamespaceSI.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1G")
+	subnamespaceAmbiguous := g.subNamespaceObj.DeepCopy()
+	subnamespaceAmbiguous.SetName("ambiguous-units")
+	subnamespaceAmbiguous.SetUID("ambiguous-units")
+	subnamespaceAmbiguous.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1m")
+	childNameAmbiguous := subnamespaceAmbiguous.GenerateChildName("")
+
+	t.Run("SI units", func(t *testing.T) {
+		defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespaceSI.GetName(), metav1.DeleteOptions{})
+		_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespaceSI, metav1.CreateOptions{})
+		util.OK(t, err)
+		time.Sleep(450 * time.Millisecond)
+		subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespaceSI.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		cpuQuantity := subnamespace.Status.ResourceAllocation[corev1.ResourceCPU]
+		memoryQuantity := subnamespace.Status.ResourceAllocation[corev1.ResourceMemory]
+		util.Equals(t, "1", cpuQuantity.String())
+		util.Equals(t, "1000000000", memoryQuantity.String())
+	})
+	t.Run("ambiguous units", func(t *testing.T) {
+		defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespaceAmbiguous.GetName(), metav1.DeleteOptions{})
+		_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespaceAmbiguous, metav1.CreateOptions{})
+		util.OK(t, err)
+		time.Sleep(450 * time.Millisecond)
+		_, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childNameAmbiguous, metav1.GetOptions{})
+		util.Equals(t, true, errors.IsNotFound(err))
+	})
+}
+
+func TestResourceAllocationRounding(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	subnamespaceUneven := g.subNamespaceObj.DeepCopy()
+	subnamespaceUneven.SetName("uneven-units")
+	subnamespaceUneven.SetUID("uneven-units")
+	subnamespaceUneven.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("0.3335")
+	subnamespaceUneven.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("0.1Gi")
+	childNameUneven := subnamespaceUneven.GenerateChildName("")
+
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespaceUneven.GetName(), metav1.DeleteOptions{})
+	_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespaceUneven, metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(450 * time.Millisecond)
+	subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespaceUneven.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	cpuQuantity := subnamespace.Status.ResourceAllocation[corev1.ResourceCPU]
+	memoryQuantity := subnamespace.Status.ResourceAllocation[corev1.ResourceMemory]
+	util.Equals(t, "333m", cpuQuantity.String())
+	util.Equals(t, "107374182", memoryQuantity.String())
+
+	childResourceQuota, err := kubeclientset.CoreV1().ResourceQuotas(childNameUneven).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	cpuQuota := childResourceQuota.Spec.Hard[corev1.ResourceCPU]
+	memoryQuota := childResourceQuota.Spec.Hard[corev1.ResourceMemory]
+	util.Equals(t, "333m", cpuQuota.String())
+	util.Equals(t, "107374182", memoryQuota.String())
+}
+
+func TestMissingChildNamespace(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	subnamespaceTest := g.subNamespaceObj.DeepCopy()
+	subnamespaceTest.SetName("missing-child")
+	subnamespaceTest.SetUID("missing-child")
+	subnamespaceTest.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1")
+	subnamespaceTest.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	childName := subnamespaceTest.GenerateChildName("")
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespaceTest.GetName(), metav1.DeleteOptions{})
+	_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespaceTest, metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(750 * time.Millisecond)
+	subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespaceTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusEstablished, subnamespace.Status.State)
+
+	// Delete the child namespace out-of-band and poke the subnamespace so that it gets reconciled. The repairs do not
+	// count toward the backoff limit, so the subnamespace survives as many deletions as it takes.
+	for i := 0; i < backoffLimit; i++ {
+		err = kubeclientset.CoreV1().Namespaces().Delete(context.TODO(), childName, metav1.DeleteOptions{})
+		util.OK(t, err)
+		subnamespace.SetLabels(map[string]string{"edge-net.io/poke": strconv.Itoa(i)})
+		_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Update(context.TODO(), subnamespace, metav1.UpdateOptions{})
+		util.OK(t, err)
+		time.Sleep(750 * time.Millisecond)
+
+		subnamespace, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespaceTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, 0, subnamespace.Status.Failed)
+		util.Equals(t, corev1alpha.StatusEstablished, subnamespace.Status.State)
+		_, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+		util.OK(t, err)
+	}
+}
+
+func TestChildNamespaceStatus(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	subnamespaceTest := g.subNamespaceObj.DeepCopy()
+	subnamespaceTest.SetName("child-status")
+	subnamespaceTest.SetUID("child-status")
+	subnamespaceTest.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1")
+	subnamespaceTest.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespaceTest.GetName(), metav1.DeleteOptions{})
+	_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespaceTest, metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(750 * time.Millisecond)
+	subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespaceTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusEstablished, subnamespace.Status.State)
+	util.Equals(t, true, subnamespace.Status.Child != nil)
+	childNamespace, err := kubeclientset.CoreV1().Namespaces().Get(context.TODO(), *subnamespace.Status.Child, metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, subnamespace.GetName(), childNamespace.GetLabels()["edge-net.io/owner"])
+
+	// Exhausting the backoff limit deletes the child namespace
+	subnamespace.Status.State = corev1alpha.StatusFailed
+	subnamespace.Status.Failed = backoffLimit
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).UpdateStatus(context.TODO(), subnamespace, metav1.UpdateOptions{})
+	util.OK(t, err)
+	time.Sleep(750 * time.Millisecond)
+	_, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childNamespace.GetName(), metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+	subnamespace, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespaceTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, true, subnamespace.Status.Child == nil)
+}
+
+func TestResetSchedule(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	subnamespaceTest := g.subNamespaceObj.DeepCopy()
+	subnamespaceTest.SetName("reset")
+	subnamespaceTest.SetUID("reset")
+	subnamespaceTest.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1")
+	subnamespaceTest.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	subnamespaceTest.Spec.ResetSchedule = "@every 2s"
+	childName := subnamespaceTest.GenerateChildName("")
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespaceTest.GetName(), metav1.DeleteOptions{})
+	_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespaceTest, metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(750 * time.Millisecond)
+	subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespaceTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusEstablished, subnamespace.Status.State)
+	util.NotEquals(t, (*metav1.Time)(nil), subnamespace.Status.LastReset)
+	lastReset := subnamespace.Status.LastReset.DeepCopy()
+	parentResourceQuota, err := kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	parentCPU := parentResourceQuota.Spec.Hard[corev1.ResourceCPU]
+
+	// Mark the child so that its re-creation can be told apart
+	childNamespace, err := kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+	util.OK(t, err)
+	childNamespace.Labels["edge-net.io/reset-marker"] = "true"
+	_, err = kubeclientset.CoreV1().Namespaces().Update(context.TODO(), childNamespace, metav1.UpdateOptions{})
+	util.OK(t, err)
+	time.Sleep(2000 * time.Millisecond)
+
+	subnamespace, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespaceTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusEstablished, subnamespace.Status.State)
+	util.Equals(t, true, subnamespace.Status.LastReset.After(lastReset.Time))
+	childNamespace, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+	util.OK(t, err)
+	_, isMarked := childNamespace.Labels["edge-net.io/reset-marker"]
+	util.Equals(t, false, isMarked)
+	// The quota grant survives the reset
+	childResourceQuota, err := kubeclientset.CoreV1().ResourceQuotas(childName).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	childCPU := childResourceQuota.Spec.Hard[corev1.ResourceCPU]
+	util.Equals(t, "1", childCPU.String())
+	parentResourceQuota, err = kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	remainingParentCPU := parentResourceQuota.Spec.Hard[corev1.ResourceCPU]
+	util.Equals(t, parentCPU.String(), remainingParentCPU.String())
+}
+
+func TestAdoptNamespace(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	cases := map[string]struct {
+		namespace   string
+		annotations map[string]string
+		expected    string
+	}{
+		"adoptable":     {"legacy", map[string]string{"edge-net.io/adopt": "true", "owner": "team-a"}, corev1alpha.StatusEstablished},
+		"not adoptable": {"foreign", map[string]string{"owner": "team-a"}, corev1alpha.StatusFailed},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			legacyName := tc.namespace
+			legacyNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: legacyName, Labels: map[string]string{"team": "a"}, Annotations: tc.annotations}}
+			_, err := kubeclientset.CoreV1().Namespaces().Create(context.TODO(), legacyNamespace, metav1.CreateOptions{})
+			util.OK(t, err)
+			defer kubeclientset.CoreV1().Namespaces().Delete(context.TODO(), legacyName, metav1.DeleteOptions{})
+
+			subnamespaceTest := g.subNamespaceObj.DeepCopy()
+			subnamespaceTest.SetName(legacyName)
+			subnamespaceTest.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1")
+			subnamespaceTest.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+			subnamespaceTest.Spec.Workspace.Adopt = legacyName
+			defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespaceTest.GetName(), metav1.DeleteOptions{})
+			_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespaceTest, metav1.CreateOptions{})
+			util.OK(t, err)
+			time.Sleep(750 * time.Millisecond)
+
+			subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespaceTest.GetName(), metav1.GetOptions{})
+			util.OK(t, err)
+			util.Equals(t, tc.expected, subnamespace.Status.State)
+			childNamespace, err := kubeclientset.CoreV1().Namespaces().Get(context.TODO(), legacyName, metav1.GetOptions{})
+			util.OK(t, err)
+			util.Equals(t, "a", childNamespace.GetLabels()["team"])
+			util.Equals(t, "team-a", childNamespace.GetAnnotations()["owner"])
+			if tc.expected != corev1alpha.StatusEstablished {
+				util.Equals(t, 0, len(childNamespace.GetOwnerReferences()))
+				return
+			}
+			util.Equals(t, legacyName, *subnamespace.Status.Child)
+			util.Equals(t, "sub", childNamespace.GetLabels()["edge-net.io/kind"])
+			util.Equals(t, g.tenantObj.GetName(), childNamespace.GetLabels()["edge-net.io/parent-namespace"])
+			util.Equals(t, 1, len(childNamespace.GetOwnerReferences()))
+			util.Equals(t, g.tenantObj.GetName(), childNamespace.GetOwnerReferences()[0].Name)
+			childResourceQuota, err := kubeclientset.CoreV1().ResourceQuotas(legacyName).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+			util.OK(t, err)
+			childCPU := childResourceQuota.Spec.Hard[corev1.ResourceCPU]
+			util.Equals(t, "1", childCPU.String())
+		})
+	}
+}
+
+func TestQuotaBorrowing(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// The siblings live under their own parent workspace so that no other subnamespace can lend to them
+	parent := g.subNamespaceObj.DeepCopy()
+	parent.SetName("borrowing")
+	parent.SetUID("borrowing")
+	parent.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("800m")
+	parent.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("2Gi")
+	parentChildName := parent.GenerateChildName("")
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), parent.GetName(), metav1.DeleteOptions{})
+	_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), parent, metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(750 * time.Millisecond)
+
+	lender := g.subNamespaceObj.DeepCopy()
+	lender.SetName("lender")
+	lender.SetUID("lender")
+	lender.SetNamespace(parentChildName)
+	lender.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("300m")
+	lender.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	lenderChildName := lender.GenerateChildName("")
+	borrower := g.subNamespaceObj.DeepCopy()
+	borrower.SetName("borrower")
+	borrower.SetUID("borrower")
+	borrower.SetNamespace(parentChildName)
+	borrower.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("300m")
+	borrower.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	borrower.Spec.Workspace.Borrow = map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse("100m")}
+	borrowerChildName := borrower.GenerateChildName("")
+
+	var checkCPU = func(t *testing.T, namespace, expected string) {
+		resourceQuota, err := kubeclientset.CoreV1().ResourceQuotas(namespace).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+		util.OK(t, err)
+		cpuQuota := resourceQuota.Spec.Hard[corev1.ResourceCPU]
+		util.Equals(t, expected, cpuQuota.String())
+	}
+
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(parentChildName).Create(context.TODO(), lender, metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(750 * time.Millisecond)
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(parentChildName).Create(context.TODO(), borrower, metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(1500 * time.Millisecond)
+
+	t.Run("borrow", func(t *testing.T) {
+		subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(parentChildName).Get(context.TODO(), borrower.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, 1, len(subnamespace.Status.Borrowed))
+		util.Equals(t, lender.GetName(), subnamespace.Status.Borrowed[0].Lender)
+		util.Equals(t, "100m", subnamespace.Status.Borrowed[0].Quantity.String())
+		checkCPU(t, borrowerChildName, "400m")
+		checkCPU(t, lenderChildName, "200m")
+		// Loans move quota between siblings without changing what is debited from the parent
+		checkCPU(t, parentChildName, "200m")
+	})
+	t.Run("return", func(t *testing.T) {
+		// The lender uses up its quota, so it needs the loan back
+		lenderResourceQuota, err := kubeclientset.CoreV1().ResourceQuotas(lenderChildName).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+		util.OK(t, err)
+		lenderResourceQuota.Status.Used = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("300m")}
+		_, err = kubeclientset.CoreV1().ResourceQuotas(lenderChildName).UpdateStatus(context.TODO(), lenderResourceQuota, metav1.UpdateOptions{})
+		util.OK(t, err)
+		subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(parentChildName).Get(context.TODO(), borrower.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		subnamespace.SetLabels(map[string]string{"edge-net.io/poke": "true"})
+		_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(parentChildName).Update(context.TODO(), subnamespace, metav1.UpdateOptions{})
+		util.OK(t, err)
+		time.Sleep(1500 * time.Millisecond)
+
+		subnamespace, err = edgenetclientset.CoreV1alpha1().SubNamespaces(parentChildName).Get(context.TODO(), borrower.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, 0, len(subnamespace.Status.Borrowed))
+		checkCPU(t, borrowerChildName, "300m")
+		checkCPU(t, lenderChildName, "300m")
+		checkCPU(t, parentChildName, "200m")
+	})
+}
+
+func TestInheritanceResync(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	parentRole := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "edgenet-resync", ResourceVersion: "1"},
+		Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}}}
+	_, err := kubeclientset.RbacV1().Roles(g.tenantObj.GetName()).Create(context.TODO(), parentRole, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer kubeclientset.RbacV1().Roles(g.tenantObj.GetName()).Delete(context.TODO(), parentRole.GetName(), metav1.DeleteOptions{})
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	subnamespace.SetName("resync")
+	subnamespace.SetUID("resync")
+	subnamespace.Spec.Workspace.Sync = true
+	subnamespace.Spec.Workspace.Inheritance["networkpolicy"] = false
+	subnamespace.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1000m")
+	subnamespace.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	childName := subnamespace.GenerateChildName("")
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespace.GetName(), metav1.DeleteOptions{})
+	time.Sleep(750 * time.Millisecond)
+
+	childRole, err := kubeclientset.RbacV1().Roles(childName).Get(context.TODO(), parentRole.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, parentRole.Rules, childRole.Rules)
+
+	// Modify the parent role only, the subnamespace object stays untouched
+	parentRole, err = kubeclientset.RbacV1().Roles(g.tenantObj.GetName()).Get(context.TODO(), parentRole.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	parentRole.Rules = []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list", "watch"}}}
+	// The fake clientset does not bump resource versions as the API server does
+	parentRole.SetResourceVersion("2")
+	_, err = kubeclientset.RbacV1().Roles(g.tenantObj.GetName()).Update(context.TODO(), parentRole, metav1.UpdateOptions{})
+	util.OK(t, err)
+	time.Sleep(750 * time.Millisecond)
+
+	childRole, err = kubeclientset.RbacV1().Roles(childName).Get(context.TODO(), parentRole.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, parentRole.Rules, childRole.Rules)
+}
+
+func TestInheritanceTakeOver(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	port := intstr.FromInt(80)
+	parentPolicy := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: "edgenet-takeover"},
+		Spec: networkingv1.NetworkPolicySpec{Ingress: []networkingv1.NetworkPolicyIngressRule{{Ports: []networkingv1.NetworkPolicyPort{{Port: &port}}}}}}
+	_, err := kubeclientset.NetworkingV1().NetworkPolicies(g.tenantObj.GetName()).Create(context.TODO(), parentPolicy, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer kubeclientset.NetworkingV1().NetworkPolicies(g.tenantObj.GetName()).Delete(context.TODO(), parentPolicy.GetName(), metav1.DeleteOptions{})
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	subnamespace.SetName("takeover")
+	subnamespace.SetUID("takeover")
+	subnamespace.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1000m")
+	subnamespace.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	childName := subnamespace.GenerateChildName("")
+
+	// The child already has a policy of the same name that inheritance does not manage
+	childPolicy := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: parentPolicy.GetName(), Namespace: childName},
+		Spec: networkingv1.NetworkPolicySpec{PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}}}
+	_, err = kubeclientset.NetworkingV1().NetworkPolicies(childName).Create(context.TODO(), childPolicy, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespace.GetName(), metav1.DeleteOptions{})
+	time.Sleep(750 * time.Millisecond)
+
+	subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusEstablished, subnamespaceCopy.Status.State)
+	childPolicy, err = kubeclientset.NetworkingV1().NetworkPolicies(childName).Get(context.TODO(), parentPolicy.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, parentPolicy.Spec, childPolicy.Spec)
+	util.Equals(t, "true", childPolicy.GetLabels()["edge-net.io/generated"])
+}
+
+func TestParallelInheritance(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// A dedicated controller, which is not started, copies the parent's objects on a single pass
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	controller, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(100)
+	controller.recorder = recorder
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	childName := subnamespace.GenerateChildName("")
+	objects := 5 * inheritanceWorkers
+	for i := 0; i < objects; i++ {
+		role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("role-%d", i)}}
+		_, err := kubeclientset.RbacV1().Roles(subnamespace.GetNamespace()).Create(context.TODO(), role, metav1.CreateOptions{})
+		util.OK(t, err)
+		policy := &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("policy-%d", i)}}
+		_, err = kubeclientset.NetworkingV1().NetworkPolicies(subnamespace.GetNamespace()).Create(context.TODO(), policy, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+
+	util.Equals(t, true, controller.handleInheritance(subnamespace.DeepCopy(), childName))
+	childRoles, err := kubeclientset.RbacV1().Roles(childName).List(context.TODO(), metav1.ListOptions{})
+	util.OK(t, err)
+	util.Equals(t, objects, len(childRoles.Items))
+	childPolicies, err := kubeclientset.NetworkingV1().NetworkPolicies(childName).List(context.TODO(), metav1.ListOptions{})
+	util.OK(t, err)
+	util.Equals(t, objects, len(childPolicies.Items))
+	// Copying again changes nothing
+	util.Equals(t, true, controller.handleInheritance(subnamespace.DeepCopy(), childName))
+	util.Equals(t, 0, len(recorder.Events))
+
+	t.Run("errors aggregated", func(t *testing.T) {
+		var failed int32
+		kubeclientset.PrependReactor("create", "roles", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetNamespace() != childName {
+				return false, nil, nil
+			}
+			if name := action.(k8stesting.CreateAction).GetObject().(*rbacv1.Role).GetName(); name == "failing-0" || name == "failing-1" {
+				atomic.AddInt32(&failed, 1)
+				return true, nil, fmt.Errorf("role %s cannot be created", name)
+			}
+			return false, nil, nil
+		})
+		for i := 0; i < 2*inheritanceWorkers; i++ {
+			role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("failing-%d", i)}}
+			_, err := kubeclientset.RbacV1().Roles(subnamespace.GetNamespace()).Create(context.TODO(), role, metav1.CreateOptions{})
+			util.OK(t, err)
+		}
+
+		util.Equals(t, false, controller.handleInheritance(subnamespace.DeepCopy(), childName))
+		util.Equals(t, int32(2), atomic.LoadInt32(&failed))
+		// The failures do not stop the others from being copied
+		childRoles, err := kubeclientset.RbacV1().Roles(childName).List(context.TODO(), metav1.ListOptions{})
+		util.OK(t, err)
+		util.Equals(t, objects+2*inheritanceWorkers-2, len(childRoles.Items))
+		util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, failureInheritance, messageInheritanceFail), <-recorder.Events)
+		util.Equals(t, 0, len(recorder.Events))
+
+		err = runInheritanceOperations([]func() error{
+			func() error { return nil },
+			func() error { return fmt.Errorf("first") },
+			func() error { return fmt.Errorf("second") },
+		})
+		util.Equals(t, 2, len(err.(utilerrors.Aggregate).Errors()))
+		util.OK(t, runInheritanceOperations(nil))
+	})
+}
+
+func TestInheritanceExclusions(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	for _, name := range []string{"edgenet-allow-web", "edgenet-deny-all"} {
+		_, err := kubeclientset.NetworkingV1().NetworkPolicies(g.tenantObj.GetName()).Create(context.TODO(), &networkingv1.NetworkPolicy{ObjectMeta: metav1.ObjectMeta{Name: name}}, metav1.CreateOptions{})
+		util.OK(t, err)
+		defer kubeclientset.NetworkingV1().NetworkPolicies(g.tenantObj.GetName()).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	}
+	_, err := kubeclientset.RbacV1().Roles(g.tenantObj.GetName()).Create(context.TODO(), &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "edgenet-auditor"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer kubeclientset.RbacV1().Roles(g.tenantObj.GetName()).Delete(context.TODO(), "edgenet-auditor", metav1.DeleteOptions{})
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	subnamespace.SetName("exclusions")
+	subnamespace.SetUID("exclusions")
+	subnamespace.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1000m")
+	subnamespace.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	subnamespace.Spec.Workspace.Sync = true
+	subnamespace.Spec.Workspace.InheritanceExclusions = []string{"edgenet-deny-all", "edgenet-auditor"}
+	childName := subnamespace.GenerateChildName("")
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespace.GetName(), metav1.DeleteOptions{})
+	time.Sleep(750 * time.Millisecond)
+
+	subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusEstablished, subnamespaceCopy.Status.State)
+	_, err = kubeclientset.NetworkingV1().NetworkPolicies(childName).Get(context.TODO(), "edgenet-allow-web", metav1.GetOptions{})
+	util.OK(t, err)
+	_, err = kubeclientset.NetworkingV1().NetworkPolicies(childName).Get(context.TODO(), "edgenet-deny-all", metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+	_, err = kubeclientset.RbacV1().Roles(childName).Get(context.TODO(), "edgenet-test", metav1.GetOptions{})
+	util.OK(t, err)
+	_, err = kubeclientset.RbacV1().Roles(childName).Get(context.TODO(), "edgenet-auditor", metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+
+	t.Run("excluded after inheritance", func(t *testing.T) {
+		subnamespaceCopy.Spec.Workspace.InheritanceExclusions = append(subnamespaceCopy.Spec.Workspace.InheritanceExclusions, "edgenet-allow-web")
+		_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Update(context.TODO(), subnamespaceCopy, metav1.UpdateOptions{})
+		util.OK(t, err)
+		time.Sleep(750 * time.Millisecond)
+		_, err = kubeclientset.NetworkingV1().NetworkPolicies(childName).Get(context.TODO(), "edgenet-allow-web", metav1.GetOptions{})
+		util.Equals(t, true, errors.IsNotFound(err))
+		_, err = kubeclientset.NetworkingV1().NetworkPolicies(childName).Get(context.TODO(), "edgenet-test", metav1.GetOptions{})
+		util.OK(t, err)
+	})
+}
+
+func TestTenantLabels(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	tenant, err := edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), g.tenantObj.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	tenant.SetUID("edgenet-uid")
+	tenant.SetLabels(map[string]string{"edge-net.io/cost-center": "lip6", "team": "network"})
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Update(context.TODO(), tenant, metav1.UpdateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().Tenants().Update(context.TODO(), g.tenantObj.DeepCopy(), metav1.UpdateOptions{})
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	subnamespace.SetName("labels")
+	subnamespace.SetUID("labels")
+	subnamespace.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1000m")
+	subnamespace.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	subnamespace.Spec.Workspace.Owner = &corev1alpha.Contact{Email: "jane.doe@edge-net.org", FirstName: "Jane", LastName: "Doe"}
+	childName := subnamespace.GenerateChildName("")
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespace.GetName(), metav1.DeleteOptions{})
+	time.Sleep(750 * time.Millisecond)
+
+	expected := map[string]string{"edge-net.io/tenant": g.tenantObj.GetName(), "edge-net.io/tenant-uid": "edgenet-uid", "edge-net.io/cost-center": "lip6"}
+	childNamespace, err := kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+	util.OK(t, err)
+	childQuota, err := kubeclientset.CoreV1().ResourceQuotas(childName).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	ownerBinding, err := kubeclientset.RbacV1().RoleBindings(childName).Get(context.TODO(), "edgenet:workspace:owner", metav1.GetOptions{})
+	util.OK(t, err)
+	for _, labels := range []map[string]string{childNamespace.GetLabels(), childQuota.GetLabels(), ownerBinding.GetLabels()} {
+		for key, value := range expected {
+			util.Equals(t, value, labels[key])
+		}
+		_, hasTeam := labels["team"]
+		util.Equals(t, false, hasTeam)
+	}
+	util.Equals(t, "sub", childNamespace.GetLabels()["edge-net.io/kind"])
+}
+
+func TestExtendedResourceQuota(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// A tenant whose quota advertises GPUs, next to CPU and memory
+	gpu := corev1.ResourceName("nvidia.com/gpu")
+	requestsGPU := corev1.ResourceName("requests.nvidia.com/gpu")
+	tenant := g.tenantObj.DeepCopy()
+	tenant.SetName("gpu-lab")
+	_, err := edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), tenant, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().Tenants().Delete(context.TODO(), tenant.GetName(), metav1.DeleteOptions{})
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tenant.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName()})
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer kubeclientset.CoreV1().Namespaces().Delete(context.TODO(), tenantCoreNamespace.GetName(), metav1.DeleteOptions{})
+	trq := g.trqObj.DeepCopy()
+	trq.SetName(tenant.GetName())
+	trq.Spec.Claim["initial"].ResourceList[gpu] = resource.MustParse("4")
+	_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), trq, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Delete(context.TODO(), trq.GetName(), metav1.DeleteOptions{})
+	coreQuota := g.resourceQuotaObj.DeepCopy()
+	coreQuota.Spec.Hard[requestsGPU] = resource.MustParse("4")
+	_, err = kubeclientset.CoreV1().ResourceQuotas(tenant.GetName()).Create(context.TODO(), coreQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	subnamespace.SetNamespace(tenant.GetName())
+	subnamespace.SetName("gpu-workspace")
+	subnamespace.SetUID("gpu-workspace")
+	subnamespace.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1000m")
+	subnamespace.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	subnamespace.Spec.Workspace.ResourceAllocation[gpu] = resource.MustParse("1")
+	childName := subnamespace.GenerateChildName("")
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(tenant.GetName()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().SubNamespaces(tenant.GetName()).Delete(context.TODO(), subnamespace.GetName(), metav1.DeleteOptions{})
+	time.Sleep(750 * time.Millisecond)
+
+	childQuota, err := kubeclientset.CoreV1().ResourceQuotas(childName).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	childGPUQuota := childQuota.Spec.Hard[requestsGPU]
+	util.Equals(t, "1", childGPUQuota.String())
+	_, hasBareName := childQuota.Spec.Hard[gpu]
+	util.Equals(t, false, hasBareName)
+	parentQuota, err := kubeclientset.CoreV1().ResourceQuotas(tenant.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	parentGPUQuota := parentQuota.Spec.Hard[requestsGPU]
+	util.Equals(t, "3", parentGPUQuota.String())
+
+	t.Run("shortage", func(t *testing.T) {
+		greedy := subnamespace.DeepCopy()
+		greedy.SetName("gpu-greedy")
+		greedy.SetUID("gpu-greedy")
+		greedy.Spec.Workspace.ResourceAllocation[gpu] = resource.MustParse("4")
+		_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(tenant.GetName()).Create(context.TODO(), greedy, metav1.CreateOptions{})
+		util.OK(t, err)
+		defer edgenetclientset.CoreV1alpha1().SubNamespaces(tenant.GetName()).Delete(context.TODO(), greedy.GetName(), metav1.DeleteOptions{})
+		time.Sleep(750 * time.Millisecond)
+		_, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), greedy.GenerateChildName(""), metav1.GetOptions{})
+		util.Equals(t, true, errors.IsNotFound(err))
+		parentQuota, err := kubeclientset.CoreV1().ResourceQuotas(tenant.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+		util.OK(t, err)
+		parentGPUQuota := parentQuota.Spec.Hard[requestsGPU]
+		util.Equals(t, "3", parentGPUQuota.String())
+	})
+}
+
+func TestConcurrentQuotaMutations(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// Both controllers work on clientsets of their own so that the tenant resource quota
+	// reconciliation does not touch the quotas of the other tests
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	subnamespaceController, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	util.OK(t, err)
+	tenantResourceQuotaController, err := tenantresourcequota.NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	util.OK(t, err)
+	kubeInformerFactory.Start(stopCh)
+	edgenetInformerFactory.Start(stopCh)
+	go subnamespaceController.Run(2, stopCh)
+	go tenantResourceQuotaController.Run(2, stopCh)
+
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(250 * time.Millisecond)
+
+	// Carve out workspaces while the tenant resource quota is being raised
+	var wg sync.WaitGroup
+	workspaces := []string{"parallel-a", "parallel-b", "parallel-c", "parallel-d"}
+	for _, name := range workspaces {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			subnamespace := g.subNamespaceObj.DeepCopy()
+			subnamespace.SetName(name)
+			subnamespace.SetUID(types.UID(name))
+			subnamespace.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1")
+			subnamespace.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+			_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+			util.OK(t, err)
+		}(name)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		trq, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), g.trqObj.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		trq.Spec.Claim["extra"] = corev1alpha.ResourceTuning{
+			ResourceList: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+		}
+		_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Update(context.TODO(), trq, metav1.UpdateOptions{})
+		util.OK(t, err)
+	}()
+	wg.Wait()
+	time.Sleep(1500 * time.Millisecond)
+
+	for _, name := range workspaces {
+		subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), name, metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, corev1alpha.StatusEstablished, subnamespace.Status.State)
+		childQuota, err := kubeclientset.CoreV1().ResourceQuotas(*subnamespace.Status.Child).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+		util.OK(t, err)
+		childCPU := childQuota.Spec.Hard[corev1.ResourceCPU]
+		util.Equals(t, "1", childCPU.String())
+	}
+	// The core quota holds what is left of the raised tenant quota after all debits
+	coreQuota, err := kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	remainingCPU := coreQuota.Spec.Hard[corev1.ResourceCPU]
+	remainingMemory := coreQuota.Spec.Hard[corev1.ResourceMemory]
+	util.Equals(t, int64(6), remainingCPU.Value())
+	util.Equals(t, int64(6*1024*1024*1024), remainingMemory.Value())
+}
+
+func TestConcurrentNestedSubnamespaces(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	controller, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	util.OK(t, err)
+	kubeInformerFactory.Start(stopCh)
+	edgenetInformerFactory.Start(stopCh)
+	go controller.Run(4, stopCh)
+
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	coreQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "core-quota"}}
+	coreQuota.Spec.Hard = g.trqObj.Fetch()
+	_, err = kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Create(context.TODO(), coreQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	var makeWorkspace = func(namespace, name, cpu, memory string) *corev1alpha.SubNamespace {
+		subnamespace := g.subNamespaceObj.DeepCopy()
+		subnamespace.SetNamespace(namespace)
+		subnamespace.SetName(name)
+		subnamespace.SetUID(types.UID(name))
+		subnamespace.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{
+			"cpu":    resource.MustParse(cpu),
+			"memory": resource.MustParse(memory),
+		}
+		return subnamespace
+	}
+	parent := makeWorkspace(g.tenantObj.GetName(), "nested-parent", "4", "4Gi")
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), parent, metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(750 * time.Millisecond)
+	parent, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), parent.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusEstablished, parent.Status.State)
+	parentNamespace := *parent.Status.Child
+
+	// Children under the same parent race for its quota, while a sibling of the parent is carved out of the core quota
+	var wg sync.WaitGroup
+	children := []string{"nested-a", "nested-b", "nested-c", "nested-d", "nested-e", "nested-f"}
+	for _, name := range children {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(parentNamespace).Create(context.TODO(), makeWorkspace(parentNamespace, name, "500m", "512Mi"), metav1.CreateOptions{})
+			util.OK(t, err)
+		}(name)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), makeWorkspace(g.tenantObj.GetName(), "nested-sibling", "1", "1Gi"), metav1.CreateOptions{})
+		util.OK(t, err)
+	}()
+	wg.Wait()
+	time.Sleep(2 * time.Second)
+
+	for _, name := range children {
+		child, err := edgenetclientset.CoreV1alpha1().SubNamespaces(parentNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, corev1alpha.StatusEstablished, child.Status.State)
+	}
+	// The parent keeps what is left of its allocation after the debits of all its children
+	parentQuota, err := kubeclientset.CoreV1().ResourceQuotas(parentNamespace).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	parentCPU := parentQuota.Spec.Hard[corev1.ResourceCPU]
+	parentMemory := parentQuota.Spec.Hard[corev1.ResourceMemory]
+	util.Equals(t, int64(1000), parentCPU.MilliValue())
+	util.Equals(t, int64(1024*1024*1024), parentMemory.Value())
+	coreQuota, err = kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	coreCPU := coreQuota.Spec.Hard[corev1.ResourceCPU]
+	util.Equals(t, int64(3), coreCPU.Value())
+}
+
+func TestCustomQuotaNames(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	quotaNames := multitenancy.QuotaNames{Core: "tenant-share", Sub: "workspace-share"}
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	options := DefaultOptions()
+	options.QuotaNames = quotaNames
+	subnamespaceController, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		options)
+	util.OK(t, err)
+	tenantResourceQuotaController, err := tenantresourcequota.NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		quotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	util.OK(t, err)
+	kubeInformerFactory.Start(stopCh)
+	edgenetInformerFactory.Start(stopCh)
+	go subnamespaceController.Run(2, stopCh)
+	go tenantResourceQuotaController.Run(2, stopCh)
+
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(250 * time.Millisecond)
+
+	coreQuota, err := kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), quotaNames.Core, metav1.GetOptions{})
+	util.OK(t, err)
+	initialCPU := coreQuota.Spec.Hard[corev1.ResourceCPU]
+	_, err = kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	subnamespace.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1")
+	subnamespace.Spec.Workspace.ResourceAllocation["memory"] = resource.MustParse("1Gi")
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(750 * time.Millisecond)
+
+	t.Run("creation", func(t *testing.T) {
+		childQuota, err := kubeclientset.CoreV1().ResourceQuotas(subnamespace.GenerateChildName("")).Get(context.TODO(), quotaNames.Sub, metav1.GetOptions{})
+		util.OK(t, err)
+		childCPU := childQuota.Spec.Hard[corev1.ResourceCPU]
+		util.Equals(t, "1", childCPU.String())
+		_, err = kubeclientset.CoreV1().ResourceQuotas(subnamespace.GenerateChildName("")).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+		util.Equals(t, true, errors.IsNotFound(err))
+		coreQuota, err := kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), quotaNames.Core, metav1.GetOptions{})
+		util.OK(t, err)
+		remainingCPU := coreQuota.Spec.Hard[corev1.ResourceCPU]
+		util.Equals(t, initialCPU.Value()-1, remainingCPU.Value())
+	})
+	t.Run("restoration", func(t *testing.T) {
+		err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Delete(context.TODO(), subnamespace.GetName(), metav1.DeleteOptions{})
+		util.OK(t, err)
+		time.Sleep(750 * time.Millisecond)
+		coreQuota, err := kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), quotaNames.Core, metav1.GetOptions{})
+		util.OK(t, err)
+		restoredCPU := coreQuota.Spec.Hard[corev1.ResourceCPU]
+		util.Equals(t, initialCPU.Value(), restoredCPU.Value())
+	})
+}
+
+func TestQuotaScopes(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	scopes := []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeNotBestEffort}
+	scopeSelector := &corev1.ScopeSelector{MatchExpressions: []corev1.ScopedResourceSelectorRequirement{
+		{ScopeName: corev1.ResourceQuotaScopePriorityClass, Operator: corev1.ScopeSelectorOpIn, Values: []string{"edgenet-low"}},
+	}}
+	cases := map[string]struct {
+		copyQuotaScopes       bool
+		expectedScopes        []corev1.ResourceQuotaScope
+		expectedScopeSelector *corev1.ScopeSelector
+	}{
+		"copied":    {true, scopes, scopeSelector},
+		"opted out": {false, nil, nil},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			// Only the subnamespace controller runs, so the scoped core quota is not rewritten behind its back
+			kubeclientset := testclient.NewSimpleClientset()
+			edgenetclientset := edgenettestclient.NewSimpleClientset()
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+			edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+			options := DefaultOptions()
+			options.CopyQuotaScopes = tc.copyQuotaScopes
+			controller, err := NewController(kubeclientset,
+				edgenetclientset,
+				kubeInformerFactory.Rbac().V1().Roles(),
+				kubeInformerFactory.Rbac().V1().RoleBindings(),
+				kubeInformerFactory.Networking().V1().NetworkPolicies(),
+				kubeInformerFactory.Core().V1().LimitRanges(),
+				kubeInformerFactory.Core().V1().Secrets(),
+				kubeInformerFactory.Core().V1().ConfigMaps(),
+				kubeInformerFactory.Core().V1().ServiceAccounts(),
+				edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+				options)
+			util.OK(t, err)
+			kubeInformerFactory.Start(stopCh)
+			edgenetInformerFactory.Start(stopCh)
+			go controller.Run(2, stopCh)
+
+			_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+			util.OK(t, err)
+			_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+			util.OK(t, err)
+			tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+			tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+			_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+			util.OK(t, err)
+			_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+			util.OK(t, err)
+			coreQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "core-quota"}}
+			coreQuota.Spec.Hard = g.trqObj.Fetch()
+			coreQuota.Spec.Scopes = scopes
+			coreQuota.Spec.ScopeSelector = scopeSelector
+			_, err = kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Create(context.TODO(), coreQuota, metav1.CreateOptions{})
+			util.OK(t, err)
+
+			subnamespace := g.subNamespaceObj.DeepCopy()
+			_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+			util.OK(t, err)
+			time.Sleep(750 * time.Millisecond)
+
+			childQuota, err := kubeclientset.CoreV1().ResourceQuotas(subnamespace.GenerateChildName("")).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+			util.OK(t, err)
+			util.Equals(t, tc.expectedScopes, childQuota.Spec.Scopes)
+			util.Equals(t, tc.expectedScopeSelector, childQuota.Spec.ScopeSelector)
+		})
+	}
+}
+
+func TestResyncPeriod(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	cases := map[string]struct {
+		resyncPeriod time.Duration
+		exists       bool
+		expected     int
+	}{
+		"disabled":        {0, true, 0},
+		"enabled":         {100 * time.Millisecond, true, 1},
+		"no longer exist": {100 * time.Millisecond, false, 0},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			resyncKubeclientset := testclient.NewSimpleClientset()
+			resyncEdgenetclientset := edgenettestclient.NewSimpleClientset()
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(resyncKubeclientset, 0)
+			edgenetInformerFactory := informers.NewSharedInformerFactory(resyncEdgenetclientset, 0)
+			options := DefaultOptions()
+			options.TenantLabelKeys = nil
+			options.ResyncPeriod = tc.resyncPeriod
+			controller, err := NewController(resyncKubeclientset,
+				resyncEdgenetclientset,
+				kubeInformerFactory.Rbac().V1().Roles(),
+				kubeInformerFactory.Rbac().V1().RoleBindings(),
+				kubeInformerFactory.Networking().V1().NetworkPolicies(),
+				kubeInformerFactory.Core().V1().LimitRanges(),
+				kubeInformerFactory.Core().V1().Secrets(),
+				kubeInformerFactory.Core().V1().ConfigMaps(),
+				kubeInformerFactory.Core().V1().ServiceAccounts(),
+				edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+				options)
+			util.OK(t, err)
+			defer controller.workqueue.ShutDown()
+
+			subnamespace := g.subNamespaceObj.DeepCopy()
+			if tc.exists {
+				edgenetInformerFactory.Core().V1alpha1().SubNamespaces().Informer().GetIndexer().Add(subnamespace)
+			}
+			util.OK(t, controller.syncHandler(fmt.Sprintf("%s/%s", subnamespace.GetNamespace(), subnamespace.GetName())))
+			util.Equals(t, 0, controller.workqueue.Len())
+			time.Sleep(300 * time.Millisecond)
+			util.Equals(t, tc.expected, controller.workqueue.Len())
+		})
+	}
+}
+
+// recordingQueue records the keys added to the work queue, and the delay of those added after one
+type recordingQueue struct {
+	workqueue.RateLimitingInterface
+	added   []interface{}
+	delayed []time.Duration
+}
+
+func (q *recordingQueue) Add(item interface{}) {
+	q.added = append(q.added, item)
+	q.RateLimitingInterface.Add(item)
+}
+
+func (q *recordingQueue) AddAfter(item interface{}, duration time.Duration) {
+	q.delayed = append(q.delayed, duration)
+	q.RateLimitingInterface.AddAfter(item, duration)
+}
+
+func TestDebounceInterval(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	debounceInterval := time.Hour
+	cases := map[string]struct {
+		debounceInterval time.Duration
+		specChange       bool
+		added            int
+		delayed          int
+	}{
+		"disabled":       {0, true, 10, 0},
+		"enabled":        {debounceInterval, true, 0, 10},
+		"status updates": {debounceInterval, false, 10, 0},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			kubeclientset := testclient.NewSimpleClientset()
+			edgenetclientset := edgenettestclient.NewSimpleClientset()
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+			edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+			options := DefaultOptions()
+			options.DebounceInterval = tc.debounceInterval
+			controller, err := NewController(kubeclientset,
+				edgenetclientset,
+				kubeInformerFactory.Rbac().V1().Roles(),
+				kubeInformerFactory.Rbac().V1().RoleBindings(),
+				kubeInformerFactory.Networking().V1().NetworkPolicies(),
+				kubeInformerFactory.Core().V1().LimitRanges(),
+				kubeInformerFactory.Core().V1().Secrets(),
+				kubeInformerFactory.Core().V1().ConfigMaps(),
+				kubeInformerFactory.Core().V1().ServiceAccounts(),
+				edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+				options)
+			util.OK(t, err)
+			queue := &recordingQueue{RateLimitingInterface: controller.workqueue}
+			controller.workqueue = queue
+			defer queue.ShutDown()
+
+			// Tweak the quota of the subnamespace ten times in a row, or only its status
+			subnamespace := g.subNamespaceObj.DeepCopy()
+			for i := 1; i <= 10; i++ {
+				subnamespaceCopy := subnamespace.DeepCopy()
+				if tc.specChange {
+					subnamespaceCopy.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse(fmt.Sprintf("%dm", 1000+100*i))
+				} else {
+					subnamespaceCopy.Status.Message = fmt.Sprintf("%d", i)
+				}
+				controller.handleSubNamespaceUpdate(subnamespace, subnamespaceCopy)
+				subnamespace = subnamespaceCopy
+			}
+			util.Equals(t, tc.added, len(queue.added))
+			util.Equals(t, tc.delayed, len(queue.delayed))
+			for _, delay := range queue.delayed {
+				util.Equals(t, debounceInterval, delay)
+			}
+			// The changes are coalesced into a single key, which is held back for the interval if debounced
+			if tc.delayed > 0 {
+				util.Equals(t, 0, queue.Len())
+			} else {
+				util.Equals(t, 1, queue.Len())
+			}
+		})
+	}
+}
+
+func TestAutoShrink(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	options := DefaultOptions()
+	options.ResyncPeriod = 100 * time.Millisecond
+	controller, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		options)
+	util.OK(t, err)
+	kubeInformerFactory.Start(stopCh)
+	edgenetInformerFactory.Start(stopCh)
+	go controller.Run(2, stopCh)
+
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	coreQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "core-quota"}}
+	coreQuota.Spec.Hard = g.trqObj.Fetch()
+	_, err = kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Create(context.TODO(), coreQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	idle := g.subNamespaceObj.DeepCopy()
+	busy := g.subNamespaceObj.DeepCopy()
+	for _, subnamespace := range []*corev1alpha.SubNamespace{idle, busy} {
+		subnamespace.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{
+			"cpu":    resource.MustParse("2"),
+			"memory": resource.MustParse("2Gi"),
+		}
+		subnamespace.Spec.Workspace.AutoShrink = &corev1alpha.AutoShrink{IdlePeriod: metav1.Duration{Duration: 200 * time.Millisecond}}
+	}
+	idle.SetName("idle")
+	idle.SetUID("idle")
+	busy.SetName("busy")
+	busy.SetUID("busy")
+	for _, subnamespace := range []*corev1alpha.SubNamespace{idle, busy} {
+		_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+	time.Sleep(750 * time.Millisecond)
+
+	coreQuotaBefore, err := kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	// Imitate the quota usage reported by the resource quota controller
+	for subnamespace, used := range map[*corev1alpha.SubNamespace]string{idle: "0", busy: "1"} {
+		childQuota, err := kubeclientset.CoreV1().ResourceQuotas(subnamespace.GenerateChildName("")).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+		util.OK(t, err)
+		childQuota.Status.Used = corev1.ResourceList{"cpu": resource.MustParse(used), "memory": resource.MustParse("0")}
+		_, err = kubeclientset.CoreV1().ResourceQuotas(childQuota.GetNamespace()).UpdateStatus(context.TODO(), childQuota, metav1.UpdateOptions{})
+		util.OK(t, err)
+	}
+	time.Sleep(1500 * time.Millisecond)
+
+	idleCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), idle.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	reclaimedCPU := idleCopy.Status.Reclaimed["cpu"]
+	util.Equals(t, int64(2), reclaimedCPU.Value())
+	util.Equals(t, corev1alpha.StatusEstablished, idleCopy.Status.State)
+	idleChildQuota, err := kubeclientset.CoreV1().ResourceQuotas(idle.GenerateChildName("")).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, int64(0), idleChildQuota.Spec.Hard.Cpu().Value())
+	util.Equals(t, int64(0), idleChildQuota.Spec.Hard.Memory().Value())
+
+	busyCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(g.tenantObj.GetName()).Get(context.TODO(), busy.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, 0, len(busyCopy.Status.Reclaimed))
+	busyChildQuota, err := kubeclientset.CoreV1().ResourceQuotas(busy.GenerateChildName("")).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	busyCPU := busy.Spec.Workspace.ResourceAllocation["cpu"]
+	util.Equals(t, busyCPU.Value(), busyChildQuota.Spec.Hard.Cpu().Value())
+
+	idleCPU := idle.Spec.Workspace.ResourceAllocation["cpu"]
+	idleMemory := idle.Spec.Workspace.ResourceAllocation["memory"]
+	coreQuotaAfter, err := kubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, coreQuotaBefore.Spec.Hard.Cpu().Value()+idleCPU.Value(), coreQuotaAfter.Spec.Hard.Cpu().Value())
+	util.Equals(t, coreQuotaBefore.Spec.Hard.Memory().Value()+idleMemory.Value(), coreQuotaAfter.Spec.Hard.Memory().Value())
+}
+
+func TestEmptySpec(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	cases := map[string]struct {
+		rejectEmpty   bool
+		expectedState string
+	}{
+		"warned":   {false, ""},
+		"rejected": {true, corev1alpha.StatusFailed},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			// A dedicated controller, which is not started, lets the test look at the events of a single pass
+			kubeclientset := testclient.NewSimpleClientset()
+			edgenetclientset := edgenettestclient.NewSimpleClientset()
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+			edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+			options := DefaultOptions()
+			options.RejectEmpty = tc.rejectEmpty
+			controller, err := NewController(kubeclientset,
+				edgenetclientset,
+				kubeInformerFactory.Rbac().V1().Roles(),
+				kubeInformerFactory.Rbac().V1().RoleBindings(),
+				kubeInformerFactory.Networking().V1().NetworkPolicies(),
+				kubeInformerFactory.Core().V1().LimitRanges(),
+				kubeInformerFactory.Core().V1().Secrets(),
+				kubeInformerFactory.Core().V1().ConfigMaps(),
+				kubeInformerFactory.Core().V1().ServiceAccounts(),
+				edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+				options)
+			util.OK(t, err)
+			recorder := record.NewFakeRecorder(20)
+			controller.recorder = recorder
+
+			_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+			util.OK(t, err)
+			_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+			util.OK(t, err)
+			tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+			tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+			_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+			util.OK(t, err)
+
+			subnamespace := g.subNamespaceObj.DeepCopy()
+			subnamespace.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse("0")}
+			for key := range subnamespace.Spec.Workspace.Inheritance {
+				subnamespace.Spec.Workspace.Inheritance[key] = false
+			}
+			util.Equals(t, true, isEmpty(subnamespace))
+			_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+			util.OK(t, err)
+
+			controller.processSubNamespace(subnamespace.DeepCopy())
+			util.Equals(t, true, len(recorder.Events) > 0)
+			if len(recorder.Events) > 0 {
+				util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, warningEmpty, messageEmpty), <-recorder.Events)
+			}
+			if tc.rejectEmpty {
+				subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+				util.OK(t, err)
+				util.Equals(t, tc.expectedState, subnamespaceCopy.Status.State)
+				util.Equals(t, messageEmpty, subnamespaceCopy.Status.Message)
+				// The rejection is reported once
+				controller.processSubNamespace(subnamespaceCopy)
+				util.Equals(t, 0, len(recorder.Events))
+			}
+		})
+	}
+
+	t.Run("not empty", func(t *testing.T) {
+		subnamespace := g.subNamespaceObj.DeepCopy()
+		util.Equals(t, false, isEmpty(subnamespace))
+		subnamespace.Spec.Workspace.ResourceAllocation = nil
+		util.Equals(t, false, isEmpty(subnamespace))
+	})
+}
+
+func TestOverallocatedTenant(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// A dedicated controller, which is not started, lets the test drive the reconciliation of each subnamespace
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	controller, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(100)
+	controller.recorder = recorder
+
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = kubeclientset.CoreV1().ResourceQuotas(tenantCoreNamespace.GetName()).Create(context.TODO(), g.resourceQuotaObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+
+	// Two established subnamespaces, one of which was edited by hand, take up 10 CPUs of the 8 in the tenant quota
+	for name, cpu := range map[string]string{"first": "6000m", "edited": "4000m"} {
+		subnamespace := g.subNamespaceObj.DeepCopy()
+		subnamespace.SetName(name)
+		subnamespace.SetUID(types.UID(name))
+		subnamespace.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse(cpu), "memory": resource.MustParse("1Gi")}
+		subnamespace.Status.State = corev1alpha.StatusEstablished
+		_, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	subnamespace.SetName("next")
+	subnamespace.SetUID("next")
+	subnamespace.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse("1000m"), "memory": resource.MustParse("1Gi")}
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	controller.processSubNamespace(subnamespace.DeepCopy())
+	subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusFailed, subnamespaceCopy.Status.State)
+	util.Equals(t, fmt.Sprintf(messageOverallocated, corev1.ResourceCPU), subnamespaceCopy.Status.Message)
+	util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, failureOverallocated, subnamespaceCopy.Status.Message), <-recorder.Events)
+	coreQuota, err := kubeclientset.CoreV1().ResourceQuotas(tenantCoreNamespace.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, g.resourceQuotaObj.Spec.Hard, coreQuota.Spec.Hard)
+
+	t.Run("allocation back under the quota", func(t *testing.T) {
+		err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Delete(context.TODO(), "edited", metav1.DeleteOptions{})
+		util.OK(t, err)
+		controller.processSubNamespace(subnamespaceCopy.DeepCopy())
+		subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, corev1alpha.StatusPartitioned, subnamespaceCopy.Status.State)
+	})
+}
+
+func TestChildQuotaRollback(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// A dedicated controller, which is not started, lets the test drive the reconciliation step by step
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	controller, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(100)
+	controller.recorder = recorder
+
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = kubeclientset.CoreV1().ResourceQuotas(tenantCoreNamespace.GetName()).Create(context.TODO(), g.resourceQuotaObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	childName := subnamespace.GenerateChildName("")
+	reconcile := func() *corev1alpha.SubNamespace {
+		subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		controller.processSubNamespace(subnamespaceCopy)
+		subnamespaceCopy, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		return subnamespaceCopy
+	}
+	util.Equals(t, corev1alpha.StatusPartitioned, reconcile().Status.State)
+	util.Equals(t, corev1alpha.StatusSubnamespaceCreated, reconcile().Status.State)
+	_, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+	util.OK(t, err)
+
+	kubeclientset.PrependReactor("create", "resourcequotas", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == childName {
+			return true, nil, fmt.Errorf("quota admission unavailable")
+		}
+		return false, nil, nil
+	})
+	// A namespace where workloads already run is kept
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "workload", Namespace: childName}}
+	_, err = kubeclientset.CoreV1().Pods(childName).Create(context.TODO(), pod, metav1.CreateOptions{})
+	util.OK(t, err)
+	subnamespaceCopy := reconcile()
+	util.Equals(t, corev1alpha.StatusFailed, subnamespaceCopy.Status.State)
+	util.Equals(t, messageApplyFail, subnamespaceCopy.Status.Message)
+	util.Equals(t, childName, *subnamespaceCopy.Status.Child)
+	_, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+	util.OK(t, err)
+
+	util.OK(t, kubeclientset.CoreV1().Pods(childName).Delete(context.TODO(), pod.GetName(), metav1.DeleteOptions{}))
+	subnamespaceCopy.Status.State = corev1alpha.StatusSubnamespaceCreated
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).UpdateStatus(context.TODO(), subnamespaceCopy, metav1.UpdateOptions{})
+	util.OK(t, err)
+	for len(recorder.Events) > 0 {
+		<-recorder.Events
+	}
+	subnamespaceCopy = reconcile()
+	util.Equals(t, corev1alpha.StatusFailed, subnamespaceCopy.Status.State)
+	util.Equals(t, messageRolledBack, subnamespaceCopy.Status.Message)
+	util.Equals(t, true, subnamespaceCopy.Status.Child == nil)
+	util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, failureApplied, messageRolledBack), <-recorder.Events)
+	_, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+}
+
+func TestQuotaUpdateConflict(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// A dedicated controller, which is not started, lets the test drive the reconciliation step by step
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	controller, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(100)
+	controller.recorder = recorder
+
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = kubeclientset.CoreV1().ResourceQuotas(tenantCoreNamespace.GetName()).Create(context.TODO(), g.resourceQuotaObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	reconcile := func() *corev1alpha.SubNamespace {
+		subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		controller.processSubNamespace(subnamespaceCopy)
+		subnamespaceCopy, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		return subnamespaceCopy
+	}
+	// The tenant resource quota controller, in a process of its own, updates the parent quota in between
+	conflicts := 0
+	kubeclientset.PrependReactor("update", "resourcequotas", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == tenantCoreNamespace.GetName() && conflicts == 0 {
+			conflicts++
+			return true, nil, errors.NewConflict(corev1.Resource("resourcequotas"), "core-quota", fmt.Errorf("the object has been modified"))
+		}
+		return false, nil, nil
+	})
+	util.Equals(t, corev1alpha.StatusPartitioned, reconcile().Status.State)
+	util.Equals(t, 1, conflicts)
+	coreQuota, err := kubeclientset.CoreV1().ResourceQuotas(tenantCoreNamespace.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	remainingCPU := coreQuota.Spec.Hard[corev1.ResourceCPU]
+	util.Equals(t, int64(2), remainingCPU.Value())
+}
+
+func TestQuotaUpdatedAnnotation(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// A dedicated controller, which is not started, lets the test drive the reconciliation step by step
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	controller, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	util.OK(t, err)
+	controller.recorder = record.NewFakeRecorder(100)
+
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = kubeclientset.CoreV1().ResourceQuotas(tenantCoreNamespace.GetName()).Create(context.TODO(), g.resourceQuotaObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	childName := subnamespace.GenerateChildName("")
+	// establish runs the controller until the subnamespace is established, and returns the annotations of its child
+	establish := func() map[string]string {
+		for i := 0; i < 10; i++ {
+			subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+			util.OK(t, err)
+			controller.processSubNamespace(subnamespaceCopy)
+		}
+		subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, corev1alpha.StatusEstablished, subnamespaceCopy.Status.State)
+		childNamespace, err := kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+		util.OK(t, err)
+		return childNamespace.GetAnnotations()
+	}
+	// stamp overwrites the annotation with a time in the past to tell whether the controller stamps it again
+	stamp := func() string {
+		childNamespace, err := kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+		util.OK(t, err)
+		stale := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		childNamespace.Annotations[QuotaUpdatedAnnotation] = stale
+		_, err = kubeclientset.CoreV1().Namespaces().Update(context.TODO(), childNamespace, metav1.UpdateOptions{})
+		util.OK(t, err)
+		return stale
+	}
+
+	quotaUpdatedAt, elementExists := establish()[QuotaUpdatedAnnotation]
+	util.Equals(t, true, elementExists)
+	_, err = time.Parse(time.RFC3339, quotaUpdatedAt)
+	util.OK(t, err)
+
+	// An unchanged quota leaves the annotation as it is
+	stale := stamp()
+	util.Equals(t, stale, establish()[QuotaUpdatedAnnotation])
+
+	subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	subnamespaceCopy.Spec.Workspace.ResourceAllocation["cpu"] = resource.MustParse("1000m")
+	_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Update(context.TODO(), subnamespaceCopy, metav1.UpdateOptions{})
+	util.OK(t, err)
+	quotaUpdatedAt = establish()[QuotaUpdatedAnnotation]
+	util.Equals(t, false, quotaUpdatedAt == stale)
+	childResourceQuota, err := kubeclientset.CoreV1().ResourceQuotas(childName).Get(context.TODO(), multitenancy.DefaultQuotaNames.Sub, metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, "1", childResourceQuota.Spec.Hard.Cpu().String())
+}
+
+func TestClone(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	cloneEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	_, err := cloneEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	source := g.subNamespaceObj.DeepCopy()
+	source.SetLabels(map[string]string{"environment": "staging"})
+	source.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse("3000m"), "memory": resource.MustParse("3Gi")}
+	source.Status.State = corev1alpha.StatusEstablished
+	_, err = cloneEdgenetclientset.CoreV1alpha1().SubNamespaces(source.GetNamespace()).Create(context.TODO(), source, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	clone, err := Clone(cloneEdgenetclientset, source.GetNamespace(), source.GetName(), "edgenet-parallel")
+	util.OK(t, err)
+	util.Equals(t, "edgenet-parallel", clone.GetName())
+	util.Equals(t, source.Spec, clone.Spec)
+	util.Equals(t, source.GetLabels(), clone.GetLabels())
+	util.Equals(t, corev1alpha.SubNamespaceStatus{}, clone.Status)
+
+	t.Run("quota shortage", func(t *testing.T) {
+		_, err := Clone(cloneEdgenetclientset, source.GetNamespace(), source.GetName(), "edgenet-third")
+		util.Equals(t, true, err != nil)
+		_, err = cloneEdgenetclientset.CoreV1alpha1().SubNamespaces(source.GetNamespace()).Get(context.TODO(), "edgenet-third", metav1.GetOptions{})
+		util.Equals(t, true, errors.IsNotFound(err))
+	})
+}
+
+func TestMinimumAllocation(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	minimumAllocation := map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("64Mi")}
+
+	cases := map[string]struct {
+		bumpBelowMinimum bool
+		established      bool
+	}{
+		"rejected":             {false, false},
+		"bumped":               {true, false},
+		"established rejected": {false, true},
+		"established bumped":   {true, true},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			// A dedicated controller, which is not started, lets the test look at the events of a single pass
+			kubeclientset := testclient.NewSimpleClientset()
+			edgenetclientset := edgenettestclient.NewSimpleClientset()
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+			edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+			options := DefaultOptions()
+			options.MinimumAllocation = minimumAllocation
+			options.BumpBelowMinimum = tc.bumpBelowMinimum
+			controller, err := NewController(kubeclientset,
+				edgenetclientset,
+				kubeInformerFactory.Rbac().V1().Roles(),
+				kubeInformerFactory.Rbac().V1().RoleBindings(),
+				kubeInformerFactory.Networking().V1().NetworkPolicies(),
+				kubeInformerFactory.Core().V1().LimitRanges(),
+				kubeInformerFactory.Core().V1().Secrets(),
+				kubeInformerFactory.Core().V1().ConfigMaps(),
+				kubeInformerFactory.Core().V1().ServiceAccounts(),
+				edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+				options)
+			util.OK(t, err)
+			recorder := record.NewFakeRecorder(20)
+			controller.recorder = recorder
+
+			subnamespace := g.subNamespaceObj.DeepCopy()
+			subnamespace.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse("1m"), "memory": resource.MustParse("1Gi")}
+			if tc.established {
+				// The allocation was applied before the minimum was raised
+				subnamespace.Status.State = corev1alpha.StatusEstablished
+				subnamespace.Status.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse("1m"), "memory": resource.MustParse("1Gi")}
+			}
+			_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+			util.OK(t, err)
+
+			controller.processSubNamespace(subnamespace.DeepCopy())
+			subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+			util.OK(t, err)
+			cpu := subnamespaceCopy.Spec.Workspace.ResourceAllocation["cpu"]
+			memory := subnamespaceCopy.Spec.Workspace.ResourceAllocation["memory"]
+			util.Equals(t, "1Gi", memory.String())
+			if tc.established {
+				util.Equals(t, "1m", cpu.String())
+				util.Equals(t, false, subnamespaceCopy.Status.Message == fmt.Sprintf(messageBelowMinimum, "cpu", "100m"))
+				for len(recorder.Events) > 0 {
+					event := <-recorder.Events
+					util.Equals(t, false, strings.Contains(event, failureBelowMinimum) || strings.Contains(event, successBumped))
+				}
+			} else if tc.bumpBelowMinimum {
+				util.Equals(t, "100m", cpu.String())
+				util.Equals(t, "", subnamespaceCopy.Status.State)
+				util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeNormal, successBumped, fmt.Sprintf(messageBumped, "cpu", "100m")), <-recorder.Events)
+			} else {
+				util.Equals(t, "1m", cpu.String())
+				util.Equals(t, corev1alpha.StatusFailed, subnamespaceCopy.Status.State)
+				util.Equals(t, fmt.Sprintf(messageBelowMinimum, "cpu", "100m"), subnamespaceCopy.Status.Message)
+				util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, failureBelowMinimum, subnamespaceCopy.Status.Message), <-recorder.Events)
+			}
+		})
+	}
+}
+
+func TestChildOnlyResources(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	cases := map[string]struct {
+		childOnlyResources []corev1.ResourceName
+		expectedParentPods string
+	}{
+		"child only": {[]corev1.ResourceName{corev1.ResourcePods}, "20"},
+		"debited":    {nil, "15"},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			// A dedicated controller, which is not started, lets the test drive the reconciliation of the subnamespace
+			kubeclientset := testclient.NewSimpleClientset()
+			edgenetclientset := edgenettestclient.NewSimpleClientset()
+			kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+			edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+			options := DefaultOptions()
+			options.ChildOnlyResources = tc.childOnlyResources
+			controller, err := NewController(kubeclientset,
+				edgenetclientset,
+				kubeInformerFactory.Rbac().V1().Roles(),
+				kubeInformerFactory.Rbac().V1().RoleBindings(),
+				kubeInformerFactory.Networking().V1().NetworkPolicies(),
+				kubeInformerFactory.Core().V1().LimitRanges(),
+				kubeInformerFactory.Core().V1().Secrets(),
+				kubeInformerFactory.Core().V1().ConfigMaps(),
+				kubeInformerFactory.Core().V1().ServiceAccounts(),
+				edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+				options)
+			util.OK(t, err)
+			controller.recorder = record.NewFakeRecorder(100)
+
+			_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+			util.OK(t, err)
+			_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+			util.OK(t, err)
+			tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+			tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+			_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+			util.OK(t, err)
+			trq := g.trqObj.DeepCopy()
+			trq.Spec.Claim["initial"].ResourceList[corev1.ResourcePods] = resource.MustParse("20")
+			_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), trq, metav1.CreateOptions{})
+			util.OK(t, err)
+			coreQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "core-quota"}}
+			coreQuota.Spec.Hard = trq.Fetch()
+			_, err = kubeclientset.CoreV1().ResourceQuotas(tenantCoreNamespace.GetName()).Create(context.TODO(), coreQuota, metav1.CreateOptions{})
+			util.OK(t, err)
+
+			// The child is capped on pods only
+			subnamespace := g.subNamespaceObj.DeepCopy()
+			subnamespace.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{corev1.ResourcePods: resource.MustParse("5")}
+			_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+			util.OK(t, err)
+			for i := 0; i < 3; i++ {
+				subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+				util.OK(t, err)
+				controller.processSubNamespace(subnamespaceCopy)
+			}
+			subnamespaceCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(subnamespace.GetNamespace()).Get(context.TODO(), subnamespace.GetName(), metav1.GetOptions{})
+			util.OK(t, err)
+			util.Equals(t, corev1alpha.StatusQuotaSet, subnamespaceCopy.Status.State)
+
+			childQuota, err := kubeclientset.CoreV1().ResourceQuotas(subnamespace.GenerateChildName("")).Get(context.TODO(), "sub-quota", metav1.GetOptions{})
+			util.OK(t, err)
+			childPods := childQuota.Spec.Hard[corev1.ResourcePods]
+			util.Equals(t, "5", childPods.String())
+			parentQuota, err := kubeclientset.CoreV1().ResourceQuotas(tenantCoreNamespace.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+			util.OK(t, err)
+			parentCPU := parentQuota.Spec.Hard[corev1.ResourceCPU]
+			parentMemory := parentQuota.Spec.Hard[corev1.ResourceMemory]
+			parentPods := parentQuota.Spec.Hard[corev1.ResourcePods]
+			util.Equals(t, "8", parentCPU.String())
+			util.Equals(t, "8Gi", parentMemory.String())
+			util.Equals(t, tc.expectedParentPods, parentPods.String())
+		})
+	}
+}
+
+func TestReconcileDeadline(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	deadline.Set(200 * time.Millisecond)
+	defer deadline.Set(0)
+
+	// A dedicated controller, which is not started, is given a clientset whose namespace lookups are slow
+	slowKubeclientset := testclient.NewSimpleClientset()
+	slowEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	var inFlight int32
+	slowKubeclientset.PrependReactor("get", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		time.Sleep(250 * time.Millisecond)
+		return false, nil, nil
+	})
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(slowKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(slowEdgenetclientset, 0)
+	controller, err := NewController(slowKubeclientset,
+		slowEdgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	util.OK(t, err)
+	controller.recorder = record.NewFakeRecorder(100)
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	err = edgenetInformerFactory.Core().V1alpha1().SubNamespaces().Informer().GetIndexer().Add(subnamespace)
+	util.OK(t, err)
+	key := fmt.Sprintf("%s/%s", subnamespace.GetNamespace(), subnamespace.GetName())
+	controller.workqueue.Add(key)
+
+	util.Equals(t, true, controller.processNextWorkItem())
+	// The slow sync has completed, rather than being left running, and the key is requeued with a backoff
+	util.Equals(t, int32(0), atomic.LoadInt32(&inFlight))
+	util.Equals(t, 1, controller.workqueue.NumRequeues(key))
+}
+
+func TestTree(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	treeKubeclientset := testclient.NewSimpleClientset()
+	treeEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	_, err := treeEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), g.trqObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName()}}
+	tenantCoreNamespace.SetLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()})
+	_, err = treeKubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	// Two subnamespaces are carved out of the core namespace, and a third out of the first of them
+	subnamespaces := []struct {
+		parent, name, child, cpu string
+	}{
+		{"edgenet", "lab", "edgenet-lab", "4000m"},
+		{"edgenet", "class", "edgenet-class", "2000m"},
+		{"edgenet-lab", "experiment", "edgenet-lab-experiment", "1000m"},
+	}
+	for _, sub := range subnamespaces {
+		subnamespace := g.subNamespaceObj.DeepCopy()
+		subnamespace.SetName(sub.name)
+		subnamespace.SetNamespace(sub.parent)
+		subnamespace.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse(sub.cpu), "memory": resource.MustParse("1Gi")}
+		_, err := treeEdgenetclientset.CoreV1alpha1().SubNamespaces(sub.parent).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+		util.OK(t, err)
+		childNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: sub.child}}
+		childNamespace.SetLabels(map[string]string{"edge-net.io/kind": "sub", "edge-net.io/tenant": g.tenantObj.GetName(),
+			"edge-net.io/owner": sub.name, "edge-net.io/parent-namespace": sub.parent})
+		_, err = treeKubeclientset.CoreV1().Namespaces().Create(context.TODO(), childNamespace, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+	otherNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other-lab"}}
+	otherNamespace.SetLabels(map[string]string{"edge-net.io/kind": "sub", "edge-net.io/tenant": "other", "edge-net.io/owner": "lab", "edge-net.io/parent-namespace": "edgenet"})
+	_, err = treeKubeclientset.CoreV1().Namespaces().Create(context.TODO(), otherNamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	root, err := Tree(treeKubeclientset, treeEdgenetclientset, g.tenantObj.GetName())
+	util.OK(t, err)
+	util.Equals(t, "edgenet", root.Namespace)
+	util.Equals(t, "", root.Owner)
+	rootCPU, rootMemory := root.Quota[corev1.ResourceCPU], root.Quota[corev1.ResourceMemory]
+	util.Equals(t, "8", rootCPU.String())
+	util.Equals(t, "8Gi", rootMemory.String())
+	util.Equals(t, 2, len(root.Children))
+	class, lab := root.Children[0], root.Children[1]
+	util.Equals(t, "edgenet-class", class.Namespace)
+	util.Equals(t, "class", class.Owner)
+	util.Equals(t, 0, len(class.Children))
+	util.Equals(t, "edgenet-lab", lab.Namespace)
+	util.Equals(t, "lab", lab.Owner)
+	util.Equals(t, 1, len(lab.Children))
+	experiment := lab.Children[0]
+	util.Equals(t, "edgenet-lab-experiment", experiment.Namespace)
+	util.Equals(t, "experiment", experiment.Owner)
+	util.Equals(t, 0, len(experiment.Children))
+	for node, cpu := range map[*Node]string{class: "2", lab: "4", experiment: "1"} {
+		quantity := node.Quota[corev1.ResourceCPU]
+		util.Equals(t, cpu, quantity.String())
+	}
+
+	t.Run("missing tenant", func(t *testing.T) {
+		_, err := Tree(treeKubeclientset, treeEdgenetclientset, "missing")
+		util.Equals(t, true, errors.IsNotFound(err))
+	})
+}
+
+func TestDuplicateChildName(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// A dedicated controller, which is not started, lets the test drive the reconciliation of each subnamespace
+	kubeclientset := testclient.NewSimpleClientset()
+	edgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+	controller, err := NewController(kubeclientset,
+		edgenetclientset,
+		kubeInformerFactory.Rbac().V1().Roles(),
+		kubeInformerFactory.Rbac().V1().RoleBindings(),
+		kubeInformerFactory.Networking().V1().NetworkPolicies(),
+		kubeInformerFactory.Core().V1().LimitRanges(),
+		kubeInformerFactory.Core().V1().Secrets(),
+		kubeInformerFactory.Core().V1().ConfigMaps(),
+		kubeInformerFactory.Core().V1().ServiceAccounts(),
+		edgenetInformerFactory.Core().V1alpha1().SubNamespaces(),
+		DefaultOptions())
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(100)
+	controller.recorder = recorder
+
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = edgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	// The names of the two parents hash alike once joined with the name of the subnamespace
+	var subnamespaces []*corev1alpha.SubNamespace
+	for i, parent := range []string{"team-bb-bb", "team-ca-ac"} {
+		parentNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: parent}}
+		parentNamespace.SetLabels(map[string]string{"edge-net.io/kind": "sub", "edge-net.io/tenant": g.tenantObj.GetName(),
+			"edge-net.io/owner": parent, "edge-net.io/parent-namespace": g.tenantObj.GetName()})
+		_, err := kubeclientset.CoreV1().Namespaces().Create(context.TODO(), parentNamespace, metav1.CreateOptions{})
+		util.OK(t, err)
+		subnamespace := g.subNamespaceObj.DeepCopy()
+		subnamespace.SetNamespace(parent)
+		subnamespace.SetName("lab")
+		subnamespace.SetUID(types.UID(parent))
+		subnamespace.SetCreationTimestamp(metav1.NewTime(time.Now().Add(time.Duration(i-2) * time.Minute)))
+		_, err = edgenetclientset.CoreV1alpha1().SubNamespaces(parent).Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+		util.OK(t, err)
+		subnamespaces = append(subnamespaces, subnamespace)
+	}
+	first, second := subnamespaces[0], subnamespaces[1]
+	childName := first.GenerateChildName("")
+	util.Equals(t, childName, second.GenerateChildName(""))
+
+	controller.processSubNamespace(second.DeepCopy())
+	secondCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(second.GetNamespace()).Get(context.TODO(), second.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusFailed, secondCopy.Status.State)
+	util.Equals(t, fmt.Sprintf(messageDuplicateName, childName, first.GetNamespace(), first.GetName()), secondCopy.Status.Message)
+	util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, failureDuplicateName, secondCopy.Status.Message), <-recorder.Events)
+	_, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), childName, metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+
+	t.Run("first created keeps the name", func(t *testing.T) {
+		controller.processSubNamespace(first.DeepCopy())
+		firstCopy, err := edgenetclientset.CoreV1alpha1().SubNamespaces(first.GetNamespace()).Get(context.TODO(), first.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.NotEquals(t, fmt.Sprintf(messageDuplicateName, childName, second.GetNamespace(), second.GetName()), firstCopy.Status.Message)
+	})
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subnamespace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Node is a namespace in the subnamespace tree of a tenant, along with its quota and the namespaces carved out of it
+type Node struct {
+	// Namespace is the name of the namespace
+	Namespace string `json:"namespace"`
+	// Owner is the name of the subnamespace the namespace belongs to, empty for the core namespace
+	Owner string `json:"owner,omitempty"`
+	// Quota is the quota allocated to the namespace, named as in a resource quota. It is that of the tenant for
+	// the core namespace, and the allocation of its subnamespace otherwise.
+	Quota map[corev1.ResourceName]resource.Quantity `json:"quota,omitempty"`
+	// Children are the nodes of the subnamespaces created in the namespace, sorted by namespace
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Tree returns the subnamespace hierarchy of the tenant, rooted at its core namespace. The namespaces of the tenant
+// are linked to their parent through their edge-net.io/parent-namespace label, and the quota of each is taken from
+// the subnamespace named by its edge-net.io/owner label.
+func Tree(kubeclientset kubernetes.Interface, edgenetclientset clientset.Interface, tenant string) (*Node, error) {
+	coreNamespace, err := kubeclientset.CoreV1().Namespaces().Get(context.TODO(), tenant, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	namespaceRaw, err := kubeclientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/tenant=%s,edge-net.io/kind=sub", tenant)})
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[string][]corev1.Namespace)
+	for _, namespaceRow := range namespaceRaw.Items {
+		parent := namespaceRow.GetLabels()["edge-net.io/parent-namespace"]
+		children[parent] = append(children[parent], namespaceRow)
+	}
+
+	root := &Node{Namespace: coreNamespace.GetName()}
+	if tenantQuota, err := multitenancy.TenantQuota(edgenetclientset, tenant); err == nil {
+		root.Quota, _ = multitenancy.QuotaResourceList(tenantQuota)
+	}
+	// A namespace is visited once, so that labels edited into a cycle do not loop forever
+	visited := map[string]bool{root.Namespace: true}
+	var grow func(node *Node)
+	grow = func(node *Node) {
+		for _, namespaceRow := range children[node.Namespace] {
+			if visited[namespaceRow.GetName()] {
+				continue
+			}
+			visited[namespaceRow.GetName()] = true
+			child := &Node{Namespace: namespaceRow.GetName(), Owner: namespaceRow.GetLabels()["edge-net.io/owner"]}
+			if subnamespace, err := edgenetclientset.CoreV1alpha1().SubNamespaces(node.Namespace).Get(context.TODO(), child.Owner, metav1.GetOptions{}); err == nil {
+				child.Quota, _ = multitenancy.QuotaResourceList(subnamespace.GetResourceAllocation())
+			}
+			grow(child)
+			node.Children = append(node.Children, child)
+		}
+		sort.Slice(node.Children, func(i, j int) bool { return node.Children[i].Namespace < node.Children[j].Namespace })
+	}
+	grow(root)
+	return root, nil
+}
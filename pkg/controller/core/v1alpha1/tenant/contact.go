@@ -0,0 +1,84 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenant
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+)
+
+// ContactFields lists the address and contact fields of a tenant that can be required, named after their JSON keys
+var ContactFields = []string{
+	"address.street", "address.zip", "address.city", "address.region", "address.country",
+	"contact.firstname", "contact.lastname", "contact.email", "contact.phone",
+}
+
+// DefaultRequiredFields lists the fields a tenant must fill in unless configured otherwise
+var DefaultRequiredFields = []string{"contact.firstname", "contact.lastname", "contact.email"}
+
+var (
+	phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 ().-]{3,19}$`)
+	zipPattern   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 -]{1,9}$`)
+)
+
+// validateContactDetails returns a message for each invalid address or contact field of the tenant, in the order of
+// ContactFields. A required field must not be empty, and the email, phone, and ZIP code must be well-formed when given.
+func validateContactDetails(tenantSpec corev1alpha1.TenantSpec, requiredFields []string) []string {
+	values := map[string]string{
+		"address.street":    tenantSpec.Address.Street,
+		"address.zip":       tenantSpec.Address.ZIP,
+		"address.city":      tenantSpec.Address.City,
+		"address.region":    tenantSpec.Address.Region,
+		"address.country":   tenantSpec.Address.Country,
+		"contact.firstname": tenantSpec.Contact.FirstName,
+		"contact.lastname":  tenantSpec.Contact.LastName,
+		"contact.email":     tenantSpec.Contact.Email,
+		"contact.phone":     tenantSpec.Contact.Phone,
+	}
+	required := make(map[string]bool, len(requiredFields))
+	for _, field := range requiredFields {
+		required[strings.ToLower(strings.TrimSpace(field))] = true
+	}
+
+	var messages []string
+	for _, field := range ContactFields {
+		value := strings.TrimSpace(values[field])
+		switch {
+		case value == "":
+			if required[field] {
+				messages = append(messages, fmt.Sprintf("%s is required", field))
+			}
+		case field == "contact.email":
+			if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+				messages = append(messages, fmt.Sprintf("%s %q is not a valid email address", field, value))
+			}
+		case field == "contact.phone":
+			if !phonePattern.MatchString(value) {
+				messages = append(messages, fmt.Sprintf("%s %q is not a valid phone number", field, value))
+			}
+		case field == "address.zip":
+			if !zipPattern.MatchString(value) {
+				messages = append(messages, fmt.Sprintf("%s %q is not a valid ZIP code", field, value))
+			}
+		}
+	}
+	return messages
+}
@@ -19,15 +19,20 @@ package tenant
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
+	"github.com/EdgeNet-project/edgenet/pkg/finalizer"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	edgenetscheme "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/core/v1alpha1"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
 	antreav1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	antrea "antrea.io/antrea/pkg/client/clientset/versioned"
@@ -36,7 +41,9 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -44,6 +51,7 @@ import (
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 )
@@ -58,14 +66,28 @@ const (
 	failureCreation      = "Not Created"
 	failureBinding       = "Binding Failed"
 	failureNetworkPolicy = "Not Applied"
+	failureDefaultRole   = "Default Role Failed"
+	failurePriority      = "Priority Rejected"
+	failurePriorityClass = "Priority Class Failed"
 	failureDeletion      = "Not Removed"
+	failureEmailDomain   = "Email Domain Not Allowed"
+	failureShortName     = "Short Name Invalid"
+	failureConflict      = "Conflict"
+	failureContact       = "Contact Invalid"
+	failureProtected     = "Deletion Protected"
+	successRestored      = "Restored"
 
 	messageResourceSynced                   = "Tenant synced successfully"
 	messageEstablished                      = "Tenant established successfully"
+	messageEstablishing                     = "Tenant is partially established, missing pieces are being completed"
 	messageCreated                          = "Core namespace created successfully"
 	messageCreationFailed                   = "Core namespace creation failed"
 	messageBindingFailed                    = "Role binding failed"
+	messageBindingRetrying                  = "Owner role binding failed transiently, retrying"
 	messageNetworkPolicyFailed              = "Applying network policy failed"
+	messageDefaultRoleFailed                = "Default role %s cannot be created"
+	messagePriorityRejected                 = "Priority %s is outside of the allowed band %d-%d"
+	messagePriorityClassFailed              = "Priority class creation failed"
 	messageSliceClaimDeletionFailed         = "Slice claim clean up failed"
 	messageSubNamespaceDeletionFailed       = "Subsidiary namespace clean up failed"
 	messageClusterRoleDeletionFailed        = "Cluster role clean up failed"
@@ -73,6 +95,13 @@ const (
 	messageRoleBindingDeletionFailed        = "Role binding clean up failed"
 	messageRoleBindingCreationFailed        = "Role binding creation for tenant failed"
 	messageReconciliation                   = "Reconciliation in progress"
+	messageEmailDomainRejected              = "Contact email domain is not in the allow-list"
+	messageShortNameRejected                = "Short name cannot be converted to a DNS label"
+	messageShortNameNormalized              = "Short name normalized to a DNS label"
+	messageShortNameConflict                = "Short name is already taken by another tenant"
+	messageContactInvalid                   = "Address or contact is invalid: %s"
+	messageDeletionProtected                = "Tenant is protected from deletion, turn off its deletion protection to delete it"
+	messageOwnerBindingRestored             = "Owner cluster role binding was missing or altered, and has been restored"
 )
 
 // Controller is the controller implementation for Tenant resources
@@ -86,6 +115,23 @@ type Controller struct {
 	tenantsLister listers.TenantLister
 	tenantsSynced cache.InformerSynced
 
+	tenantresourcequotasLister listers.TenantResourceQuotaLister
+	tenantresourcequotasSynced cache.InformerSynced
+
+	// allowedEmailDomains restricts the contact email domains of tenants, an empty list allows any domain
+	allowedEmailDomains []string
+	// tenantLabelKeys lists the tenant labels copied to the objects generated for the tenant
+	tenantLabelKeys []string
+	// requiredFields lists the address and contact fields a tenant must fill in before it is provisioned
+	requiredFields []string
+	// defaultRoles lists the templates of the roles created in the core namespace of each tenant, as the name of a
+	// cluster role or the namespace/name of a role
+	defaultRoles []string
+	// priorityBand is the range of priority values the tenants can request for a priority class of their own
+	priorityBand PriorityBand
+	// ownerBindingBackoff paces the retries of the owner role binding on transient API errors
+	ownerBindingBackoff wait.Backoff
+
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
@@ -102,25 +148,77 @@ func NewController(
 	kubeclientset kubernetes.Interface,
 	edgenetclientset clientset.Interface,
 	antreaclientset antrea.Interface,
-	tenantInformer informers.TenantInformer) *Controller {
+	tenantInformer informers.TenantInformer,
+	allowedEmailDomains []string,
+	eventNamespace string,
+	tenantLabelKeys []string,
+	requiredFields []string,
+	tenantresourcequotaInformer informers.TenantResourceQuotaInformer,
+	defaultRoles []string,
+	priorityBand PriorityBand) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("antreaclientset", antreaclientset)
+	validator.NotNil("tenantInformer", tenantInformer)
+	validator.NotNil("tenantresourcequotaInformer", tenantresourcequotaInformer)
+	var contactFields []string
+	for _, field := range requiredFields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			validator.OneOf("requiredFields", field, ContactFields...)
+			contactFields = append(contactFields, field)
+		}
+	}
+	var roleTemplates []string
+	for _, template := range defaultRoles {
+		if template = strings.TrimSpace(template); template != "" {
+			roleTemplates = append(roleTemplates, template)
+		}
+	}
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
 
 	utilruntime.Must(edgenetscheme.AddToScheme(scheme.Scheme))
 	klog.Infoln("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
-	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	if eventNamespace == "" {
+		eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	} else {
+		eventBroadcaster.StartRecordingToSink(&namespacedEventSink{
+			EventSinkImpl: &typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events(eventNamespace)},
+			namespace:     eventNamespace,
+		})
+	}
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
-	controller := &Controller{
-		kubeclientset:    kubeclientset,
-		edgenetclientset: edgenetclientset,
-		antreaclientset:  antreaclientset,
-		tenantsLister:    tenantInformer.Lister(),
-		tenantsSynced:    tenantInformer.Informer().HasSynced,
-		workqueue:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Tenants"),
-		recorder:         recorder,
+	var emailDomains []string
+	for _, allowedDomain := range allowedEmailDomains {
+		if allowedDomain = strings.ToLower(strings.TrimSpace(allowedDomain)); allowedDomain != "" {
+			emailDomains = append(emailDomains, allowedDomain)
+		}
 	}
 
+	controller := &Controller{
+		kubeclientset:       kubeclientset,
+		edgenetclientset:    edgenetclientset,
+		antreaclientset:     antreaclientset,
+		tenantsLister:       tenantInformer.Lister(),
+		tenantsSynced:       tenantInformer.Informer().HasSynced,
+		allowedEmailDomains: emailDomains,
+		tenantLabelKeys:     tenantLabelKeys,
+		requiredFields:      contactFields,
+		defaultRoles:        roleTemplates,
+		priorityBand:        priorityBand,
+		ownerBindingBackoff: wait.Backoff{Steps: 5, Duration: 100 * time.Millisecond, Factor: 2.0, Jitter: 0.1},
+		workqueue:           workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "Tenants"),
+		recorder:            recorder,
+	}
+	controller.tenantresourcequotasLister = tenantresourcequotaInformer.Lister()
+	controller.tenantresourcequotasSynced = tenantresourcequotaInformer.Informer().HasSynced
+
 	klog.Infoln("Setting up event handlers")
 	tenantInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: controller.enqueueTenant,
@@ -128,8 +226,38 @@ func NewController(
 			controller.enqueueTenant(newObj)
 		},
 	})
+	// The projected quota of a tenant follows its tenant resource quotas
+	tenantresourcequotaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueTenantOfQuota,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			controller.enqueueTenantOfQuota(newObj)
+		},
+		DeleteFunc: controller.enqueueTenantOfQuota,
+	})
+
+	return controller, nil
+}
+
+// namespacedEventSink records all events in a single namespace, regardless of the namespace of the
+// involved object, for clusters where the controller is not allowed to write events everywhere
+type namespacedEventSink struct {
+	*typedcorev1.EventSinkImpl
+	namespace string
+}
+
+func (s *namespacedEventSink) Create(event *corev1.Event) (*corev1.Event, error) {
+	event.Namespace = s.namespace
+	return s.EventSinkImpl.Create(event)
+}
 
-	return controller
+func (s *namespacedEventSink) Update(event *corev1.Event) (*corev1.Event, error) {
+	event.Namespace = s.namespace
+	return s.EventSinkImpl.Update(event)
+}
+
+func (s *namespacedEventSink) Patch(event *corev1.Event, data []byte) (*corev1.Event, error) {
+	event.Namespace = s.namespace
+	return s.EventSinkImpl.Patch(event, data)
 }
 
 // Run will set up the event handlers for the types of tenant, as well
@@ -144,7 +272,8 @@ func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
 
 	klog.Infoln("Waiting for informer caches to sync")
 	if ok := cache.WaitForCacheSync(stopCh,
-		c.tenantsSynced); !ok {
+		c.tenantsSynced,
+		c.tenantresourcequotasSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
@@ -187,12 +316,13 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
@@ -243,34 +373,95 @@ func (c *Controller) enqueueTenant(obj interface{}) {
 	c.workqueue.Add(key)
 }
 
+// enqueueTenantOfQuota puts the tenant a tenant resource quota belongs to onto the work queue
+func (c *Controller) enqueueTenantOfQuota(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if tenantResourceQuota, ok := obj.(*corev1alpha1.TenantResourceQuota); ok {
+		c.workqueue.Add(tenantResourceQuota.Tenant())
+	}
+}
+
 func (c *Controller) processTenant(tenantCopy *corev1alpha1.Tenant) {
 	systemNamespace, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), "kube-system", metav1.GetOptions{})
 	if err != nil {
 		klog.Infoln(err)
 		return
 	}
+	if blocked := c.protectFromDeletion(tenantCopy); blocked {
+		return
+	}
 	if exceedsBackoffLimit := tenantCopy.Status.Failed >= backoffLimit; exceedsBackoffLimit {
 		c.cleanup(tenantCopy, string(systemNamespace.GetUID()))
 		return
 	}
+	// The quota is projected whether or not the tenant is enabled, so that it can be reviewed beforehand
+	if changed := c.projectQuota(tenantCopy); changed {
+		c.updateStatus(context.TODO(), tenantCopy)
+	}
 
 	if tenantCopy.Spec.Enabled {
+		// Tenants whose contact email is out of the allowed domains are not provisioned
+		if !c.isEmailDomainAllowed(tenantCopy.Spec.Contact.Email) {
+			if tenantCopy.Status.State != corev1alpha1.StatusRejected {
+				c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureEmailDomain, messageEmailDomainRejected)
+				tenantCopy.Status.State = corev1alpha1.StatusRejected
+				tenantCopy.Status.Message = messageEmailDomainRejected
+				c.updateStatus(context.TODO(), tenantCopy)
+			}
+			return
+		}
+		// Tenants with missing or malformed contact details are not provisioned until these are fixed
+		if invalidFields := validateContactDetails(tenantCopy.Spec, c.requiredFields); len(invalidFields) != 0 {
+			message := fmt.Sprintf(messageContactInvalid, strings.Join(invalidFields, "; "))
+			if tenantCopy.Status.State != corev1alpha1.StatusRejected || tenantCopy.Status.Message != message {
+				c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureContact, message)
+				tenantCopy.Status.State = corev1alpha1.StatusRejected
+				tenantCopy.Status.Message = message
+				c.updateStatus(context.TODO(), tenantCopy)
+			}
+			return
+		}
+		// The short name flows into labels and object names, so it must be a valid DNS label
+		if shortName, err := multitenancy.NormalizeShortName(tenantCopy.Spec.ShortName); err != nil {
+			if tenantCopy.Status.State != corev1alpha1.StatusRejected || tenantCopy.Status.Message != messageShortNameRejected {
+				klog.Infoln(err)
+				c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureShortName, messageShortNameRejected)
+				tenantCopy.Status.State = corev1alpha1.StatusRejected
+				tenantCopy.Status.Message = messageShortNameRejected
+				c.updateStatus(context.TODO(), tenantCopy)
+			}
+			return
+		} else if shortName != tenantCopy.Spec.ShortName {
+			// Updating the spec triggers another reconcile, which resumes the provisioning with the normalized name
+			tenantCopy.Spec.ShortName = shortName
+			if _, err := c.edgenetclientset.CoreV1alpha1().Tenants().Update(context.TODO(), tenantCopy, metav1.UpdateOptions{}); err != nil {
+				klog.Infoln(err)
+				return
+			}
+			c.recorder.Event(tenantCopy, corev1.EventTypeNormal, corev1alpha1.StatusReconciliation, messageShortNameNormalized)
+			return
+		}
+		// Provisioning a tenant whose short name is taken would clobber the objects of the other tenant
+		if owner := c.shortNameOwner(tenantCopy); owner != "" {
+			if tenantCopy.Status.State != corev1alpha1.StatusRejected || tenantCopy.Status.Message != messageShortNameConflict {
+				klog.Infof("Short name %s of tenant %s is taken by tenant %s", tenantCopy.Spec.ShortName, tenantCopy.GetName(), owner)
+				c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureConflict, messageShortNameConflict)
+				tenantCopy.Status.State = corev1alpha1.StatusRejected
+				tenantCopy.Status.Message = messageShortNameConflict
+				c.updateStatus(context.TODO(), tenantCopy)
+			}
+			return
+		}
 		// When a tenant is deleted, the owner references feature drives the namespace to be automatically removed
 		ownerReferences := []metav1.OwnerReference{tenantCopy.MakeOwnerReference()}
 		switch tenantCopy.Status.State {
 		case corev1alpha1.StatusEstablished:
 			c.reconcile(tenantCopy)
-		case corev1alpha1.StatusCoreNamespaceCreated:
-			// Apply network policies
-			if err := c.applyNetworkPolicy(tenantCopy.GetName(), string(tenantCopy.GetUID()), string(systemNamespace.GetUID()), tenantCopy.Spec.ClusterNetworkPolicy, ownerReferences); err != nil {
-				c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureNetworkPolicy, messageNetworkPolicyFailed)
-				tenantCopy.Status.State = corev1alpha1.StatusFailed
-				tenantCopy.Status.Message = messageNetworkPolicyFailed
-				c.updateStatus(context.TODO(), tenantCopy)
-				return
-			}
-			// Deliver required permissions to the tenant owner
-			if err := c.configureOwnerPermissions(tenantCopy); err != nil {
+		case corev1alpha1.StatusEstablishing, corev1alpha1.StatusCoreNamespaceCreated:
+			// Each step below is idempotent, so that the missing pieces of a partially-created tenant are completed
+			if err := c.establish(tenantCopy, ownerReferences, string(systemNamespace.GetUID())); err != nil {
 				return
 			}
 			c.recorder.Event(tenantCopy, corev1.EventTypeNormal, corev1alpha1.StatusEstablished, messageEstablished)
@@ -278,25 +469,11 @@ func (c *Controller) processTenant(tenantCopy *corev1alpha1.Tenant) {
 			tenantCopy.Status.Message = messageEstablished
 			c.updateStatus(context.TODO(), tenantCopy)
 		default:
-			// Create the core namespace
-			if err = c.makeCoreNamespace(tenantCopy, ownerReferences, string(systemNamespace.GetUID())); err != nil {
-				c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureCreation, messageCreationFailed)
-				tenantCopy.Status.State = corev1alpha1.StatusFailed
-				tenantCopy.Status.Message = messageCreationFailed
-				c.updateStatus(context.TODO(), tenantCopy)
-				return
-			}
-			// Create the cluster role and role binding for the tenant resource
-			multitenancyManager := multitenancy.NewManager(c.kubeclientset, c.edgenetclientset)
-			if err := multitenancyManager.GrantObjectOwnership("core.edgenet.io", "tenants", tenantCopy.GetName(), tenantCopy.Spec.Contact.Email, ownerReferences); err != nil {
-				c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureCreation, messageRoleBindingCreationFailed)
-				tenantCopy.Status.State = corev1alpha1.StatusFailed
-				tenantCopy.Status.Message = messageRoleBindingCreationFailed
-				c.updateStatus(context.TODO(), tenantCopy)
+			if err := c.makeCoreNamespaceAndOwnership(tenantCopy, ownerReferences, string(systemNamespace.GetUID())); err != nil {
 				return
 			}
-			c.recorder.Event(tenantCopy, corev1.EventTypeNormal, corev1alpha1.StatusCoreNamespaceCreated, messageCreated)
-			tenantCopy.Status.State = corev1alpha1.StatusCoreNamespaceCreated
+			c.recorder.Event(tenantCopy, corev1.EventTypeNormal, corev1alpha1.StatusEstablishing, messageCreated)
+			tenantCopy.Status.State = corev1alpha1.StatusEstablishing
 			tenantCopy.Status.Message = messageCreated
 			c.updateStatus(context.TODO(), tenantCopy)
 		}
@@ -305,11 +482,224 @@ func (c *Controller) processTenant(tenantCopy *corev1alpha1.Tenant) {
 	}
 }
 
+// protectFromDeletion holds a finalizer on the tenants protected from deletion, and releases it once the protection
+// is turned off. It reports whether the deletion of the tenant is blocked, in which case the tenant is left as it is.
+func (c *Controller) protectFromDeletion(tenantCopy *corev1alpha1.Tenant) bool {
+	var changed bool
+	if tenantCopy.Spec.DeletionProtection {
+		changed = finalizer.Add(tenantCopy, finalizer.TenantDeletionProtection)
+	} else {
+		changed = finalizer.Remove(tenantCopy, finalizer.TenantDeletionProtection)
+	}
+	if changed {
+		if tenant, err := c.edgenetclientset.CoreV1alpha1().Tenants().Update(context.TODO(), tenantCopy, metav1.UpdateOptions{}); err != nil {
+			klog.Infoln(err)
+		} else {
+			tenantCopy.SetResourceVersion(tenant.GetResourceVersion())
+		}
+	}
+
+	if tenantCopy.GetDeletionTimestamp() == nil || !tenantCopy.Spec.DeletionProtection {
+		return false
+	}
+	if tenantCopy.Status.Message != messageDeletionProtected {
+		c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureProtected, messageDeletionProtected)
+		tenantCopy.Status.Message = messageDeletionProtected
+		c.updateStatus(context.TODO(), tenantCopy)
+	}
+	return true
+}
+
+// projectQuota sets the quota of the core namespace resulting from the tenant resource quotas of the tenant, named as
+// in a resource quota, in the status of the tenant. It reports whether the projected quota changed.
+func (c *Controller) projectQuota(tenantCopy *corev1alpha1.Tenant) bool {
+	projectedQuota, err := c.getProjectedQuota(tenantCopy.GetName())
+	if err != nil {
+		klog.Infoln(err)
+		return false
+	}
+	if isQuotaEqual(tenantCopy.Status.ProjectedQuota, projectedQuota) {
+		return false
+	}
+	tenantCopy.Status.ProjectedQuota = projectedQuota
+	return true
+}
+
+// getProjectedQuota aggregates the tenant resource quota named after the tenant with the additional ones labeled for
+// it, as the tenant resource quota controller does. It returns nil if the tenant has no tenant resource quota yet.
+func (c *Controller) getProjectedQuota(tenant string) (map[corev1.ResourceName]resource.Quantity, error) {
+	tenantResourceQuota, err := c.tenantresourcequotasLister.Get(tenant)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	tenantResourceQuotas := []corev1alpha1.TenantResourceQuota{*tenantResourceQuota.DeepCopy()}
+	additionalQuotas, err := c.tenantresourcequotasLister.List(labels.SelectorFromSet(labels.Set{"edge-net.io/tenant": tenant}))
+	if err != nil {
+		return nil, err
+	}
+	for _, additionalQuota := range additionalQuotas {
+		if additionalQuota.GetName() != tenant {
+			tenantResourceQuotas = append(tenantResourceQuotas, *additionalQuota.DeepCopy())
+		}
+	}
+	for i := range tenantResourceQuotas {
+		tenantResourceQuotas[i].DropExpiredItems()
+	}
+	return multitenancy.QuotaResourceList(multitenancy.AggregateQuota(tenantResourceQuotas...))
+}
+
+// isQuotaEqual tells whether both resource lists hold the same quantities of the same resources
+func isQuotaEqual(resourceList, otherResourceList map[corev1.ResourceName]resource.Quantity) bool {
+	if len(resourceList) != len(otherResourceList) {
+		return false
+	}
+	for key, quantity := range resourceList {
+		if otherQuantity, elementExists := otherResourceList[key]; !elementExists || quantity.Cmp(otherQuantity) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmailDomainAllowed checks whether the domain of the email address, or a parent domain of it, is in the allow-list
+func (c *Controller) isEmailDomainAllowed(email string) bool {
+	if len(c.allowedEmailDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowedDomain := range c.allowedEmailDomains {
+		if domain == allowedDomain || strings.HasSuffix(domain, "."+allowedDomain) {
+			return true
+		}
+	}
+	return false
+}
+
+// shortNameOwner returns the name of another tenant holding the same normalized short name as the tenant, empty if none.
+// The short name belongs to the tenant created first, the tenant name breaks ties.
+func (c *Controller) shortNameOwner(tenantCopy *corev1alpha1.Tenant) string {
+	tenants, err := c.tenantsLister.List(labels.Everything())
+	if err != nil {
+		klog.Infoln(err)
+		return ""
+	}
+	for _, tenant := range tenants {
+		if tenant.GetName() == tenantCopy.GetName() {
+			continue
+		}
+		if shortName, err := multitenancy.NormalizeShortName(tenant.Spec.ShortName); err != nil || shortName != tenantCopy.Spec.ShortName {
+			continue
+		}
+		creationTimestamp, otherCreationTimestamp := tenantCopy.GetCreationTimestamp(), tenant.GetCreationTimestamp()
+		if otherCreationTimestamp.Before(&creationTimestamp) ||
+			(otherCreationTimestamp.Equal(&creationTimestamp) && tenant.GetName() < tenantCopy.GetName()) {
+			return tenant.GetName()
+		}
+	}
+	return ""
+}
+
+// establish completes the provisioning of the tenant by applying the core namespace, the cluster role and
+// the cluster role binding for the tenant object, the network policies, and the owner permissions.
+func (c *Controller) establish(tenantCopy *corev1alpha1.Tenant, ownerReferences []metav1.OwnerReference, clusterUID string) error {
+	if err := c.makeCoreNamespaceAndOwnership(tenantCopy, ownerReferences, clusterUID); err != nil {
+		return err
+	}
+	// Apply network policies
+	if err := c.applyNetworkPolicy(tenantCopy.GetName(), string(tenantCopy.GetUID()), clusterUID, tenantCopy.Spec.ClusterNetworkPolicy, ownerReferences); err != nil {
+		c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureNetworkPolicy, messageNetworkPolicyFailed)
+		tenantCopy.Status.State = corev1alpha1.StatusFailed
+		tenantCopy.Status.Message = messageNetworkPolicyFailed
+		c.updateStatus(context.TODO(), tenantCopy)
+		return err
+	}
+	// Instantiate the default roles configured for all tenants
+	if template, err := c.applyDefaultRoles(tenantCopy); err != nil {
+		message := fmt.Sprintf(messageDefaultRoleFailed, template)
+		c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureDefaultRole, message)
+		tenantCopy.Status.State = corev1alpha1.StatusFailed
+		tenantCopy.Status.Message = message
+		c.updateStatus(context.TODO(), tenantCopy)
+		return err
+	}
+	// Create the priority class the tenant requests for its workloads
+	if err := c.applyPriorityClass(tenantCopy, ownerReferences); err != nil {
+		c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failurePriorityClass, messagePriorityClassFailed)
+		tenantCopy.Status.State = corev1alpha1.StatusFailed
+		tenantCopy.Status.Message = messagePriorityClassFailed
+		c.updateStatus(context.TODO(), tenantCopy)
+		return err
+	}
+	// Deliver required permissions to the tenant owner
+	return c.configureOwnerPermissions(tenantCopy)
+}
+
+// applyDefaultRoles creates a role in the core namespace of the tenant out of each default role template, with the
+// rules of the template. The roles already there are left as they are. It returns the template that failed, if any.
+func (c *Controller) applyDefaultRoles(tenantCopy *corev1alpha1.Tenant) (string, error) {
+	for _, template := range c.defaultRoles {
+		var name string
+		var rules []rbacv1.PolicyRule
+		if namespace, roleName, err := cache.SplitMetaNamespaceKey(template); err != nil {
+			return template, err
+		} else if namespace == "" {
+			clusterRole, err := c.kubeclientset.RbacV1().ClusterRoles().Get(context.TODO(), roleName, metav1.GetOptions{})
+			if err != nil {
+				return template, err
+			}
+			name, rules = clusterRole.GetName(), clusterRole.Rules
+		} else {
+			role, err := c.kubeclientset.RbacV1().Roles(namespace).Get(context.TODO(), roleName, metav1.GetOptions{})
+			if err != nil {
+				return template, err
+			}
+			name, rules = role.GetName(), role.Rules
+		}
+		role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: tenantCopy.GetName()}, Rules: rules}
+		role.SetLabels(map[string]string{"edge-net.io/generated": "true"})
+		multitenancy.StampLabels(role, multitenancy.TenantLabels(tenantCopy, c.tenantLabelKeys))
+		if _, err := c.kubeclientset.RbacV1().Roles(role.GetNamespace()).Create(context.TODO(), role, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+			return template, err
+		}
+	}
+	return "", nil
+}
+
+func (c *Controller) makeCoreNamespaceAndOwnership(tenantCopy *corev1alpha1.Tenant, ownerReferences []metav1.OwnerReference, clusterUID string) error {
+	// Create the core namespace
+	if err := c.makeCoreNamespace(tenantCopy, ownerReferences, clusterUID); err != nil {
+		c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureCreation, messageCreationFailed)
+		tenantCopy.Status.State = corev1alpha1.StatusFailed
+		tenantCopy.Status.Message = messageCreationFailed
+		c.updateStatus(context.TODO(), tenantCopy)
+		return err
+	}
+	// Create the cluster role and role binding for the tenant resource
+	multitenancyManager := multitenancy.NewManager(c.kubeclientset, c.edgenetclientset)
+	if err := multitenancyManager.GrantObjectOwnership("core.edgenet.io", "tenants", tenantCopy.GetName(), tenantCopy.Spec.Contact.Email, ownerReferences); err != nil {
+		c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureCreation, messageRoleBindingCreationFailed)
+		tenantCopy.Status.State = corev1alpha1.StatusFailed
+		tenantCopy.Status.Message = messageRoleBindingCreationFailed
+		c.updateStatus(context.TODO(), tenantCopy)
+		return err
+	}
+	return nil
+}
+
+// reconcile checks whether any piece of an established tenant is missing. If so, the tenant falls back
+// to the establishing state so that the next pass completes the missing pieces.
 func (c *Controller) reconcile(tenantCopy *corev1alpha1.Tenant) {
+	isEstablished := true
 	// Reconcile with the owner permissions in the core namespace
 	if roleBinding, err := c.kubeclientset.RbacV1().RoleBindings(tenantCopy.GetName()).Get(context.TODO(), corev1alpha1.TenantOwnerClusterRoleName, metav1.GetOptions{}); err != nil {
-		tenantCopy.Status.State = corev1alpha1.StatusCoreNamespaceCreated
-		tenantCopy.Status.Message = messageCreated
+		isEstablished = false
 	} else {
 		if roleBinding.RoleRef.Kind == "ClusterRole" && roleBinding.RoleRef.Name == corev1alpha1.TenantOwnerClusterRoleName {
 			isConsiled := false
@@ -319,35 +709,65 @@ func (c *Controller) reconcile(tenantCopy *corev1alpha1.Tenant) {
 				}
 			}
 			if !isConsiled {
-				tenantCopy.Status.State = corev1alpha1.StatusCoreNamespaceCreated
-				tenantCopy.Status.Message = messageCreated
+				isEstablished = false
 			}
 		}
 	}
 	// Reconcile with the network policies
 	if _, err := c.kubeclientset.NetworkingV1().NetworkPolicies(tenantCopy.GetName()).Get(context.TODO(), "baseline", metav1.GetOptions{}); err != nil {
-		tenantCopy.Status.State = corev1alpha1.StatusCoreNamespaceCreated
-		tenantCopy.Status.Message = messageCreated
+		isEstablished = false
 	}
 	if _, err := c.antreaclientset.CrdV1alpha1().ClusterNetworkPolicies().Get(context.TODO(), tenantCopy.GetName(), metav1.GetOptions{}); (err != nil && tenantCopy.Spec.ClusterNetworkPolicy) || (err == nil && !tenantCopy.Spec.ClusterNetworkPolicy) {
-		tenantCopy.Status.State = corev1alpha1.StatusCoreNamespaceCreated
-		tenantCopy.Status.Message = messageCreated
+		isEstablished = false
 	}
 	// Reconcile with the core namespace and the associated permissions of the tenant resource
-	if _, err := c.kubeclientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), fmt.Sprintf("edgenet:tenants:%s-owner", tenantCopy.GetName()), metav1.GetOptions{}); err != nil {
-		tenantCopy.Status.State = corev1alpha1.StatusReconciliation
-		tenantCopy.Status.Message = messageReconciliation
+	_, clusterRoleErr := c.kubeclientset.RbacV1().ClusterRoles().Get(context.TODO(), fmt.Sprintf("edgenet:tenants:%s-owner", tenantCopy.GetName()), metav1.GetOptions{})
+	if clusterRoleErr != nil {
+		isEstablished = false
+	}
+	if clusterRoleBinding, err := c.kubeclientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), fmt.Sprintf("edgenet:tenants:%s-owner", tenantCopy.GetName()), metav1.GetOptions{}); err != nil || !bindsUser(clusterRoleBinding.Subjects, tenantCopy.Spec.Contact.Email) {
+		// The owner loses sight of the tenant without the binding, so a binding deleted or altered out-of-band is
+		// restored right away rather than on the next pass, unless the cluster role is gone along with it
+		if clusterRoleErr != nil || !c.restoreOwnerClusterRoleBinding(tenantCopy) {
+			isEstablished = false
+		}
 	}
-	if _, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), tenantCopy.GetName(), metav1.GetOptions{}); err != nil {
-		tenantCopy.Status.State = corev1alpha1.StatusReconciliation
-		tenantCopy.Status.Message = messageReconciliation
+	if coreNamespace, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), tenantCopy.GetName(), metav1.GetOptions{}); err != nil {
+		isEstablished = false
+	} else if !c.isPriorityClassReconciled(tenantCopy, coreNamespace) {
+		// Reconcile with the priority class the tenant requests, which may have been set or changed since
+		isEstablished = false
 	}
 
-	if tenantCopy.Status.State != corev1alpha1.StatusEstablished {
+	if !isEstablished {
+		c.recorder.Event(tenantCopy, corev1.EventTypeWarning, corev1alpha1.StatusEstablishing, messageEstablishing)
+		tenantCopy.Status.State = corev1alpha1.StatusEstablishing
+		tenantCopy.Status.Message = messageEstablishing
 		c.updateStatus(context.TODO(), tenantCopy)
 	}
 }
 
+// restoreOwnerClusterRoleBinding binds the owner to the cluster role of the tenant again, and reports whether it succeeded
+func (c *Controller) restoreOwnerClusterRoleBinding(tenantCopy *corev1alpha1.Tenant) bool {
+	multitenancyManager := multitenancy.NewManager(c.kubeclientset, c.edgenetclientset)
+	if err := multitenancyManager.GrantObjectOwnership("core.edgenet.io", "tenants", tenantCopy.GetName(), tenantCopy.Spec.Contact.Email, []metav1.OwnerReference{tenantCopy.MakeOwnerReference()}); err != nil {
+		klog.Infoln(err)
+		return false
+	}
+	c.recorder.Event(tenantCopy, corev1.EventTypeNormal, successRestored, messageOwnerBindingRestored)
+	return true
+}
+
+// bindsUser tells whether the subjects include the user
+func bindsUser(subjects []rbacv1.Subject, user string) bool {
+	for _, subject := range subjects {
+		if subject.Kind == "User" && subject.Name == user {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Controller) makeCoreNamespace(tenantCopy *corev1alpha1.Tenant, ownerReferences []metav1.OwnerReference, clusterUID string) error {
 	// Core namespace has the same name as the tenant
 	coreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tenantCopy.GetName(), OwnerReferences: ownerReferences}}
@@ -355,15 +775,19 @@ func (c *Controller) makeCoreNamespace(tenantCopy *corev1alpha1.Tenant, ownerRef
 	labels := map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenantCopy.GetName(),
 		"edge-net.io/tenant-uid": string(tenantCopy.GetUID()), "edge-net.io/cluster-uid": clusterUID}
 	coreNamespace.SetLabels(labels)
+	multitenancy.StampLabels(coreNamespace, multitenancy.TenantLabels(tenantCopy, c.tenantLabelKeys))
 	annotations := map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}
 	if nodeSelector, elementExists := tenantCopy.GetAnnotations()["scheduler.alpha.kubernetes.io/node-selector"]; elementExists {
 		annotations["scheduler.alpha.kubernetes.io/node-selector"] = nodeSelector
 	}
+	if _, _, allowed := c.requestedPriority(tenantCopy); allowed {
+		annotations[multitenancy.DefaultPriorityClassAnnotation] = priorityClassName(tenantCopy.GetName())
+	}
 	coreNamespace.SetAnnotations(annotations)
 	if _, err := c.kubeclientset.CoreV1().Namespaces().Create(context.TODO(), coreNamespace, metav1.CreateOptions{}); err != nil {
 		if errors.IsAlreadyExists(err) {
 			if namespace, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), coreNamespace.GetName(), metav1.GetOptions{}); err == nil {
-				namespace.SetLabels(labels)
+				namespace.SetLabels(coreNamespace.GetLabels())
 				namespace.SetAnnotations(annotations)
 				namespace.SetOwnerReferences(ownerReferences)
 				if _, err := c.kubeclientset.CoreV1().Namespaces().Update(context.TODO(), namespace, metav1.UpdateOptions{}); err == nil {
@@ -383,18 +807,20 @@ func (c *Controller) configureOwnerPermissions(tenantCopy *corev1alpha1.Tenant)
 		Subjects: rbSubjects, RoleRef: roleRef}
 	roleBindLabels := map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true"}
 	roleBind.SetLabels(roleBindLabels)
-	if _, err := c.kubeclientset.RbacV1().RoleBindings(tenantCopy.GetName()).Create(context.TODO(), roleBind, metav1.CreateOptions{}); err != nil {
-		if errors.IsAlreadyExists(err) {
-			if roleBinding, err := c.kubeclientset.RbacV1().RoleBindings(tenantCopy.GetName()).Get(context.TODO(), roleBind.GetName(), metav1.GetOptions{}); err == nil {
-				roleBindingCopy := roleBinding.DeepCopy()
-				roleBindingCopy.RoleRef = roleBind.RoleRef
-				roleBindingCopy.Subjects = roleBind.Subjects
-				roleBindingCopy.SetLabels(roleBind.GetLabels())
-				if _, err := c.kubeclientset.RbacV1().RoleBindings(tenantCopy.GetName()).Update(context.TODO(), roleBindingCopy, metav1.UpdateOptions{}); err == nil {
-					return nil
-				}
-			}
+	multitenancy.StampLabels(roleBind, multitenancy.TenantLabels(tenantCopy, c.tenantLabelKeys))
+	// Transient API errors are retried with backoff rather than counted against the backoff limit of the tenant,
+	// the tenant stays in its current state with a message telling that the binding is being retried
+	attempts := 0
+	err := retry.OnError(c.ownerBindingBackoff, multitenancy.IsTransient, func() error {
+		if attempts > 0 && tenantCopy.Status.Message != messageBindingRetrying {
+			c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureBinding, messageBindingRetrying)
+			tenantCopy.Status.Message = messageBindingRetrying
+			c.updateStatus(context.TODO(), tenantCopy)
 		}
+		attempts++
+		return c.applyOwnerRoleBinding(roleBind)
+	})
+	if err != nil {
 		c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failureBinding, messageBindingFailed)
 		tenantCopy.Status.State = corev1alpha1.StatusFailed
 		tenantCopy.Status.Message = messageBindingFailed
@@ -404,6 +830,22 @@ func (c *Controller) configureOwnerPermissions(tenantCopy *corev1alpha1.Tenant)
 	return nil
 }
 
+// applyOwnerRoleBinding creates the owner role binding in the core namespace, or updates the existing one
+func (c *Controller) applyOwnerRoleBinding(roleBind *rbacv1.RoleBinding) error {
+	_, err := c.kubeclientset.RbacV1().RoleBindings(roleBind.GetNamespace()).Create(context.TODO(), roleBind, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		var roleBinding *rbacv1.RoleBinding
+		if roleBinding, err = c.kubeclientset.RbacV1().RoleBindings(roleBind.GetNamespace()).Get(context.TODO(), roleBind.GetName(), metav1.GetOptions{}); err == nil {
+			roleBindingCopy := roleBinding.DeepCopy()
+			roleBindingCopy.RoleRef = roleBind.RoleRef
+			roleBindingCopy.Subjects = roleBind.Subjects
+			roleBindingCopy.SetLabels(roleBind.GetLabels())
+			_, err = c.kubeclientset.RbacV1().RoleBindings(roleBind.GetNamespace()).Update(context.TODO(), roleBindingCopy, metav1.UpdateOptions{})
+		}
+	}
+	return err
+}
+
 func (c *Controller) applyNetworkPolicy(tenant, tenantUID, clusterUID string, clusterNetworkPolicyEnabled bool, ownerReferences []metav1.OwnerReference) error {
 	// TODO: Apply a network policy to the core namespace according to spec
 	// Restricted only allows intra-tenant communication
@@ -552,7 +994,10 @@ func (c *Controller) updateStatus(ctx context.Context, tenantCopy *corev1alpha1.
 	if tenantCopy.Status.State == corev1alpha1.StatusFailed {
 		tenantCopy.Status.Failed++
 	}
-	if _, err := c.edgenetclientset.CoreV1alpha1().Tenants().UpdateStatus(ctx, tenantCopy, metav1.UpdateOptions{}); err != nil {
+	if tenant, err := c.edgenetclientset.CoreV1alpha1().Tenants().UpdateStatus(ctx, tenantCopy, metav1.UpdateOptions{}); err != nil {
 		klog.Infoln(err)
+	} else {
+		// Keep the resource version current so that a later status update in the same pass does not conflict
+		tenantCopy.SetResourceVersion(tenant.GetResourceVersion())
 	}
 }
@@ -1,15 +1,19 @@
 package tenant
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	corev1alpha "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/finalizer"
 	edgenetfake "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/fake"
 	edgeinformers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
 
 	antreav1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	antreafake "antrea.io/antrea/pkg/client/clientset/versioned/fake"
@@ -17,11 +21,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	core "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
@@ -48,6 +55,7 @@ type fixture struct {
 	rolebindingLister          []*rbacv1.RoleBinding
 	networkpolicyLister        []*networkingv1.NetworkPolicy
 	clusternetworkpolicyLister []*antreav1alpha1.ClusterNetworkPolicy
+	tenantresourcequotaLister  []*corev1alpha1.TenantResourceQuota
 
 	// Actions expected to happen on the client.
 	kubeactions    []core.Action
@@ -58,6 +66,10 @@ type fixture struct {
 	kubeobjects    []runtime.Object
 	edgenetobjects []runtime.Object
 	antreaobjects  []runtime.Object
+
+	allowedEmailDomains []string
+	defaultRoles        []string
+	priorityBand        PriorityBand
 }
 
 func newFixture(t *testing.T) *fixture {
@@ -293,15 +305,23 @@ func (f *fixture) newController() (*Controller, edgeinformers.SharedInformerFact
 	edgeinformer := edgeinformers.NewSharedInformerFactory(f.edgenetclientset, noResyncPeriodFunc())
 	//kubeinformer := kubeinformers.NewSharedInformerFactory(f.kubeclientset, noResyncPeriodFunc())
 
-	controller := NewController(f.kubeclientset, f.edgenetclientset, f.antreaclientset,
-		edgeinformer.Core().V1alpha1().Tenants())
+	controller, err := NewController(f.kubeclientset, f.edgenetclientset, f.antreaclientset,
+		edgeinformer.Core().V1alpha1().Tenants(), f.allowedEmailDomains, "", multitenancy.DefaultTenantLabelKeys, DefaultRequiredFields,
+		edgeinformer.Core().V1alpha1().TenantResourceQuotas(), f.defaultRoles, f.priorityBand)
+	if err != nil {
+		f.t.Fatalf("controller not created: %v", err)
+	}
 
 	controller.tenantsSynced = alwaysReady
+	controller.tenantresourcequotasSynced = alwaysReady
 	controller.recorder = &record.FakeRecorder{}
 
 	for _, tenant := range f.tenantLister {
 		edgeinformer.Core().V1alpha1().Tenants().Informer().GetIndexer().Add(tenant)
 	}
+	for _, tenantResourceQuota := range f.tenantresourcequotaLister {
+		edgeinformer.Core().V1alpha1().TenantResourceQuotas().Informer().GetIndexer().Add(tenantResourceQuota)
+	}
 
 	return controller, edgeinformer
 }
@@ -469,6 +489,8 @@ func filterInformerActions(actions []core.Action) []core.Action {
 		if len(action.GetNamespace()) == 0 &&
 			(action.Matches("list", "tenants") ||
 				action.Matches("watch", "tenants") ||
+				action.Matches("list", "tenantresourcequotas") ||
+				action.Matches("watch", "tenantresourcequotas") ||
 				action.Matches("list", "namespaces") ||
 				action.Matches("watch", "namespaces")) {
 			continue
@@ -522,6 +544,9 @@ func (f *fixture) expectDeleteClusterNetworkPolicyAction(name string) {
 func (f *fixture) expectUpdateTenantStatusAction(tenant *corev1alpha1.Tenant) {
 	f.edgenetactions = append(f.edgenetactions, core.NewRootUpdateSubresourceAction(schema.GroupVersionResource{Resource: "tenants"}, "status", tenant))
 }
+func (f *fixture) expectUpdateTenantAction(tenant *corev1alpha1.Tenant) {
+	f.edgenetactions = append(f.edgenetactions, core.NewRootUpdateAction(schema.GroupVersionResource{Resource: "tenants"}, tenant))
+}
 func (f *fixture) expectUpdateNamespaceAction(namespace *corev1.Namespace) {
 	f.kubeactions = append(f.kubeactions, core.NewRootUpdateAction(schema.GroupVersionResource{Resource: "namespaces"}, namespace))
 }
@@ -572,16 +597,331 @@ func TestCreateTenant(t *testing.T) {
 	f.run(getKey(tenant, t))
 }
 
+func TestProjectedQuota(t *testing.T) {
+	f := newFixture(t)
+	tenant := newTenant("tenant16", true, true)
+	claim := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8000m"), corev1.ResourceMemory: resource.MustParse("8Gi")}
+	tenantResourceQuota := &corev1alpha1.TenantResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: tenant.GetName()},
+		Spec: corev1alpha1.TenantResourceQuotaSpec{Claim: map[string]corev1alpha1.ResourceTuning{"initial": {ResourceList: claim}}}}
+
+	kubenamespace := newNamespace("kube-system", nil, nil, nil)
+	namespace := newNamespace(tenant.GetName(), map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": ""}, map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	clusterrole := newClusterRole(tenant.GetName(), tenant.GetName(), []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	clusterrolebinding := newClusterRoleBinding(tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
+
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.tenantresourcequotaLister = append(f.tenantresourcequotaLister, tenantResourceQuota)
+	f.edgenetobjects = append(f.edgenetobjects, tenant, tenantResourceQuota)
+	f.kubeobjects = append(f.kubeobjects, kubenamespace)
+
+	f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+	f.expectUpdateTenantStatusAction(tenant)
+	f.expectCreateNamespaceAction(namespace)
+	f.expectCreateClusterRoleAction(clusterrole)
+	f.expectCreateClusterRoleBindingAction(clusterrolebinding)
+	f.expectUpdateTenantStatusAction(tenant)
+
+	f.run(getKey(tenant, t))
+
+	projectedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting tenant: %v", err)
+	}
+	if !isQuotaEqual(claim, projectedTenant.Status.ProjectedQuota) {
+		t.Errorf("expected projected quota %v, got %v", claim, projectedTenant.Status.ProjectedQuota)
+	}
+	if projectedTenant.Status.State != corev1alpha1.StatusEstablishing {
+		t.Errorf("expected state %q, got %q", corev1alpha1.StatusEstablishing, projectedTenant.Status.State)
+	}
+}
+
+func TestCreateTenantAllowedEmailDomain(t *testing.T) {
+	f := newFixture(t)
+	f.allowedEmailDomains = []string{"edge-net.org", " Tenant9.org "}
+	tenant := newTenant("tenant9", true, true)
+
+	kubenamespace := newNamespace("kube-system", nil, nil, nil)
+	namespace := newNamespace(tenant.GetName(), map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": ""}, map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	clusterrole := newClusterRole(tenant.GetName(), tenant.GetName(), []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	clusterrolebinding := newClusterRoleBinding(tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
+
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, tenant)
+	f.kubeobjects = append(f.kubeobjects, kubenamespace)
+
+	f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+	f.expectCreateNamespaceAction(namespace)
+	f.expectCreateClusterRoleAction(clusterrole)
+	f.expectCreateClusterRoleBindingAction(clusterrolebinding)
+	f.expectUpdateTenantStatusAction(tenant)
+
+	f.run(getKey(tenant, t))
+}
+
+func TestCreateTenantRejectedEmailDomain(t *testing.T) {
+	f := newFixture(t)
+	f.allowedEmailDomains = []string{"edge-net.org"}
+	tenant := newTenant("tenant10", true, true)
+
+	kubenamespace := newNamespace("kube-system", nil, nil, nil)
+
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, tenant)
+	f.kubeobjects = append(f.kubeobjects, kubenamespace)
+
+	// No provisioning takes place, only the status is updated
+	f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+	f.expectUpdateTenantStatusAction(tenant)
+
+	f.run(getKey(tenant, t))
+
+	rejectedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting tenant: %v", err)
+	}
+	if rejectedTenant.Status.State != corev1alpha1.StatusRejected {
+		t.Errorf("expected tenant state %q, got %q", corev1alpha1.StatusRejected, rejectedTenant.Status.State)
+	}
+}
+
+func TestCreateTenantInvalidContact(t *testing.T) {
+	cases := map[string]struct {
+		edit    func(tenant *corev1alpha1.Tenant)
+		message string
+	}{
+		"missing email": {func(tenant *corev1alpha1.Tenant) { tenant.Spec.Contact.Email = "" }, "contact.email is required"},
+		"invalid phone": {func(tenant *corev1alpha1.Tenant) { tenant.Spec.Contact.Phone = "call me" }, "contact.phone \"call me\" is not a valid phone number"},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			f := newFixture(t)
+			tenant := newTenant("tenant16", true, true)
+			tc.edit(tenant)
+
+			kubenamespace := newNamespace("kube-system", nil, nil, nil)
+
+			f.tenantLister = append(f.tenantLister, tenant)
+			f.edgenetobjects = append(f.edgenetobjects, tenant)
+			f.kubeobjects = append(f.kubeobjects, kubenamespace)
+
+			// No provisioning takes place, only the status is updated
+			f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+			f.expectUpdateTenantStatusAction(tenant)
+
+			f.run(getKey(tenant, t))
+
+			rejectedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting tenant: %v", err)
+			}
+			message := fmt.Sprintf(messageContactInvalid, tc.message)
+			if rejectedTenant.Status.State != corev1alpha1.StatusRejected || rejectedTenant.Status.Message != message {
+				t.Errorf("expected tenant state %q with message %q, got %q with message %q", corev1alpha1.StatusRejected, message, rejectedTenant.Status.State, rejectedTenant.Status.Message)
+			}
+		})
+	}
+}
+
+func TestValidateContactDetails(t *testing.T) {
+	tenant := newTenant("tenant17", true, true)
+	if messages := validateContactDetails(tenant.Spec, ContactFields[:4]); len(messages) != 1 || messages[0] != "address.region is required" {
+		t.Errorf("expected the missing region only, got %q", messages)
+	}
+	tenant.Spec.Contact.Email = "John Doe <john.doe@tenant17.org>"
+	tenant.Spec.Address.ZIP = "75005!"
+	expected := []string{"address.zip \"75005!\" is not a valid ZIP code", "contact.email \"John Doe <john.doe@tenant17.org>\" is not a valid email address"}
+	if messages := validateContactDetails(tenant.Spec, nil); !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected %q, got %q", expected, messages)
+	}
+	edgeinformer := edgeinformers.NewSharedInformerFactory(edgenetfake.NewSimpleClientset(), 0)
+	if _, err := NewController(k8sfake.NewSimpleClientset(), edgenetfake.NewSimpleClientset(), antreafake.NewSimpleClientset(),
+		edgeinformer.Core().V1alpha1().Tenants(), nil, "", nil, []string{"contact.fax"}, edgeinformer.Core().V1alpha1().TenantResourceQuotas(), nil, PriorityBand{}); err == nil {
+		t.Errorf("expected an unknown required field to be refused")
+	}
+}
+
+func TestCreateTenantRejectedShortName(t *testing.T) {
+	f := newFixture(t)
+	tenant := newTenant("tenant11", true, true)
+	tenant.Spec.ShortName = "東京大学"
+
+	kubenamespace := newNamespace("kube-system", nil, nil, nil)
+
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, tenant)
+	f.kubeobjects = append(f.kubeobjects, kubenamespace)
+
+	// No provisioning takes place, only the status is updated
+	f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+	f.expectUpdateTenantStatusAction(tenant)
+
+	f.run(getKey(tenant, t))
+
+	rejectedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting tenant: %v", err)
+	}
+	if rejectedTenant.Status.State != corev1alpha1.StatusRejected || rejectedTenant.Status.Message != messageShortNameRejected {
+		t.Errorf("expected tenant state %q with message %q, got %q with message %q", corev1alpha1.StatusRejected, messageShortNameRejected, rejectedTenant.Status.State, rejectedTenant.Status.Message)
+	}
+}
+
+func TestCreateTenantShortNameConflict(t *testing.T) {
+	f := newFixture(t)
+	existing := newTenant("tenant13", true, true)
+	existing.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-time.Hour)))
+	existing.Spec.ShortName = "lab"
+	existing.Status.State = corev1alpha1.StatusEstablished
+	tenant := newTenant("tenant14", true, true)
+	tenant.SetCreationTimestamp(metav1.Now())
+	tenant.Spec.ShortName = "lab"
+
+	kubenamespace := newNamespace("kube-system", nil, nil, nil)
+
+	f.tenantLister = append(f.tenantLister, existing, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, existing, tenant)
+	f.kubeobjects = append(f.kubeobjects, kubenamespace)
+
+	// The second tenant is not provisioned, only the status is updated
+	f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+	f.expectUpdateTenantStatusAction(tenant)
+
+	f.run(getKey(tenant, t))
+
+	rejectedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting tenant: %v", err)
+	}
+	if rejectedTenant.Status.State != corev1alpha1.StatusRejected || rejectedTenant.Status.Message != messageShortNameConflict {
+		t.Errorf("expected tenant state %q with message %q, got %q with message %q", corev1alpha1.StatusRejected, messageShortNameConflict, rejectedTenant.Status.State, rejectedTenant.Status.Message)
+	}
+
+	// The tenant created first keeps the short name
+	c, _ := f.newController()
+	if owner := c.shortNameOwner(existing); owner != "" {
+		t.Errorf("expected tenant %q to own the short name, got it taken by %q", existing.GetName(), owner)
+	}
+	if owner := c.shortNameOwner(tenant); owner != existing.GetName() {
+		t.Errorf("expected the short name to be taken by %q, got %q", existing.GetName(), owner)
+	}
+}
+
+func TestCreateTenantNormalizedShortName(t *testing.T) {
+	cases := map[string]struct {
+		shortName string
+		expected  string
+	}{
+		"spaces":    {"Tenant 12 Lab", "tenant-12-lab"},
+		"unicode":   {"Université", "universite"},
+		"uppercase": {"TENANT12", "tenant12"},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			f := newFixture(t)
+			tenant := newTenant("tenant12", true, true)
+			tenant.Spec.ShortName = tc.shortName
+
+			kubenamespace := newNamespace("kube-system", nil, nil, nil)
+
+			f.tenantLister = append(f.tenantLister, tenant)
+			f.edgenetobjects = append(f.edgenetobjects, tenant)
+			f.kubeobjects = append(f.kubeobjects, kubenamespace)
+
+			// The spec is updated first, provisioning follows with the normalized name
+			normalized := tenant.DeepCopy()
+			normalized.Spec.ShortName = tc.expected
+			f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+			f.expectUpdateTenantAction(normalized)
+
+			f.run(getKey(tenant, t))
+
+			normalizedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting tenant: %v", err)
+			}
+			if normalizedTenant.Spec.ShortName != tc.expected {
+				t.Errorf("expected short name %q, got %q", tc.expected, normalizedTenant.Spec.ShortName)
+			}
+		})
+	}
+}
+
+func TestDeletionProtection(t *testing.T) {
+	cases := map[string]struct {
+		protected       bool
+		deleting        bool
+		finalizers      []string
+		expectUpdate    bool
+		expectFinalizer bool
+		expectMessage   string
+	}{
+		"protection turned on":  {true, false, nil, true, true, messageEmailDomainRejected},
+		"deletion blocked":      {true, true, []string{finalizer.TenantDeletionProtection}, false, true, messageDeletionProtected},
+		"protection turned off": {false, true, []string{finalizer.TenantDeletionProtection}, true, false, messageEmailDomainRejected},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			f := newFixture(t)
+			// The tenant is left aside by the provisioning, so that only the protection acts on it
+			f.allowedEmailDomains = []string{"edge-net.org"}
+			tenant := newTenant("tenant15", true, true)
+			tenant.Spec.DeletionProtection = tc.protected
+			tenant.SetFinalizers(tc.finalizers)
+			if tc.deleting {
+				deletionTimestamp := metav1.Now()
+				tenant.SetDeletionTimestamp(&deletionTimestamp)
+			}
+			tenant.Status.State = corev1alpha1.StatusRejected
+			tenant.Status.Message = messageEmailDomainRejected
+
+			kubenamespace := newNamespace("kube-system", nil, nil, nil)
+
+			f.tenantLister = append(f.tenantLister, tenant)
+			f.edgenetobjects = append(f.edgenetobjects, tenant)
+			f.kubeobjects = append(f.kubeobjects, kubenamespace)
+
+			f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+			if tc.expectUpdate {
+				updatedTenant := tenant.DeepCopy()
+				if tc.expectFinalizer {
+					updatedTenant.SetFinalizers([]string{finalizer.TenantDeletionProtection})
+				} else {
+					updatedTenant.SetFinalizers(nil)
+				}
+				f.expectUpdateTenantAction(updatedTenant)
+			}
+			if tc.expectMessage == messageDeletionProtected {
+				f.expectUpdateTenantStatusAction(tenant)
+			}
+
+			f.run(getKey(tenant, t))
+
+			retainedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting tenant: %v", err)
+			}
+			if finalizer.Has(retainedTenant, finalizer.TenantDeletionProtection) != tc.expectFinalizer {
+				t.Errorf("expected deletion protection finalizer %t, got finalizers %v", tc.expectFinalizer, retainedTenant.GetFinalizers())
+			}
+			if retainedTenant.Status.Message != tc.expectMessage {
+				t.Errorf("expected status message %q, got %q", tc.expectMessage, retainedTenant.Status.Message)
+			}
+		})
+	}
+}
+
 func TestTenantEstablishment(t *testing.T) {
 	f := newFixture(t)
 	tenant := newTenant("tenant2", true, true)
 	tenant.Status.Failed = 0
-	tenant.Status.State = corev1alpha1.StatusCoreNamespaceCreated
+	tenant.Status.State = corev1alpha1.StatusEstablishing
 	tenant.Status.Message = messageCreated
 
 	kubenamespace := newNamespace("kube-system", nil, nil, nil)
 	namespace := newNamespace(tenant.GetName(), map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": ""}, map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
-	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true"})
+	clusterrole := newClusterRole(tenant.GetName(), tenant.GetName(), []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	clusterrolebinding := newClusterRoleBinding(tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID())})
 	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"edge-net.io/subtenant": "false", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": string(kubenamespace.GetUID())}}
 	networkpolicy := newNetworkPolicy("baseline", tenant.GetName(), labelSelector)
 	clusternetworkpolicy := newClusterNetworkPolicy(tenant.GetName(), labelSelector, []metav1.OwnerReference{tenant.MakeOwnerReference()})
@@ -590,12 +930,19 @@ func TestTenantEstablishment(t *testing.T) {
 	f.edgenetobjects = append(f.edgenetobjects, tenant)
 
 	f.namespaceLister = append(f.namespaceLister, kubenamespace, namespace)
+	f.clusterroleLister = append(f.clusterroleLister, clusterrole)
+	f.clusterrolebindingLister = append(f.clusterrolebindingLister, clusterrolebinding)
 	f.networkpolicyLister = append(f.networkpolicyLister, networkpolicy)
 	f.clusternetworkpolicyLister = append(f.clusternetworkpolicyLister, clusternetworkpolicy)
 	f.rolebindingLister = append(f.rolebindingLister, rolebinding)
 	f.kubeobjects = append(f.kubeobjects, kubenamespace, namespace)
 
 	f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+	f.expectCreateNamespaceAction(namespace)
+	f.expectGetRootAction(namespace.GetName(), "namespaces", "kube")
+	f.expectUpdateNamespaceAction(namespace)
+	f.expectCreateClusterRoleAction(clusterrole)
+	f.expectCreateClusterRoleBindingAction(clusterrolebinding)
 	f.expectCreateNetworkPolicyAction(networkpolicy)
 	f.expectCreateClusterNetworkPolicyAction(clusternetworkpolicy)
 	f.expectCreateRoleBindingAction(rolebinding)
@@ -604,6 +951,248 @@ func TestTenantEstablishment(t *testing.T) {
 	f.run(getKey(tenant, t))
 }
 
+func TestTenantEstablishmentDefaultRoles(t *testing.T) {
+	f := newFixture(t)
+	f.defaultRoles = []string{"edgenet:project-template", "templates/developer"}
+	tenant := newTenant("tenant2b", false, true)
+	tenant.Status.Failed = 0
+	tenant.Status.State = corev1alpha1.StatusEstablishing
+	tenant.Status.Message = messageCreated
+
+	kubenamespace := newNamespace("kube-system", nil, nil, nil)
+	rules := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list", "watch"}}}
+	clusterRoleTemplate := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "edgenet:project-template"}, Rules: rules}
+	roleTemplate := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "developer", Namespace: "templates"}, Rules: rules}
+
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, tenant)
+	f.kubeobjects = append(f.kubeobjects, kubenamespace, clusterRoleTemplate, roleTemplate)
+
+	c, edgei := f.newController()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	edgei.Start(stopCh)
+
+	// The default roles are left as they are on the next passes
+	for i := 0; i < 2; i++ {
+		if err := c.syncHandler(getKey(tenant, t)); err != nil {
+			t.Fatalf("error syncing tenant: %v", err)
+		}
+		updatedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("error getting tenant: %v", err)
+		}
+		if updatedTenant.Status.State != corev1alpha1.StatusEstablished {
+			t.Errorf("expected tenant state %q, got %q", corev1alpha1.StatusEstablished, updatedTenant.Status.State)
+		}
+		roles, err := f.kubeclientset.RbacV1().Roles(tenant.GetName()).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("error listing roles: %v", err)
+		}
+		if len(roles.Items) != 2 {
+			t.Fatalf("expected 2 default roles, got %d", len(roles.Items))
+		}
+		for _, role := range roles.Items {
+			if role.GetName() != clusterRoleTemplate.GetName() && role.GetName() != roleTemplate.GetName() {
+				t.Errorf("unexpected role %s", role.GetName())
+			}
+			if !reflect.DeepEqual(role.Rules, rules) {
+				t.Errorf("expected role %s to have the rules of its template, got %v", role.GetName(), role.Rules)
+			}
+			if role.GetLabels()["edge-net.io/tenant"] != tenant.GetName() {
+				t.Errorf("expected role %s to be labeled with the tenant, got %v", role.GetName(), role.GetLabels())
+			}
+		}
+		edgei.Core().V1alpha1().Tenants().Informer().GetIndexer().Update(updatedTenant)
+	}
+}
+
+func TestTenantDefaultRoleMissing(t *testing.T) {
+	f := newFixture(t)
+	f.defaultRoles = []string{"edgenet:missing-template"}
+	tenant := newTenant("tenant2c", false, true)
+	tenant.Status.Failed = 0
+	tenant.Status.State = corev1alpha1.StatusEstablishing
+	tenant.Status.Message = messageCreated
+
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, tenant)
+	f.kubeobjects = append(f.kubeobjects, newNamespace("kube-system", nil, nil, nil))
+
+	c, edgei := f.newController()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	edgei.Start(stopCh)
+
+	if err := c.syncHandler(getKey(tenant, t)); err != nil {
+		t.Fatalf("error syncing tenant: %v", err)
+	}
+	updatedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting tenant: %v", err)
+	}
+	if expected := fmt.Sprintf(messageDefaultRoleFailed, "edgenet:missing-template"); updatedTenant.Status.State != corev1alpha1.StatusFailed || updatedTenant.Status.Message != expected {
+		t.Errorf("expected tenant to fail with %q, got %q: %q", expected, updatedTenant.Status.State, updatedTenant.Status.Message)
+	}
+}
+
+func TestTenantPriorityClass(t *testing.T) {
+	cases := map[string]struct {
+		priority string
+		expected bool
+	}{
+		"within band":  {"5000", true},
+		"above band":   {"2000000000", false},
+		"not a number": {"high", false},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			f := newFixture(t)
+			f.priorityBand = PriorityBand{Min: 1000, Max: 10000}
+			tenant := newTenant("tenant2d", false, true)
+			tenant.Status.Failed = 0
+			tenant.Status.State = corev1alpha1.StatusEstablishing
+			tenant.Status.Message = messageCreated
+			tenant.SetAnnotations(map[string]string{PriorityAnnotation: tc.priority})
+
+			f.tenantLister = append(f.tenantLister, tenant)
+			f.edgenetobjects = append(f.edgenetobjects, tenant)
+			f.kubeobjects = append(f.kubeobjects, newNamespace("kube-system", nil, nil, nil))
+
+			c, edgei := f.newController()
+			recorder := record.NewFakeRecorder(20)
+			c.recorder = recorder
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			edgei.Start(stopCh)
+
+			if err := c.syncHandler(getKey(tenant, t)); err != nil {
+				t.Fatalf("error syncing tenant: %v", err)
+			}
+			priorityClass, err := f.kubeclientset.SchedulingV1().PriorityClasses().Get(context.TODO(), priorityClassName(tenant.GetName()), metav1.GetOptions{})
+			if tc.expected != (err == nil) {
+				t.Fatalf("expected priority class to exist %t, got error %v", tc.expected, err)
+			}
+			namespace, err := f.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting core namespace: %v", err)
+			}
+			if _, elementExists := namespace.GetAnnotations()[multitenancy.DefaultPriorityClassAnnotation]; elementExists != tc.expected {
+				t.Errorf("expected default priority class annotation %t, got %v", tc.expected, namespace.GetAnnotations())
+			}
+			updatedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting tenant: %v", err)
+			}
+			if updatedTenant.Status.State != corev1alpha1.StatusEstablished {
+				t.Errorf("expected tenant state %q, got %q", corev1alpha1.StatusEstablished, updatedTenant.Status.State)
+			}
+			if tc.expected {
+				if priorityClass.Value != 5000 || priorityClass.GetLabels()["edge-net.io/tenant"] != tenant.GetName() {
+					t.Errorf("unexpected priority class %v", priorityClass)
+				}
+				return
+			}
+			rejected := false
+			for len(recorder.Events) > 0 {
+				if strings.Contains(<-recorder.Events, failurePriority) {
+					rejected = true
+				}
+			}
+			if !rejected {
+				t.Errorf("expected the priority to be rejected")
+			}
+		})
+	}
+}
+
+func TestTenantPriorityClassReconcile(t *testing.T) {
+	f := newFixture(t)
+	f.priorityBand = PriorityBand{Min: 1000, Max: 10000}
+	tenant := newTenant("tenant2e", false, true)
+	tenant.Status.Failed = 0
+	tenant.Status.State = corev1alpha1.StatusEstablishing
+	tenant.Status.Message = messageCreated
+
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, tenant)
+	f.kubeobjects = append(f.kubeobjects, newNamespace("kube-system", nil, nil, nil))
+
+	c, edgei := f.newController()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	edgei.Start(stopCh)
+
+	// An established tenant requests a priority class, and then gives it up
+	for _, priority := range []string{"", "5000", ""} {
+		updatedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("error getting tenant: %v", err)
+		}
+		if priority != "" {
+			updatedTenant.SetAnnotations(map[string]string{PriorityAnnotation: priority})
+		} else {
+			updatedTenant.SetAnnotations(nil)
+		}
+		edgei.Core().V1alpha1().Tenants().Informer().GetIndexer().Update(updatedTenant)
+		// The first pass finds the tenant out of date, and the second one establishes it again
+		for i := 0; i < 2; i++ {
+			if err := c.syncHandler(getKey(tenant, t)); err != nil {
+				t.Fatalf("error syncing tenant: %v", err)
+			}
+			updatedTenant, err = f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting tenant: %v", err)
+			}
+			if priority != "" {
+				updatedTenant.SetAnnotations(map[string]string{PriorityAnnotation: priority})
+			}
+			edgei.Core().V1alpha1().Tenants().Informer().GetIndexer().Update(updatedTenant)
+		}
+		if updatedTenant.Status.State != corev1alpha1.StatusEstablished {
+			t.Errorf("expected tenant state %q, got %q", corev1alpha1.StatusEstablished, updatedTenant.Status.State)
+		}
+		_, err = f.kubeclientset.SchedulingV1().PriorityClasses().Get(context.TODO(), priorityClassName(tenant.GetName()), metav1.GetOptions{})
+		if expected := priority != ""; expected != (err == nil) {
+			t.Errorf("expected priority class to exist %t, got error %v", expected, err)
+		}
+		namespace, err := f.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("error getting core namespace: %v", err)
+		}
+		if annotation := namespace.GetAnnotations()[multitenancy.DefaultPriorityClassAnnotation]; (priority != "") != (annotation == priorityClassName(tenant.GetName())) {
+			t.Errorf("expected default priority class annotation %t, got %v", priority != "", namespace.GetAnnotations())
+		}
+	}
+}
+
+func TestParsePriorityBand(t *testing.T) {
+	cases := map[string]struct {
+		band     string
+		expected PriorityBand
+		valid    bool
+	}{
+		"empty":       {"", PriorityBand{}, true},
+		"valid":       {"1000-10000", PriorityBand{Min: 1000, Max: 10000}, true},
+		"spaces":      {" 0 - 100 ", PriorityBand{Min: 0, Max: 100}, true},
+		"reversed":    {"10000-1000", PriorityBand{}, false},
+		"system":      {"1000-2000000000", PriorityBand{}, false},
+		"single":      {"1000", PriorityBand{}, false},
+		"not numeric": {"low-high", PriorityBand{}, false},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			band, err := ParsePriorityBand(tc.band)
+			if tc.valid != (err == nil) {
+				t.Fatalf("expected valid %t, got error %v", tc.valid, err)
+			}
+			if band != tc.expected {
+				t.Errorf("expected band %v, got %v", tc.expected, band)
+			}
+		})
+	}
+}
+
 func TestTenantDisabled(t *testing.T) {
 	f := newFixture(t)
 	tenant := newTenant("tenant3", true, false)
@@ -615,7 +1204,7 @@ func TestTenantDisabled(t *testing.T) {
 	namespace := newNamespace(tenant.GetName(), map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": ""}, map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
 	clusterrole := newClusterRole(tenant.GetName(), tenant.GetName(), []metav1.OwnerReference{tenant.MakeOwnerReference()})
 	clusterrolebinding := newClusterRoleBinding(tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
-	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true"})
+	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID())})
 	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"edge-net.io/subtenant": "false", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": string(kubenamespace.GetUID())}}
 
 	networkpolicy := newNetworkPolicy("baseline", tenant.GetName(), labelSelector)
@@ -655,7 +1244,7 @@ func TestReconcileDoNothing(t *testing.T) {
 	namespace := newNamespace(tenant.GetName(), map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": ""}, map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
 	clusterrole := newClusterRole(tenant.GetName(), tenant.GetName(), []metav1.OwnerReference{tenant.MakeOwnerReference()})
 	clusterrolebinding := newClusterRoleBinding(tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
-	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true"})
+	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID())})
 	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"edge-net.io/subtenant": "false", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": string(kubenamespace.GetUID())}}
 
 	networkpolicy := newNetworkPolicy("baseline", tenant.GetName(), labelSelector)
@@ -677,6 +1266,7 @@ func TestReconcileDoNothing(t *testing.T) {
 	f.expectGetAction(rolebinding.GetName(), rolebinding.GetNamespace(), "rolebindings")
 	f.expectGetAction(networkpolicy.GetName(), networkpolicy.GetNamespace(), "networkpolicies")
 	f.expectGetRootAction(clusternetworkpolicy.GetName(), "clusternetworkpolicies", "antrea")
+	f.expectGetRootAction(clusterrole.GetName(), "clusterroles", "kube")
 	f.expectGetRootAction(clusterrolebinding.GetName(), "clusterrolebindings", "kube")
 	f.expectGetRootAction(namespace.GetName(), "namespaces", "kube")
 
@@ -692,8 +1282,9 @@ func TestReconcile(t *testing.T) {
 
 	kubenamespace := newNamespace("kube-system", nil, nil, nil)
 	namespace := newNamespace(tenant.GetName(), map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": ""}, map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	clusterrole := newClusterRole(tenant.GetName(), tenant.GetName(), []metav1.OwnerReference{tenant.MakeOwnerReference()})
 	clusterrolebinding := newClusterRoleBinding(tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
-	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true"})
+	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID())})
 	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"edge-net.io/subtenant": "false", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": string(kubenamespace.GetUID())}}
 	networkpolicy := newNetworkPolicy("baseline", tenant.GetName(), labelSelector)
 	clusternetworkpolicy := newClusterNetworkPolicy(tenant.GetName(), labelSelector, []metav1.OwnerReference{tenant.MakeOwnerReference()})
@@ -707,6 +1298,7 @@ func TestReconcile(t *testing.T) {
 	f.expectGetAction(rolebinding.GetName(), rolebinding.GetNamespace(), "rolebindings")
 	f.expectGetAction(networkpolicy.GetName(), networkpolicy.GetNamespace(), "networkpolicies")
 	f.expectGetRootAction(clusternetworkpolicy.GetName(), "clusternetworkpolicies", "antrea")
+	f.expectGetRootAction(clusterrole.GetName(), "clusterroles", "kube")
 	f.expectGetRootAction(clusterrolebinding.GetName(), "clusterrolebindings", "kube")
 	f.expectGetRootAction(namespace.GetName(), "namespaces", "kube")
 	f.expectUpdateTenantStatusAction(tenant)
@@ -725,7 +1317,7 @@ func TestReconcileThroughStatusCoreNamespaceCreated(t *testing.T) {
 	namespace := newNamespace(tenant.GetName(), map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": ""}, map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
 	clusterrole := newClusterRole(tenant.GetName(), tenant.GetName(), []metav1.OwnerReference{tenant.MakeOwnerReference()})
 	clusterrolebinding := newClusterRoleBinding(tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
-	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true"})
+	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID())})
 	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"edge-net.io/subtenant": "false", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": string(kubenamespace.GetUID())}}
 
 	networkpolicy := newNetworkPolicy("baseline", tenant.GetName(), labelSelector)
@@ -744,6 +1336,15 @@ func TestReconcileThroughStatusCoreNamespaceCreated(t *testing.T) {
 	f.antreaobjects = append(f.antreaobjects, clusternetworkpolicy)
 
 	f.expectGetRootAction(kubenamespace.GetName(), "namespaces", "kube")
+	f.expectCreateNamespaceAction(namespace)
+	f.expectGetRootAction(namespace.GetName(), "namespaces", "kube")
+	f.expectUpdateNamespaceAction(namespace)
+	f.expectCreateClusterRoleAction(clusterrole)
+	f.expectGetRootAction(clusterrole.GetName(), "clusterroles", "kube")
+	f.expectUpdateClusterRoleAction(clusterrole)
+	f.expectCreateClusterRoleBindingAction(clusterrolebinding)
+	f.expectGetRootAction(clusterrolebinding.GetName(), "clusterrolebindings", "kube")
+	f.expectUpdateClusterRoleBindingAction(clusterrolebinding)
 	f.expectCreateNetworkPolicyAction(networkpolicy)
 	f.expectDeleteClusterNetworkPolicyAction(clusternetworkpolicy.GetName())
 	f.expectCreateRoleBindingAction(rolebinding)
@@ -788,3 +1389,198 @@ func TestReconcileThroughStatusReconciliation(t *testing.T) {
 
 	f.run(getKey(tenant, t))
 }
+
+func TestReconcileMissingRoleBinding(t *testing.T) {
+	f := newFixture(t)
+	tenant := newTenant("tenant8", false, true)
+	tenant.Status.Failed = 0
+	tenant.Status.State = corev1alpha1.StatusEstablished
+	tenant.Status.Message = messageEstablished
+
+	kubenamespace := newNamespace("kube-system", nil, nil, nil)
+	namespace := newNamespace(tenant.GetName(), map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": ""}, map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	clusterrole := newClusterRole(tenant.GetName(), tenant.GetName(), []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	clusterrolebinding := newClusterRoleBinding(tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"edge-net.io/subtenant": "false", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": string(kubenamespace.GetUID())}}
+	networkpolicy := newNetworkPolicy("baseline", tenant.GetName(), labelSelector)
+
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, tenant)
+	// The owner role binding is missing, as if the tenant controller failed midway
+	f.kubeobjects = append(f.kubeobjects, kubenamespace, namespace, clusterrole, clusterrolebinding, networkpolicy)
+
+	c, edgei := f.newController()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	edgei.Start(stopCh)
+
+	// The first pass detects the missing role binding
+	if err := c.syncHandler(getKey(tenant, t)); err != nil {
+		t.Fatalf("error syncing tenant: %v", err)
+	}
+	updatedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting tenant: %v", err)
+	}
+	if updatedTenant.Status.State != corev1alpha1.StatusEstablishing {
+		t.Errorf("expected tenant state %q, got %q", corev1alpha1.StatusEstablishing, updatedTenant.Status.State)
+	}
+
+	// The next pass completes the missing pieces
+	edgei.Core().V1alpha1().Tenants().Informer().GetIndexer().Update(updatedTenant)
+	if err := c.syncHandler(getKey(tenant, t)); err != nil {
+		t.Fatalf("error syncing tenant: %v", err)
+	}
+	if _, err := f.kubeclientset.RbacV1().RoleBindings(tenant.GetName()).Get(context.TODO(), corev1alpha1.TenantOwnerClusterRoleName, metav1.GetOptions{}); err != nil {
+		t.Errorf("expected owner role binding to be recreated: %v", err)
+	}
+	updatedTenant, err = f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting tenant: %v", err)
+	}
+	if updatedTenant.Status.State != corev1alpha1.StatusEstablished {
+		t.Errorf("expected tenant state %q, got %q", corev1alpha1.StatusEstablished, updatedTenant.Status.State)
+	}
+}
+
+func TestReconcileMissingOwnerClusterRoleBinding(t *testing.T) {
+	f := newFixture(t)
+	tenant := newTenant("tenant8b", false, true)
+	tenant.Status.Failed = 0
+	tenant.Status.State = corev1alpha1.StatusEstablished
+	tenant.Status.Message = messageEstablished
+
+	kubenamespace := newNamespace("kube-system", nil, nil, nil)
+	namespace := newNamespace(tenant.GetName(), map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": ""}, map[string]string{"scheduler.alpha.kubernetes.io/node-selector": "edge-net.io/access=public,edge-net.io/slice=none"}, []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	clusterrole := newClusterRole(tenant.GetName(), tenant.GetName(), []metav1.OwnerReference{tenant.MakeOwnerReference()})
+	rolebinding := newRoleBinding(corev1alpha1.TenantOwnerClusterRoleName, tenant.GetName(), tenant.Spec.Contact.Email, map[string]string{"edge-net.io/generated": "true", "edge-net.io/notification": "true", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID())})
+	labelSelector := metav1.LabelSelector{MatchLabels: map[string]string{"edge-net.io/subtenant": "false", "edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID()), "edge-net.io/cluster-uid": string(kubenamespace.GetUID())}}
+	networkpolicy := newNetworkPolicy("baseline", tenant.GetName(), labelSelector)
+
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, tenant)
+	// The owner cluster role binding is deleted out-of-band
+	f.kubeobjects = append(f.kubeobjects, kubenamespace, namespace, clusterrole, rolebinding, networkpolicy)
+
+	c, edgei := f.newController()
+	recorder := record.NewFakeRecorder(10)
+	c.recorder = recorder
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	edgei.Start(stopCh)
+
+	if err := c.syncHandler(getKey(tenant, t)); err != nil {
+		t.Fatalf("error syncing tenant: %v", err)
+	}
+	clusterrolebinding, err := f.kubeclientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), fmt.Sprintf("edgenet:tenants:%s-owner", tenant.GetName()), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected owner cluster role binding to be restored: %v", err)
+	}
+	if !bindsUser(clusterrolebinding.Subjects, tenant.Spec.Contact.Email) {
+		t.Errorf("expected owner cluster role binding to bind %q, got %v", tenant.Spec.Contact.Email, clusterrolebinding.Subjects)
+	}
+	isRestoreRecorded := false
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; event == fmt.Sprintf("%s %s %s", corev1.EventTypeNormal, successRestored, messageOwnerBindingRestored) {
+			isRestoreRecorded = true
+		}
+	}
+	if !isRestoreRecorded {
+		t.Errorf("expected event %q to be recorded", successRestored)
+	}
+	// The tenant is repaired in place, without going back to establishing
+	updatedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting tenant: %v", err)
+	}
+	if updatedTenant.Status.State != corev1alpha1.StatusEstablished {
+		t.Errorf("expected tenant state %q, got %q", corev1alpha1.StatusEstablished, updatedTenant.Status.State)
+	}
+}
+
+func TestTenantEstablishmentRetriesOwnerBinding(t *testing.T) {
+	f := newFixture(t)
+	tenant := newTenant("tenant9", false, true)
+	tenant.Status.Failed = 0
+	tenant.Status.State = corev1alpha1.StatusEstablishing
+	tenant.Status.Message = messageCreated
+
+	kubenamespace := newNamespace("kube-system", nil, nil, nil)
+	f.tenantLister = append(f.tenantLister, tenant)
+	f.edgenetobjects = append(f.edgenetobjects, tenant)
+	f.kubeobjects = append(f.kubeobjects, kubenamespace)
+
+	c, edgei := f.newController()
+	c.ownerBindingBackoff = wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 2.0}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	edgei.Start(stopCh)
+
+	// The first creation of the owner role binding fails as if the API server were briefly unavailable
+	failures := 1
+	f.kubeclientset.PrependReactor("create", "rolebindings", func(action core.Action) (bool, runtime.Object, error) {
+		if failures > 0 {
+			failures--
+			return true, nil, errors.NewServiceUnavailable("temporarily unavailable")
+		}
+		return false, nil, nil
+	})
+
+	if err := c.syncHandler(getKey(tenant, t)); err != nil {
+		t.Fatalf("error syncing tenant: %v", err)
+	}
+	if _, err := f.kubeclientset.RbacV1().RoleBindings(tenant.GetName()).Get(context.TODO(), corev1alpha1.TenantOwnerClusterRoleName, metav1.GetOptions{}); err != nil {
+		t.Errorf("expected owner role binding to be created: %v", err)
+	}
+	updatedTenant, err := f.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant.GetName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting tenant: %v", err)
+	}
+	if updatedTenant.Status.State != corev1alpha1.StatusEstablished {
+		t.Errorf("expected tenant state %q, got %q", corev1alpha1.StatusEstablished, updatedTenant.Status.State)
+	}
+	if updatedTenant.Status.Failed != 0 {
+		t.Errorf("expected no failure to be counted, got %d", updatedTenant.Status.Failed)
+	}
+
+	// The retry is surfaced in the status before the tenant is established
+	var messages []string
+	for _, action := range f.edgenetclientset.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+			messages = append(messages, action.(core.UpdateAction).GetObject().(*corev1alpha1.Tenant).Status.Message)
+		}
+	}
+	if !reflect.DeepEqual(messages, []string{messageBindingRetrying, messageEstablished}) {
+		t.Errorf("expected status messages %q, got %q", []string{messageBindingRetrying, messageEstablished}, messages)
+	}
+}
+
+func TestEventNamespace(t *testing.T) {
+	tenant := newTenant("tenant1", true, true)
+	kubeclientset := k8sfake.NewSimpleClientset()
+	edgenetclientset := edgenetfake.NewSimpleClientset()
+	edgeinformer := edgeinformers.NewSharedInformerFactory(edgenetclientset, noResyncPeriodFunc())
+	controller, err := NewController(kubeclientset, edgenetclientset, antreafake.NewSimpleClientset(),
+		edgeinformer.Core().V1alpha1().Tenants(), nil, "edgenet-events", nil, nil, edgeinformer.Core().V1alpha1().TenantResourceQuotas(), nil, PriorityBand{})
+	if err != nil {
+		t.Fatalf("controller not created: %v", err)
+	}
+
+	// Tenants are cluster-scoped, so their events would otherwise be recorded in the default namespace
+	controller.recorder.Event(tenant, corev1.EventTypeNormal, corev1alpha1.StatusEstablished, messageEstablished)
+	var events *corev1.EventList
+	err = wait.PollImmediate(50*time.Millisecond, 5*time.Second, func() (bool, error) {
+		var err error
+		events, err = kubeclientset.CoreV1().Events("").List(context.TODO(), metav1.ListOptions{})
+		return err == nil && len(events.Items) > 0, err
+	})
+	if err != nil {
+		t.Fatalf("event not recorded: %v", err)
+	}
+	if len(events.Items) != 1 || events.Items[0].GetNamespace() != "edgenet-events" {
+		t.Fatalf("Expected a single event in namespace edgenet-events, got %+v", events.Items)
+	}
+	if events.Items[0].InvolvedObject.Name != tenant.GetName() || events.Items[0].Reason != corev1alpha1.StatusEstablished {
+		t.Errorf("Unexpected event %+v", events.Items[0])
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PriorityAnnotation is the annotation through which a tenant requests a priority class of its own, set to the
+// priority value of its workloads
+const PriorityAnnotation = "edge-net.io/priority"
+
+// highestUserDefinablePriority is the highest priority value of a priority class not reserved for the system
+const highestUserDefinablePriority = 1000000000
+
+// PriorityBand is the range of priority values the tenants can request for their priority class, so that none can
+// preempt the workloads of the cluster or of the other tenants at will. The zero band disables the priority classes.
+type PriorityBand struct {
+	Min int32
+	Max int32
+}
+
+// ParsePriorityBand parses a band given as min-max, such as 1000-10000. An empty band disables the priority classes.
+func ParsePriorityBand(band string) (PriorityBand, error) {
+	if band = strings.TrimSpace(band); band == "" {
+		return PriorityBand{}, nil
+	}
+	bounds := strings.Split(band, "-")
+	if len(bounds) != 2 {
+		return PriorityBand{}, fmt.Errorf("priority band %q must be given as min-max", band)
+	}
+	min, err := strconv.ParseInt(strings.TrimSpace(bounds[0]), 10, 32)
+	if err != nil {
+		return PriorityBand{}, fmt.Errorf("priority band %q has an invalid minimum: %w", band, err)
+	}
+	max, err := strconv.ParseInt(strings.TrimSpace(bounds[1]), 10, 32)
+	if err != nil {
+		return PriorityBand{}, fmt.Errorf("priority band %q has an invalid maximum: %w", band, err)
+	}
+	if min > max || max > highestUserDefinablePriority {
+		return PriorityBand{}, fmt.Errorf("priority band %q must be ordered and not exceed %d", band, highestUserDefinablePriority)
+	}
+	return PriorityBand{Min: int32(min), Max: int32(max)}, nil
+}
+
+// Enabled tells whether the tenants can request a priority class
+func (b PriorityBand) Enabled() bool {
+	return b != PriorityBand{}
+}
+
+// Contains tells whether the value lies within the band
+func (b PriorityBand) Contains(value int32) bool {
+	return value >= b.Min && value <= b.Max && value <= highestUserDefinablePriority
+}
+
+// priorityClassName returns the name of the priority class of the tenant
+func priorityClassName(tenant string) string {
+	return fmt.Sprintf("tenant-%s", tenant)
+}
+
+// requestedPriority returns the priority value the tenant requests, if any, and whether it is allowed by the band
+func (c *Controller) requestedPriority(tenantCopy *corev1alpha1.Tenant) (int32, bool, bool) {
+	annotation, elementExists := tenantCopy.GetAnnotations()[PriorityAnnotation]
+	if !elementExists || !c.priorityBand.Enabled() {
+		return 0, false, false
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(annotation), 10, 32)
+	if err != nil {
+		return 0, true, false
+	}
+	return int32(value), true, c.priorityBand.Contains(int32(value))
+}
+
+// isPriorityClassReconciled tells whether the priority class of the tenant, and the annotation through which the
+// workloads in its namespaces inherit it, match the priority the tenant requests. The priority classes are left
+// alone while they are disabled.
+func (c *Controller) isPriorityClassReconciled(tenantCopy *corev1alpha1.Tenant, coreNamespace *corev1.Namespace) bool {
+	if !c.priorityBand.Enabled() {
+		return true
+	}
+	value, _, allowed := c.requestedPriority(tenantCopy)
+	annotation, annotated := coreNamespace.GetAnnotations()[multitenancy.DefaultPriorityClassAnnotation]
+	priorityClass, err := c.kubeclientset.SchedulingV1().PriorityClasses().Get(context.TODO(), priorityClassName(tenantCopy.GetName()), metav1.GetOptions{})
+	if !allowed {
+		return !annotated && (err != nil || !ownsPriorityClass(priorityClass, tenantCopy.GetName()))
+	}
+	return err == nil && priorityClass.Value == value && annotated && annotation == priorityClass.GetName()
+}
+
+// ownsPriorityClass tells whether the priority class is the one generated for the tenant
+func ownsPriorityClass(priorityClass *schedulingv1.PriorityClass, tenant string) bool {
+	return priorityClass.GetLabels()["edge-net.io/generated"] == "true" && priorityClass.GetLabels()["edge-net.io/tenant"] == tenant
+}
+
+// applyPriorityClass creates the priority class the tenant requests, or re-creates it if its value has changed, as
+// the value of a priority class cannot be updated. A value outside of the band is refused with a warning, leaving
+// the tenant without a priority class, and so is a tenant that no longer requests one.
+func (c *Controller) applyPriorityClass(tenantCopy *corev1alpha1.Tenant, ownerReferences []metav1.OwnerReference) error {
+	if !c.priorityBand.Enabled() {
+		return nil
+	}
+	value, requested, allowed := c.requestedPriority(tenantCopy)
+	if requested && !allowed {
+		c.recorder.Event(tenantCopy, corev1.EventTypeWarning, failurePriority, fmt.Sprintf(messagePriorityRejected, tenantCopy.GetAnnotations()[PriorityAnnotation], c.priorityBand.Min, c.priorityBand.Max))
+	}
+	if !allowed {
+		current, err := c.kubeclientset.SchedulingV1().PriorityClasses().Get(context.TODO(), priorityClassName(tenantCopy.GetName()), metav1.GetOptions{})
+		if err != nil || !ownsPriorityClass(current, tenantCopy.GetName()) {
+			return nil
+		}
+		if err := c.kubeclientset.SchedulingV1().PriorityClasses().Delete(context.TODO(), current.GetName(), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	priorityClass := &schedulingv1.PriorityClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: priorityClassName(tenantCopy.GetName()), OwnerReferences: ownerReferences},
+		Value:       value,
+		Description: fmt.Sprintf("Priority of the workloads of tenant %s", tenantCopy.GetName()),
+	}
+	priorityClass.SetLabels(map[string]string{"edge-net.io/generated": "true", "edge-net.io/tenant": tenantCopy.GetName()})
+	multitenancy.StampLabels(priorityClass, multitenancy.TenantLabels(tenantCopy, c.tenantLabelKeys))
+	current, err := c.kubeclientset.SchedulingV1().PriorityClasses().Get(context.TODO(), priorityClass.GetName(), metav1.GetOptions{})
+	if err == nil {
+		if current.Value == value {
+			return nil
+		}
+		if err := c.kubeclientset.SchedulingV1().PriorityClasses().Delete(context.TODO(), current.GetName(), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+	_, err = c.kubeclientset.SchedulingV1().PriorityClasses().Create(context.TODO(), priorityClass, metav1.CreateOptions{})
+	return err
+}
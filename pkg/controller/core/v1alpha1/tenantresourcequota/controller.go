@@ -20,21 +20,30 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	edgenetscheme "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/core/v1alpha1"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
+	"github.com/EdgeNet-project/edgenet/pkg/metrics"
 	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/notification"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	coreinformers "k8s.io/client-go/informers/core/v1"
@@ -43,12 +52,35 @@ import (
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
+	"sigs.k8s.io/yaml"
 )
 
 const controllerAgentName = "tenantresourcequota-controller"
 
+// Quota profiles are defined in a config map, each key of which is the name of a profile
+// and each value a resource list in YAML, e.g. "cpu: 2\nmemory: 4Gi"
+const (
+	quotaProfilesNamespace = "edgenet"
+	quotaProfilesName      = "quota-profiles"
+)
+
+// quotaDryRunAnnotation, set to true on a tenant, holds back the resource quota of its core namespace, so that
+// only the quota projected in the status of the tenant can be reviewed
+const quotaDryRunAnnotation = "edge-net.io/quota-dry-run"
+
+// Quota utilization of the tenants, exposed for the dashboards of the platform teams
+var (
+	tenantQuotaUsed      = metrics.NewGaugeVec("edgenet_tenant_quota_used", "Quota in use by the tenant, summed over its namespaces.", "tenant", "resource")
+	tenantQuotaAllocated = metrics.NewGaugeVec("edgenet_tenant_quota_allocated", "Quota allocated to the tenant by its tenant resource quota.", "tenant", "resource")
+)
+
+func init() {
+	metrics.MustRegister(tenantQuotaUsed, tenantQuotaAllocated)
+}
+
 // Definitions of the state of the tenantresourcequota resource
 const (
 	backoffLimit = 3
@@ -60,6 +92,12 @@ const (
 	successDeleted          = "Deleted"
 	successRemoved          = "Removed"
 	warningNotFound         = "Not Found"
+	warningQuotaAlert       = "Quota Alert"
+	warningExpiryNotice     = "Expiry Notice"
+	failureProfile          = "Profile Invalid"
+	failureCapacity         = "Capacity Share Invalid"
+	successAggregated       = "Aggregated"
+	successDryRun           = "Dry Run"
 
 	messageResourceSynced   = "Tenant Resource Quota synced successfully"
 	messageTraversalStarted = "Namespace traversal initiated successfully"
@@ -71,6 +109,12 @@ const (
 	messageQuotaCreated     = "Core resource quota created"
 	messageReconciliation   = "Reconciliation in progress"
 	messageApplied          = "Tenant Resource Quota applied to tenant's namespaces"
+	messageQuotaAlert       = "%s usage reached %d%% of the tenant quota"
+	messageExpiryNotice     = "Claimed resources about to expire: %s"
+	messageProfileFail      = "Quota profile cannot be expanded"
+	messageCapacityFail     = "Share of the cluster capacity cannot be computed"
+	messageAggregated       = "Tenant Resource Quota aggregated into the quota of tenant %s"
+	messageDryRun           = "Resource quota held back by the quota dry run of the tenant"
 )
 
 type traverseStatus struct {
@@ -85,6 +129,11 @@ type Controller struct {
 	kubeclientset kubernetes.Interface
 	// edgenetclientset is a clientset for the EdgeNet API groups
 	edgenetclientset clientset.Interface
+	// readKubeclientset and readEdgenetclientset serve the gets and lists, so that these can be offloaded to a
+	// cache or a replica of the API server, while the writes go to kubeclientset and edgenetclientset. The reads
+	// that an update or a deletion is decided on go to the latter, as a stale copy would be written back.
+	readKubeclientset    kubernetes.Interface
+	readEdgenetclientset clientset.Interface
 
 	nodesLister corelisters.NodeLister
 	nodesSynced cache.InformerSynced
@@ -92,6 +141,26 @@ type Controller struct {
 	tenantresourcequotasLister listers.TenantResourceQuotaLister
 	tenantresourcequotasSynced cache.InformerSynced
 
+	// alertThresholds are the utilization percentages, in ascending order, at which the tenant
+	// owner is alerted, an empty list disables the alerts
+	alertThresholds []int
+	// alertCooldown is the minimum time between two alerts to the same tenant
+	alertCooldown time.Duration
+	// alertInterval is the interval at which the utilization is checked, as the usage in
+	// resource quotas is not watched
+	alertInterval time.Duration
+	// quotaNames are the names of the resource quotas in the core and child namespaces
+	quotaNames multitenancy.QuotaNames
+	// reapingInterval is the window within which the expiries of claims and drops are reaped in a single sweep
+	reapingInterval time.Duration
+	// expiryNoticeLead is how long before a claim expires the tenant owner is notified, zero disables the notices
+	expiryNoticeLead time.Duration
+	// childOnlyResources lists the resources that only limit the child of a subnamespace, which are not debited
+	// from the quota of its parent
+	childOnlyResources multitenancy.ChildOnlyResources
+	// notify sends a notification to the tenant owner
+	notify func(content *notification.Content, purpose string) error
+
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
@@ -108,65 +177,106 @@ func NewController(
 	kubeclientset kubernetes.Interface,
 	edgenetclientset clientset.Interface,
 	nodeInformer coreinformers.NodeInformer,
-	tenantresourcequotaInformer informers.TenantResourceQuotaInformer) *Controller {
+	tenantresourcequotaInformer informers.TenantResourceQuotaInformer,
+	alertThresholds []int,
+	alertCooldown time.Duration,
+	quotaNames multitenancy.QuotaNames,
+	reapingInterval time.Duration,
+	readKubeclientset kubernetes.Interface,
+	readEdgenetclientset clientset.Interface,
+	expiryNoticeLead time.Duration,
+	childOnlyResources multitenancy.ChildOnlyResources) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("nodeInformer", nodeInformer)
+	validator.NotNil("tenantresourcequotaInformer", tenantresourcequotaInformer)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
 
 	utilruntime.Must(edgenetscheme.AddToScheme(scheme.Scheme))
 	klog.V(4).Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
+	if readKubeclientset == nil {
+		readKubeclientset = kubeclientset
+	}
+	if readEdgenetclientset == nil {
+		readEdgenetclientset = edgenetclientset
+	}
+
 	controller := &Controller{
 		kubeclientset:              kubeclientset,
 		edgenetclientset:           edgenetclientset,
+		readKubeclientset:          readKubeclientset,
+		readEdgenetclientset:       readEdgenetclientset,
 		nodesLister:                nodeInformer.Lister(),
 		nodesSynced:                nodeInformer.Informer().HasSynced,
 		tenantresourcequotasLister: tenantresourcequotaInformer.Lister(),
 		tenantresourcequotasSynced: tenantresourcequotaInformer.Informer().HasSynced,
-		workqueue:                  workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "TenantResourceQuotas"),
-		recorder:                   recorder,
+		alertThresholds:            alertThresholds,
+		alertCooldown:              alertCooldown,
+		alertInterval:              time.Minute,
+		quotaNames:                 quotaNames,
+		reapingInterval:            reapingInterval,
+		expiryNoticeLead:           expiryNoticeLead,
+		childOnlyResources:         childOnlyResources,
+		notify: func(content *notification.Content, purpose string) error {
+			return content.SendNotification(purpose)
+		},
+		workqueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "TenantResourceQuotas"),
+		recorder:  recorder,
 	}
 
 	klog.V(4).Infoln("Setting up event handlers")
-	// Set up an event handler for when Tenant Resource Quota resources change
-	var getClosestExpiryDate = func(stale bool, objects ...map[string]corev1alpha1.ResourceTuning) (*metav1.Time, bool) {
-		var closestDate *metav1.Time
-		expiryDateExists := false
-		for _, obj := range objects {
-			for _, value := range obj {
-				if value.Expiry != nil && time.Until(value.Expiry.Time) > 0 {
-					if stale || !expiryDateExists || closestDate.Sub(value.Expiry.Time) >= 0 {
-						expiryDateExists = true
-						closestDate = value.Expiry
-					}
-				}
+	// The quotas given as a share of the cluster capacity follow the nodes joining and leaving the cluster
+	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueueCapacityShares,
+		UpdateFunc: func(old, new interface{}) {
+			newNode := new.(*corev1.Node)
+			oldNode := old.(*corev1.Node)
+			if !reflect.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable) || !reflect.DeepEqual(oldNode.Status.Capacity, newNode.Status.Capacity) {
+				controller.enqueueCapacityShares(new)
 			}
-		}
-		return closestDate, expiryDateExists
-	}
+		},
+		DeleteFunc: controller.enqueueCapacityShares,
+	})
+	// Set up an event handler for when Tenant Resource Quota resources change
 	tenantresourcequotaInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			tenantResourceQuota := obj.(*corev1alpha1.TenantResourceQuota)
-			if expiryDate, exists := getClosestExpiryDate(false, tenantResourceQuota.Spec.Claim, tenantResourceQuota.Spec.Drop); exists {
-				controller.enqueueTenantResourceQuotaAfter(tenantResourceQuota, time.Until(expiryDate.Time))
+			if reapingDate, exists := getReapingDate(controller.reapingInterval, tenantResourceQuota.Spec.Claim, tenantResourceQuota.Spec.Drop); exists {
+				controller.enqueueTenantResourceQuotaAfter(tenantResourceQuota, time.Until(reapingDate.Time))
 			}
 			controller.enqueueTenantResourceQuota(obj)
 		},
 		UpdateFunc: func(old, new interface{}) {
 			newTenantResourceQuota := new.(*corev1alpha1.TenantResourceQuota)
 			oldTenantResourceQuota := old.(*corev1alpha1.TenantResourceQuota)
-			if newExpiryDate, exists := getClosestExpiryDate(false, newTenantResourceQuota.Spec.Claim, newTenantResourceQuota.Spec.Drop); exists {
+			if newReapingDate, exists := getReapingDate(controller.reapingInterval, newTenantResourceQuota.Spec.Claim, newTenantResourceQuota.Spec.Drop); exists {
 				if previousExpiryDate, exists := getClosestExpiryDate(true, oldTenantResourceQuota.Spec.Claim, oldTenantResourceQuota.Spec.Drop); !exists ||
-					(exists && previousExpiryDate.Sub(newExpiryDate.Time) > 0) {
-					controller.enqueueTenantResourceQuotaAfter(newTenantResourceQuota, time.Until(newExpiryDate.Time))
+					(exists && previousExpiryDate.Sub(newReapingDate.Time) > 0) {
+					controller.enqueueTenantResourceQuotaAfter(newTenantResourceQuota, time.Until(newReapingDate.Time))
 				}
 			}
 			controller.enqueueTenantResourceQuota(new)
 		},
+		DeleteFunc: func(obj interface{}) {
+			// The quota of the tenant shrinks when one of its additional tenant resource quotas goes away
+			if tenantResourceQuota, ok := obj.(*corev1alpha1.TenantResourceQuota); ok {
+				if tenant := tenantResourceQuota.Tenant(); tenant != tenantResourceQuota.GetName() {
+					controller.workqueue.Add(tenant)
+				}
+			}
+		},
 	})
 
-	return controller
+	return controller, nil
 }
 
 // Run will set up the event handlers for the types of tenant resource quota and node, as well
@@ -225,12 +335,13 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.V(4).Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.V(4).Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
@@ -294,26 +405,119 @@ func (c *Controller) enqueueTenantResourceQuotaAfter(obj interface{}, after time
 	c.workqueue.AddAfter(key, after)
 }
 
+// getClosestExpiryDate returns the closest expiration date among the claims and drops, including
+// the expiration dates of individual resources.
+func getClosestExpiryDate(stale bool, objects ...map[string]corev1alpha1.ResourceTuning) (*metav1.Time, bool) {
+	var closestDate *metav1.Time
+	expiryDateExists := false
+	for _, obj := range objects {
+		for _, value := range obj {
+			for _, expiry := range value.GetExpiryDates() {
+				if time.Until(expiry.Time) > 0 {
+					if stale || !expiryDateExists || closestDate.Sub(expiry.Time) >= 0 {
+						expiryDateExists = true
+						closestDate = expiry
+					}
+				}
+			}
+		}
+	}
+	return closestDate, expiryDateExists
+}
+
+// getClosestStartDate returns the closest start date of the claims and drops yet to start
+func getClosestStartDate(objects ...map[string]corev1alpha1.ResourceTuning) (*metav1.Time, bool) {
+	var closestDate *metav1.Time
+	for _, obj := range objects {
+		for _, value := range obj {
+			if value.HasStarted() {
+				continue
+			}
+			if closestDate == nil || value.Start.Before(closestDate) {
+				closestDate = value.Start
+			}
+		}
+	}
+	return closestDate, closestDate != nil
+}
+
+// getReapingDate returns when the expired claims and drops are reaped. The expiries that fall within the reaping
+// interval of the closest one are batched into a single sweep at the latest of them, so that nothing is reaped
+// before it expires.
+func getReapingDate(interval time.Duration, objects ...map[string]corev1alpha1.ResourceTuning) (*metav1.Time, bool) {
+	closestDate, exists := getClosestExpiryDate(false, objects...)
+	if !exists || interval <= 0 {
+		return closestDate, exists
+	}
+	reapingDate := closestDate
+	for _, obj := range objects {
+		for _, value := range obj {
+			for _, expiry := range value.GetExpiryDates() {
+				if expiry.After(reapingDate.Time) && expiry.Sub(closestDate.Time) <= interval {
+					reapingDate = expiry
+				}
+			}
+		}
+	}
+	return reapingDate, true
+}
+
 func (c *Controller) processTenantResourceQuota(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota) {
 	if exceedsBackoffLimit := tenantResourceQuotaCopy.Status.Failed >= backoffLimit; exceedsBackoffLimit {
 		c.cleanup(tenantResourceQuotaCopy)
 		return
 	}
+	// The quota is tuned again once the next claim or drop scheduled for later takes effect
+	if startDate, exists := getClosestStartDate(tenantResourceQuotaCopy.Spec.Claim, tenantResourceQuotaCopy.Spec.Drop); exists {
+		c.enqueueTenantResourceQuotaAfter(tenantResourceQuotaCopy, time.Until(startDate.Time))
+	}
+	if tenant := tenantResourceQuotaCopy.Tenant(); tenant != tenantResourceQuotaCopy.GetName() {
+		c.processAdditionalQuota(tenantResourceQuotaCopy, tenant)
+		return
+	}
 
-	multitenancyManager := multitenancy.NewManager(c.kubeclientset, c.edgenetclientset)
+	multitenancyManager := multitenancy.NewManager(c.readKubeclientset, c.readEdgenetclientset)
 	permitted, _, parentNamespaceLabels := multitenancyManager.EligibilityCheck(tenantResourceQuotaCopy.GetName())
 	if permitted {
+		// Quota mutations of a tenant are serialized with the subnamespace partitioning running in this process
+		unlock := multitenancy.LockTenant(tenantResourceQuotaCopy.GetName())
+		defer unlock()
+
 		if expired := tenantResourceQuotaCopy.DropExpiredItems(); expired {
 			c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeNormal, successRemoved, messageRemoved)
+			// Resources of a claim / drop may expire one after another
+			if reapingDate, exists := getReapingDate(c.reapingInterval, tenantResourceQuotaCopy.Spec.Claim, tenantResourceQuotaCopy.Spec.Drop); exists {
+				c.enqueueTenantResourceQuotaAfter(tenantResourceQuotaCopy, time.Until(reapingDate.Time))
+			}
 			tenantResourceQuotaCopy.Status.State = corev1alpha1.StatusReconciliation
 			tenantResourceQuotaCopy.Status.Message = messageReconciliation
 			c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
 			return
 		}
 
+		if err := c.expandQuotaProfiles(tenantResourceQuotaCopy); err != nil {
+			c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeWarning, failureProfile, err.Error())
+			tenantResourceQuotaCopy.Status.State = corev1alpha1.StatusFailed
+			tenantResourceQuotaCopy.Status.Message = fmt.Sprintf("%s: %s", messageProfileFail, err)
+			c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+			return
+		}
+		if err := c.expandCapacityShares(tenantResourceQuotaCopy); err != nil {
+			c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeWarning, failureCapacity, err.Error())
+			tenantResourceQuotaCopy.Status.State = corev1alpha1.StatusFailed
+			tenantResourceQuotaCopy.Status.Message = fmt.Sprintf("%s: %s", messageCapacityFail, err)
+			c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+			return
+		}
+
 		switch tenantResourceQuotaCopy.Status.State {
 		case corev1alpha1.StatusApplied:
 			c.reconcile(tenantResourceQuotaCopy, parentNamespaceLabels["edge-net.io/cluster-uid"])
+			if tenantResourceQuotaCopy.Status.State == corev1alpha1.StatusApplied {
+				c.exportQuotaUtilization(tenantResourceQuotaCopy)
+				c.alertOnQuotaUtilization(tenantResourceQuotaCopy, parentNamespaceLabels["edge-net.io/cluster-uid"])
+				c.noticeClaimExpiry(tenantResourceQuotaCopy, parentNamespaceLabels["edge-net.io/cluster-uid"])
+			}
 		case corev1alpha1.StatusQuotaCreated:
 			if ok := c.tuneHierarchicalResourceQuota(tenantResourceQuotaCopy, parentNamespaceLabels["edge-net.io/cluster-uid"]); !ok {
 				c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeWarning, corev1alpha1.StatusFailed, messageNotUpdated)
@@ -326,9 +530,19 @@ func (c *Controller) processTenantResourceQuota(tenantResourceQuotaCopy *corev1a
 			tenantResourceQuotaCopy.Status.Message = messageApplied
 			c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
 		default:
+			if c.isQuotaDryRun(tenantResourceQuotaCopy.GetName()) {
+				if tenantResourceQuotaCopy.Status.Message != messageDryRun {
+					c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeNormal, successDryRun, messageDryRun)
+					tenantResourceQuotaCopy.Status.Message = messageDryRun
+					c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+				}
+				// Tenants are not watched, so whether the dry run is over is checked again later
+				c.enqueueTenantResourceQuotaAfter(tenantResourceQuotaCopy, time.Minute)
+				return
+			}
 			// The initial resource quota in the core namespace is equal to the defined tenant resource quota.
 			resourceQuota := corev1.ResourceQuota{}
-			resourceQuota.Name = "core-quota"
+			resourceQuota.Name = c.quotaNames.Core
 			resourceQuota.Spec = corev1.ResourceQuotaSpec{
 				Hard: tenantResourceQuotaCopy.Spec.Claim["initial"].ResourceList,
 			}
@@ -347,12 +561,225 @@ func (c *Controller) processTenantResourceQuota(tenantResourceQuotaCopy *corev1a
 	}
 }
 
+// isQuotaDryRun tells whether the tenant holds back the resource quota of its core namespace
+func (c *Controller) isQuotaDryRun(tenant string) bool {
+	tenantObj, err := c.readEdgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant, metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+	return tenantObj.GetAnnotations()[quotaDryRunAnnotation] == "true"
+}
+
+// processAdditionalQuota validates a tenant resource quota that layers a grant on top of the tenant's own, and
+// enqueues the tenant resource quota named after the tenant, which applies the aggregated quota.
+func (c *Controller) processAdditionalQuota(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota, tenant string) {
+	multitenancyManager := multitenancy.NewManager(c.readKubeclientset, c.readEdgenetclientset)
+	if permitted, _, _ := multitenancyManager.EligibilityCheck(tenant); !permitted {
+		return
+	}
+	expandedCopy := tenantResourceQuotaCopy.DeepCopy()
+	if err := c.expandQuotaProfiles(expandedCopy); err != nil {
+		c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeWarning, failureProfile, err.Error())
+		tenantResourceQuotaCopy.Status.State = corev1alpha1.StatusFailed
+		tenantResourceQuotaCopy.Status.Message = fmt.Sprintf("%s: %s", messageProfileFail, err)
+		c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+		return
+	}
+	if err := c.expandCapacityShares(expandedCopy); err != nil {
+		c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeWarning, failureCapacity, err.Error())
+		tenantResourceQuotaCopy.Status.State = corev1alpha1.StatusFailed
+		tenantResourceQuotaCopy.Status.Message = fmt.Sprintf("%s: %s", messageCapacityFail, err)
+		c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+		return
+	}
+	if message := fmt.Sprintf(messageAggregated, tenant); tenantResourceQuotaCopy.Status.State != corev1alpha1.StatusApplied || tenantResourceQuotaCopy.Status.Message != message {
+		c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeNormal, successAggregated, message)
+		tenantResourceQuotaCopy.Status.State = corev1alpha1.StatusApplied
+		tenantResourceQuotaCopy.Status.Message = message
+		c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+	}
+	c.workqueue.Add(tenant)
+}
+
+// getEffectiveQuota aggregates the tenant resource quota named after the tenant, whose profiles and capacity shares
+// are already expanded, with the additional ones labeled for the tenant.
+func (c *Controller) getEffectiveQuota(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota) map[corev1.ResourceName]resource.Quantity {
+	return multitenancy.AggregateQuota(c.getEffectiveQuotas(tenantResourceQuotaCopy)...)
+}
+
+// getEffectiveQuotas returns the tenant resource quota named after the tenant, followed by the valid additional ones
+// labeled for the tenant, with their expired items dropped and their profiles and capacity shares expanded.
+func (c *Controller) getEffectiveQuotas(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota) []corev1alpha1.TenantResourceQuota {
+	tenantResourceQuotas := []corev1alpha1.TenantResourceQuota{*tenantResourceQuotaCopy}
+	tenant := tenantResourceQuotaCopy.GetName()
+	additionalQuotas, err := c.tenantresourcequotasLister.List(labels.SelectorFromSet(labels.Set{"edge-net.io/tenant": tenant}))
+	if err != nil {
+		klog.Infoln(err)
+	}
+	for _, additionalQuota := range additionalQuotas {
+		if additionalQuota.GetName() == tenant {
+			continue
+		}
+		additionalQuotaCopy := additionalQuota.DeepCopy()
+		additionalQuotaCopy.DropExpiredItems()
+		// The invalid ones are reported in their own status
+		if err := c.expandQuotaProfiles(additionalQuotaCopy); err != nil {
+			continue
+		}
+		if err := c.expandCapacityShares(additionalQuotaCopy); err != nil {
+			continue
+		}
+		tenantResourceQuotas = append(tenantResourceQuotas, *additionalQuotaCopy)
+	}
+	return tenantResourceQuotas
+}
+
+// expandQuotaProfiles adds the resources of the profiles referenced by the claims and drops to their resource lists
+func (c *Controller) expandQuotaProfiles(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota) error {
+	var profiles map[string]corev1.ResourceList
+	for _, tunings := range []map[string]corev1alpha1.ResourceTuning{tenantResourceQuotaCopy.Spec.Claim, tenantResourceQuotaCopy.Spec.Drop} {
+		for key, tuning := range tunings {
+			if tuning.Profile == "" {
+				continue
+			}
+			if profiles == nil {
+				var err error
+				if profiles, err = c.getQuotaProfiles(); err != nil {
+					return err
+				}
+			}
+			profile, ok := profiles[tuning.Profile]
+			if !ok {
+				return fmt.Errorf("quota profile %q referenced by %q is not defined", tuning.Profile, key)
+			}
+			tuning.ResourceList = expandResourceList(tuning.ResourceList, profile)
+			tunings[key] = tuning
+		}
+	}
+	return nil
+}
+
+// getQuotaProfiles returns the quota profiles by name, none if they are not configured
+func (c *Controller) getQuotaProfiles() (map[string]corev1.ResourceList, error) {
+	profiles := make(map[string]corev1.ResourceList)
+	configMap, err := c.readKubeclientset.CoreV1().ConfigMaps(quotaProfilesNamespace).Get(context.TODO(), quotaProfilesName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return profiles, nil
+		}
+		return nil, err
+	}
+	for name, value := range configMap.Data {
+		resourceList := make(corev1.ResourceList)
+		if err := yaml.Unmarshal([]byte(value), &resourceList); err != nil {
+			return nil, fmt.Errorf("quota profile %q is malformed: %s", name, err)
+		}
+		if profiles[name], err = multitenancy.NormalizeResourceList(resourceList); err != nil {
+			return nil, fmt.Errorf("quota profile %q is malformed: %s", name, err)
+		}
+	}
+	return profiles, nil
+}
+
+// expandResourceList returns the profile resources overridden by those in the resource list
+func expandResourceList(resourceList, profile corev1.ResourceList) corev1.ResourceList {
+	expanded := make(corev1.ResourceList, len(profile)+len(resourceList))
+	for name, quantity := range profile {
+		expanded[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range resourceList {
+		expanded[name] = quantity
+	}
+	return expanded
+}
+
+// expandCapacityShares sets the quantities of the resources given as a share of the cluster capacity in the
+// resource lists of the claims and drops
+func (c *Controller) expandCapacityShares(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota) error {
+	var capacity corev1.ResourceList
+	for _, tunings := range []map[string]corev1alpha1.ResourceTuning{tenantResourceQuotaCopy.Spec.Claim, tenantResourceQuotaCopy.Spec.Drop} {
+		for key, tuning := range tunings {
+			if len(tuning.CapacityPercentage) == 0 {
+				continue
+			}
+			if capacity == nil {
+				var err error
+				if capacity, err = c.getClusterCapacity(); err != nil {
+					return err
+				}
+			}
+			resourceList := make(corev1.ResourceList, len(tuning.ResourceList)+len(tuning.CapacityPercentage))
+			for name, quantity := range tuning.ResourceList {
+				resourceList[name] = quantity
+			}
+			for name, percentage := range tuning.CapacityPercentage {
+				if percentage < 0 || percentage > 100 {
+					return fmt.Errorf("share of %s in %q is %d%%, it must be between 0 and 100", name, key, percentage)
+				}
+				resourceList[name] = shareOf(capacity[name], percentage)
+			}
+			tuning.ResourceList = resourceList
+			tunings[key] = tuning
+		}
+	}
+	return nil
+}
+
+// getClusterCapacity returns the sum of the allocatable resources of the nodes, or of their capacity
+// for the resources a node does not report as allocatable
+func (c *Controller) getClusterCapacity() (corev1.ResourceList, error) {
+	nodes, err := c.nodesLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	capacity := make(corev1.ResourceList)
+	for _, node := range nodes {
+		resourceList := expandResourceList(node.Status.Allocatable, node.Status.Capacity)
+		for name, quantity := range resourceList {
+			total := capacity[name]
+			total.Add(quantity)
+			capacity[name] = total
+		}
+	}
+	return capacity, nil
+}
+
+// shareOf returns the percentage of the quantity, rounded down to the millis
+func shareOf(total resource.Quantity, percentage int) resource.Quantity {
+	return *resource.NewMilliQuantity(total.MilliValue()*int64(percentage)/100, total.Format)
+}
+
+// enqueueCapacityShares puts the tenant resource quotas claiming or dropping a share of the cluster capacity
+// onto the work queue
+func (c *Controller) enqueueCapacityShares(obj interface{}) {
+	tenantResourceQuotas, err := c.tenantresourcequotasLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, tenantResourceQuota := range tenantResourceQuotas {
+		for _, tunings := range []map[string]corev1alpha1.ResourceTuning{tenantResourceQuota.Spec.Claim, tenantResourceQuota.Spec.Drop} {
+			shared := false
+			for _, tuning := range tunings {
+				if len(tuning.CapacityPercentage) != 0 {
+					shared = true
+					break
+				}
+			}
+			if shared {
+				c.enqueueTenantResourceQuota(tenantResourceQuota)
+				break
+			}
+		}
+	}
+}
+
 func (c *Controller) reconcile(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota, clusterUID string) {
 	if ok := c.tuneHierarchicalResourceQuota(tenantResourceQuotaCopy, clusterUID); !ok {
 		tenantResourceQuotaCopy.Status.State = corev1alpha1.StatusQuotaCreated
 		tenantResourceQuotaCopy.Status.Message = messageQuotaCreated
 	}
-	if _, err := c.kubeclientset.CoreV1().ResourceQuotas(tenantResourceQuotaCopy.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{}); err != nil {
+	if _, err := c.readKubeclientset.CoreV1().ResourceQuotas(tenantResourceQuotaCopy.GetName()).Get(context.TODO(), c.quotaNames.Core, metav1.GetOptions{}); err != nil {
 		tenantResourceQuotaCopy.Status.State = corev1alpha1.StatusReconciliation
 		tenantResourceQuotaCopy.Status.Message = messageReconciliation
 	}
@@ -365,7 +792,7 @@ func (c *Controller) tuneHierarchicalResourceQuota(tenantResourceQuotaCopy *core
 	c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeNormal, successTraversalStarted, messageTraversalStarted)
 	ok := true
 	statusChannel := make(chan traverseStatus, 1)
-	go c.traverse(tenantResourceQuotaCopy.GetName(), "core", clusterUID, tenantResourceQuotaCopy.Fetch(), statusChannel)
+	go c.traverse(tenantResourceQuotaCopy.GetName(), "core", clusterUID, c.getEffectiveQuota(tenantResourceQuotaCopy), statusChannel)
 traverseNamespaces:
 	for {
 		select {
@@ -392,7 +819,7 @@ func (c *Controller) traverse(namespace, namespaceKind, clusterUID string, remai
 	isDeleted, isFailed := c.tuneResourceQuota(namespace, namespaceKind, remainingQuotaResourceList)
 	statusChannel <- traverseStatus{deleted: isDeleted, failed: isFailed}
 	if !isFailed {
-		subNamespaceRaw, _ := c.edgenetclientset.CoreV1alpha1().SubNamespaces(namespace).List(context.TODO(), metav1.ListOptions{})
+		subNamespaceRaw, _ := c.readEdgenetclientset.CoreV1alpha1().SubNamespaces(namespace).List(context.TODO(), metav1.ListOptions{})
 		if len(subNamespaceRaw.Items) != 0 {
 			for _, subnamespaceRow := range subNamespaceRaw.Items {
 				if subnamespaceRow.Spec.Workspace != nil {
@@ -412,20 +839,32 @@ func (c *Controller) traverse(namespace, namespaceKind, clusterUID string, remai
 }
 
 func (c *Controller) tuneResourceQuota(namespace, namespaceKind string, remainingQuotaResourceList map[corev1.ResourceName]resource.Quantity) (bool, bool) {
-	if resourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(namespace).Get(context.TODO(), fmt.Sprintf("%s-quota", namespaceKind), metav1.GetOptions{}); err == nil {
-		remainingQuotaResourceList, lastInSubnamespace, isQuotaSufficient := c.subtractSubnamespaceQuotas(namespace, remainingQuotaResourceList)
-		if !isQuotaSufficient {
+	isDeleted := false
+	// The subnamespace controller, which runs in a process of its own, partitions the quota too, so a conflicting
+	// update is tuned again from the current quota and subnamespaces
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		resourceQuota, err := c.kubeclientset.CoreV1().ResourceQuotas(namespace).Get(context.TODO(), c.quotaNames.ForKind(namespaceKind), metav1.GetOptions{})
+		if err != nil {
+			return nil
+		}
+		tunedQuotaResourceList := make(map[corev1.ResourceName]resource.Quantity, len(remainingQuotaResourceList))
+		for key, value := range remainingQuotaResourceList {
+			tunedQuotaResourceList[key] = value.DeepCopy()
+		}
+		tunedQuotaResourceList, lastInSubnamespace, isQuotaSufficient := c.subtractSubnamespaceQuotas(namespace, tunedQuotaResourceList)
+		if !isQuotaSufficient && !isDeleted {
+			// A single subnamespace is deleted per tuning, as the one deleted may still be listed on a retry
 			c.edgenetclientset.CoreV1alpha1().SubNamespaces(namespace).Delete(context.TODO(), lastInSubnamespace, metav1.DeleteOptions{})
+			isDeleted = true
 		}
-		if !reflect.DeepEqual(remainingQuotaResourceList, resourceQuota.Spec.Hard) {
-			resourceQuota.Spec.Hard = remainingQuotaResourceList
-			if _, err := c.kubeclientset.CoreV1().ResourceQuotas(namespace).Update(context.TODO(), resourceQuota, metav1.UpdateOptions{}); err != nil {
-				return !isQuotaSufficient, true
-			}
+		if !reflect.DeepEqual(tunedQuotaResourceList, resourceQuota.Spec.Hard) {
+			resourceQuota.Spec.Hard = tunedQuotaResourceList
+			_, err = c.kubeclientset.CoreV1().ResourceQuotas(namespace).Update(context.TODO(), resourceQuota, metav1.UpdateOptions{})
+			return err
 		}
-		return !isQuotaSufficient, false
-	}
-	return false, false
+		return nil
+	})
+	return isDeleted, err != nil
 }
 
 func (c *Controller) subtractSubnamespaceQuotas(namespace string, remainingQuotaResourceList map[corev1.ResourceName]resource.Quantity) (map[corev1.ResourceName]resource.Quantity, string, bool) {
@@ -433,12 +872,18 @@ func (c *Controller) subtractSubnamespaceQuotas(namespace string, remainingQuota
 	var lastInSubnamespace string
 	if subnamespaceRaw, err := c.edgenetclientset.CoreV1alpha1().SubNamespaces(namespace).List(context.TODO(), metav1.ListOptions{}); err == nil {
 		for _, subnamespaceRow := range subnamespaceRaw.Items {
+			if multitenancy.IsSubNamespaceQuotaExempt(c.readKubeclientset, subnamespaceRow) {
+				continue
+			}
 			if subnamespaceRow.Status.State == corev1alpha1.StatusEstablished || subnamespaceRow.Status.State == corev1alpha1.StatusQuotaSet || subnamespaceRow.Status.State == corev1alpha1.StatusSubnamespaceCreated || subnamespaceRow.Status.State == corev1alpha1.StatusPartitioned {
 				if lastInDate.IsZero() || subnamespaceRow.GetCreationTimestamp().After(lastInDate.Time) {
 					lastInSubnamespace = subnamespaceRow.GetName()
 					lastInDate = subnamespaceRow.GetCreationTimestamp()
 				}
 				for remainingQuotaResource, remainingQuotaQuantity := range remainingQuotaResourceList {
+					if c.childOnlyResources.Contains(remainingQuotaResource) {
+						continue
+					}
 					childQuota := subnamespaceRow.RetrieveQuantity(remainingQuotaResource)
 					if remainingQuotaQuantity.Cmp(childQuota) == -1 {
 						return remainingQuotaResourceList, lastInSubnamespace, false
@@ -452,6 +897,211 @@ func (c *Controller) subtractSubnamespaceQuotas(namespace string, remainingQuota
 	return remainingQuotaResourceList, lastInSubnamespace, true
 }
 
+// ParseAlertThresholds parses a comma-separated list of utilization percentages, such as "80,95".
+func ParseAlertThresholds(value string) ([]int, error) {
+	thresholds := []int{}
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		threshold, err := strconv.Atoi(strings.TrimSuffix(field, "%"))
+		if err != nil || threshold < 1 || threshold > 100 {
+			return nil, fmt.Errorf("invalid quota alert threshold %q, expected a percentage between 1 and 100", field)
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	sort.Ints(thresholds)
+	return thresholds, nil
+}
+
+// alertOnQuotaUtilization emits an event and notifies the tenant owner when the utilization of a resource
+// crosses a threshold higher than the one last alerted about.
+func (c *Controller) alertOnQuotaUtilization(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota, clusterUID string) {
+	if len(c.alertThresholds) == 0 {
+		return
+	}
+	defer c.enqueueTenantResourceQuotaAfter(tenantResourceQuotaCopy, c.alertInterval)
+
+	resourceName, used, allocated, utilization := c.getQuotaUtilization(tenantResourceQuotaCopy)
+	threshold := 0
+	for _, alertThreshold := range c.alertThresholds {
+		if utilization >= float64(alertThreshold) {
+			threshold = alertThreshold
+		}
+	}
+	if threshold < tenantResourceQuotaCopy.Status.AlertedThreshold {
+		// The utilization has dropped, so that crossing the threshold again raises a new alert
+		tenantResourceQuotaCopy.Status.AlertedThreshold = threshold
+		c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+		return
+	}
+	if threshold == tenantResourceQuotaCopy.Status.AlertedThreshold {
+		return
+	}
+	if lastAlerted := tenantResourceQuotaCopy.Status.LastAlerted; lastAlerted != nil && time.Since(lastAlerted.Time) < c.alertCooldown {
+		return
+	}
+
+	c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeWarning, warningQuotaAlert, fmt.Sprintf(messageQuotaAlert, resourceName, threshold))
+	if tenant, err := c.readEdgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenantResourceQuotaCopy.GetName(), metav1.GetOptions{}); err == nil {
+		contact := tenant.Spec.Contact
+		content := new(notification.Content)
+		content.Init(contact.FirstName, contact.LastName, contact.Email, "[EdgeNet] Tenant quota alert", clusterUID, []string{contact.Email})
+		content.QuotaAlert = &notification.QuotaAlert{
+			Tenant:    tenant.GetName(),
+			Resource:  string(resourceName),
+			Threshold: threshold,
+			Used:      used.String(),
+			Allocated: allocated.String(),
+		}
+		if err := c.notify(content, "tenant-quota-alert"); err != nil {
+			klog.Infoln(err)
+		}
+	} else {
+		klog.Infoln(err)
+	}
+	now := metav1.Now()
+	tenantResourceQuotaCopy.Status.AlertedThreshold = threshold
+	tenantResourceQuotaCopy.Status.LastAlerted = &now
+	c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+}
+
+// noticeClaimExpiry emits an event and notifies the tenant owner of the claimed resources that expire within the
+// lead time, in a single notification. Each expiry is notified once, unless the claim is extended.
+func (c *Controller) noticeClaimExpiry(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota, clusterUID string) {
+	if c.expiryNoticeLead <= 0 {
+		return
+	}
+	if noticeDate, exists := getExpiryNoticeDate(c.expiryNoticeLead, tenantResourceQuotaCopy.Spec.Claim); exists {
+		defer c.enqueueTenantResourceQuotaAfter(tenantResourceQuotaCopy, time.Until(noticeDate.Time))
+	}
+
+	notified := make(map[string]metav1.Time)
+	var expiringResources []notification.ExpiringResource
+	for claimName, claim := range tenantResourceQuotaCopy.Spec.Claim {
+		lastNotified, isNotified := tenantResourceQuotaCopy.Status.ExpiryNotified[claimName]
+		if isNotified {
+			notified[claimName] = lastNotified
+		}
+		for resourceName, quantity := range claim.ResourceList {
+			expiry := claim.GetResourceExpiry(resourceName)
+			if expiry == nil || time.Until(expiry.Time) <= 0 || time.Until(expiry.Time) > c.expiryNoticeLead {
+				continue
+			}
+			if isNotified && !expiry.After(lastNotified.Time) {
+				continue
+			}
+			expiringResources = append(expiringResources, notification.ExpiringResource{
+				Claim:    claimName,
+				Resource: string(resourceName),
+				Quantity: quantity.String(),
+				Expiry:   expiry.UTC().Format(time.RFC1123),
+			})
+			if latest, exists := notified[claimName]; !exists || expiry.After(latest.Time) {
+				notified[claimName] = *expiry.DeepCopy()
+			}
+		}
+	}
+	if len(expiringResources) == 0 {
+		if len(notified) != len(tenantResourceQuotaCopy.Status.ExpiryNotified) {
+			// The notices of the claims that are gone are forgotten
+			tenantResourceQuotaCopy.Status.ExpiryNotified = notified
+			c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+		}
+		return
+	}
+	sort.Slice(expiringResources, func(i, j int) bool {
+		if expiringResources[i].Claim != expiringResources[j].Claim {
+			return expiringResources[i].Claim < expiringResources[j].Claim
+		}
+		return expiringResources[i].Resource < expiringResources[j].Resource
+	})
+
+	if err := c.notifyClaimExpiry(tenantResourceQuotaCopy.GetName(), expiringResources, clusterUID); err != nil {
+		// The expiries are not recorded as notified, so that the notice is sent again
+		klog.Infoln(err)
+		c.enqueueTenantResourceQuotaAfter(tenantResourceQuotaCopy, time.Minute)
+		return
+	}
+	var affected []string
+	for _, expiringResource := range expiringResources {
+		affected = append(affected, fmt.Sprintf("%s/%s", expiringResource.Claim, expiringResource.Resource))
+	}
+	c.recorder.Event(tenantResourceQuotaCopy, corev1.EventTypeWarning, warningExpiryNotice, fmt.Sprintf(messageExpiryNotice, strings.Join(affected, ", ")))
+	tenantResourceQuotaCopy.Status.ExpiryNotified = notified
+	c.updateStatus(context.TODO(), tenantResourceQuotaCopy)
+}
+
+// notifyClaimExpiry notifies the owner of the tenant of the claimed resources that are about to expire
+func (c *Controller) notifyClaimExpiry(tenantName string, expiringResources []notification.ExpiringResource, clusterUID string) error {
+	tenant, err := c.readEdgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenantName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	contact := tenant.Spec.Contact
+	content := new(notification.Content)
+	content.Init(contact.FirstName, contact.LastName, contact.Email, "[EdgeNet] Tenant quota expiry", clusterUID, []string{contact.Email})
+	content.ClaimExpiry = &notification.ClaimExpiry{
+		Tenant:    tenant.GetName(),
+		Resources: expiringResources,
+	}
+	return c.notify(content, "tenant-quota-expiry")
+}
+
+// getExpiryNoticeDate returns the closest date at which a claimed resource enters the lead time before its expiry.
+func getExpiryNoticeDate(lead time.Duration, claims map[string]corev1alpha1.ResourceTuning) (*metav1.Time, bool) {
+	var noticeDate *metav1.Time
+	for _, claim := range claims {
+		for _, expiry := range claim.GetExpiryDates() {
+			date := metav1.NewTime(expiry.Add(-lead))
+			if time.Until(date.Time) > 0 && (noticeDate == nil || date.Before(noticeDate)) {
+				noticeDate = &date
+			}
+		}
+	}
+	return noticeDate, noticeDate != nil
+}
+
+// exportQuotaUtilization sets the gauges of the quota used by and allocated to the tenant, one per resource
+// of the tenant resource quota.
+func (c *Controller) exportQuotaUtilization(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota) {
+	tenant := tenantResourceQuotaCopy.GetName()
+	usedResourceList := c.getQuotaUsage(tenantResourceQuotaCopy)
+	// Resources no longer in the tenant resource quota are not exposed anymore
+	tenantQuotaUsed.DeleteMatching("tenant", tenant)
+	tenantQuotaAllocated.DeleteMatching("tenant", tenant)
+	for key, allocatedQuantity := range c.getEffectiveQuota(tenantResourceQuotaCopy) {
+		usedQuantity := usedResourceList[key]
+		tenantQuotaUsed.Set(usedQuantity.AsApproximateFloat64(), tenant, string(key))
+		tenantQuotaAllocated.Set(allocatedQuantity.AsApproximateFloat64(), tenant, string(key))
+	}
+}
+
+// getQuotaUsage sums up the usage in the resource quotas of the tenant's namespaces, leaving out those exempt from
+// quota accounting.
+func (c *Controller) getQuotaUsage(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota) map[corev1.ResourceName]resource.Quantity {
+	return multitenancy.TenantQuotaUsage(c.readKubeclientset, tenantResourceQuotaCopy.GetName())
+}
+
+// getQuotaUtilization returns the resource with the highest utilization in percent of the tenant resource quota,
+// along with its usage summed up over the tenant's namespaces.
+func (c *Controller) getQuotaUtilization(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota) (corev1.ResourceName, resource.Quantity, resource.Quantity, float64) {
+	usedResourceList := c.getQuotaUsage(tenantResourceQuotaCopy)
+	var resourceName corev1.ResourceName
+	var used, allocated resource.Quantity
+	utilization := float64(0)
+	for key, allocatedQuantity := range c.getEffectiveQuota(tenantResourceQuotaCopy) {
+		if allocatedQuantity.IsZero() {
+			continue
+		}
+		usedQuantity := usedResourceList[key]
+		if resourceUtilization := float64(usedQuantity.MilliValue()) / float64(allocatedQuantity.MilliValue()) * 100; resourceUtilization > utilization {
+			resourceName, used, allocated, utilization = key, usedQuantity, allocatedQuantity, resourceUtilization
+		}
+	}
+	return resourceName, used, allocated, utilization
+}
+
 func (c *Controller) cleanup(tenantResourceQuotaCopy *corev1alpha1.TenantResourceQuota) {
 
 }
@@ -1,11 +1,16 @@
 package tenantresourcequota
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,18 +19,25 @@ import (
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	edgenettestclient "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/fake"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/metrics"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/notification"
 	"github.com/EdgeNet-project/edgenet/pkg/signals"
 	"github.com/EdgeNet-project/edgenet/pkg/util"
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 )
 
@@ -56,10 +68,21 @@ func TestMain(m *testing.M) {
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeclientset, time.Second*30)
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, time.Second*30)
 
-	controller := NewController(kubeclientset,
+	controller, err := NewController(kubeclientset,
 		edgenetclientset,
 		kubeInformerFactory.Core().V1().Nodes(),
-		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas())
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	kubeInformerFactory.Start(stopCh)
 	edgenetInformerFactory.Start(stopCh)
@@ -481,6 +504,805 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestResourceExpiry(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	randomString := util.GenerateRandomString(6)
+	g.CreateTenant(randomString)
+	tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+	tenantResourceQuota.SetName(randomString)
+	tenantResourceQuota.SetUID(types.UID(randomString))
+	claim := g.claimObj
+	claim.ResourceExpiry = map[corev1.ResourceName]metav1.Time{
+		corev1.ResourceCPU:    {Time: time.Now().Add(300 * time.Millisecond)},
+		corev1.ResourceMemory: {Time: time.Now().Add(900 * time.Millisecond)},
+	}
+	tenantResourceQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"staggered": claim}
+	_, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Delete(context.TODO(), tenantResourceQuota.GetName(), metav1.DeleteOptions{})
+
+	time.Sleep(150 * time.Millisecond)
+	tenantResourceQuotaCopy, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, 2, len(tenantResourceQuotaCopy.Spec.Claim["staggered"].ResourceList))
+
+	time.Sleep(450 * time.Millisecond)
+	tenantResourceQuotaCopy, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, 1, len(tenantResourceQuotaCopy.Spec.Claim["staggered"].ResourceList))
+	_, cpuExists := tenantResourceQuotaCopy.Spec.Claim["staggered"].ResourceList[corev1.ResourceCPU]
+	util.Equals(t, false, cpuExists)
+	assignedQuota := tenantResourceQuotaCopy.Fetch()
+	memoryQuota := assignedQuota[corev1.ResourceMemory]
+	util.Equals(t, true, memoryQuota.Equal(g.claimObj.ResourceList[corev1.ResourceMemory]))
+
+	time.Sleep(600 * time.Millisecond)
+	tenantResourceQuotaCopy, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, 0, len(tenantResourceQuotaCopy.Spec.Claim))
+}
+
+func TestClaimStart(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	randomString := util.GenerateRandomString(6)
+	g.CreateTenant(randomString)
+	tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+	tenantResourceQuota.SetName(randomString)
+	tenantResourceQuota.SetUID(types.UID(randomString))
+	scheduled := corev1alpha.ResourceTuning{
+		ResourceList: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4000m")},
+		Start:        &metav1.Time{Time: time.Now().Add(700 * time.Millisecond)},
+	}
+	tenantResourceQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"initial": g.claimObj, "next-quarter": scheduled}
+	_, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Delete(context.TODO(), tenantResourceQuota.GetName(), metav1.DeleteOptions{})
+
+	expectedCPU := g.claimObj.ResourceList[corev1.ResourceCPU]
+	time.Sleep(300 * time.Millisecond)
+	tenantResourceQuotaCopy, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, false, tenantResourceQuotaCopy.Spec.Claim["next-quarter"].HasStarted())
+	assignedCPU := tenantResourceQuotaCopy.Fetch()[corev1.ResourceCPU]
+	util.Equals(t, expectedCPU.MilliValue(), assignedCPU.MilliValue())
+	coreResourceQuota, err := kubeclientset.CoreV1().ResourceQuotas(tenantResourceQuota.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	coreCPU := coreResourceQuota.Spec.Hard[corev1.ResourceCPU]
+	util.Equals(t, expectedCPU.MilliValue(), coreCPU.MilliValue())
+
+	// The claim counts once its start date passes, without any change to the tenant resource quota
+	expectedCPU.Add(scheduled.ResourceList[corev1.ResourceCPU])
+	time.Sleep(900 * time.Millisecond)
+	tenantResourceQuotaCopy, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	assignedCPU = tenantResourceQuotaCopy.Fetch()[corev1.ResourceCPU]
+	util.Equals(t, expectedCPU.MilliValue(), assignedCPU.MilliValue())
+	coreResourceQuota, err = kubeclientset.CoreV1().ResourceQuotas(tenantResourceQuota.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+	util.OK(t, err)
+	coreCPU = coreResourceQuota.Spec.Hard[corev1.ResourceCPU]
+	util.Equals(t, expectedCPU.MilliValue(), coreCPU.MilliValue())
+}
+
+func TestClaimLabels(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	randomString := util.GenerateRandomString(6)
+	g.CreateTenant(randomString)
+	tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+	tenantResourceQuota.SetName(randomString)
+	tenantResourceQuota.SetUID(types.UID(randomString))
+	grant := g.claimObj.DeepCopy()
+	grant.Labels = map[string]string{"grant-id": "anr-19-ce25", "cost-center": "lip6"}
+	staggered := g.claimObj.DeepCopy()
+	staggered.Labels = map[string]string{"grant-id": "erc-2021"}
+	staggered.ResourceExpiry = map[corev1.ResourceName]metav1.Time{corev1.ResourceCPU: {Time: time.Now().Add(300 * time.Millisecond)}}
+	expiring := g.claimObj.DeepCopy()
+	expiring.Labels = map[string]string{"grant-id": "h2020"}
+	expiring.Expiry = &metav1.Time{Time: time.Now().Add(300 * time.Millisecond)}
+	tenantResourceQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"grant": *grant, "staggered": *staggered, "expiring": *expiring}
+	_, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Delete(context.TODO(), tenantResourceQuota.GetName(), metav1.DeleteOptions{})
+
+	// The labels survive the reconciliation, and go away only along with their expired claims
+	time.Sleep(750 * time.Millisecond)
+	tenantResourceQuotaCopy, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, 2, len(tenantResourceQuotaCopy.Spec.Claim))
+	util.Equals(t, grant.Labels, tenantResourceQuotaCopy.Spec.Claim["grant"].Labels)
+	util.Equals(t, staggered.Labels, tenantResourceQuotaCopy.Spec.Claim["staggered"].Labels)
+	util.Equals(t, 1, len(tenantResourceQuotaCopy.Spec.Claim["staggered"].ResourceList))
+	_, expiringExists := tenantResourceQuotaCopy.Spec.Claim["expiring"]
+	util.Equals(t, false, expiringExists)
+	util.Equals(t, map[string]map[string]string{"grant": grant.Labels, "staggered": staggered.Labels}, claimLabels(*tenantResourceQuotaCopy))
+}
+
+func TestQuotaProfiles(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	profiles := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: quotaProfilesName, Namespace: quotaProfilesNamespace},
+		Data: map[string]string{"small": "cpu: 2\nmemory: 4Gi", "medium": "cpu: 4000m\nmemory: 8Gi"}}
+	_, err := kubeclientset.CoreV1().ConfigMaps(quotaProfilesNamespace).Create(context.TODO(), profiles, metav1.CreateOptions{})
+	util.OK(t, err)
+	defer kubeclientset.CoreV1().ConfigMaps(quotaProfilesNamespace).Delete(context.TODO(), quotaProfilesName, metav1.DeleteOptions{})
+
+	cases := map[string]struct {
+		profile      string
+		resourceList corev1.ResourceList
+		expected     corev1.ResourceList
+	}{
+		"profile only": {"small", nil, corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("4Gi")}},
+		"overridden":   {"medium", corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("6Gi")}, corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("6Gi")}},
+		"unknown":      {"huge", corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}, nil},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			randomString := util.GenerateRandomString(6)
+			g.CreateTenant(randomString)
+			tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+			tenantResourceQuota.SetName(randomString)
+			tenantResourceQuota.SetUID(types.UID(randomString))
+			tenantResourceQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"initial": {Profile: tc.profile, ResourceList: tc.resourceList}}
+			tenantResourceQuota.Spec.Drop = nil
+			_, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+			util.OK(t, err)
+			defer edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Delete(context.TODO(), tenantResourceQuota.GetName(), metav1.DeleteOptions{})
+			time.Sleep(300 * time.Millisecond)
+
+			tenantResourceQuotaCopy, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+			util.OK(t, err)
+			if tc.expected == nil {
+				util.Equals(t, corev1alpha.StatusFailed, tenantResourceQuotaCopy.Status.State)
+				util.Equals(t, fmt.Sprintf("%s: quota profile \"huge\" referenced by \"initial\" is not defined", messageProfileFail), tenantResourceQuotaCopy.Status.Message)
+				return
+			}
+			util.Equals(t, corev1alpha.StatusApplied, tenantResourceQuotaCopy.Status.State)
+			coreResourceQuota, err := kubeclientset.CoreV1().ResourceQuotas(randomString).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+			util.OK(t, err)
+			for name, quantity := range tc.expected {
+				hard := coreResourceQuota.Spec.Hard[name]
+				util.Equals(t, true, quantity.Equal(hard))
+			}
+		})
+	}
+}
+
+func TestExpandQuotaProfiles(t *testing.T) {
+	profiles := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: quotaProfilesName, Namespace: quotaProfilesNamespace},
+		Data: map[string]string{"small": "cpu: 2\nmemory: 4Gi"}}
+	malformed := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: quotaProfilesName, Namespace: quotaProfilesNamespace},
+		Data: map[string]string{"small": "cpu: 2\nmemory: 4m"}}
+
+	cases := map[string]struct {
+		configMap *corev1.ConfigMap
+		claim     corev1alpha.ResourceTuning
+		drop      corev1alpha.ResourceTuning
+		expected  map[corev1.ResourceName]resource.Quantity
+		fails     bool
+	}{
+		"no profile":         {nil, corev1alpha.ResourceTuning{ResourceList: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}}, corev1alpha.ResourceTuning{}, map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("1")}, false},
+		"claim and drop":     {profiles, corev1alpha.ResourceTuning{Profile: "small"}, corev1alpha.ResourceTuning{Profile: "small", ResourceList: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}}, map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("0"), corev1.ResourceMemory: resource.MustParse("3Gi")}, false},
+		"no profiles":        {nil, corev1alpha.ResourceTuning{Profile: "small"}, corev1alpha.ResourceTuning{}, nil, true},
+		"unknown profile":    {profiles, corev1alpha.ResourceTuning{Profile: "large"}, corev1alpha.ResourceTuning{}, nil, true},
+		"malformed profiles": {malformed, corev1alpha.ResourceTuning{Profile: "small"}, corev1alpha.ResourceTuning{}, nil, true},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			profileKubeclientset := testclient.NewSimpleClientset()
+			if tc.configMap != nil {
+				profileKubeclientset = testclient.NewSimpleClientset(tc.configMap)
+			}
+			controller := &Controller{kubeclientset: profileKubeclientset, readKubeclientset: profileKubeclientset}
+			tenantResourceQuota := &corev1alpha.TenantResourceQuota{Spec: corev1alpha.TenantResourceQuotaSpec{
+				Claim: map[string]corev1alpha.ResourceTuning{"claim": tc.claim},
+				Drop:  map[string]corev1alpha.ResourceTuning{"drop": tc.drop},
+			}}
+			err := controller.expandQuotaProfiles(tenantResourceQuota)
+			if tc.fails {
+				util.NotEquals(t, nil, err)
+				return
+			}
+			util.OK(t, err)
+			assignedQuota := tenantResourceQuota.Fetch()
+			util.Equals(t, len(tc.expected), len(assignedQuota))
+			for name, quantity := range tc.expected {
+				util.Equals(t, true, quantity.Equal(assignedQuota[name]))
+			}
+		})
+	}
+}
+
+func TestCapacityShares(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller keeps the nodes of the other tests out of the cluster capacity
+	capacityKubeclientset := testclient.NewSimpleClientset()
+	capacityEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(capacityKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(capacityEdgenetclientset, 0)
+	controller, err := NewController(capacityKubeclientset,
+		capacityEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	util.OK(t, err)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	kubeInformerFactory.Start(stopCh)
+	edgenetInformerFactory.Start(stopCh)
+	go controller.Run(1, stopCh)
+
+	_, err = capacityKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = capacityEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName(), Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()}}}
+	_, err = capacityKubeclientset.CoreV1().Namespaces().Create(context.TODO(), namespace, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = capacityKubeclientset.CoreV1().Nodes().Create(context.TODO(), g.nodeObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	time.Sleep(100 * time.Millisecond)
+
+	// Half of the cluster CPU, and a quarter of its memory, along with a fixed amount of storage
+	tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+	tenantResourceQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"initial": {
+		ResourceList:       corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100"), "requests.storage": resource.MustParse("8Gi")},
+		CapacityPercentage: map[corev1.ResourceName]int{corev1.ResourceCPU: 50, corev1.ResourceMemory: 25},
+	}}
+	_, err = capacityEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	var checkQuota = func(cpu, memory string) {
+		coreResourceQuota, err := capacityKubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+		util.OK(t, err)
+		for name, expected := range map[corev1.ResourceName]string{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory, "requests.storage": "8Gi"} {
+			hard := coreResourceQuota.Spec.Hard[name]
+			util.Equals(t, true, resource.MustParse(expected).Equal(hard))
+		}
+	}
+	time.Sleep(500 * time.Millisecond)
+	checkQuota("1", "1Gi")
+
+	t.Run("node added", func(t *testing.T) {
+		node := g.nodeObj.DeepCopy()
+		node.SetName("fr-idf-0001.edge-net.io")
+		node.Status.Allocatable[corev1.ResourceCPU] = resource.MustParse("3")
+		_, err := capacityKubeclientset.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+		util.OK(t, err)
+		time.Sleep(500 * time.Millisecond)
+		checkQuota("2500m", "2Gi")
+	})
+	t.Run("node removed", func(t *testing.T) {
+		err := capacityKubeclientset.CoreV1().Nodes().Delete(context.TODO(), g.nodeObj.GetName(), metav1.DeleteOptions{})
+		util.OK(t, err)
+		time.Sleep(500 * time.Millisecond)
+		checkQuota("1500m", "1Gi")
+	})
+	t.Run("invalid share", func(t *testing.T) {
+		tenantResourceQuotaCopy := tenantResourceQuota.DeepCopy()
+		tenantResourceQuotaCopy.Spec.Claim["initial"].CapacityPercentage[corev1.ResourceCPU] = 150
+		err := controller.expandCapacityShares(tenantResourceQuotaCopy)
+		util.Equals(t, "share of cpu in \"initial\" is 150%, it must be between 0 and 100", err.Error())
+	})
+}
+
+func TestReapingInterval(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// expiringClaims returns claims expiring after the given delays
+	var expiringClaims = func(delays ...time.Duration) map[string]corev1alpha.ResourceTuning {
+		claims := map[string]corev1alpha.ResourceTuning{"initial": g.claimObj}
+		for i, delay := range delays {
+			claims[fmt.Sprintf("expiring-%d", i)] = corev1alpha.ResourceTuning{
+				ResourceList: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				Expiry:       &metav1.Time{Time: time.Now().Add(delay)},
+			}
+		}
+		return claims
+	}
+
+	t.Run("reaping date", func(t *testing.T) {
+		claims := expiringClaims(time.Minute, 90*time.Second, 100*time.Second, 10*time.Minute)
+		reapingDate, exists := getReapingDate(0, claims)
+		util.Equals(t, true, exists)
+		util.Equals(t, claims["expiring-0"].Expiry, reapingDate)
+		reapingDate, _ = getReapingDate(time.Minute, claims)
+		util.Equals(t, claims["expiring-2"].Expiry, reapingDate)
+		reapingDate, _ = getReapingDate(time.Hour, claims)
+		util.Equals(t, claims["expiring-3"].Expiry, reapingDate)
+		_, exists = getReapingDate(time.Hour, map[string]corev1alpha.ResourceTuning{"initial": g.claimObj})
+		util.Equals(t, false, exists)
+	})
+	t.Run("batched sweep", func(t *testing.T) {
+		// A dedicated controller, whose events are counted, reaps the expiries within a second at once
+		reapingKubeclientset := testclient.NewSimpleClientset()
+		reapingEdgenetclientset := edgenettestclient.NewSimpleClientset()
+		kubeInformerFactory := kubeinformers.NewSharedInformerFactory(reapingKubeclientset, 0)
+		edgenetInformerFactory := informers.NewSharedInformerFactory(reapingEdgenetclientset, 0)
+		controller, err := NewController(reapingKubeclientset,
+			reapingEdgenetclientset,
+			kubeInformerFactory.Core().V1().Nodes(),
+			edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+			nil,
+			time.Hour,
+			multitenancy.DefaultQuotaNames,
+			time.Second,
+			nil,
+			nil,
+			0,
+			nil)
+		util.OK(t, err)
+		recorder := record.NewFakeRecorder(1000)
+		controller.recorder = recorder
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		kubeInformerFactory.Start(stopCh)
+		edgenetInformerFactory.Start(stopCh)
+		go controller.Run(1, stopCh)
+
+		_, err = reapingKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+		util.OK(t, err)
+		_, err = reapingEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+		util.OK(t, err)
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName(), Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()}}}
+		_, err = reapingKubeclientset.CoreV1().Namespaces().Create(context.TODO(), namespace, metav1.CreateOptions{})
+		util.OK(t, err)
+		tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+		tenantResourceQuota.Spec.Claim = expiringClaims(300*time.Millisecond, 450*time.Millisecond, 600*time.Millisecond)
+		_, err = reapingEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+		util.OK(t, err)
+
+		// Nothing is reaped before the last of the clustered expiries
+		time.Sleep(450 * time.Millisecond)
+		tenantResourceQuotaCopy, err := reapingEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, 4, len(tenantResourceQuotaCopy.Spec.Claim))
+
+		time.Sleep(450 * time.Millisecond)
+		tenantResourceQuotaCopy, err = reapingEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, 1, len(tenantResourceQuotaCopy.Spec.Claim))
+		sweeps := 0
+		for len(recorder.Events) > 0 {
+			if event := <-recorder.Events; strings.HasPrefix(event, fmt.Sprintf("%s %s", corev1.EventTypeNormal, successRemoved)) {
+				sweeps++
+			}
+		}
+		util.Equals(t, 1, sweeps)
+	})
+}
+
+func TestAggregatedQuotas(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller keeps the tenant resource quotas of the other tests apart
+	aggregateKubeclientset := testclient.NewSimpleClientset()
+	aggregateEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(aggregateKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(aggregateEdgenetclientset, 0)
+	controller, err := NewController(aggregateKubeclientset,
+		aggregateEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	util.OK(t, err)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	kubeInformerFactory.Start(stopCh)
+	edgenetInformerFactory.Start(stopCh)
+	go controller.Run(1, stopCh)
+
+	_, err = aggregateKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = aggregateEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName(), Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()}}}
+	_, err = aggregateKubeclientset.CoreV1().Namespaces().Create(context.TODO(), namespace, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	// The base quota of the tenant, and a grant funded by another budget source
+	baseQuota := g.tenantResourceQuotaObj.DeepCopy()
+	baseQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"initial": g.claimObj}
+	_, err = aggregateEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), baseQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	grantQuota := g.tenantResourceQuotaObj.DeepCopy()
+	grantQuota.SetName("edgenet-grant")
+	grantQuota.SetUID("grant")
+	grantQuota.SetLabels(map[string]string{"edge-net.io/tenant": g.tenantObj.GetName()})
+	grantQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"grant": {
+		ResourceList: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+	}}
+	grantQuota.Spec.Drop = map[string]corev1alpha.ResourceTuning{"overhead": {
+		ResourceList: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+	}}
+	_, err = aggregateEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), grantQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	var checkQuota = func(cpu, memory string) {
+		coreResourceQuota, err := aggregateKubeclientset.CoreV1().ResourceQuotas(g.tenantObj.GetName()).Get(context.TODO(), "core-quota", metav1.GetOptions{})
+		util.OK(t, err)
+		for name, expected := range map[corev1.ResourceName]string{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory} {
+			hard := coreResourceQuota.Spec.Hard[name]
+			util.Equals(t, true, resource.MustParse(expected).Equal(hard))
+		}
+	}
+	time.Sleep(500 * time.Millisecond)
+	checkQuota("15", "14Gi")
+	grant, err := aggregateEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), grantQuota.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, corev1alpha.StatusApplied, grant.Status.State)
+	util.Equals(t, "Tenant Resource Quota aggregated into the quota of tenant edgenet", grant.Status.Message)
+
+	t.Run("effective quota", func(t *testing.T) {
+		tenantQuota, err := multitenancy.TenantQuota(aggregateEdgenetclientset, g.tenantObj.GetName())
+		util.OK(t, err)
+		util.Equals(t, true, resource.MustParse("15").Equal(tenantQuota[corev1.ResourceCPU]))
+		util.Equals(t, true, resource.MustParse("14Gi").Equal(tenantQuota[corev1.ResourceMemory]))
+	})
+	t.Run("grant removed", func(t *testing.T) {
+		err := aggregateEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Delete(context.TODO(), grantQuota.GetName(), metav1.DeleteOptions{})
+		util.OK(t, err)
+		time.Sleep(500 * time.Millisecond)
+		checkQuota("12", "12Gi")
+	})
+}
+
+func TestQuotaAlert(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, lets the test drive the alerts step by step
+	alertKubeclientset := testclient.NewSimpleClientset()
+	alertEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(alertKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(alertEdgenetclientset, 0)
+	controller, err := NewController(alertKubeclientset,
+		alertEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		[]int{80, 95},
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(10)
+	controller.recorder = recorder
+	notifications := []*notification.Content{}
+	controller.notify = func(content *notification.Content, purpose string) error {
+		util.Equals(t, "tenant-quota-alert", purpose)
+		notifications = append(notifications, content)
+		return nil
+	}
+
+	_, err = alertEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+	tenantResourceQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"initial": g.claimObj}
+	tenantResourceQuota.Status.State = corev1alpha1.StatusApplied
+	_, err = alertEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	// The usage is spread over the core namespace and a workspace
+	for _, namespace := range []string{"edgenet", "edgenet-workspace"} {
+		_, err = alertKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: map[string]string{"edge-net.io/tenant": "edgenet"}}}, metav1.CreateOptions{})
+		util.OK(t, err)
+		resourceQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: namespace}}
+		resourceQuota.Status.Used = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("5000m"), corev1.ResourceMemory: resource.MustParse("1Gi")}
+		_, err = alertKubeclientset.CoreV1().ResourceQuotas(namespace).Create(context.TODO(), resourceQuota, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+
+	var checkAlert = func(expectedThreshold int) {
+		tenantResourceQuotaCopy, err := alertEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		controller.alertOnQuotaUtilization(tenantResourceQuotaCopy, "")
+		tenantResourceQuotaCopy, err = alertEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, expectedThreshold, tenantResourceQuotaCopy.Status.AlertedThreshold)
+	}
+
+	t.Run("cross threshold", func(t *testing.T) {
+		// 10 of 12 CPUs are in use
+		checkAlert(80)
+		util.Equals(t, 1, len(recorder.Events))
+		util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, warningQuotaAlert, fmt.Sprintf(messageQuotaAlert, "cpu", 80)), <-recorder.Events)
+		util.Equals(t, 1, len(notifications))
+		util.Equals(t, []string{"john.doe@edge-net.org"}, notifications[0].Recipient)
+		util.Equals(t, 80, notifications[0].QuotaAlert.Threshold)
+		util.Equals(t, "10", notifications[0].QuotaAlert.Used)
+	})
+	t.Run("same threshold", func(t *testing.T) {
+		checkAlert(80)
+		util.Equals(t, 0, len(recorder.Events))
+		util.Equals(t, 1, len(notifications))
+	})
+	t.Run("cooldown", func(t *testing.T) {
+		resourceQuota, err := alertKubeclientset.CoreV1().ResourceQuotas("edgenet").Get(context.TODO(), "quota", metav1.GetOptions{})
+		util.OK(t, err)
+		resourceQuota.Status.Used[corev1.ResourceCPU] = resource.MustParse("7")
+		_, err = alertKubeclientset.CoreV1().ResourceQuotas("edgenet").UpdateStatus(context.TODO(), resourceQuota, metav1.UpdateOptions{})
+		util.OK(t, err)
+		checkAlert(80)
+		util.Equals(t, 0, len(recorder.Events))
+		util.Equals(t, 1, len(notifications))
+	})
+}
+
+func TestClaimExpiryNotice(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, lets the test drive the notices step by step
+	expiryKubeclientset := testclient.NewSimpleClientset()
+	expiryEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(expiryKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(expiryEdgenetclientset, 0)
+	controller, err := NewController(expiryKubeclientset,
+		expiryEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		time.Hour,
+		nil)
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(10)
+	controller.recorder = recorder
+	notifications := []*notification.Content{}
+	failing := false
+	controller.notify = func(content *notification.Content, purpose string) error {
+		util.Equals(t, "tenant-quota-expiry", purpose)
+		if failing {
+			return fmt.Errorf("mail server unavailable")
+		}
+		notifications = append(notifications, content)
+		return nil
+	}
+
+	_, err = expiryEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	util.OK(t, err)
+	burstExpiry := metav1.NewTime(time.Now().Add(30 * time.Minute))
+	laterExpiry := metav1.NewTime(time.Now().Add(3 * time.Hour))
+	burstClaim := g.claimObj
+	burstClaim.Expiry = &burstExpiry
+	laterClaim := g.claimObj
+	laterClaim.Expiry = &laterExpiry
+	tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+	tenantResourceQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"initial": g.claimObj, "burst": burstClaim, "later": laterClaim}
+	tenantResourceQuota.Status.State = corev1alpha1.StatusApplied
+	_, err = expiryEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	var checkNotice = func() *corev1alpha1.TenantResourceQuota {
+		tenantResourceQuotaCopy, err := expiryEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		controller.noticeClaimExpiry(tenantResourceQuotaCopy, "")
+		tenantResourceQuotaCopy, err = expiryEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		return tenantResourceQuotaCopy
+	}
+
+	t.Run("notification failure", func(t *testing.T) {
+		failing = true
+		defer func() { failing = false }()
+		tenantResourceQuotaCopy := checkNotice()
+		util.Equals(t, 0, len(recorder.Events))
+		util.Equals(t, 0, len(notifications))
+		util.Equals(t, 0, len(tenantResourceQuotaCopy.Status.ExpiryNotified))
+	})
+	t.Run("within lead time", func(t *testing.T) {
+		tenantResourceQuotaCopy := checkNotice()
+		util.Equals(t, 1, len(recorder.Events))
+		util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, warningExpiryNotice, fmt.Sprintf(messageExpiryNotice, "burst/cpu, burst/memory")), <-recorder.Events)
+		util.Equals(t, 1, len(notifications))
+		util.Equals(t, []string{"john.doe@edge-net.org"}, notifications[0].Recipient)
+		util.Equals(t, 2, len(notifications[0].ClaimExpiry.Resources))
+		util.Equals(t, "burst", notifications[0].ClaimExpiry.Resources[0].Claim)
+		util.Equals(t, "cpu", notifications[0].ClaimExpiry.Resources[0].Resource)
+		util.Equals(t, "12", notifications[0].ClaimExpiry.Resources[0].Quantity)
+		_, notified := tenantResourceQuotaCopy.Status.ExpiryNotified["burst"]
+		util.Equals(t, true, notified)
+		_, notified = tenantResourceQuotaCopy.Status.ExpiryNotified["later"]
+		util.Equals(t, false, notified)
+	})
+	t.Run("already notified", func(t *testing.T) {
+		checkNotice()
+		util.Equals(t, 0, len(recorder.Events))
+		util.Equals(t, 1, len(notifications))
+	})
+	t.Run("claim extended", func(t *testing.T) {
+		tenantResourceQuotaCopy, err := expiryEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenantResourceQuota.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		extendedExpiry := metav1.NewTime(time.Now().Add(45 * time.Minute))
+		burstClaim := tenantResourceQuotaCopy.Spec.Claim["burst"]
+		burstClaim.Expiry = &extendedExpiry
+		tenantResourceQuotaCopy.Spec.Claim["burst"] = burstClaim
+		_, err = expiryEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Update(context.TODO(), tenantResourceQuotaCopy, metav1.UpdateOptions{})
+		util.OK(t, err)
+		checkNotice()
+		util.Equals(t, 1, len(recorder.Events))
+		<-recorder.Events
+		util.Equals(t, 2, len(notifications))
+	})
+}
+
+func TestQuotaUtilizationMetrics(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, for a tenant the controller of the other tests does not know of
+	metricsKubeclientset := testclient.NewSimpleClientset()
+	metricsEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(metricsKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(metricsEdgenetclientset, 0)
+	controller, err := NewController(metricsKubeclientset,
+		metricsEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	util.OK(t, err)
+
+	tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+	tenantResourceQuota.SetName("lip6")
+	tenantResourceQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"initial": g.claimObj}
+	for _, namespace := range []string{"lip6", "lip6-workspace"} {
+		_, err := metricsKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: map[string]string{"edge-net.io/tenant": "lip6"}}}, metav1.CreateOptions{})
+		util.OK(t, err)
+		resourceQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: namespace}}
+		resourceQuota.Status.Used = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1500m"), corev1.ResourceMemory: resource.MustParse("1Gi")}
+		_, err = metricsKubeclientset.CoreV1().ResourceQuotas(namespace).Create(context.TODO(), resourceQuota, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+	controller.exportQuotaUtilization(tenantResourceQuota)
+
+	recorder := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	for _, sample := range []string{
+		"edgenet_tenant_quota_used{tenant=\"lip6\",resource=\"cpu\"} 3\n",
+		"edgenet_tenant_quota_used{tenant=\"lip6\",resource=\"memory\"} 2.147483648e+09\n",
+		"edgenet_tenant_quota_allocated{tenant=\"lip6\",resource=\"cpu\"} 12\n",
+		"edgenet_tenant_quota_allocated{tenant=\"lip6\",resource=\"memory\"} 1.2884901888e+10\n",
+	} {
+		util.Equals(t, true, strings.Contains(body, sample))
+	}
+
+	t.Run("resource dropped", func(t *testing.T) {
+		tenantResourceQuota.Spec.Claim["initial"] = corev1alpha.ResourceTuning{ResourceList: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("12")}}
+		controller.exportQuotaUtilization(tenantResourceQuota)
+		_, exists := tenantQuotaAllocated.Get("lip6", "memory")
+		util.Equals(t, false, exists)
+		_, exists = tenantQuotaUsed.Get("lip6", "memory")
+		util.Equals(t, false, exists)
+		value, exists := tenantQuotaUsed.Get("lip6", "cpu")
+		util.Equals(t, true, exists)
+		util.Equals(t, float64(3), value)
+	})
+}
+
+func TestQuotaSnapshot(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, on a cluster of its own so that only the tenants below are snapshotted
+	snapshotKubeclientset := testclient.NewSimpleClientset()
+	snapshotEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(snapshotKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(snapshotEdgenetclientset, 0)
+	controller, err := NewController(snapshotKubeclientset,
+		snapshotEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	util.OK(t, err)
+
+	for _, key := range []struct{ name, state string }{{"lip6", corev1alpha1.StatusApplied}, {"inria", corev1alpha1.StatusFailed}} {
+		tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+		tenantResourceQuota.SetName(key.name)
+		initial := g.claimObj.DeepCopy()
+		initial.Labels = map[string]string{"cost-center": key.name}
+		tenantResourceQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"initial": *initial, "unlabeled": g.claimObj}
+		tenantResourceQuota.Status.State = key.state
+		_, err := snapshotEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+		util.OK(t, err)
+		_, err = snapshotKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: key.name, Labels: map[string]string{"edge-net.io/tenant": key.name}}}, metav1.CreateOptions{})
+		util.OK(t, err)
+		resourceQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: key.name}}
+		resourceQuota.Status.Used = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1500m"), corev1.ResourceMemory: resource.MustParse("1Gi")}
+		_, err = snapshotKubeclientset.CoreV1().ResourceQuotas(key.name).Create(context.TODO(), resourceQuota, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+	// An additional quota granted to the tenant adds up to its allocation rather than being snapshotted on its own
+	additionalQuota := g.tenantResourceQuotaObj.DeepCopy()
+	additionalQuota.SetName("lip6-grant")
+	additionalQuota.SetLabels(map[string]string{"edge-net.io/tenant": "lip6"})
+	additionalQuota.Spec.Claim = map[string]corev1alpha.ResourceTuning{"grant": {ResourceList: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+		Labels: map[string]string{"grant-id": "anr-19-ce25"}}}
+	additionalQuota.Status.State = corev1alpha1.StatusApplied
+	_, err = snapshotEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), additionalQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	util.OK(t, edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas().Informer().GetIndexer().Add(additionalQuota))
+
+	snapshotTime := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+	// The labels of the claims making up the allocation are surfaced
+	snapshots, err := controller.TakeQuotaSnapshots(snapshotTime)
+	util.OK(t, err)
+	util.Equals(t, 1, len(snapshots))
+	util.Equals(t, map[string]map[string]string{"initial": {"cost-center": "lip6"}, "lip6-grant/grant": {"grant-id": "anr-19-ce25"}}, snapshots[0].Labels)
+	sink := new(bytes.Buffer)
+	util.OK(t, controller.writeQuotaSnapshots(snapshotTime, sink))
+	// The usage changing afterwards does not alter the snapshot taken
+	resourceQuota, err := snapshotKubeclientset.CoreV1().ResourceQuotas("lip6").Get(context.TODO(), "quota", metav1.GetOptions{})
+	util.OK(t, err)
+	resourceQuota.Status.Used[corev1.ResourceCPU] = resource.MustParse("8")
+	_, err = snapshotKubeclientset.CoreV1().ResourceQuotas("lip6").Update(context.TODO(), resourceQuota, metav1.UpdateOptions{})
+	util.OK(t, err)
+
+	lines := strings.Split(strings.TrimSpace(sink.String()), "\n")
+	util.Equals(t, 1, len(lines))
+	var snapshot QuotaSnapshot
+	util.OK(t, json.Unmarshal([]byte(lines[0]), &snapshot))
+	util.Equals(t, "lip6", snapshot.Tenant)
+	util.Equals(t, true, snapshotTime.Equal(snapshot.Time))
+	quantities := func(resourceList map[corev1.ResourceName]resource.Quantity) map[corev1.ResourceName]string {
+		values := make(map[corev1.ResourceName]string)
+		for key, quantity := range resourceList {
+			values[key] = quantity.String()
+		}
+		return values
+	}
+	util.Equals(t, map[corev1.ResourceName]string{corev1.ResourceCPU: "26", corev1.ResourceMemory: "26Gi"}, quantities(snapshot.Allocated))
+	util.Equals(t, map[string]map[string]string{"initial": {"cost-center": "lip6"}, "lip6-grant/grant": {"grant-id": "anr-19-ce25"}}, snapshot.Labels)
+	util.Equals(t, map[corev1.ResourceName]string{corev1.ResourceCPU: "1500m", corev1.ResourceMemory: "1Gi"}, quantities(snapshot.Used))
+}
+
+func TestParseAlertThresholds(t *testing.T) {
+	thresholds, err := ParseAlertThresholds(" 95, 80% ,")
+	util.OK(t, err)
+	util.Equals(t, []int{80, 95}, thresholds)
+	thresholds, err = ParseAlertThresholds("")
+	util.OK(t, err)
+	util.Equals(t, 0, len(thresholds))
+	for _, value := range []string{"0", "101", "eighty"} {
+		_, err = ParseAlertThresholds(value)
+		util.NotEquals(t, nil, err)
+	}
+}
+
 func getQuotas(claimRaw map[string]corev1alpha.ResourceTuning) (int64, int64) {
 	var cpuQuota int64
 	var memoryQuota int64
@@ -492,3 +1314,274 @@ func getQuotas(claimRaw map[string]corev1alpha.ResourceTuning) (int64, int64) {
 	}
 	return cpuQuota, memoryQuota
 }
+
+func TestTransfer(t *testing.T) {
+	transferEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	for name, cpu := range map[string]string{"lab": "8000m", "partner": "2000m"} {
+		tenantResourceQuota := corev1alpha1.TenantResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		tenantResourceQuota.Spec.Claim = map[string]corev1alpha1.ResourceTuning{
+			"initial": {ResourceList: map[corev1.ResourceName]resource.Quantity{"cpu": resource.MustParse(cpu), "memory": resource.MustParse("8Gi")}},
+		}
+		_, err := transferEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), &tenantResourceQuota, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+	assigned := func(name string, key corev1.ResourceName) string {
+		tenantResourceQuota, err := transferEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), name, metav1.GetOptions{})
+		util.OK(t, err)
+		quantity := tenantResourceQuota.Fetch()[key]
+		return quantity.String()
+	}
+
+	err := Transfer(transferEdgenetclientset, "lab", "partner", corev1.ResourceList{"cpu": resource.MustParse("3000m"), "memory": resource.MustParse("2Gi")})
+	util.OK(t, err)
+	util.Equals(t, "5", assigned("lab", "cpu"))
+	util.Equals(t, "6Gi", assigned("lab", "memory"))
+	util.Equals(t, "5", assigned("partner", "cpu"))
+	util.Equals(t, "10Gi", assigned("partner", "memory"))
+	lab, err := transferEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), "lab", metav1.GetOptions{})
+	util.OK(t, err)
+	for key := range lab.Spec.Drop {
+		util.Equals(t, true, strings.HasPrefix(key, "transfer-to-partner-"))
+	}
+
+	t.Run("insufficient", func(t *testing.T) {
+		err := Transfer(transferEdgenetclientset, "lab", "partner", corev1.ResourceList{"cpu": resource.MustParse("1000m"), "memory": resource.MustParse("7Gi")})
+		util.Equals(t, true, err != nil)
+		util.Equals(t, "5", assigned("lab", "cpu"))
+		util.Equals(t, "6Gi", assigned("lab", "memory"))
+		util.Equals(t, "5", assigned("partner", "cpu"))
+	})
+	t.Run("unknown destination", func(t *testing.T) {
+		err := Transfer(transferEdgenetclientset, "lab", "unknown", corev1.ResourceList{"cpu": resource.MustParse("1000m")})
+		util.Equals(t, true, err != nil)
+		util.Equals(t, "5", assigned("lab", "cpu"))
+	})
+	t.Run("reverted", func(t *testing.T) {
+		transferEdgenetclientset.PrependReactor("update", "tenantresourcequotas", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.(k8stesting.UpdateAction).GetObject().(*corev1alpha1.TenantResourceQuota).GetName() == "partner" {
+				return true, nil, errors.NewForbidden(corev1alpha1.Resource("tenantresourcequotas"), "partner", fmt.Errorf("quota frozen"))
+			}
+			return false, nil, nil
+		})
+		err := Transfer(transferEdgenetclientset, "lab", "partner", corev1.ResourceList{"cpu": resource.MustParse("1000m")})
+		util.Equals(t, true, errors.IsForbidden(err))
+		util.Equals(t, "5", assigned("lab", "cpu"))
+		util.Equals(t, "5", assigned("partner", "cpu"))
+	})
+	t.Run("invalid amount", func(t *testing.T) {
+		util.Equals(t, true, Transfer(transferEdgenetclientset, "lab", "partner", corev1.ResourceList{"cpu": resource.MustParse("-1")}) != nil)
+		util.Equals(t, true, Transfer(transferEdgenetclientset, "lab", "lab", corev1.ResourceList{"cpu": resource.MustParse("1")}) != nil)
+	})
+}
+
+func TestQuotaExemptNamespaces(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, for a tenant with a monitoring namespace exempt from quota accounting
+	exemptKubeclientset := testclient.NewSimpleClientset()
+	exemptEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(exemptKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(exemptEdgenetclientset, 0)
+	controller, err := NewController(exemptKubeclientset,
+		exemptEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	util.OK(t, err)
+
+	tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+	tenantResourceQuota.SetName("inria")
+	for namespace, exempt := range map[string]string{"inria": "false", "inria-monitoring": "true"} {
+		namespaceObj := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: map[string]string{"edge-net.io/tenant": "inria", multitenancy.QuotaExemptLabel: exempt}}}
+		_, err := exemptKubeclientset.CoreV1().Namespaces().Create(context.TODO(), namespaceObj, metav1.CreateOptions{})
+		util.OK(t, err)
+		resourceQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: namespace}}
+		resourceQuota.Status.Used = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+		_, err = exemptKubeclientset.CoreV1().ResourceQuotas(namespace).Create(context.TODO(), resourceQuota, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+
+	usedResourceList := controller.getQuotaUsage(tenantResourceQuota)
+	cpu := usedResourceList[corev1.ResourceCPU]
+	util.Equals(t, "2", cpu.String())
+
+	t.Run("exempt subnamespace", func(t *testing.T) {
+		subnamespace := g.subNamespaceObj.DeepCopy()
+		subnamespace.SetNamespace("inria")
+		child := "inria-monitoring"
+		subnamespace.Status.Child = &child
+		_, err := exemptEdgenetclientset.CoreV1alpha1().SubNamespaces("inria").Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+		util.OK(t, err)
+		remainingQuotaResourceList := map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("4")}
+		remainingQuotaResourceList, lastInSubnamespace, sufficient := controller.subtractSubnamespaceQuotas("inria", remainingQuotaResourceList)
+		util.Equals(t, true, sufficient)
+		util.Equals(t, "", lastInSubnamespace)
+		cpu := remainingQuotaResourceList[corev1.ResourceCPU]
+		util.Equals(t, "4", cpu.String())
+	})
+}
+
+func TestChildOnlyResources(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, that leaves the pods of children out of the parent quota
+	childOnlyKubeclientset := testclient.NewSimpleClientset()
+	childOnlyEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(childOnlyKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(childOnlyEdgenetclientset, 0)
+	controller, err := NewController(childOnlyKubeclientset,
+		childOnlyEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		multitenancy.ChildOnlyResources{corev1.ResourcePods})
+	util.OK(t, err)
+
+	subnamespace := g.subNamespaceObj.DeepCopy()
+	subnamespace.SetNamespace("lab")
+	subnamespace.Spec.Workspace.ResourceAllocation = map[corev1.ResourceName]resource.Quantity{
+		corev1.ResourceCPU:  resource.MustParse("2"),
+		corev1.ResourcePods: resource.MustParse("50"),
+	}
+	_, err = childOnlyEdgenetclientset.CoreV1alpha1().SubNamespaces("lab").Create(context.TODO(), subnamespace, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	// The pods of the child exceed those left to the parent, which does not make the quota insufficient
+	remainingQuotaResourceList := map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")}
+	remainingQuotaResourceList, lastInSubnamespace, sufficient := controller.subtractSubnamespaceQuotas("lab", remainingQuotaResourceList)
+	util.Equals(t, true, sufficient)
+	util.Equals(t, subnamespace.GetName(), lastInSubnamespace)
+	cpu := remainingQuotaResourceList[corev1.ResourceCPU]
+	util.Equals(t, "2", cpu.String())
+	pods := remainingQuotaResourceList[corev1.ResourcePods]
+	util.Equals(t, "10", pods.String())
+
+	controller.childOnlyResources = nil
+	remainingQuotaResourceList = map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("4"), corev1.ResourcePods: resource.MustParse("10")}
+	_, _, sufficient = controller.subtractSubnamespaceQuotas("lab", remainingQuotaResourceList)
+	util.Equals(t, false, sufficient)
+}
+
+func TestTuneConflict(t *testing.T) {
+	// A dedicated controller, which is not started, whose quota update conflicts with the subnamespace controller
+	conflictKubeclientset := testclient.NewSimpleClientset()
+	conflictEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(conflictKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(conflictEdgenetclientset, 0)
+	controller, err := NewController(conflictKubeclientset,
+		conflictEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		nil,
+		nil,
+		0,
+		nil)
+	util.OK(t, err)
+	coreQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: multitenancy.DefaultQuotaNames.Core, Namespace: "lab"}}
+	_, err = conflictKubeclientset.CoreV1().ResourceQuotas("lab").Create(context.TODO(), coreQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	conflicts := 0
+	conflictKubeclientset.PrependReactor("update", "resourcequotas", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts == 0 {
+			conflicts++
+			return true, nil, errors.NewConflict(corev1.Resource("resourcequotas"), coreQuota.GetName(), fmt.Errorf("the object has been modified"))
+		}
+		return false, nil, nil
+	})
+
+	remainingQuotaResourceList := map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("4")}
+	deleted, failed := controller.tuneResourceQuota("lab", "core", remainingQuotaResourceList)
+	util.Equals(t, false, deleted)
+	util.Equals(t, false, failed)
+	util.Equals(t, 1, conflicts)
+	coreQuota, err = conflictKubeclientset.CoreV1().ResourceQuotas("lab").Get(context.TODO(), coreQuota.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	cpu := coreQuota.Spec.Hard[corev1.ResourceCPU]
+	util.Equals(t, "4", cpu.String())
+	// The quantities given are left as they are for the traversal to go on with
+	cpu = remainingQuotaResourceList[corev1.ResourceCPU]
+	util.Equals(t, "4", cpu.String())
+}
+
+func TestReadClientsets(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// The primary clientsets take the writes, the read clientsets stand for a replica serving the gets and lists
+	primaryKubeclientset := testclient.NewSimpleClientset()
+	primaryEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	readKubeclientset := testclient.NewSimpleClientset()
+	readEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(primaryKubeclientset, 0)
+	edgenetInformerFactory := informers.NewSharedInformerFactory(primaryEdgenetclientset, 0)
+	controller, err := NewController(primaryKubeclientset,
+		primaryEdgenetclientset,
+		kubeInformerFactory.Core().V1().Nodes(),
+		edgenetInformerFactory.Core().V1alpha1().TenantResourceQuotas(),
+		nil,
+		time.Hour,
+		multitenancy.DefaultQuotaNames,
+		0,
+		readKubeclientset,
+		readEdgenetclientset,
+		0,
+		nil)
+	util.OK(t, err)
+
+	tenantResourceQuota := g.tenantResourceQuotaObj.DeepCopy()
+	tenantResourceQuota.SetName("replica")
+	_, err = readKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "replica", Labels: map[string]string{"edge-net.io/tenant": "replica"}}}, metav1.CreateOptions{})
+	util.OK(t, err)
+	resourceQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: "replica"}}
+	resourceQuota.Status.Used = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+	_, err = readKubeclientset.CoreV1().ResourceQuotas("replica").Create(context.TODO(), resourceQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = primaryEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().Create(context.TODO(), tenantResourceQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	primaryKubeclientset.ClearActions()
+	primaryEdgenetclientset.ClearActions()
+	readKubeclientset.ClearActions()
+
+	usedResourceList := controller.getQuotaUsage(tenantResourceQuota)
+	cpu := usedResourceList[corev1.ResourceCPU]
+	util.Equals(t, "2", cpu.String())
+	util.Equals(t, 0, len(primaryKubeclientset.Actions()))
+	util.Equals(t, true, len(readKubeclientset.Actions()) > 0)
+
+	tenantResourceQuota.Status.State = corev1alpha1.StatusApplied
+	controller.updateStatus(context.TODO(), tenantResourceQuota)
+	util.Equals(t, 1, len(primaryEdgenetclientset.Actions()))
+	util.Equals(t, "update", primaryEdgenetclientset.Actions()[0].GetVerb())
+	util.Equals(t, 0, len(readEdgenetclientset.Actions()))
+
+	// The quota tuned is read from the primary clientset, as it is written back
+	coreQuota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: multitenancy.DefaultQuotaNames.ForKind("core"), Namespace: "replica"}}
+	_, err = primaryKubeclientset.CoreV1().ResourceQuotas("replica").Create(context.TODO(), coreQuota, metav1.CreateOptions{})
+	util.OK(t, err)
+	readKubeclientset.ClearActions()
+	readEdgenetclientset.ClearActions()
+	deleted, failed := controller.tuneResourceQuota("replica", "core", map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("4")})
+	util.Equals(t, false, deleted)
+	util.Equals(t, false, failed)
+	coreQuota, err = primaryKubeclientset.CoreV1().ResourceQuotas("replica").Get(context.TODO(), coreQuota.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	cpu = coreQuota.Spec.Hard[corev1.ResourceCPU]
+	util.Equals(t, "4", cpu.String())
+	util.Equals(t, 0, len(readEdgenetclientset.Actions()))
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenantresourcequota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+// QuotaSnapshot is the quota allocated to and used by a tenant at a point in time, for chargeback
+type QuotaSnapshot struct {
+	Time      time.Time                                 `json:"time"`
+	Tenant    string                                    `json:"tenant"`
+	Allocated map[corev1.ResourceName]resource.Quantity `json:"allocated"`
+	Used      map[corev1.ResourceName]resource.Quantity `json:"used"`
+	Labels    map[string]map[string]string              `json:"labels,omitempty"`
+}
+
+// OpenSnapshotSink returns the writer the quota snapshots go to from its target, which is either stdout or the path
+// of a file the snapshots are appended to.
+func OpenSnapshotSink(target string) (io.Writer, error) {
+	switch target = strings.TrimSpace(target); target {
+	case "", "stdout":
+		return os.Stdout, nil
+	default:
+		file, err := os.OpenFile(target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("quota snapshot sink %s cannot be opened: %w", target, err)
+		}
+		return file, nil
+	}
+}
+
+// TakeQuotaSnapshots returns a snapshot, timestamped now, of each tenant whose tenant resource quota is applied,
+// sorted by tenant. The allocation is the effective quota of the tenant, which takes in its additional tenant
+// resource quotas, and the usage is summed up over the tenant's namespaces as for the quota alerts. The labels are
+// those of the claims making up the allocation, where the claims of an additional quota are prefixed with its name.
+func (c *Controller) TakeQuotaSnapshots(now time.Time) ([]QuotaSnapshot, error) {
+	tenantResourceQuotaRaw, err := c.readEdgenetclientset.CoreV1alpha1().TenantResourceQuotas().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []QuotaSnapshot{}
+	for _, tenantResourceQuotaRow := range tenantResourceQuotaRaw.Items {
+		// The additional quotas of a tenant are part of its own snapshot
+		if tenantResourceQuotaRow.Status.State != corev1alpha1.StatusApplied || tenantResourceQuotaRow.Tenant() != tenantResourceQuotaRow.GetName() {
+			continue
+		}
+		tenantResourceQuotaCopy := tenantResourceQuotaRow.DeepCopy()
+		tenantResourceQuotaCopy.DropExpiredItems()
+		if err := c.expandQuotaProfiles(tenantResourceQuotaCopy); err != nil {
+			klog.Infoln(err)
+			continue
+		}
+		if err := c.expandCapacityShares(tenantResourceQuotaCopy); err != nil {
+			klog.Infoln(err)
+			continue
+		}
+		tenantResourceQuotas := c.getEffectiveQuotas(tenantResourceQuotaCopy)
+		labels := claimLabels(tenantResourceQuotas[0])
+		for _, additionalQuota := range tenantResourceQuotas[1:] {
+			for key, claimLabels := range claimLabels(additionalQuota) {
+				if labels == nil {
+					labels = make(map[string]map[string]string)
+				}
+				labels[fmt.Sprintf("%s/%s", additionalQuota.GetName(), key)] = claimLabels
+			}
+		}
+		snapshots = append(snapshots, QuotaSnapshot{
+			Time:      now,
+			Tenant:    tenantResourceQuotaCopy.GetName(),
+			Allocated: multitenancy.AggregateQuota(tenantResourceQuotas...),
+			Used:      c.getQuotaUsage(tenantResourceQuotaCopy),
+			Labels:    labels,
+		})
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Tenant < snapshots[j].Tenant })
+	return snapshots, nil
+}
+
+// claimLabels returns the labels of the claims of the tenant resource quota that are labeled, by claim
+func claimLabels(tenantResourceQuota corev1alpha1.TenantResourceQuota) map[string]map[string]string {
+	var labels map[string]map[string]string
+	for key, claim := range tenantResourceQuota.Spec.Claim {
+		if len(claim.Labels) == 0 {
+			continue
+		}
+		if labels == nil {
+			labels = make(map[string]map[string]string)
+		}
+		labels[key] = claim.Labels
+	}
+	return labels
+}
+
+// RunQuotaSnapshots writes the quota snapshots of the tenants to the sink as JSON lines each time the schedule
+// triggers, until stopCh is closed.
+func (c *Controller) RunQuotaSnapshots(schedule *multitenancy.Schedule, sink io.Writer, stopCh <-chan struct{}) {
+	for {
+		next := schedule.Next(time.Now())
+		if next.IsZero() {
+			klog.Infoln("quota snapshot schedule never triggers")
+			return
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(time.Until(next)):
+		}
+		if err := c.writeQuotaSnapshots(next, sink); err != nil {
+			klog.Infoln(err)
+		}
+	}
+}
+
+// writeQuotaSnapshots writes the quota snapshots of the tenants taken at the given time to the sink
+func (c *Controller) writeQuotaSnapshots(now time.Time, sink io.Writer) error {
+	snapshots, err := c.TakeQuotaSnapshots(now)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(sink)
+	for _, snapshot := range snapshots {
+		if err := encoder.Encode(snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tenantresourcequota
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+)
+
+// Transfer moves an amount of quota from the tenant resource quota of one tenant to that of another. The amount is
+// dropped from the source and claimed on the destination under matching keys, transfer-to-<tenant>-<id> and
+// transfer-from-<tenant>-<id>, so that the grant can be traced back. It fails without changing either quota if the
+// net quota of the source lacks the amount, and the drop is reverted if the claim on the destination cannot be made.
+func Transfer(edgenetclientset clientset.Interface, from, to string, amount corev1.ResourceList) error {
+	if from == to {
+		return fmt.Errorf("quota cannot be transferred from tenant %s to itself", from)
+	}
+	if len(amount) == 0 {
+		return fmt.Errorf("no quota to transfer from tenant %s to tenant %s", from, to)
+	}
+	for key, quantity := range amount {
+		if quantity.Sign() <= 0 {
+			return fmt.Errorf("quota transferred must be positive, got %s of %s", quantity.String(), key)
+		}
+	}
+	if _, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), to, metav1.GetOptions{}); err != nil {
+		return err
+	}
+
+	id := strconv.FormatInt(time.Now().UnixNano(), 36)
+	dropKey := fmt.Sprintf("transfer-to-%s-%s", to, id)
+	claimKey := fmt.Sprintf("transfer-from-%s-%s", from, id)
+	resourceTuning := func() corev1alpha1.ResourceTuning {
+		resourceList := make(map[corev1.ResourceName]resource.Quantity, len(amount))
+		for key, quantity := range amount {
+			resourceList[key] = quantity.DeepCopy()
+		}
+		return corev1alpha1.ResourceTuning{ResourceList: resourceList}
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		tenantResourceQuota, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), from, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		assignedQuota := tenantResourceQuota.Fetch()
+		for key, quantity := range amount {
+			if assignedQuantity := assignedQuota[key]; assignedQuantity.Cmp(quantity) == -1 {
+				return fmt.Errorf("tenant %s lacks the %s of %s to transfer, it has %s", from, quantity.String(), key, assignedQuantity.String())
+			}
+		}
+		tenantResourceQuotaCopy := tenantResourceQuota.DeepCopy()
+		if tenantResourceQuotaCopy.Spec.Drop == nil {
+			tenantResourceQuotaCopy.Spec.Drop = make(map[string]corev1alpha1.ResourceTuning)
+		}
+		tenantResourceQuotaCopy.Spec.Drop[dropKey] = resourceTuning()
+		_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Update(context.TODO(), tenantResourceQuotaCopy, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		tenantResourceQuota, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), to, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		tenantResourceQuotaCopy := tenantResourceQuota.DeepCopy()
+		if tenantResourceQuotaCopy.Spec.Claim == nil {
+			tenantResourceQuotaCopy.Spec.Claim = make(map[string]corev1alpha1.ResourceTuning)
+		}
+		tenantResourceQuotaCopy.Spec.Claim[claimKey] = resourceTuning()
+		_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Update(context.TODO(), tenantResourceQuotaCopy, metav1.UpdateOptions{})
+		return err
+	})
+	if err == nil {
+		return nil
+	}
+
+	// The source gets its quota back, as the destination did not receive it
+	if revertErr := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		tenantResourceQuota, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), from, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		tenantResourceQuotaCopy := tenantResourceQuota.DeepCopy()
+		delete(tenantResourceQuotaCopy.Spec.Drop, dropKey)
+		_, err = edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Update(context.TODO(), tenantResourceQuotaCopy, metav1.UpdateOptions{})
+		return err
+	}); revertErr != nil {
+		klog.Infof("Quota dropped from tenant %s as %s could not be reverted: %s", from, dropKey, revertErr)
+	}
+	return err
+}
@@ -62,7 +62,7 @@ func TestMain(m *testing.M) {
 	go func() {
 		edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, time.Second*30)
 
-		newController := NewController(
+		newController, err := NewController(
 			kubeclientset,
 			edgenetclientset,
 			edgenetInformerFactory.Federation().V1alpha1().Clusters(),
@@ -70,6 +70,9 @@ func TestMain(m *testing.M) {
 			"null-account-id",
 			"null-license-key",
 		)
+		if err != nil {
+			klog.Fatalf("Error creating controller: %s", err.Error())
+		}
 
 		edgenetInformerFactory.Start(stopCh)
 		controller = newController
@@ -22,12 +22,15 @@ import (
 	"time"
 
 	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	edgenetscheme "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/registration/v1alpha1"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/registration/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -87,13 +90,21 @@ type Controller struct {
 func NewController(
 	kubeclientset kubernetes.Interface,
 	edgenetclientset clientset.Interface,
-	clusterrolerequestInformer informers.ClusterRoleRequestInformer) *Controller {
+	clusterrolerequestInformer informers.ClusterRoleRequestInformer) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("clusterrolerequestInformer", clusterrolerequestInformer)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
 
 	utilruntime.Must(edgenetscheme.AddToScheme(scheme.Scheme))
 	klog.V(4).Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
 	controller := &Controller{
@@ -120,7 +131,7 @@ func NewController(
 		},
 	})
 
-	return controller
+	return controller, nil
 }
 
 // Run will set up the event handlers for the types of cluster role request and node, as well
@@ -178,12 +189,13 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.V(4).Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.V(4).Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
@@ -272,7 +284,7 @@ func (c *Controller) processClusterRoleRequest(clusterRoleRequestCopy *registrat
 		// Try to create a cluster role binding for the user.
 		// If cluster role binding exists, check if the user already holds the role. If not, pin the cluster role to the user.
 		roleRef := rbacv1.RoleRef{Kind: "ClusterRole", Name: clusterRoleRequestCopy.Spec.RoleName}
-		rbSubjects := []rbacv1.Subject{{Kind: "User", Name: clusterRoleRequestCopy.Spec.Email, APIGroup: "rbac.authorization.k8s.io"}}
+		rbSubjects := []rbacv1.Subject{{Kind: "User", Name: clusterRoleRequestCopy.GetSubjectName(), APIGroup: "rbac.authorization.k8s.io"}}
 		requestedBinding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: clusterRoleRequestCopy.Spec.RoleName},
 			Subjects: rbSubjects, RoleRef: roleRef}
 		requestedBindingLabels := map[string]string{"edge-net.io/generated": "true"}
@@ -289,14 +301,14 @@ func (c *Controller) processClusterRoleRequest(clusterRoleRequestCopy *registrat
 			} else {
 				isBound := false
 				for _, subjectRow := range clusterRoleBinding.Subjects {
-					if subjectRow.Kind == "User" && subjectRow.Name == clusterRoleRequestCopy.Spec.Email {
+					if subjectRow.Kind == "User" && clusterRoleRequestCopy.IsSubject(subjectRow.Name) {
 						isBound = true
 						break
 					}
 				}
 				if !isBound {
 					clusterRoleBindingCopy := clusterRoleBinding.DeepCopy()
-					clusterRoleBindingCopy.Subjects = append(clusterRoleBindingCopy.Subjects, rbacv1.Subject{Kind: "User", Name: clusterRoleRequestCopy.Spec.Email, APIGroup: "rbac.authorization.k8s.io"})
+					clusterRoleBindingCopy.Subjects = append(clusterRoleBindingCopy.Subjects, rbacv1.Subject{Kind: "User", Name: clusterRoleRequestCopy.GetSubjectName(), APIGroup: "rbac.authorization.k8s.io"})
 					if _, err := c.kubeclientset.RbacV1().ClusterRoleBindings().Update(context.TODO(), clusterRoleBindingCopy, metav1.UpdateOptions{}); err != nil {
 						c.recorder.Event(clusterRoleBindingCopy, corev1.EventTypeWarning, failureBinding, messageBindingFailed)
 						return
@@ -317,7 +329,7 @@ func (c *Controller) processClusterRoleRequest(clusterRoleRequestCopy *registrat
 		}
 	default:
 		multitenancyManager := multitenancy.NewManager(c.kubeclientset, c.edgenetclientset)
-		if err := multitenancyManager.GrantObjectOwnership("registration.edgenet.io", "clusterrolerequests", clusterRoleRequestCopy.GetName(), clusterRoleRequestCopy.Spec.Email, []metav1.OwnerReference{clusterRoleRequestCopy.MakeOwnerReference()}); err != nil {
+		if err := multitenancyManager.GrantObjectOwnership("registration.edgenet.io", "clusterrolerequests", clusterRoleRequestCopy.GetName(), clusterRoleRequestCopy.GetSubjectName(), []metav1.OwnerReference{clusterRoleRequestCopy.MakeOwnerReference()}); err != nil {
 			clusterRoleRequestCopy.Status.State = registrationv1alpha1.StatusFailed
 			clusterRoleRequestCopy.Status.Message = messageOwnershipFailure
 			c.updateStatus(context.TODO(), clusterRoleRequestCopy)
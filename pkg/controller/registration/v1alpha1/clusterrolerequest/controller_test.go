@@ -46,9 +46,12 @@ func TestMain(m *testing.M) {
 
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, time.Second*30)
 
-	controller := NewController(kubeclientset,
+	controller, err := NewController(kubeclientset,
 		edgenetclientset,
 		edgenetInformerFactory.Registration().V1alpha1().ClusterRoleRequests())
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	edgenetInformerFactory.Start(stopCh)
 
@@ -25,10 +25,12 @@ import (
 	"time"
 
 	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/registration/v1alpha1"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/registration/v1alpha1"
 	"github.com/EdgeNet-project/edgenet/pkg/notification"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
 	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -47,6 +49,10 @@ import (
 
 const controllerAgentName = "notifier-controller"
 
+// PrimaryApproverLabel marks the role bindings whose subjects are the primary approvers of the role requests in the
+// namespace, who are notified first when escalation is enabled
+const PrimaryApproverLabel = "edge-net.io/primary-approver"
+
 // Controller is the controller implementation for notifier resources
 type Controller struct {
 	kubeclientset    kubernetes.Interface
@@ -59,6 +65,12 @@ type Controller struct {
 	clusterrolerequestsLister listers.ClusterRoleRequestLister
 	clusterrolerequestsSynced cache.InformerSynced
 
+	// escalationTimeout is the time a role request stays pending with only its primary approvers notified, before
+	// the others are notified too, zero notifies all approvers at once
+	escalationTimeout time.Duration
+	// notify sends a notification
+	notify func(content *notification.Content, purpose string) error
+
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
@@ -78,13 +90,25 @@ func NewController(
 	edgenetclientset clientset.Interface,
 	tenantrequestInformer informers.TenantRequestInformer,
 	rolerequestInformer informers.RoleRequestInformer,
-	clusterrolerequestInformer informers.ClusterRoleRequestInformer) *Controller {
+	clusterrolerequestInformer informers.ClusterRoleRequestInformer,
+	escalationTimeout time.Duration) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("tenantrequestInformer", tenantrequestInformer)
+	validator.NotNil("rolerequestInformer", rolerequestInformer)
+	validator.NotNil("clusterrolerequestInformer", clusterrolerequestInformer)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
+
 	// Create event broadcaster
 	utilruntime.Must(scheme.AddToScheme(scheme.Scheme))
 	klog.Infoln("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
 	controller := &Controller{
@@ -96,10 +120,14 @@ func NewController(
 		rolerequestsSynced:          rolerequestInformer.Informer().HasSynced,
 		clusterrolerequestsLister:   clusterrolerequestInformer.Lister(),
 		clusterrolerequestsSynced:   clusterrolerequestInformer.Informer().HasSynced,
+		escalationTimeout:           escalationTimeout,
 		workqueueTenantRequest:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "NotifierTenantRequest"),
 		workqueueClusterRoleRequest: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "NotifierClusterRoleRequest"),
 		workqueueRoleRequest:        workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "NotifierRoleRequest"),
 		recorder:                    recorder,
+		notify: func(content *notification.Content, purpose string) error {
+			return content.SendNotification(purpose)
+		},
 	}
 	klog.Infoln("Setting up event handlers")
 
@@ -132,7 +160,7 @@ func NewController(
 		},
 	})
 
-	return controller
+	return controller, nil
 }
 
 func (c *Controller) Run(threadiness int, stopCh <-chan struct{}) error {
@@ -374,6 +402,18 @@ func (c *Controller) enqueueNotifier(obj interface{}) {
 	}
 }
 
+// enqueueRoleRequestAfter puts the role request into the work queue once the time given has passed
+func (c *Controller) enqueueRoleRequestAfter(obj interface{}, after time.Duration) {
+	var key string
+	var err error
+
+	if key, err = cache.MetaNamespaceKeyFunc(obj); err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	c.workqueueRoleRequest.AddAfter(key, after)
+}
+
 func (c *Controller) processTenantRequest(tenantrequest *registrationv1alpha1.TenantRequest) {
 	klog.Infoln("processTenantRequest")
 
@@ -446,58 +486,134 @@ func (c *Controller) processRoleRequest(rolerequest *registrationv1alpha1.RoleRe
 		return
 	}
 
+	escalated := false
 	var sendNotification = func(subject, purpose string, recipient []string) {
 		content := new(notification.Content)
 		content.Init(rolerequest.Spec.FirstName, rolerequest.Spec.LastName, rolerequest.Spec.Email, subject, string(systemNamespace.GetUID()), recipient)
 		content.RoleRequest = new(notification.RoleRequest)
 		content.RoleRequest.Name = rolerequest.GetName()
 		content.RoleRequest.Namespace = rolerequest.GetNamespace()
-		if errNotification := content.SendNotification(purpose); errNotification == nil {
+		content.Locale = c.getTenantLocale(rolerequest.GetNamespace())
+		if errNotification := c.notify(content, purpose); errNotification == nil {
 			rolerequestCopy := rolerequest.DeepCopy()
 			rolerequestCopy.Status.Notified = true
+			rolerequestCopy.Status.Escalated = rolerequestCopy.Status.Escalated || escalated
 			c.edgenetclientset.RegistrationV1alpha1().RoleRequests(rolerequestCopy.GetNamespace()).UpdateStatus(context.TODO(), rolerequestCopy, metav1.UpdateOptions{})
 		}
 	}
 
 	switch rolerequest.Status.State {
 	case registrationv1alpha1.StatusBound:
-		sendNotification("[EdgeNet] Role request approved", "role-request-approved", []string{rolerequest.Spec.Email})
+		if !rolerequest.Status.Notified {
+			sendNotification("[EdgeNet] Role request approved", "role-request-approved", []string{rolerequest.Spec.Email})
+		}
 	case registrationv1alpha1.StatusApproved:
 		rolerequestCopy := rolerequest.DeepCopy()
 		rolerequestCopy.Status.Notified = false
 		c.edgenetclientset.RegistrationV1alpha1().RoleRequests(rolerequestCopy.GetNamespace()).UpdateStatus(context.TODO(), rolerequestCopy, metav1.UpdateOptions{})
 	case registrationv1alpha1.StatusPending:
+		// The approvers are notified when the request becomes pending, on escalation, and when a reminder clears the
+		// notified flag, rather than on every status change such as the count of approvals or the flags set here
+		if rolerequest.Status.Notified && (c.escalationTimeout <= 0 || rolerequest.Status.Escalated) {
+			break
+		}
+		primaryList, otherList := c.getRoleRequestApprovers(rolerequest)
 		emailList := []string{}
-		if roleBindingRaw, err := c.kubeclientset.RbacV1().RoleBindings(rolerequest.GetNamespace()).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/notification=true"}); err == nil {
-			for _, roleBindingRow := range roleBindingRaw.Items {
-				for _, subjectRow := range roleBindingRow.Subjects {
-					if subjectRow.Kind == "User" {
-						_, err := mail.ParseAddress(subjectRow.Name)
-						if err == nil {
-							subjectAccessReview := new(authorizationv1.SubjectAccessReview)
-							resourceAttributes := new(authorizationv1.ResourceAttributes)
-							resourceAttributes.Group = "registration.edgenet.io"
-							resourceAttributes.Version = "v1alpha1"
-							resourceAttributes.Resource = "rolerequests"
-							resourceAttributes.Verb = "UPDATE"
-							resourceAttributes.Namespace = rolerequest.GetNamespace()
-							resourceAttributes.Name = rolerequest.GetName()
-							subjectAccessReview.Spec.ResourceAttributes = resourceAttributes
-							subjectAccessReview.Spec.User = subjectRow.Name
-							if subjectAccessReviewResult, err := c.kubeclientset.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), subjectAccessReview, metav1.CreateOptions{}); err == nil {
-								if subjectAccessReviewResult.Status.Allowed {
-									emailList = append(emailList, subjectRow.Name)
-								}
+		if c.escalationTimeout > 0 && len(primaryList) > 0 && !rolerequest.Status.Escalated {
+			// The primary approvers are notified first, and the others only if the request is still pending
+			// once the escalation timeout has passed
+			if wait := c.escalationTimeout - time.Since(pendingSince(rolerequest)); wait > 0 {
+				c.enqueueRoleRequestAfter(rolerequest, wait)
+				if !rolerequest.Status.Notified {
+					emailList = primaryList
+				}
+			} else {
+				escalated = true
+				emailList = otherList
+				if !rolerequest.Status.Notified {
+					emailList = append(primaryList, otherList...)
+				}
+			}
+		} else if !rolerequest.Status.Notified {
+			emailList = append(primaryList, otherList...)
+		}
+		if len(emailList) > 0 {
+			sendNotification("[EdgeNet Admin] A role request made", "role-request-made", emailList)
+		}
+	}
+}
+
+// getRoleRequestApprovers returns the emails of the primary approvers of the role request, and those of the other
+// approvers, the subjects of the role bindings labeled for notification that are allowed to update the role request
+func (c *Controller) getRoleRequestApprovers(rolerequest *registrationv1alpha1.RoleRequest) ([]string, []string) {
+	// Approvers bound by a username other than their email, such as an OIDC subject, are reached
+	// through the email address given in their own role request.
+	emailByUsername := make(map[string]string)
+	if roleRequestRaw, err := c.edgenetclientset.RegistrationV1alpha1().RoleRequests(rolerequest.GetNamespace()).List(context.TODO(), metav1.ListOptions{}); err == nil {
+		for _, roleRequestRow := range roleRequestRaw.Items {
+			if roleRequestRow.Spec.Username != "" {
+				emailByUsername[roleRequestRow.Spec.Username] = roleRequestRow.Spec.Email
+			}
+		}
+	}
+	primaryList, emailList := []string{}, []string{}
+	isPrimary := make(map[string]bool)
+	roleBindingRaw, err := c.kubeclientset.RbacV1().RoleBindings(rolerequest.GetNamespace()).List(context.TODO(), metav1.ListOptions{LabelSelector: "edge-net.io/notification=true"})
+	if err != nil {
+		return primaryList, emailList
+	}
+	for _, roleBindingRow := range roleBindingRaw.Items {
+		for _, subjectRow := range roleBindingRow.Subjects {
+			if subjectRow.Kind == "User" {
+				email := subjectRow.Name
+				if approverEmail, elementExists := emailByUsername[subjectRow.Name]; elementExists {
+					email = approverEmail
+				}
+				_, err := mail.ParseAddress(email)
+				if err == nil {
+					subjectAccessReview := new(authorizationv1.SubjectAccessReview)
+					resourceAttributes := new(authorizationv1.ResourceAttributes)
+					resourceAttributes.Group = "registration.edgenet.io"
+					resourceAttributes.Version = "v1alpha1"
+					resourceAttributes.Resource = "rolerequests"
+					resourceAttributes.Verb = "UPDATE"
+					resourceAttributes.Namespace = rolerequest.GetNamespace()
+					resourceAttributes.Name = rolerequest.GetName()
+					subjectAccessReview.Spec.ResourceAttributes = resourceAttributes
+					subjectAccessReview.Spec.User = subjectRow.Name
+					if subjectAccessReviewResult, err := c.kubeclientset.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), subjectAccessReview, metav1.CreateOptions{}); err == nil {
+						if subjectAccessReviewResult.Status.Allowed {
+							if roleBindingRow.GetLabels()[PrimaryApproverLabel] == "true" {
+								primaryList = append(primaryList, email)
+								isPrimary[email] = true
+							} else {
+								emailList = append(emailList, email)
 							}
 						}
 					}
 				}
 			}
 		}
-		if len(emailList) > 0 {
-			sendNotification("[EdgeNet Admin] A role request made", "role-request-made", emailList)
+	}
+	// An approver bound both as primary and not is only counted as primary
+	otherList := []string{}
+	for _, email := range emailList {
+		if !isPrimary[email] {
+			otherList = append(otherList, email)
+		}
+	}
+	return primaryList, otherList
+}
+
+// pendingSince returns the time the role request last became pending, or its creation time if its history does not
+// tell
+func pendingSince(rolerequest *registrationv1alpha1.RoleRequest) time.Time {
+	for i := len(rolerequest.Status.History) - 1; i >= 0; i-- {
+		if rolerequest.Status.History[i].State == registrationv1alpha1.StatusPending {
+			return rolerequest.Status.History[i].Timestamp.Time
 		}
 	}
+	return rolerequest.GetCreationTimestamp().Time
 }
 
 func (c *Controller) processClusterRoleRequest(clusterrolerequest *registrationv1alpha1.ClusterRoleRequest) {
@@ -559,3 +675,20 @@ func (c *Controller) processClusterRoleRequest(clusterrolerequest *registrationv
 		}
 	}
 }
+
+// getTenantLocale returns the locale set on the tenant that owns the namespace, empty if none
+func (c *Controller) getTenantLocale(namespace string) string {
+	namespaceObj, err := c.kubeclientset.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	tenantName, ok := namespaceObj.GetLabels()["edge-net.io/tenant"]
+	if !ok {
+		return ""
+	}
+	tenant, err := c.edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenantName, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	return tenant.GetAnnotations()["edge-net.io/locale"]
+}
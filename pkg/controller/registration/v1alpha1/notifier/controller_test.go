@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+	edgenettestclient "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/fake"
+	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/notification"
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+func TestMain(m *testing.M) {
+	klog.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+func TestPrimaryApproverEscalation(t *testing.T) {
+	cases := map[string]struct {
+		escalationTimeout time.Duration
+		expected          [][]string
+	}{
+		"disabled": {0, [][]string{
+			{"primary@edge-net.org", "other@edge-net.org"},
+			{"primary@edge-net.org", "other@edge-net.org"},
+		}},
+		"enabled": {time.Hour, [][]string{
+			{"primary@edge-net.org"},
+			{"other@edge-net.org"},
+			{"primary@edge-net.org", "other@edge-net.org"},
+		}},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			kubeclientset := testclient.NewSimpleClientset()
+			edgenetclientset := edgenettestclient.NewSimpleClientset()
+			// Every approver bound for notification is allowed to approve
+			kubeclientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				subjectAccessReview := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+				subjectAccessReview.Status.Allowed = true
+				return true, subjectAccessReview, nil
+			})
+			edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, 0)
+			controller, err := NewController(kubeclientset,
+				edgenetclientset,
+				edgenetInformerFactory.Registration().V1alpha1().TenantRequests(),
+				edgenetInformerFactory.Registration().V1alpha1().RoleRequests(),
+				edgenetInformerFactory.Registration().V1alpha1().ClusterRoleRequests(),
+				tc.escalationTimeout)
+			util.OK(t, err)
+			controller.recorder = record.NewFakeRecorder(100)
+			notified := [][]string{}
+			controller.notify = func(content *notification.Content, purpose string) error {
+				notified = append(notified, content.Recipient)
+				return nil
+			}
+
+			_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "cluster-uid"}}, metav1.CreateOptions{})
+			util.OK(t, err)
+			roleRef := rbacv1.RoleRef{Kind: "ClusterRole", Name: "edgenet:tenant-owner"}
+			primaryBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "primary", Namespace: "edgenet",
+				Labels: map[string]string{"edge-net.io/notification": "true", PrimaryApproverLabel: "true"}},
+				Subjects: []rbacv1.Subject{{Kind: "User", Name: "primary@edge-net.org"}}, RoleRef: roleRef}
+			_, err = kubeclientset.RbacV1().RoleBindings("edgenet").Create(context.TODO(), primaryBinding, metav1.CreateOptions{})
+			util.OK(t, err)
+			// The primary approver also holds the binding of the other approvers, without being notified twice
+			otherBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "edgenet",
+				Labels: map[string]string{"edge-net.io/notification": "true"}},
+				Subjects: []rbacv1.Subject{{Kind: "User", Name: "other@edge-net.org"}, {Kind: "User", Name: "primary@edge-net.org"}}, RoleRef: roleRef}
+			_, err = kubeclientset.RbacV1().RoleBindings("edgenet").Create(context.TODO(), otherBinding, metav1.CreateOptions{})
+			util.OK(t, err)
+
+			roleRequest := &registrationv1alpha1.RoleRequest{ObjectMeta: metav1.ObjectMeta{Name: "johndoe", Namespace: "edgenet"}}
+			roleRequest.Spec.Email = "john.doe@edge-net.org"
+			roleRequest.Status.State = registrationv1alpha1.StatusPending
+			roleRequest.Status.History = []registrationv1alpha1.Transition{{State: registrationv1alpha1.StatusPending, Timestamp: metav1.Now()}}
+			_, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequest.GetNamespace()).Create(context.TODO(), roleRequest, metav1.CreateOptions{})
+			util.OK(t, err)
+
+			// process runs the controller on the role request as if it had been pending for the given time
+			var process = func(elapsed time.Duration) *registrationv1alpha1.RoleRequest {
+				roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests("edgenet").Get(context.TODO(), "johndoe", metav1.GetOptions{})
+				util.OK(t, err)
+				roleRequest.Status.History[0].Timestamp = metav1.NewTime(time.Now().Add(-elapsed))
+				controller.processRoleRequest(roleRequest)
+				roleRequest, err = edgenetclientset.RegistrationV1alpha1().RoleRequests("edgenet").Get(context.TODO(), "johndoe", metav1.GetOptions{})
+				util.OK(t, err)
+				return roleRequest
+			}
+
+			roleRequest = process(time.Minute)
+			util.Equals(t, true, roleRequest.Status.Notified)
+			util.Equals(t, false, roleRequest.Status.Escalated)
+			roleRequest = process(2 * time.Hour)
+			util.Equals(t, tc.escalationTimeout > 0, roleRequest.Status.Escalated)
+			// The status updates that follow, such as the flags just set, notify no one again
+			process(3 * time.Hour)
+			util.Equals(t, tc.expected[:len(tc.expected)-1], notified)
+
+			// A reminder clears the notified flag, upon which all approvers are notified
+			roleRequest.Status.Notified = false
+			_, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequest.GetNamespace()).UpdateStatus(context.TODO(), roleRequest, metav1.UpdateOptions{})
+			util.OK(t, err)
+			process(4 * time.Hour)
+			util.Equals(t, tc.expected, notified)
+		})
+	}
+}
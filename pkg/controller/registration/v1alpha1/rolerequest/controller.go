@@ -19,47 +19,75 @@ package rolerequest
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	edgenetscheme "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/scheme"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions/registration/v1alpha1"
 	listers "github.com/EdgeNet-project/edgenet/pkg/generated/listers/registration/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	multitenancy "github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/notification"
+	"github.com/EdgeNet-project/edgenet/pkg/validation"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 )
 
 const controllerAgentName = "rolerequest-controller"
 
+// maxHistory is the number of transitions kept in the history of a role request
+const maxHistory = 10
+
 // Definitions of the state of the rolerequest resource
 const (
-	successSynced  = "Synced"
-	successFound   = "Found"
-	failureFound   = "Not Found"
-	failureBinding = "Binding Failed"
-
-	messageResourceSynced   = "Role Request synced successfully"
-	messageRoleBound        = "Requested Role / Cluster Role is bound"
-	messageRoleFound        = "Requested Role / Cluster Role found"
-	messageRoleNotFound     = "Requested Role / Cluster Role does not exist"
-	messageRoleApproved     = "Requested Role / Cluster Role approved successfully"
-	messagePending          = "Waiting for approval"
-	messageBindingFailed    = "Role binding failed"
-	messageOwnershipFailure = "Role Request ownership cannot be granted"
+	successSynced      = "Synced"
+	successFound       = "Found"
+	successReminded    = "Reminded"
+	failureFound       = "Not Found"
+	failureBinding     = "Binding Failed"
+	failureRevoke      = "Revoke Failed"
+	failureWithdrawn   = "Deployment Withdrawn"
+	failurePropagation = "Propagation Failed"
+	failureStuck       = "ReconcileStuck"
+
+	messageResourceSynced    = "Role Request synced successfully"
+	messageRoleBound         = "Requested Role / Cluster Role is bound"
+	messageRoleFound         = "Requested Role / Cluster Role found"
+	messageRoleNotFound      = "Requested Role / Cluster Role does not exist"
+	messageRoleNotInNS       = "Requested Role %s does not exist in namespace %s"
+	messageRoleInOtherNS     = "Requested Role %s does not exist in namespace %s, only in %s; a Role can only be bound in its own namespace"
+	messageRoleKindInvalid   = "Requested role kind %s is neither Role nor ClusterRole"
+	messageRoleApproved      = "Requested Role / Cluster Role approved successfully"
+	messageRoleAutoApproved  = "Requested Role / Cluster Role approved automatically: %s"
+	messagePending           = "Waiting for approval"
+	messageBindingFailed     = "Role binding failed"
+	messageOwnershipFailure  = "Role Request ownership cannot be granted"
+	messageRevokeFailed      = "Temporary access cannot be revoked"
+	messageReminded          = "Approvers reminded of the pending request (%d/%d)"
+	messageWithdrawn         = "Selective Deployment %s governing the namespace has been withdrawn"
+	messageApprovals         = "Requested Role / Cluster Role approved by %d of %d required approvers"
+	messagePropagationFailed = "Role binding cannot be propagated to member cluster %s"
+	messageStuck             = "Role Request failed to sync %d times in a row"
 )
 
 // Controller is the controller implementation for Role Request resources
@@ -68,10 +96,35 @@ type Controller struct {
 	kubeclientset kubernetes.Interface
 	// edgenetclientset is a clientset for the EdgeNet API groups
 	edgenetclientset clientset.Interface
+	// readKubeclientset and readEdgenetclientset serve the gets and lists, so that these can be offloaded to a
+	// cache or a replica of the API server, while the writes go to kubeclientset and edgenetclientset. The reads
+	// that an update or a deletion is decided on go to the latter, as a stale copy would be written back.
+	readKubeclientset    kubernetes.Interface
+	readEdgenetclientset clientset.Interface
 
 	rolerequestsLister listers.RoleRequestLister
 	rolerequestsSynced cache.InformerSynced
 
+	// tenantLabelKeys lists the tenant labels copied to the objects generated for the tenant
+	tenantLabelKeys []string
+	// approvalPolicy decides whether pending role requests are approved without waiting for an approver
+	approvalPolicy ApprovalPolicy
+	// reminderInterval is the time after which the approvers of a request still pending approval are
+	// notified again, zero disables the reminders
+	reminderInterval time.Duration
+	// maxReminders caps the number of reminders sent for a request
+	maxReminders int
+	// memberClientsets are the clientsets of the member clusters of the federation, by cluster name, to which the
+	// role bindings are propagated once bound in this cluster
+	memberClientsets map[string]kubernetes.Interface
+	// stuckThreshold is the number of failed syncs in a row after which a role request is reported as stuck, zero
+	// disables the reports
+	stuckThreshold int
+	// notifyStuck tells whether the cluster administrators are notified of the role requests reported as stuck
+	notifyStuck bool
+	// notify sends a notification to the cluster administrators
+	notify func(content *notification.Content, purpose string) error
+
 	// workqueue is a rate limited work queue. This is used to queue work to be
 	// processed instead of performing it as soon as a change happens. This
 	// means we can ensure we only process a fixed amount of resources at a
@@ -87,22 +140,62 @@ type Controller struct {
 func NewController(
 	kubeclientset kubernetes.Interface,
 	edgenetclientset clientset.Interface,
-	rolerequestInformer informers.RoleRequestInformer) *Controller {
+	rolerequestInformer informers.RoleRequestInformer,
+	tenantLabelKeys []string,
+	approvalPolicy ApprovalPolicy,
+	reminderInterval time.Duration,
+	maxReminders int,
+	readKubeclientset kubernetes.Interface,
+	readEdgenetclientset clientset.Interface,
+	memberClientsets map[string]kubernetes.Interface,
+	stuckThreshold int,
+	notifyStuck bool) (*Controller, error) {
+	validator := validation.NewValidator(controllerAgentName)
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("edgenetclientset", edgenetclientset)
+	validator.NotNil("rolerequestInformer", rolerequestInformer)
+	if err := validator.Err(); err != nil {
+		return nil, err
+	}
 
 	utilruntime.Must(edgenetscheme.AddToScheme(scheme.Scheme))
 	klog.Info("Creating event broadcaster")
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeclientset.CoreV1().Events("")})
+	eventsink.Watch(eventBroadcaster)
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: controllerAgentName})
 
+	if readKubeclientset == nil {
+		readKubeclientset = kubeclientset
+	}
+	if readEdgenetclientset == nil {
+		readEdgenetclientset = edgenetclientset
+	}
+
+	if approvalPolicy == nil {
+		approvalPolicy = NeverAutoApprove{}
+	}
+
 	controller := &Controller{
-		kubeclientset:      kubeclientset,
-		edgenetclientset:   edgenetclientset,
-		rolerequestsLister: rolerequestInformer.Lister(),
-		rolerequestsSynced: rolerequestInformer.Informer().HasSynced,
-		workqueue:          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "RoleRequests"),
-		recorder:           recorder,
+		kubeclientset:        kubeclientset,
+		edgenetclientset:     edgenetclientset,
+		readKubeclientset:    readKubeclientset,
+		readEdgenetclientset: readEdgenetclientset,
+		rolerequestsLister:   rolerequestInformer.Lister(),
+		rolerequestsSynced:   rolerequestInformer.Informer().HasSynced,
+		tenantLabelKeys:      tenantLabelKeys,
+		approvalPolicy:       approvalPolicy,
+		reminderInterval:     reminderInterval,
+		maxReminders:         maxReminders,
+		memberClientsets:     memberClientsets,
+		stuckThreshold:       stuckThreshold,
+		notifyStuck:          notifyStuck,
+		notify: func(content *notification.Content, purpose string) error {
+			return content.SendNotification(purpose)
+		},
+		workqueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "RoleRequests"),
+		recorder:  recorder,
 	}
 
 	klog.Infoln("Setting up event handlers")
@@ -120,7 +213,7 @@ func NewController(
 		},
 	})
 
-	return controller
+	return controller, nil
 }
 
 // Run will set up the event handlers for the types of role request and node, as well
@@ -178,12 +271,14 @@ func (c *Controller) processNextWorkItem() bool {
 			utilruntime.HandleError(fmt.Errorf("expected string in workqueue but got %#v", obj))
 			return nil
 		}
-		if err := c.syncHandler(key); err != nil {
-			c.workqueue.AddRateLimited(key)
+		synced, err := maintenance.Process(c.workqueue, key, c.syncHandler)
+		if err != nil {
+			c.reportStuck(key)
 			return fmt.Errorf("error syncing '%s': %s, requeuing", key, err.Error())
 		}
-		c.workqueue.Forget(obj)
-		klog.Infof("Successfully synced '%s'", key)
+		if synced {
+			klog.Infof("Successfully synced '%s'", key)
+		}
 		return nil
 	}(obj)
 
@@ -216,11 +311,50 @@ func (c *Controller) syncHandler(key string) error {
 		return err
 	}
 
-	c.processRoleRequest(rolerequest.DeepCopy())
+	if err := c.processRoleRequest(rolerequest.DeepCopy()); err != nil {
+		return err
+	}
 	c.recorder.Event(rolerequest, corev1.EventTypeNormal, successSynced, messageResourceSynced)
 	return nil
 }
 
+// reportStuck emits an event, and notifies the cluster administrators if enabled, once the role request has failed
+// to sync as many times in a row as the stuck threshold. The count is reset when the role request syncs.
+func (c *Controller) reportStuck(key string) {
+	failures := c.workqueue.NumRequeues(key)
+	if c.stuckThreshold <= 0 || failures != c.stuckThreshold {
+		return
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return
+	}
+	rolerequest, err := c.rolerequestsLister.RoleRequests(namespace).Get(name)
+	if err != nil {
+		return
+	}
+	c.recorder.Event(rolerequest, corev1.EventTypeWarning, failureStuck, fmt.Sprintf(messageStuck, failures))
+	if !c.notifyStuck {
+		return
+	}
+	var clusterUID string
+	if systemNamespace, err := c.readKubeclientset.CoreV1().Namespaces().Get(context.TODO(), "kube-system", metav1.GetOptions{}); err == nil {
+		clusterUID = string(systemNamespace.GetUID())
+	}
+	content := new(notification.Content)
+	// No recipient sends the notification to the cluster administrators
+	content.Init("", "", "", "[EdgeNet] Role request reconcile stuck", clusterUID, nil)
+	content.ReconcileStuck = &notification.ReconcileStuck{
+		Kind:      "RoleRequest",
+		Name:      name,
+		Namespace: namespace,
+		Failures:  failures,
+	}
+	if err := c.notify(content, "reconcile-stuck"); err != nil {
+		klog.Infoln(err)
+	}
+}
+
 // enqueueRoleRequest takes a RoleRequest resource and converts it into a namespace/name
 // string which is then put onto the work queue. This method should *not* be
 // passed resources of any type other than RoleRequest.
@@ -247,113 +381,298 @@ func (c *Controller) enqueueRoleRequestAfter(obj interface{}, after time.Duratio
 	c.workqueue.AddAfter(key, after)
 }
 
-func (c *Controller) processRoleRequest(roleRequestCopy *registrationv1alpha1.RoleRequest) {
+// processRoleRequest reconciles the role request, and returns the errors that the role request is requeued on
+func (c *Controller) processRoleRequest(roleRequestCopy *registrationv1alpha1.RoleRequest) error {
 	if roleRequestCopy.Status.Expiry == nil {
 		// Set the approval timeout which is 72 hours
 		roleRequestCopy.Status.Expiry = &metav1.Time{
 			Time: time.Now().Add(72 * time.Hour),
 		}
 	} else if time.Until(roleRequestCopy.Status.Expiry.Time) <= 0 {
-		c.edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestCopy.GetNamespace()).Delete(context.TODO(), roleRequestCopy.GetName(), metav1.DeleteOptions{})
-		return
+		// The subject of a temporary grant is unbound before the role request goes away, so that a
+		// failure to revoke the access is retried rather than leaving the binding behind
+		if roleRequestCopy.Spec.TemporaryAccess != nil && roleRequestCopy.Status.State == registrationv1alpha1.StatusBound {
+			if err := c.revokeTemporaryAccess(roleRequestCopy); err != nil {
+				c.recorder.Event(roleRequestCopy, corev1.EventTypeWarning, failureRevoke, messageRevokeFailed)
+				return err
+			}
+		}
+		return c.deleteRoleRequest(roleRequestCopy)
 	}
 
-	multitenancyManager := multitenancy.NewManager(c.kubeclientset, c.edgenetclientset)
-	permitted, _, _ := multitenancyManager.EligibilityCheck(roleRequestCopy.GetNamespace())
+	multitenancyManager := multitenancy.NewManager(c.readKubeclientset, c.readEdgenetclientset)
+	permitted, _, namespaceLabels := multitenancyManager.EligibilityCheck(roleRequestCopy.GetNamespace())
 	if permitted {
 		// Below is to ensure that the requested Role / ClusterRole exists before moving forward in the procedure.
 		// If not, the status of the object falls into an error state.
-		roleExists := c.checkForRequestedRole(roleRequestCopy)
-		if !roleExists {
-			return
+		if roleExists, err := c.checkForRequestedRole(roleRequestCopy, namespaceLabels); !roleExists {
+			return err
 		}
 
 		switch roleRequestCopy.Status.State {
 		case registrationv1alpha1.StatusBound:
 			c.recorder.Event(roleRequestCopy, corev1.EventTypeNormal, registrationv1alpha1.StatusBound, messageRoleBound)
+			// The member clusters the binding failed to propagate to are retried
+			changed, propagated := c.propagateBinding(roleRequestCopy)
+			if changed {
+				c.updateStatus(context.TODO(), roleRequestCopy)
+			}
+			if !propagated {
+				c.enqueueRoleRequestAfter(roleRequestCopy, time.Minute)
+			}
 		case registrationv1alpha1.StatusApproved:
 			// The following section handles role binding. There are two basic logical steps here.
 			// Check if role binding already exists; if not, create a role binding for the user.
 			// If role binding exists, check if the user already holds the role. If not, pin the role to the user.
 
+			// A federated namespace lives as long as the Selective Deployment that propagates it
+			if governed, err := c.checkSelectiveDeployment(roleRequestCopy, namespaceLabels); !governed {
+				return err
+			}
+
 			roleRef := rbacv1.RoleRef{Kind: roleRequestCopy.Spec.RoleRef.Kind, Name: roleRequestCopy.Spec.RoleRef.Name}
-			rbSubjects := []rbacv1.Subject{{Kind: "User", Name: roleRequestCopy.Spec.Email, APIGroup: "rbac.authorization.k8s.io"}}
+			rbSubjects := []rbacv1.Subject{{Kind: "User", Name: roleRequestCopy.GetSubjectName(), APIGroup: "rbac.authorization.k8s.io"}}
 			requestedBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: roleRequestCopy.Spec.RoleRef.Name, Namespace: roleRequestCopy.GetNamespace()},
 				Subjects: rbSubjects, RoleRef: roleRef}
 			requestedBindingLabels := map[string]string{"edge-net.io/generated": "true"}
 			requestedBinding.SetLabels(requestedBindingLabels)
+			multitenancy.StampLabels(requestedBinding, multitenancy.ClusterLabels(namespaceLabels))
+			if tenant, err := c.readEdgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), namespaceLabels["edge-net.io/tenant"], metav1.GetOptions{}); err == nil {
+				multitenancy.StampLabels(requestedBinding, multitenancy.TenantLabels(tenant, c.tenantLabelKeys))
+			}
 			if _, err := c.kubeclientset.RbacV1().RoleBindings(requestedBinding.GetNamespace()).Create(context.TODO(), requestedBinding, metav1.CreateOptions{}); err != nil {
 				if !errors.IsAlreadyExists(err) {
 					c.recorder.Event(roleRequestCopy, corev1.EventTypeWarning, failureBinding, messageBindingFailed)
-					return
+					return err
 				}
 
 				if roleBinding, err := c.kubeclientset.RbacV1().RoleBindings(requestedBinding.GetNamespace()).Get(context.TODO(), requestedBinding.GetName(), metav1.GetOptions{}); err == nil {
 					isBound := false
 					for _, subjectRow := range roleBinding.Subjects {
-						if subjectRow.Kind == "User" && subjectRow.Name == roleRequestCopy.Spec.Email {
+						if subjectRow.Kind == "User" && roleRequestCopy.IsSubject(subjectRow.Name) {
 							isBound = true
 							break
 						}
 					}
 					if !isBound {
 						roleBindingCopy := roleBinding.DeepCopy()
-						roleBindingCopy.Subjects = append(roleBindingCopy.Subjects, rbacv1.Subject{Kind: "User", Name: roleRequestCopy.Spec.Email, APIGroup: "rbac.authorization.k8s.io"})
+						roleBindingCopy.Subjects = append(roleBindingCopy.Subjects, rbacv1.Subject{Kind: "User", Name: roleRequestCopy.GetSubjectName(), APIGroup: "rbac.authorization.k8s.io"})
 						if _, err := c.kubeclientset.RbacV1().RoleBindings(roleBindingCopy.GetNamespace()).Update(context.TODO(), roleBindingCopy, metav1.UpdateOptions{}); err != nil {
 							c.recorder.Event(roleBindingCopy, corev1.EventTypeWarning, failureBinding, messageBindingFailed)
-							return
+							return err
 						}
 					}
 				} else {
 					c.recorder.Event(roleRequestCopy, corev1.EventTypeWarning, failureBinding, messageBindingFailed)
-					return
+					return err
 				}
 
 			}
 
+			c.propagateBinding(roleRequestCopy)
+
+			if roleRequestCopy.Spec.TemporaryAccess != nil {
+				// The grant expires along with the role request
+				roleRequestCopy.Status.Expiry = &metav1.Time{
+					Time: time.Now().Add(roleRequestCopy.Spec.TemporaryAccess.Duration.Duration),
+				}
+			}
 			roleRequestCopy.Status.State = registrationv1alpha1.StatusBound
 			roleRequestCopy.Status.Message = messageRoleBound
 			c.updateStatus(context.TODO(), roleRequestCopy)
 		case registrationv1alpha1.StatusPending:
-			if roleRequestCopy.Spec.Approved {
-				c.recorder.Event(roleRequestCopy, corev1.EventTypeNormal, registrationv1alpha1.StatusApproved, messageRoleApproved)
+			message := messageRoleApproved
+			approved := roleRequestCopy.Spec.Approved
+			if requiredApprovals := roleRequestCopy.GetRequiredApprovals(); requiredApprovals > 1 {
+				// Neither the approved flag nor the approval policy stand in for the quorum of approvers
+				approvals := c.countApprovals(roleRequestCopy)
+				approved = approvals >= requiredApprovals
+				message = fmt.Sprintf(messageApprovals, approvals, requiredApprovals)
+			} else if !approved {
+				if autoApproved, reason := c.approvalPolicy.ShouldAutoApprove(roleRequestCopy.DeepCopy()); autoApproved {
+					if err := c.autoApprove(roleRequestCopy, reason); err != nil {
+						return err
+					}
+					approved = true
+					message = fmt.Sprintf(messageRoleAutoApproved, reason)
+				}
+			}
+			if approved {
+				c.recorder.Event(roleRequestCopy, corev1.EventTypeNormal, registrationv1alpha1.StatusApproved, message)
 				roleRequestCopy.Status.State = registrationv1alpha1.StatusApproved
-				roleRequestCopy.Status.Message = messageRoleApproved
+				roleRequestCopy.Status.Message = message
 				c.updateStatus(context.TODO(), roleRequestCopy)
+			} else if roleRequestCopy.GetRequiredApprovals() > 1 && roleRequestCopy.Status.Message != message {
+				// The approvals received so far are shown before the approvers are reminded
+				roleRequestCopy.Status.Message = message
+				c.updateStatus(context.TODO(), roleRequestCopy)
+			} else {
+				c.remindApprovers(roleRequestCopy)
+			}
+		case registrationv1alpha1.StatusFailed:
+			// A failed request is not started over, it is left as it is until it expires. Only the ownership
+			// grant, which fails on transient errors, is retried.
+			if roleRequestCopy.Status.Message != messageOwnershipFailure {
+				return nil
 			}
+			fallthrough
 		default:
-			if ownershipGranted := c.grantRequestOwnership(roleRequestCopy); !ownershipGranted {
-				return
+			if err := c.grantRequestOwnership(roleRequestCopy, namespaceLabels); err != nil {
+				return err
 			}
 
 			roleRequestCopy.Status.State = registrationv1alpha1.StatusPending
 			roleRequestCopy.Status.Message = messagePending
+			roleRequestCopy.Status.LastNotified = &metav1.Time{Time: time.Now()}
 			c.updateStatus(context.TODO(), roleRequestCopy)
 		}
 	} else {
-		c.edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestCopy.GetNamespace()).Delete(context.TODO(), roleRequestCopy.GetName(), metav1.DeleteOptions{})
+		return c.deleteRoleRequest(roleRequestCopy)
 	}
+	return nil
+}
+
+// deleteRoleRequest deletes the role request, which may already be gone
+func (c *Controller) deleteRoleRequest(roleRequestCopy *registrationv1alpha1.RoleRequest) error {
+	if err := c.edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestCopy.GetNamespace()).Delete(context.TODO(), roleRequestCopy.GetName(), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// remindApprovers counts a reminder in the status of a request that has been pending approval for the reminder
+// interval since the approvers were last notified. The notifier sends the reminder on this status change.
+func (c *Controller) remindApprovers(roleRequestCopy *registrationv1alpha1.RoleRequest) {
+	if c.reminderInterval <= 0 || roleRequestCopy.Status.Reminders >= c.maxReminders {
+		return
+	}
+	if roleRequestCopy.Status.LastNotified == nil {
+		// Requests made before the reminders were enabled are counted from now on
+		roleRequestCopy.Status.LastNotified = &metav1.Time{Time: time.Now()}
+		c.updateStatus(context.TODO(), roleRequestCopy)
+		return
+	}
+	if remaining := time.Until(roleRequestCopy.Status.LastNotified.Add(c.reminderInterval)); remaining > 0 {
+		c.enqueueRoleRequestAfter(roleRequestCopy, remaining)
+		return
+	}
+	roleRequestCopy.Status.Reminders++
+	roleRequestCopy.Status.LastNotified = &metav1.Time{Time: time.Now()}
+	// The notifier sends the reminder as the approvers are no longer notified
+	roleRequestCopy.Status.Notified = false
+	c.recorder.Event(roleRequestCopy, corev1.EventTypeNormal, successReminded, fmt.Sprintf(messageReminded, roleRequestCopy.Status.Reminders, c.maxReminders))
+	c.updateStatus(context.TODO(), roleRequestCopy)
+}
+
+// countApprovals returns the number of approvers of the role request who are still allowed to approve it, according
+// to the same subject access review as the one used to pick the approvers to notify. The requester, who is allowed
+// to update their own role request, does not count.
+func (c *Controller) countApprovals(roleRequestCopy *registrationv1alpha1.RoleRequest) int {
+	approvals := 0
+	for _, approver := range roleRequestCopy.Status.Approvals {
+		if isRequester(roleRequestCopy, approver) {
+			continue
+		}
+		allowed, err := canApprove(c.kubeclientset, approver, roleRequestCopy.GetNamespace(), roleRequestCopy.GetName())
+		if err != nil {
+			klog.Infoln(err)
+			continue
+		}
+		if allowed {
+			approvals++
+		}
+	}
+	return approvals
+}
+
+// autoApprove approves the role request on behalf of the approval policy, recording the reason in an annotation
+func (c *Controller) autoApprove(roleRequestCopy *registrationv1alpha1.RoleRequest, reason string) error {
+	roleRequestCopy.Spec.Approved = true
+	annotations := roleRequestCopy.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[autoApprovalReasonAnnotation] = reason
+	roleRequestCopy.SetAnnotations(annotations)
+	roleRequestUpdated, err := c.edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestCopy.GetNamespace()).Update(context.TODO(), roleRequestCopy, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	// The status update that follows needs the current resource version
+	roleRequestCopy.SetResourceVersion(roleRequestUpdated.GetResourceVersion())
+	return nil
+}
+
+// revokeTemporaryAccess removes the subject of the role request from the role binding created on approval, in this
+// cluster and in the member clusters.
+func (c *Controller) revokeTemporaryAccess(roleRequestCopy *registrationv1alpha1.RoleRequest) error {
+	if err := unbindSubject(c.kubeclientset, roleRequestCopy); err != nil {
+		return err
+	}
+	// The access propagated to the member clusters is revoked as well
+	for _, cluster := range c.memberClusters() {
+		if err := unbindSubject(c.memberClientsets[cluster], roleRequestCopy); err != nil {
+			return fmt.Errorf("member cluster %s: %w", cluster, err)
+		}
+	}
+	return nil
 }
 
-func (c *Controller) grantRequestOwnership(roleRequestCopy *registrationv1alpha1.RoleRequest) bool {
+// unbindSubject removes the subject of the role request from the binding of the requested role through the given
+// clientset. The binding is deleted once it has no subjects left, provided it was generated for role requests.
+func unbindSubject(kubeclientset kubernetes.Interface, roleRequestCopy *registrationv1alpha1.RoleRequest) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		roleBinding, err := kubeclientset.RbacV1().RoleBindings(roleRequestCopy.GetNamespace()).Get(context.TODO(), roleRequestCopy.Spec.RoleRef.Name, metav1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		roleBindingCopy := roleBinding.DeepCopy()
+		roleBindingCopy.Subjects = []rbacv1.Subject{}
+		for _, subjectRow := range roleBinding.Subjects {
+			if subjectRow.Kind == "User" && roleRequestCopy.IsSubject(subjectRow.Name) {
+				continue
+			}
+			roleBindingCopy.Subjects = append(roleBindingCopy.Subjects, subjectRow)
+		}
+		if len(roleBindingCopy.Subjects) == len(roleBinding.Subjects) {
+			return nil
+		}
+		if len(roleBindingCopy.Subjects) == 0 && roleBinding.GetLabels()["edge-net.io/generated"] == "true" {
+			if err := kubeclientset.RbacV1().RoleBindings(roleBinding.GetNamespace()).Delete(context.TODO(), roleBinding.GetName(), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			return nil
+		}
+		_, err = kubeclientset.RbacV1().RoleBindings(roleBindingCopy.GetNamespace()).Update(context.TODO(), roleBindingCopy, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (c *Controller) grantRequestOwnership(roleRequestCopy *registrationv1alpha1.RoleRequest, namespaceLabels map[string]string) error {
 	objectName := fmt.Sprintf("edgenet:%s:%s", "rolerequest", roleRequestCopy.GetName())
 	policyRule := []rbacv1.PolicyRule{{APIGroups: []string{"registration.edgenet.io"}, Resources: []string{"rolerequests"}, ResourceNames: []string{roleRequestCopy.GetName()}, Verbs: []string{"get", "update", "patch", "delete"}},
 		{APIGroups: []string{"registration.edgenet.io"}, Resources: []string{fmt.Sprintf("%s/status", "rolerequests")}, ResourceNames: []string{roleRequestCopy.GetName()}, Verbs: []string{"get", "list", "watch"}},
 	}
 	role := &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: objectName, OwnerReferences: []metav1.OwnerReference{roleRequestCopy.MakeOwnerReference()}},
 		Rules: policyRule}
-	if _, err := c.kubeclientset.RbacV1().Roles(roleRequestCopy.GetNamespace()).Create(context.TODO(), role, metav1.CreateOptions{}); err == nil || errors.IsAlreadyExists(err) {
+	multitenancy.StampLabels(role, multitenancy.ClusterLabels(namespaceLabels))
+	var err error
+	if _, err = c.kubeclientset.RbacV1().Roles(roleRequestCopy.GetNamespace()).Create(context.TODO(), role, metav1.CreateOptions{}); err == nil || errors.IsAlreadyExists(err) {
 		roleRef := rbacv1.RoleRef{Kind: "Role", Name: objectName}
-		rbSubjects := []rbacv1.Subject{{Kind: "User", Name: roleRequestCopy.Spec.Email, APIGroup: "rbac.authorization.k8s.io"}}
+		rbSubjects := []rbacv1.Subject{{Kind: "User", Name: roleRequestCopy.GetSubjectName(), APIGroup: "rbac.authorization.k8s.io"}}
 		roleBind := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: objectName},
 			Subjects: rbSubjects, RoleRef: roleRef}
 		roleBind.ObjectMeta.OwnerReferences = []metav1.OwnerReference{roleRequestCopy.MakeOwnerReference()}
-		if _, err := c.kubeclientset.RbacV1().RoleBindings(roleRequestCopy.GetNamespace()).Create(context.TODO(), roleBind, metav1.CreateOptions{}); err == nil || errors.IsAlreadyExists(err) {
-			return true
+		multitenancy.StampLabels(roleBind, multitenancy.ClusterLabels(namespaceLabels))
+		if _, err = c.kubeclientset.RbacV1().RoleBindings(roleRequestCopy.GetNamespace()).Create(context.TODO(), roleBind, metav1.CreateOptions{}); err == nil || errors.IsAlreadyExists(err) {
+			return nil
 		}
-		klog.Infof("Couldn't create %s  role binding: %s", objectName, err)
+		err = fmt.Errorf("couldn't create %s role binding: %w", objectName, err)
 	} else {
-		klog.Infof("Couldn't create %s role: %s", objectName, err)
+		err = fmt.Errorf("couldn't create %s role: %w", objectName, err)
 	}
 
 	if roleRequestCopy.Status.State != registrationv1alpha1.StatusFailed {
@@ -362,39 +681,309 @@ func (c *Controller) grantRequestOwnership(roleRequestCopy *registrationv1alpha1
 		c.updateStatus(context.TODO(), roleRequestCopy)
 	}
 
-	return false
+	return err
 }
 
-func (c *Controller) checkForRequestedRole(roleRequestCopy *registrationv1alpha1.RoleRequest) bool {
-	if roleRequestCopy.Spec.RoleRef.Kind == "ClusterRole" {
-		if clusterRoleRaw, err := c.kubeclientset.RbacV1().ClusterRoles().List(context.TODO(), metav1.ListOptions{}); err == nil {
-			for _, clusterRoleRow := range clusterRoleRaw.Items {
-				if clusterRoleRow.GetName() == roleRequestCopy.Spec.RoleRef.Name {
-					c.recorder.Event(roleRequestCopy, corev1.EventTypeNormal, successFound, messageRoleFound)
-					return true
-				}
+func (c *Controller) checkForRequestedRole(roleRequestCopy *registrationv1alpha1.RoleRequest, namespaceLabels map[string]string) (bool, error) {
+	message, missing, err := c.requestedRoleMissing(roleRequestCopy, namespaceLabels["edge-net.io/tenant"])
+	if err != nil {
+		// The request is retried, as the role may well be there
+		return false, err
+	}
+	if !missing {
+		c.recorder.Event(roleRequestCopy, corev1.EventTypeNormal, successFound, messageRoleFound)
+		return true, nil
+	}
+
+	c.recorder.Event(roleRequestCopy, corev1.EventTypeWarning, failureFound, message)
+	if roleRequestCopy.Status.State != registrationv1alpha1.StatusFailed || roleRequestCopy.Status.Message != message {
+		roleRequestCopy.Status.State = registrationv1alpha1.StatusFailed
+		roleRequestCopy.Status.Message = message
+		c.updateStatus(context.TODO(), roleRequestCopy)
+	}
+	return false, nil
+}
+
+// checkSelectiveDeployment tells whether the namespace of the role request is still governed by the Selective
+// Deployment that propagates it, if any, and fails the role request otherwise.
+func (c *Controller) checkSelectiveDeployment(roleRequestCopy *registrationv1alpha1.RoleRequest, namespaceLabels map[string]string) (bool, error) {
+	message, withdrawn, err := c.selectiveDeploymentWithdrawn(roleRequestCopy, namespaceLabels)
+	if err != nil {
+		// The request is retried, as the Selective Deployment may well be there
+		return false, err
+	}
+	if !withdrawn {
+		return true, nil
+	}
+
+	c.recorder.Event(roleRequestCopy, corev1.EventTypeWarning, failureWithdrawn, message)
+	roleRequestCopy.Status.State = registrationv1alpha1.StatusFailed
+	roleRequestCopy.Status.Message = message
+	c.updateStatus(context.TODO(), roleRequestCopy)
+	return false, nil
+}
+
+// selectiveDeploymentWithdrawn tells whether the namespace of the role request is federated and the Selective
+// Deployment that propagates it is gone, along with the reason. An error is returned when it cannot be looked up.
+func (c *Controller) selectiveDeploymentWithdrawn(roleRequestCopy *registrationv1alpha1.RoleRequest, namespaceLabels map[string]string) (string, bool, error) {
+	selectiveDeploymentName, isFederated := namespaceLabels["edge-net.io/selective-deployment-name"]
+	if !isFederated {
+		return "", false, nil
+	}
+	selectiveDeployment, err := c.readEdgenetclientset.AppsV1alpha2().SelectiveDeployments(roleRequestCopy.GetNamespace()).Get(context.TODO(), selectiveDeploymentName, metav1.GetOptions{})
+	if err == nil && selectiveDeployment.GetDeletionTimestamp() == nil {
+		return "", false, nil
+	}
+	if err != nil && !errors.IsNotFound(err) {
+		return "", false, err
+	}
+	return fmt.Sprintf(messageWithdrawn, selectiveDeploymentName), true, nil
+}
+
+// requestedRoleMissing tells whether the requested Role / Cluster Role cannot be bound, along with the reason. A
+// Role is looked up in the namespace of the role request only. One by the same name in another namespace of the
+// tenant is pointed out, as it cannot be bound across namespaces, whereas the namespaces of other tenants are kept
+// out of the message. An error is returned when the roles cannot be looked up.
+func (c *Controller) requestedRoleMissing(roleRequestCopy *registrationv1alpha1.RoleRequest, tenant string) (string, bool, error) {
+	roleName := roleRequestCopy.Spec.RoleRef.Name
+	switch roleRequestCopy.Spec.RoleRef.Kind {
+	case "ClusterRole":
+		if _, err := c.readKubeclientset.RbacV1().ClusterRoles().Get(context.TODO(), roleName, metav1.GetOptions{}); err != nil {
+			if errors.IsNotFound(err) {
+				return messageRoleNotFound, true, nil
 			}
+			return "", false, err
 		}
-	} else if roleRequestCopy.Spec.RoleRef.Kind == "Role" {
-		if roleRaw, err := c.kubeclientset.RbacV1().Roles(roleRequestCopy.GetNamespace()).List(context.TODO(), metav1.ListOptions{}); err == nil {
-			for _, roleRow := range roleRaw.Items {
-				if roleRow.GetName() == roleRequestCopy.Spec.RoleRef.Name {
-					c.recorder.Event(roleRequestCopy, corev1.EventTypeNormal, successFound, messageRoleFound)
-					return true
-				}
+		return "", false, nil
+	case "Role":
+		if _, err := c.readKubeclientset.RbacV1().Roles(roleRequestCopy.GetNamespace()).Get(context.TODO(), roleName, metav1.GetOptions{}); err == nil {
+			return "", false, nil
+		} else if !errors.IsNotFound(err) {
+			return "", false, err
+		}
+		if tenant == "" {
+			return fmt.Sprintf(messageRoleNotInNS, roleName, roleRequestCopy.GetNamespace()), true, nil
+		}
+		namespaceRaw, err := c.readKubeclientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/tenant=%s", tenant)})
+		if err != nil {
+			return "", false, err
+		}
+		otherNamespaces := []string{}
+		for _, namespaceRow := range namespaceRaw.Items {
+			if namespaceRow.GetName() == roleRequestCopy.GetNamespace() {
+				continue
+			}
+			if _, err := c.readKubeclientset.RbacV1().Roles(namespaceRow.GetName()).Get(context.TODO(), roleName, metav1.GetOptions{}); err == nil {
+				otherNamespaces = append(otherNamespaces, namespaceRow.GetName())
+			} else if !errors.IsNotFound(err) {
+				return "", false, err
 			}
 		}
+		if len(otherNamespaces) > 0 {
+			sort.Strings(otherNamespaces)
+			return fmt.Sprintf(messageRoleInOtherNS, roleName, roleRequestCopy.GetNamespace(), strings.Join(otherNamespaces, ", ")), true, nil
+		}
+		return fmt.Sprintf(messageRoleNotInNS, roleName, roleRequestCopy.GetNamespace()), true, nil
+	default:
+		return fmt.Sprintf(messageRoleKindInvalid, roleRequestCopy.Spec.RoleRef.Kind), true, nil
 	}
+}
 
-	c.recorder.Event(roleRequestCopy, corev1.EventTypeWarning, failureFound, messageRoleNotFound)
-	roleRequestCopy.Status.State = registrationv1alpha1.StatusFailed
-	roleRequestCopy.Status.Message = messageRoleNotFound
-	return false
+// recordTransition appends the current state to the history of the role request if it differs from the last one
+// recorded, keeping the latest maxHistory transitions.
+func recordTransition(roleRequestCopy *registrationv1alpha1.RoleRequest) {
+	history := roleRequestCopy.Status.History
+	if roleRequestCopy.Status.State == "" || (len(history) > 0 && history[len(history)-1].State == roleRequestCopy.Status.State) {
+		return
+	}
+	history = append(history, registrationv1alpha1.Transition{State: roleRequestCopy.Status.State, Timestamp: metav1.Now(), Message: roleRequestCopy.Status.Message})
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	roleRequestCopy.Status.History = history
 }
 
 // updateStatus calls the API to update the role request status.
 func (c *Controller) updateStatus(ctx context.Context, roleRequestCopy *registrationv1alpha1.RoleRequest) {
+	recordTransition(roleRequestCopy)
 	if _, err := c.edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestCopy.GetNamespace()).UpdateStatus(ctx, roleRequestCopy, metav1.UpdateOptions{}); err != nil {
 		klog.Infoln(err)
 	}
 }
+
+// ApproveBySelector approves all pending role requests in the namespace that match the label selector.
+// Each approval is retried on conflict, and the number of role requests approved is returned along with
+// the first error encountered.
+func ApproveBySelector(edgenetclientset clientset.Interface, namespace string, selector labels.Selector) (int, error) {
+	roleRequestRaw, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return 0, err
+	}
+
+	approved := 0
+	var firstErr error
+	for _, roleRequestRow := range roleRequestRaw.Items {
+		if roleRequestRow.Spec.Approved || roleRequestRow.Status.State != registrationv1alpha1.StatusPending {
+			continue
+		}
+		isApproved := false
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestRow.GetNamespace()).Get(context.TODO(), roleRequestRow.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			// The role request may have been approved or processed further in the meantime
+			if roleRequest.Spec.Approved || roleRequest.Status.State != registrationv1alpha1.StatusPending {
+				return nil
+			}
+			roleRequestCopy := roleRequest.DeepCopy()
+			roleRequestCopy.Spec.Approved = true
+			if _, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestCopy.GetNamespace()).Update(context.TODO(), roleRequestCopy, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+			isApproved = true
+			return nil
+		})
+		if err != nil {
+			klog.V(4).Infof("Role request %s/%s cannot be approved: %s", roleRequestRow.GetNamespace(), roleRequestRow.GetName(), err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if isApproved {
+			approved++
+		}
+	}
+	return approved, firstErr
+}
+
+// Approve records the approval of the role request by the approver, once a subject access review confirms that the
+// approver is allowed to update it. An approver counts once, whatever the case of the email, and the role request is
+// flagged as approved when the required number of approvers is reached. The requester cannot approve their own
+// role request.
+func Approve(kubeclientset kubernetes.Interface, edgenetclientset clientset.Interface, namespace, name, approver string) error {
+	approver = strings.ToLower(strings.TrimSpace(approver))
+	roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if isRequester(roleRequest, approver) {
+		return fmt.Errorf("%s cannot approve their own role request %s/%s", approver, namespace, name)
+	}
+	allowed, err := canApprove(kubeclientset, approver, namespace, name)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("%s is not allowed to approve role request %s/%s", approver, namespace, name)
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		roleRequestCopy := roleRequest.DeepCopy()
+		if !roleRequestCopy.HasApproved(approver) {
+			roleRequestCopy.Status.Approvals = append(roleRequestCopy.Status.Approvals, approver)
+			if roleRequestCopy, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(namespace).UpdateStatus(context.TODO(), roleRequestCopy, metav1.UpdateOptions{}); err != nil {
+				return err
+			}
+		}
+		if roleRequestCopy.Spec.Approved || len(roleRequestCopy.Status.Approvals) < roleRequestCopy.GetRequiredApprovals() {
+			return nil
+		}
+		roleRequestCopy.Spec.Approved = true
+		_, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(namespace).Update(context.TODO(), roleRequestCopy, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// approverReviewTTL is how long the outcome of an access review of an approver is reused.
+var approverReviewTTL = 30 * time.Second
+
+type approverReview struct {
+	allowed bool
+	expiry  time.Time
+}
+
+// approverReviews caches the access reviews by approver, namespace, and name of the role request.
+var approverReviews = struct {
+	sync.Mutex
+	items map[string]approverReview
+}{items: make(map[string]approverReview)}
+
+// PendingForApprover returns the pending role requests the approver can act on, that is, those the approver
+// is allowed to update according to a subject access review, as done to pick the approvers to notify, and has not
+// approved yet.
+// The list is sorted by namespace and name. The reviews are cached briefly, so the list may lag behind
+// a change of permissions.
+func PendingForApprover(kubeclientset kubernetes.Interface, edgenetclientset clientset.Interface, email string) ([]registrationv1alpha1.RoleRequest, error) {
+	roleRequestRaw, err := edgenetclientset.RegistrationV1alpha1().RoleRequests("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pending := []registrationv1alpha1.RoleRequest{}
+	for _, roleRequestRow := range roleRequestRaw.Items {
+		if roleRequestRow.Status.State != registrationv1alpha1.StatusPending || roleRequestRow.HasApproved(email) || isRequester(&roleRequestRow, email) ||
+			(roleRequestRow.Spec.Approved && roleRequestRow.GetRequiredApprovals() <= 1) {
+			continue
+		}
+		allowed, err := canApprove(kubeclientset, email, roleRequestRow.GetNamespace(), roleRequestRow.GetName())
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			pending = append(pending, roleRequestRow)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].GetNamespace() != pending[j].GetNamespace() {
+			return pending[i].GetNamespace() < pending[j].GetNamespace()
+		}
+		return pending[i].GetName() < pending[j].GetName()
+	})
+	return pending, nil
+}
+
+// isRequester tells whether the approver is the subject of the role request, by username or by email
+func isRequester(roleRequest *registrationv1alpha1.RoleRequest, approver string) bool {
+	return roleRequest.IsSubject(approver) || strings.EqualFold(approver, roleRequest.Spec.Email)
+}
+
+// canApprove reports whether the user is allowed to update the role request, using the cached review if any.
+func canApprove(kubeclientset kubernetes.Interface, user, namespace, name string) (bool, error) {
+	key := fmt.Sprintf("%s/%s/%s", user, namespace, name)
+	approverReviews.Lock()
+	review, elementExists := approverReviews.items[key]
+	approverReviews.Unlock()
+	if elementExists && time.Now().Before(review.expiry) {
+		return review.allowed, nil
+	}
+
+	subjectAccessReview := new(authorizationv1.SubjectAccessReview)
+	resourceAttributes := new(authorizationv1.ResourceAttributes)
+	resourceAttributes.Group = "registration.edgenet.io"
+	resourceAttributes.Version = "v1alpha1"
+	resourceAttributes.Resource = "rolerequests"
+	resourceAttributes.Verb = "update"
+	resourceAttributes.Namespace = namespace
+	resourceAttributes.Name = name
+	subjectAccessReview.Spec.ResourceAttributes = resourceAttributes
+	subjectAccessReview.Spec.User = user
+	subjectAccessReviewResult, err := kubeclientset.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), subjectAccessReview, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	approverReviews.Lock()
+	for cachedKey, cachedReview := range approverReviews.items {
+		if now.After(cachedReview.expiry) {
+			delete(approverReviews.items, cachedKey)
+		}
+	}
+	approverReviews.items[key] = approverReview{allowed: subjectAccessReviewResult.Status.Allowed, expiry: now.Add(approverReviewTTL)}
+	approverReviews.Unlock()
+	return subjectAccessReviewResult.Status.Allowed, nil
+}
@@ -1,29 +1,45 @@
 package rolerequest
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	appsv1alpha2 "github.com/EdgeNet-project/edgenet/pkg/apis/apps/v1alpha2"
 	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
 	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/eventsink"
 	"github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
 	edgenettestclient "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/fake"
 	informers "github.com/EdgeNet-project/edgenet/pkg/generated/informers/externalversions"
+	"github.com/EdgeNet-project/edgenet/pkg/maintenance"
 	multitenancy "github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+	"github.com/EdgeNet-project/edgenet/pkg/notification"
 	"github.com/EdgeNet-project/edgenet/pkg/signals"
 	"github.com/EdgeNet-project/edgenet/pkg/util"
 	"github.com/sirupsen/logrus"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
 )
 
@@ -35,6 +51,16 @@ type TestGroup struct {
 var kubeclientset kubernetes.Interface = testclient.NewSimpleClientset()
 var edgenetclientset versioned.Interface = edgenettestclient.NewSimpleClientset()
 
+// cohortApprovalPolicy approves the role requests labeled as part of a trusted cohort
+type cohortApprovalPolicy struct{}
+
+func (cohortApprovalPolicy) ShouldAutoApprove(roleRequest *registrationv1alpha1.RoleRequest) (bool, string) {
+	if roleRequest.GetLabels()["edge-net.io/cohort"] == "trusted" {
+		return true, "member of a trusted cohort"
+	}
+	return false, ""
+}
+
 func TestMain(m *testing.M) {
 	klog.SetOutput(ioutil.Discard)
 	log.SetOutput(ioutil.Discard)
@@ -48,9 +74,21 @@ func TestMain(m *testing.M) {
 
 	edgenetInformerFactory := informers.NewSharedInformerFactory(edgenetclientset, time.Second*30)
 
-	controller := NewController(kubeclientset,
+	controller, err := NewController(kubeclientset,
 		edgenetclientset,
-		edgenetInformerFactory.Registration().V1alpha1().RoleRequests())
+		edgenetInformerFactory.Registration().V1alpha1().RoleRequests(),
+		multitenancy.DefaultTenantLabelKeys,
+		cohortApprovalPolicy{},
+		0,
+		0,
+		nil,
+		nil,
+		nil,
+		0,
+		false)
+	if err != nil {
+		klog.Fatalf("Error creating controller: %s", err.Error())
+	}
 
 	edgenetInformerFactory.Start(stopCh)
 
@@ -62,7 +100,7 @@ func TestMain(m *testing.M) {
 
 	multitenancyManager := multitenancy.NewManager(kubeclientset, edgenetclientset)
 	multitenancyManager.CreateClusterRoles()
-	kubeSystemNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}
+	kubeSystemNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "cluster-uid"}}
 	kubeclientset.CoreV1().Namespaces().Create(context.TODO(), kubeSystemNamespace, metav1.CreateOptions{})
 
 	time.Sleep(500 * time.Millisecond)
@@ -162,6 +200,194 @@ func TestStartController(t *testing.T) {
 	util.Equals(t, messageRoleBound, roleRequest.Status.Message)
 }
 
+func TestBindingByUsername(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	roleRequestTest := g.roleRequestObj.DeepCopy()
+	roleRequestTest.SetName("role-request-username-test")
+	roleRequestTest.Spec.Email = "jane.doe@edge-net.org"
+	roleRequestTest.Spec.Username = "oidc|5f2b8a1c9d"
+	util.Equals(t, "oidc|5f2b8a1c9d", roleRequestTest.GetSubjectName())
+
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	time.Sleep(time.Millisecond * 500)
+	roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, registrationv1alpha1.StatusPending, roleRequest.Status.State)
+	ownerBinding, err := kubeclientset.RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), fmt.Sprintf("edgenet:rolerequest:%s", roleRequestTest.GetName()), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, roleRequestTest.Spec.Username, ownerBinding.Subjects[0].Name)
+
+	roleRequest.Spec.Approved = true
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Update(context.TODO(), roleRequest, metav1.UpdateOptions{})
+	time.Sleep(time.Millisecond * 500)
+	roleRequest, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, registrationv1alpha1.StatusBound, roleRequest.Status.State)
+
+	roleBinding, err := kubeclientset.RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.Spec.RoleRef.Name, metav1.GetOptions{})
+	util.OK(t, err)
+	boundByUsername, boundByEmail := false, false
+	for _, subject := range roleBinding.Subjects {
+		if subject.Kind == "User" && subject.Name == roleRequestTest.Spec.Username {
+			boundByUsername = true
+		}
+		if subject.Kind == "User" && subject.Name == roleRequestTest.Spec.Email {
+			boundByEmail = true
+		}
+	}
+	util.Equals(t, true, boundByUsername)
+	util.Equals(t, false, boundByEmail)
+
+	// Usernames are case-sensitive, unlike emails
+	roleRequestTest.Spec.Username = "Johnsmith"
+	util.Equals(t, true, roleRequestTest.IsSubject("Johnsmith"))
+	util.Equals(t, false, roleRequestTest.IsSubject("johnsmith"))
+
+	roleRequestTest.Spec.Username = ""
+	util.Equals(t, roleRequestTest.Spec.Email, roleRequestTest.GetSubjectName())
+	roleRequestTest.Spec.Email = "John.Smith@Edge-Net.org"
+	util.Equals(t, "john.smith@edge-net.org", roleRequestTest.GetSubjectName())
+	util.Equals(t, true, roleRequestTest.IsSubject("john.smith@edge-net.org"))
+	util.Equals(t, false, roleRequestTest.IsSubject("John.Smith@Edge-Net.org"))
+}
+
+func TestMixedCaseEmail(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// The same user requests the role twice, with the email written differently
+	for i, email := range []string{"Mixed.Case@Edge-Net.org", "mixed.case@edge-net.org"} {
+		roleRequestTest := g.roleRequestObj.DeepCopy()
+		roleRequestTest.SetName(fmt.Sprintf("role-request-mixed-case-test-%d", i))
+		roleRequestTest.Spec.Email = email
+		roleRequestTest.Spec.Approved = true
+		_, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+		util.OK(t, err)
+		time.Sleep(time.Millisecond * 500)
+		roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, registrationv1alpha1.StatusBound, roleRequest.Status.State)
+		util.Equals(t, "mixed.case@edge-net.org", roleRequest.GetSubjectName())
+	}
+
+	roleBinding, err := kubeclientset.RbacV1().RoleBindings(g.roleRequestObj.GetNamespace()).Get(context.TODO(), g.roleRequestObj.Spec.RoleRef.Name, metav1.GetOptions{})
+	util.OK(t, err)
+	subjects := []string{}
+	for _, subject := range roleBinding.Subjects {
+		if subject.Kind == "User" && strings.EqualFold(subject.Name, "mixed.case@edge-net.org") {
+			subjects = append(subjects, subject.Name)
+		}
+	}
+	util.Equals(t, []string{"mixed.case@edge-net.org"}, subjects)
+}
+
+func TestClusterLabels(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	roleRequestTest := g.roleRequestObj.DeepCopy()
+	roleRequestTest.SetName("role-request-cluster-labels-test")
+	roleRequestTest.Spec.Email = "jack.doe@edge-net.org"
+
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	time.Sleep(time.Millisecond * 500)
+	ownerRole, err := kubeclientset.RbacV1().Roles(roleRequestTest.GetNamespace()).Get(context.TODO(), fmt.Sprintf("edgenet:rolerequest:%s", roleRequestTest.GetName()), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, "cluster-uid", ownerRole.GetLabels()["edge-net.io/cluster-uid"])
+	ownerBinding, err := kubeclientset.RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), fmt.Sprintf("edgenet:rolerequest:%s", roleRequestTest.GetName()), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, "cluster-uid", ownerBinding.GetLabels()["edge-net.io/cluster-uid"])
+
+	roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	roleRequest.Spec.Approved = true
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Update(context.TODO(), roleRequest, metav1.UpdateOptions{})
+	time.Sleep(time.Millisecond * 500)
+	roleBinding, err := kubeclientset.RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.Spec.RoleRef.Name, metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, "cluster-uid", roleBinding.GetLabels()["edge-net.io/cluster-uid"])
+	util.Equals(t, g.tenantObj.GetName(), roleBinding.GetLabels()["edge-net.io/tenant"])
+}
+
+func TestSelectiveDeployment(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// The namespaces propagated by a Selective Deployment are not bound to the cluster
+	for _, name := range []string{"federated-withdrawn", "federated-present"} {
+		federatedNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		federatedNamespace.SetLabels(map[string]string{"edge-net.io/tenant": g.tenantObj.GetName(), "edge-net.io/selective-deployment-name": "sd"})
+		_, err := kubeclientset.CoreV1().Namespaces().Create(context.TODO(), federatedNamespace, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+	selectiveDeployment := &appsv1alpha2.SelectiveDeployment{ObjectMeta: metav1.ObjectMeta{Name: "sd", Namespace: "federated-present"}}
+	_, err := edgenetclientset.AppsV1alpha2().SelectiveDeployments(selectiveDeployment.GetNamespace()).Create(context.TODO(), selectiveDeployment, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	var request = func(namespace string) *registrationv1alpha1.RoleRequest {
+		roleRequestTest := g.roleRequestObj.DeepCopy()
+		roleRequestTest.SetName("role-request-selective-deployment-test")
+		roleRequestTest.SetNamespace(namespace)
+		roleRequestTest.Spec.Approved = true
+		_, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(namespace).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+		util.OK(t, err)
+		time.Sleep(time.Millisecond * 500)
+		roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(namespace).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		return roleRequest
+	}
+
+	t.Run("withdrawn", func(t *testing.T) {
+		roleRequest := request("federated-withdrawn")
+		util.Equals(t, registrationv1alpha1.StatusFailed, roleRequest.Status.State)
+		util.Equals(t, fmt.Sprintf(messageWithdrawn, "sd"), roleRequest.Status.Message)
+		_, err := kubeclientset.RbacV1().RoleBindings("federated-withdrawn").Get(context.TODO(), roleRequest.Spec.RoleRef.Name, metav1.GetOptions{})
+		util.Equals(t, true, errors.IsNotFound(err))
+	})
+	t.Run("present", func(t *testing.T) {
+		roleRequest := request("federated-present")
+		util.Equals(t, registrationv1alpha1.StatusBound, roleRequest.Status.State)
+		_, err := kubeclientset.RbacV1().RoleBindings("federated-present").Get(context.TODO(), roleRequest.Spec.RoleRef.Name, metav1.GetOptions{})
+		util.OK(t, err)
+	})
+}
+
+func TestEventSink(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	sinkPath := filepath.Join(t.TempDir(), "events.json")
+	err := eventsink.Start(sinkPath)
+	util.OK(t, err)
+	defer eventsink.SetWriter(nil)
+
+	roleRequestTest := g.roleRequestObj.DeepCopy()
+	roleRequestTest.SetName("role-request-event-sink-test")
+	roleRequestTest.Spec.Email = "sink.watcher@edge-net.org"
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	time.Sleep(time.Millisecond * 500)
+	roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	roleRequest.Spec.Approved = true
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Update(context.TODO(), roleRequest, metav1.UpdateOptions{})
+	time.Sleep(time.Millisecond * 500)
+
+	sink, err := os.Open(sinkPath)
+	util.OK(t, err)
+	defer sink.Close()
+	approved := false
+	scanner := bufio.NewScanner(sink)
+	for scanner.Scan() {
+		var event eventsink.Event
+		util.OK(t, json.Unmarshal(scanner.Bytes(), &event))
+		if event.Name == roleRequestTest.GetName() && event.Reason == registrationv1alpha1.StatusApproved {
+			util.Equals(t, corev1.EventTypeNormal, event.Type)
+			util.Equals(t, messageRoleApproved, event.Message)
+			util.Equals(t, roleRequestTest.GetNamespace(), event.Namespace)
+			util.Equals(t, controllerAgentName, event.Component)
+			approved = true
+		}
+	}
+	util.Equals(t, true, approved)
+}
+
 func TestTimeout(t *testing.T) {
 	g := TestGroup{}
 	g.Init()
@@ -190,3 +416,882 @@ func TestTimeout(t *testing.T) {
 		util.Equals(t, true, errors.IsNotFound(err))
 	})
 }
+
+func TestTemporaryAccess(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	roleRequestTest := g.roleRequestObj.DeepCopy()
+	roleRequestTest.SetName("role-request-temporary-test")
+	roleRequestTest.Spec.Email = "temporary.admin@edge-net.org"
+	roleRequestTest.Spec.TemporaryAccess = &registrationv1alpha1.TemporaryAccess{Duration: metav1.Duration{Duration: time.Second}}
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	time.Sleep(time.Millisecond * 500)
+
+	isBound := func() bool {
+		roleBinding, err := kubeclientset.RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.Spec.RoleRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return false
+		}
+		for _, subject := range roleBinding.Subjects {
+			if subject.Kind == "User" && subject.Name == roleRequestTest.Spec.Email {
+				return true
+			}
+		}
+		return false
+	}
+
+	roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	roleRequest.Spec.Approved = true
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Update(context.TODO(), roleRequest, metav1.UpdateOptions{})
+	time.Sleep(time.Millisecond * 500)
+	roleRequest, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, registrationv1alpha1.StatusBound, roleRequest.Status.State)
+	util.Equals(t, true, time.Until(roleRequest.Status.Expiry.Time) <= time.Second)
+	util.Equals(t, true, isBound())
+
+	time.Sleep(time.Millisecond * 1500)
+	util.Equals(t, false, isBound())
+	_, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+}
+
+func TestMaintenance(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	requeueDelay := maintenance.RequeueDelay
+	maintenance.RequeueDelay = 100 * time.Millisecond
+	defer func() { maintenance.RequeueDelay = requeueDelay }()
+	maintenance.SetPaused(true)
+	defer maintenance.SetPaused(false)
+
+	roleRequestTest := g.roleRequestObj.DeepCopy()
+	roleRequestTest.SetName("role-request-maintenance-test")
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	time.Sleep(time.Millisecond * 500)
+	roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, "", roleRequest.Status.State)
+	util.Equals(t, true, roleRequest.Status.Expiry == nil)
+	_, err = kubeclientset.RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), fmt.Sprintf("edgenet:rolerequest:%s", roleRequestTest.GetName()), metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+
+	// The role request queued while paused is processed once resumed
+	maintenance.SetPaused(false)
+	time.Sleep(time.Millisecond * 500)
+	roleRequest, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, registrationv1alpha1.StatusPending, roleRequest.Status.State)
+	_, err = kubeclientset.RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), fmt.Sprintf("edgenet:rolerequest:%s", roleRequestTest.GetName()), metav1.GetOptions{})
+	util.OK(t, err)
+}
+
+func TestApprovalReminders(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	roleRequestTest := g.roleRequestObj.DeepCopy()
+	roleRequestTest.SetName("role-request-reminder-test")
+	roleRequestTest.Spec.Email = "forgotten.request@edge-net.org"
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	time.Sleep(time.Millisecond * 500)
+
+	// A dedicated controller, which is not started, reminds the approvers every day at most twice
+	controller, err := NewController(kubeclientset,
+		edgenetclientset,
+		informers.NewSharedInformerFactory(edgenetclientset, 0).Registration().V1alpha1().RoleRequests(),
+		multitenancy.DefaultTenantLabelKeys,
+		NeverAutoApprove{},
+		24*time.Hour,
+		2,
+		nil,
+		nil,
+		nil,
+		0,
+		false)
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(10)
+	controller.recorder = recorder
+
+	// elapse pretends that the approvers were last notified the given time ago, and processes the request
+	var elapse = func(elapsed time.Duration) *registrationv1alpha1.RoleRequest {
+		roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, registrationv1alpha1.StatusPending, roleRequest.Status.State)
+		roleRequest.Status.LastNotified = &metav1.Time{Time: time.Now().Add(-elapsed)}
+		roleRequest.Status.Notified = true
+		roleRequest, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).UpdateStatus(context.TODO(), roleRequest, metav1.UpdateOptions{})
+		util.OK(t, err)
+		controller.processRoleRequest(roleRequest.DeepCopy())
+		roleRequest, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		return roleRequest
+	}
+
+	// reminders drains the recorded events, and returns the reminders among them
+	var reminders = func() []string {
+		events := []string{}
+		for len(recorder.Events) > 0 {
+			if event := <-recorder.Events; strings.HasPrefix(event, fmt.Sprintf("%s %s", corev1.EventTypeNormal, successReminded)) {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	roleRequest := elapse(23 * time.Hour)
+	util.Equals(t, 0, roleRequest.Status.Reminders)
+	util.Equals(t, true, roleRequest.Status.Notified)
+	util.Equals(t, 0, len(reminders()))
+
+	// The reminder clears the notified flag for the notifier to send it
+	roleRequest = elapse(25 * time.Hour)
+	util.Equals(t, 1, roleRequest.Status.Reminders)
+	util.Equals(t, false, roleRequest.Status.Notified)
+	util.Equals(t, true, time.Since(roleRequest.Status.LastNotified.Time) < time.Minute)
+	util.Equals(t, []string{fmt.Sprintf("%s %s %s", corev1.EventTypeNormal, successReminded, fmt.Sprintf(messageReminded, 1, 2))}, reminders())
+	// The reminder just sent is not repeated within the interval
+	controller.processRoleRequest(roleRequest.DeepCopy())
+	util.Equals(t, 0, len(reminders()))
+
+	roleRequest = elapse(25 * time.Hour)
+	util.Equals(t, 2, roleRequest.Status.Reminders)
+	util.Equals(t, 1, len(reminders()))
+
+	// No more reminders once the maximum is reached
+	roleRequest = elapse(48 * time.Hour)
+	util.Equals(t, 2, roleRequest.Status.Reminders)
+	util.Equals(t, 0, len(reminders()))
+}
+
+func TestReconcileStuck(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// A dedicated controller, which is not started, is given a clientset that fails to grant the ownership of
+	// role requests while failing
+	stuckKubeclientset := testclient.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "cluster-uid"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.roleRequestObj.GetNamespace(), Labels: map[string]string{"edge-net.io/cluster-uid": "cluster-uid", "edge-net.io/tenant": g.tenantObj.GetName()}}},
+		&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: g.roleRequestObj.Spec.RoleRef.Name}})
+	var failing int32 = 1
+	stuckKubeclientset.PrependReactor("create", "roles", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if atomic.LoadInt32(&failing) == 1 {
+			return true, nil, fmt.Errorf("roles unavailable")
+		}
+		return false, nil, nil
+	})
+	stuckEdgenetclientset := edgenettestclient.NewSimpleClientset(g.tenantObj.DeepCopy())
+	edgenetInformerFactory := informers.NewSharedInformerFactory(stuckEdgenetclientset, 0)
+	controller, err := NewController(stuckKubeclientset,
+		stuckEdgenetclientset,
+		edgenetInformerFactory.Registration().V1alpha1().RoleRequests(),
+		multitenancy.DefaultTenantLabelKeys,
+		NeverAutoApprove{},
+		0,
+		0,
+		nil,
+		nil,
+		nil,
+		3,
+		true)
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(100)
+	controller.recorder = recorder
+	notified := []*notification.Content{}
+	controller.notify = func(content *notification.Content, purpose string) error {
+		util.Equals(t, "reconcile-stuck", purpose)
+		notified = append(notified, content)
+		return nil
+	}
+
+	roleRequest := g.roleRequestObj.DeepCopy()
+	roleRequest.SetName("role-request-stuck-test")
+	err = edgenetInformerFactory.Registration().V1alpha1().RoleRequests().Informer().GetIndexer().Add(roleRequest)
+	util.OK(t, err)
+	key := fmt.Sprintf("%s/%s", roleRequest.GetNamespace(), roleRequest.GetName())
+	controller.workqueue.Add(key)
+
+	// stuckEvents drains the recorded events, and returns the stuck reports among them
+	var stuckEvents = func() []string {
+		events := []string{}
+		for len(recorder.Events) > 0 {
+			if event := <-recorder.Events; strings.HasPrefix(event, fmt.Sprintf("%s %s", corev1.EventTypeWarning, failureStuck)) {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	for i := 1; i < 3; i++ {
+		util.Equals(t, true, controller.processNextWorkItem())
+		util.Equals(t, i, controller.workqueue.NumRequeues(key))
+		util.Equals(t, 0, len(stuckEvents()))
+	}
+	util.Equals(t, true, controller.processNextWorkItem())
+	util.Equals(t, []string{fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, failureStuck, fmt.Sprintf(messageStuck, 3))}, stuckEvents())
+	util.Equals(t, 1, len(notified))
+	util.Equals(t, "cluster-uid", notified[0].Cluster)
+	util.Equals(t, notification.ReconcileStuck{Kind: "RoleRequest", Name: roleRequest.GetName(), Namespace: roleRequest.GetNamespace(), Failures: 3}, *notified[0].ReconcileStuck)
+	// The role request is reported once while it stays stuck
+	util.Equals(t, true, controller.processNextWorkItem())
+	util.Equals(t, 0, len(stuckEvents()))
+	util.Equals(t, 1, len(notified))
+
+	// A successful sync resets the count
+	atomic.StoreInt32(&failing, 0)
+	util.Equals(t, true, controller.processNextWorkItem())
+	util.Equals(t, 0, controller.workqueue.NumRequeues(key))
+}
+
+func TestApprovalPolicy(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	roleRequestTrusted := g.roleRequestObj.DeepCopy()
+	roleRequestTrusted.SetName("role-request-policy-test-trusted")
+	roleRequestTrusted.SetLabels(map[string]string{"edge-net.io/cohort": "trusted"})
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTrusted.GetNamespace()).Create(context.TODO(), roleRequestTrusted, metav1.CreateOptions{})
+	roleRequestOther := g.roleRequestObj.DeepCopy()
+	roleRequestOther.SetName("role-request-policy-test-other")
+	roleRequestOther.SetLabels(map[string]string{"edge-net.io/cohort": "unknown"})
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestOther.GetNamespace()).Create(context.TODO(), roleRequestOther, metav1.CreateOptions{})
+	time.Sleep(time.Millisecond * 500)
+
+	roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTrusted.GetNamespace()).Get(context.TODO(), roleRequestTrusted.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, true, roleRequest.Spec.Approved)
+	util.Equals(t, registrationv1alpha1.StatusBound, roleRequest.Status.State)
+	util.Equals(t, "member of a trusted cohort", roleRequest.GetAnnotations()[autoApprovalReasonAnnotation])
+
+	roleRequest, err = edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestOther.GetNamespace()).Get(context.TODO(), roleRequestOther.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, false, roleRequest.Spec.Approved)
+	util.Equals(t, registrationv1alpha1.StatusPending, roleRequest.Status.State)
+	_, annotated := roleRequest.GetAnnotations()[autoApprovalReasonAnnotation]
+	util.Equals(t, false, annotated)
+
+	t.Run("default policy", func(t *testing.T) {
+		approved, reason := NeverAutoApprove{}.ShouldAutoApprove(roleRequestTrusted)
+		util.Equals(t, false, approved)
+		util.Equals(t, "", reason)
+	})
+}
+
+func TestApproveBySelector(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	cohort := map[string]string{"edge-net.io/event": "onboarding"}
+	for i := 0; i < 3; i++ {
+		roleRequestTest := g.roleRequestObj.DeepCopy()
+		roleRequestTest.SetName(fmt.Sprintf("role-request-selector-test-%d", i))
+		roleRequestTest.SetLabels(cohort)
+		edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	}
+	roleRequestApproved := g.roleRequestObj.DeepCopy()
+	roleRequestApproved.SetName("role-request-selector-test-approved")
+	roleRequestApproved.SetLabels(cohort)
+	roleRequestApproved.Spec.Approved = true
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestApproved.GetNamespace()).Create(context.TODO(), roleRequestApproved, metav1.CreateOptions{})
+	roleRequestOther := g.roleRequestObj.DeepCopy()
+	roleRequestOther.SetName("role-request-selector-test-other")
+	roleRequestOther.SetLabels(map[string]string{"edge-net.io/event": "workshop"})
+	edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestOther.GetNamespace()).Create(context.TODO(), roleRequestOther, metav1.CreateOptions{})
+	time.Sleep(time.Millisecond * 500)
+
+	approved, err := ApproveBySelector(edgenetclientset, g.roleRequestObj.GetNamespace(), labels.SelectorFromSet(cohort))
+	util.OK(t, err)
+	util.Equals(t, 3, approved)
+	time.Sleep(time.Millisecond * 500)
+
+	for i := 0; i < 3; i++ {
+		roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(g.roleRequestObj.GetNamespace()).Get(context.TODO(), fmt.Sprintf("role-request-selector-test-%d", i), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, true, roleRequest.Spec.Approved)
+		util.Equals(t, registrationv1alpha1.StatusBound, roleRequest.Status.State)
+	}
+	roleRequest, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestOther.GetNamespace()).Get(context.TODO(), roleRequestOther.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, false, roleRequest.Spec.Approved)
+	util.Equals(t, registrationv1alpha1.StatusPending, roleRequest.Status.State)
+
+	approved, err = ApproveBySelector(edgenetclientset, g.roleRequestObj.GetNamespace(), labels.SelectorFromSet(cohort))
+	util.OK(t, err)
+	util.Equals(t, 0, approved)
+}
+
+func TestPendingForApprover(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	// The tenant admin can act on the requests of the lab namespace only, the cluster admin on all
+	reviewKubeclientset := testclient.NewSimpleClientset()
+	reviewEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	reviews := 0
+	reviewKubeclientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		reviews++
+		subjectAccessReview := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		switch subjectAccessReview.Spec.User {
+		case "tenant.admin@edge-net.org":
+			subjectAccessReview.Status.Allowed = subjectAccessReview.Spec.ResourceAttributes.Namespace == "lab"
+		case "cluster.admin@edge-net.org":
+			subjectAccessReview.Status.Allowed = true
+		}
+		return true, subjectAccessReview, nil
+	})
+
+	for _, key := range []struct{ namespace, name, state string }{
+		{"lab", "alice", registrationv1alpha1.StatusPending},
+		{"lab", "bob", registrationv1alpha1.StatusBound},
+		{"workshop", "carol", registrationv1alpha1.StatusPending},
+	} {
+		roleRequest := g.roleRequestObj.DeepCopy()
+		roleRequest.SetNamespace(key.namespace)
+		roleRequest.SetName(key.name)
+		roleRequest.Status.State = key.state
+		_, err := reviewEdgenetclientset.RegistrationV1alpha1().RoleRequests(key.namespace).Create(context.TODO(), roleRequest, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+
+	var names = func(roleRequests []registrationv1alpha1.RoleRequest) []string {
+		roleRequestNames := []string{}
+		for _, roleRequest := range roleRequests {
+			roleRequestNames = append(roleRequestNames, fmt.Sprintf("%s/%s", roleRequest.GetNamespace(), roleRequest.GetName()))
+		}
+		return roleRequestNames
+	}
+	cases := map[string]struct {
+		approver string
+		expected []string
+	}{
+		"tenant admin":  {"tenant.admin@edge-net.org", []string{"lab/alice"}},
+		"cluster admin": {"cluster.admin@edge-net.org", []string{"lab/alice", "workshop/carol"}},
+		"outsider":      {"joe.public@edge-net.org", []string{}},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			pending, err := PendingForApprover(reviewKubeclientset, reviewEdgenetclientset, tc.approver)
+			util.OK(t, err)
+			util.Equals(t, tc.expected, names(pending))
+		})
+	}
+
+	t.Run("cached reviews", func(t *testing.T) {
+		reviewsBefore := reviews
+		pending, err := PendingForApprover(reviewKubeclientset, reviewEdgenetclientset, "tenant.admin@edge-net.org")
+		util.OK(t, err)
+		util.Equals(t, []string{"lab/alice"}, names(pending))
+		util.Equals(t, reviewsBefore, reviews)
+	})
+}
+
+func TestSimulate(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, on a cluster of its own so that every change is accounted for
+	simulationKubeclientset := testclient.NewSimpleClientset()
+	simulationEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	memberKubeclientset := testclient.NewSimpleClientset()
+	controller, err := NewController(simulationKubeclientset,
+		simulationEdgenetclientset,
+		informers.NewSharedInformerFactory(simulationEdgenetclientset, 0).Registration().V1alpha1().RoleRequests(),
+		multitenancy.DefaultTenantLabelKeys,
+		cohortApprovalPolicy{},
+		0,
+		0,
+		nil,
+		nil,
+		map[string]kubernetes.Interface{"member": memberKubeclientset},
+		0,
+		false)
+	util.OK(t, err)
+	controller.recorder = record.NewFakeRecorder(100)
+
+	simulationKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}}, metav1.CreateOptions{})
+	simulationEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName(), Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()}}}
+	simulationKubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	simulationKubeclientset.RbacV1().ClusterRoles().Create(context.TODO(), &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: g.roleRequestObj.Spec.RoleRef.Name}}, metav1.CreateOptions{})
+	// Someone already holds the requested role in the namespace
+	existingBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: g.roleRequestObj.Spec.RoleRef.Name, Namespace: g.tenantObj.GetName()},
+		Subjects: []rbacv1.Subject{{Kind: "User", Name: "jane.doe@edge-net.org", APIGroup: "rbac.authorization.k8s.io"}},
+		RoleRef:  rbacv1.RoleRef{Kind: "ClusterRole", Name: g.roleRequestObj.Spec.RoleRef.Name}}
+	simulationKubeclientset.RbacV1().RoleBindings(g.tenantObj.GetName()).Create(context.TODO(), existingBinding, metav1.CreateOptions{})
+
+	// simulate returns the result of the simulation, and the changes it made to the cluster
+	var simulate = func(roleRequest *registrationv1alpha1.RoleRequest) (SimulationResult, []string) {
+		simulationKubeclientset.ClearActions()
+		simulationEdgenetclientset.ClearActions()
+		memberKubeclientset.ClearActions()
+		result, err := controller.Simulate(roleRequest)
+		util.OK(t, err)
+		mutations := []string{}
+		actions := append(simulationKubeclientset.Actions(), simulationEdgenetclientset.Actions()...)
+		for _, action := range append(actions, memberKubeclientset.Actions()...) {
+			// The access reviews of the approvers leave nothing behind
+			if action.GetVerb() != "get" && action.GetVerb() != "list" && action.GetResource().Resource != "subjectaccessreviews" {
+				mutations = append(mutations, fmt.Sprintf("%s %s", action.GetVerb(), action.GetResource().Resource))
+			}
+		}
+		return result, mutations
+	}
+
+	t.Run("approved", func(t *testing.T) {
+		roleRequestTest := g.roleRequestObj.DeepCopy()
+		roleRequestTest.SetName("role-request-simulation-trusted")
+		roleRequestTest.SetLabels(map[string]string{"edge-net.io/cohort": "trusted"})
+		roleRequest, err := simulationEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+		util.OK(t, err)
+
+		result, mutations := simulate(roleRequest)
+		util.Equals(t, []string{}, mutations)
+		util.Equals(t, registrationv1alpha1.StatusBound, result.State)
+		util.Equals(t, true, result.AutoApproved)
+		util.Equals(t, "member of a trusted cohort", result.AutoApprovalReason)
+		ownershipName := fmt.Sprintf("edgenet:rolerequest:%s", roleRequestTest.GetName())
+		subject := rbacv1.Subject{Kind: "User", Name: roleRequestTest.Spec.Email, APIGroup: "rbac.authorization.k8s.io"}
+		util.Equals(t, []PlannedAction{
+			{Verb: VerbCreate, Kind: "Role", Namespace: "edgenet", Name: ownershipName},
+			{Verb: VerbCreate, Kind: "RoleBinding", Namespace: "edgenet", Name: ownershipName, Subjects: []rbacv1.Subject{subject}},
+			{Verb: VerbUpdate, Kind: "RoleRequest", Namespace: "edgenet", Name: roleRequestTest.GetName()},
+			{Verb: VerbUpdate, Kind: "RoleBinding", Namespace: "edgenet", Name: existingBinding.GetName(), Subjects: append(existingBinding.Subjects, subject)},
+			{Verb: VerbCreate, Kind: "RoleBinding", Cluster: "member", Namespace: "edgenet", Name: existingBinding.GetName(), Subjects: []rbacv1.Subject{subject}},
+		}, result.Actions)
+		util.Equals(t, []PlannedNotification{
+			{Purpose: "role-request-made"},
+			{Purpose: "role-request-approved", Recipients: []string{roleRequestTest.Spec.Email}},
+		}, result.Notifications)
+		util.Equals(t, []string{registrationv1alpha1.StatusPending, registrationv1alpha1.StatusApproved, registrationv1alpha1.StatusBound}, historyStates(result.History))
+
+		// The real approval, step by step, ends up where the simulation said it would
+		states := []string{}
+		for i := 0; i < 5 && roleRequest.Status.State != registrationv1alpha1.StatusBound; i++ {
+			controller.processRoleRequest(roleRequest.DeepCopy())
+			roleRequest, err = simulationEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+			util.OK(t, err)
+			states = append(states, roleRequest.Status.State)
+		}
+		util.Equals(t, []string{registrationv1alpha1.StatusPending, registrationv1alpha1.StatusApproved, registrationv1alpha1.StatusBound}, states)
+		util.Equals(t, result.State, roleRequest.Status.State)
+		util.Equals(t, result.Message, roleRequest.Status.Message)
+		util.Equals(t, result.AutoApprovalReason, roleRequest.GetAnnotations()[autoApprovalReasonAnnotation])
+		util.Equals(t, historyStates(result.History), historyStates(roleRequest.Status.History))
+		_, err = simulationKubeclientset.RbacV1().Roles("edgenet").Get(context.TODO(), ownershipName, metav1.GetOptions{})
+		util.OK(t, err)
+		for _, action := range result.Actions {
+			if action.Kind != "RoleBinding" {
+				continue
+			}
+			clusterKubeclientset := kubernetes.Interface(simulationKubeclientset)
+			if action.Cluster == "member" {
+				clusterKubeclientset = memberKubeclientset
+			}
+			roleBinding, err := clusterKubeclientset.RbacV1().RoleBindings(action.Namespace).Get(context.TODO(), action.Name, metav1.GetOptions{})
+			util.OK(t, err)
+			util.Equals(t, action.Subjects, roleBinding.Subjects)
+		}
+
+		// Nothing is left to do once bound
+		result, mutations = simulate(roleRequest)
+		util.Equals(t, []string{}, mutations)
+		util.Equals(t, 0, len(result.Actions))
+		util.Equals(t, 0, len(result.Notifications))
+	})
+	t.Run("pending", func(t *testing.T) {
+		roleRequestTest := g.roleRequestObj.DeepCopy()
+		roleRequestTest.SetName("role-request-simulation-other")
+		roleRequest, err := simulationEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+		util.OK(t, err)
+
+		result, mutations := simulate(roleRequest)
+		util.Equals(t, []string{}, mutations)
+		util.Equals(t, registrationv1alpha1.StatusPending, result.State)
+		util.Equals(t, false, result.AutoApproved)
+		util.Equals(t, 2, len(result.Actions))
+		util.Equals(t, []PlannedNotification{{Purpose: "role-request-made"}}, result.Notifications)
+
+		controller.processRoleRequest(roleRequest.DeepCopy())
+		roleRequest, err = simulationEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, result.State, roleRequest.Status.State)
+		util.Equals(t, result.Message, roleRequest.Status.Message)
+	})
+	t.Run("quorum", func(t *testing.T) {
+		roleRequestTest := g.roleRequestObj.DeepCopy()
+		roleRequestTest.SetName("role-request-simulation-quorum")
+		roleRequestTest.SetLabels(map[string]string{"edge-net.io/cohort": "trusted"})
+		roleRequestTest.Spec.RequiredApprovals = 2
+		roleRequestTest.Spec.Approved = true
+		roleRequest, err := simulationEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+		util.OK(t, err)
+
+		// Neither the approved flag nor the approval policy stand in for the quorum
+		result, mutations := simulate(roleRequest)
+		util.Equals(t, []string{}, mutations)
+		util.Equals(t, registrationv1alpha1.StatusPending, result.State)
+		util.Equals(t, fmt.Sprintf(messageApprovals, 0, 2), result.Message)
+		util.Equals(t, false, result.AutoApproved)
+		util.Equals(t, 2, len(result.Actions))
+	})
+	t.Run("failed", func(t *testing.T) {
+		roleRequestTest := g.roleRequestObj.DeepCopy()
+		roleRequestTest.SetName("role-request-simulation-failed")
+		roleRequestTest.Status.State = registrationv1alpha1.StatusFailed
+		roleRequestTest.Status.Message = fmt.Sprintf(messageWithdrawn, "sd")
+		result, mutations := simulate(roleRequestTest)
+		util.Equals(t, []string{}, mutations)
+		util.Equals(t, registrationv1alpha1.StatusFailed, result.State)
+		util.Equals(t, 0, len(result.Actions))
+		util.Equals(t, 0, len(result.Notifications))
+	})
+	t.Run("withdrawn", func(t *testing.T) {
+		federatedNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "simulation-federated",
+			Labels: map[string]string{"edge-net.io/tenant": g.tenantObj.GetName(), "edge-net.io/selective-deployment-name": "sd"}}}
+		simulationKubeclientset.CoreV1().Namespaces().Create(context.TODO(), federatedNamespace, metav1.CreateOptions{})
+		roleRequestTest := g.roleRequestObj.DeepCopy()
+		roleRequestTest.SetName("role-request-simulation-withdrawn")
+		roleRequestTest.SetNamespace(federatedNamespace.GetName())
+		roleRequestTest.Spec.Approved = true
+		result, mutations := simulate(roleRequestTest)
+		util.Equals(t, []string{}, mutations)
+		util.Equals(t, registrationv1alpha1.StatusFailed, result.State)
+		util.Equals(t, fmt.Sprintf(messageWithdrawn, "sd"), result.Message)
+		util.Equals(t, []string{registrationv1alpha1.StatusPending, registrationv1alpha1.StatusApproved, registrationv1alpha1.StatusFailed}, historyStates(result.History))
+		for _, action := range result.Actions {
+			util.Equals(t, true, action.Name != roleRequestTest.Spec.RoleRef.Name)
+		}
+	})
+}
+
+// historyStates returns the states in the history of a role request
+func historyStates(history []registrationv1alpha1.Transition) []string {
+	states := []string{}
+	for _, transition := range history {
+		states = append(states, transition.State)
+	}
+	return states
+}
+
+func TestApprovalQuorum(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, whose subject access reviews let two approvers in
+	quorumKubeclientset := testclient.NewSimpleClientset()
+	quorumEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	quorumKubeclientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		subjectAccessReview := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		switch subjectAccessReview.Spec.User {
+		// The requester is allowed to update their own role request, as granted by its ownership
+		case "first.approver@edge-net.org", "second.approver@edge-net.org", g.roleRequestObj.Spec.Email:
+			subjectAccessReview.Status.Allowed = true
+		}
+		return true, subjectAccessReview, nil
+	})
+	controller, err := NewController(quorumKubeclientset,
+		quorumEdgenetclientset,
+		informers.NewSharedInformerFactory(quorumEdgenetclientset, 0).Registration().V1alpha1().RoleRequests(),
+		multitenancy.DefaultTenantLabelKeys,
+		cohortApprovalPolicy{},
+		0,
+		0,
+		nil,
+		nil,
+		nil,
+		0,
+		false)
+	util.OK(t, err)
+	controller.recorder = record.NewFakeRecorder(100)
+
+	quorumKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "cluster-uid"}}, metav1.CreateOptions{})
+	quorumEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName(), Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()}}}
+	quorumKubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	quorumKubeclientset.RbacV1().ClusterRoles().Create(context.TODO(), &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: g.roleRequestObj.Spec.RoleRef.Name}}, metav1.CreateOptions{})
+
+	roleRequestTest := g.roleRequestObj.DeepCopy()
+	roleRequestTest.SetName("role-request-quorum-test")
+	roleRequestTest.Spec.RequiredApprovals = 2
+	// The approved flag alone does not stand in for the quorum
+	roleRequestTest.Spec.Approved = true
+	_, err = quorumEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	// process runs the controller on the role request until it settles, and returns it
+	var process = func() *registrationv1alpha1.RoleRequest {
+		var roleRequest *registrationv1alpha1.RoleRequest
+		state, message := "", ""
+		for i := 0; i < 5; i++ {
+			roleRequest, err = quorumEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+			util.OK(t, err)
+			if i != 0 && roleRequest.Status.State == state && roleRequest.Status.Message == message {
+				break
+			}
+			state, message = roleRequest.Status.State, roleRequest.Status.Message
+			controller.processRoleRequest(roleRequest.DeepCopy())
+		}
+		return roleRequest
+	}
+
+	roleRequest := process()
+	util.Equals(t, registrationv1alpha1.StatusPending, roleRequest.Status.State)
+	util.Equals(t, fmt.Sprintf(messageApprovals, 0, 2), roleRequest.Status.Message)
+
+	util.OK(t, Approve(quorumKubeclientset, quorumEdgenetclientset, roleRequestTest.GetNamespace(), roleRequestTest.GetName(), "first.approver@edge-net.org"))
+	roleRequest = process()
+	util.Equals(t, registrationv1alpha1.StatusPending, roleRequest.Status.State)
+	util.Equals(t, fmt.Sprintf(messageApprovals, 1, 2), roleRequest.Status.Message)
+	_, err = quorumKubeclientset.RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.Spec.RoleRef.Name, metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+
+	// Neither an outsider nor the same approver, whatever the case of the email, makes up the quorum
+	util.Equals(t, true, Approve(quorumKubeclientset, quorumEdgenetclientset, roleRequestTest.GetNamespace(), roleRequestTest.GetName(), "joe.public@edge-net.org") != nil)
+	util.OK(t, Approve(quorumKubeclientset, quorumEdgenetclientset, roleRequestTest.GetNamespace(), roleRequestTest.GetName(), "First.Approver@Edge-Net.org"))
+	roleRequest = process()
+	util.Equals(t, []string{"first.approver@edge-net.org"}, roleRequest.Status.Approvals)
+	util.Equals(t, registrationv1alpha1.StatusPending, roleRequest.Status.State)
+
+	// Nor does the requester, even when their approval makes it to the status
+	util.Equals(t, true, Approve(quorumKubeclientset, quorumEdgenetclientset, roleRequestTest.GetNamespace(), roleRequestTest.GetName(), roleRequestTest.Spec.Email) != nil)
+	selfApproved := roleRequest.DeepCopy()
+	selfApproved.Status.Approvals = append(selfApproved.Status.Approvals, strings.ToUpper(roleRequestTest.Spec.Email))
+	util.Equals(t, 1, controller.countApprovals(selfApproved))
+	pending, err := PendingForApprover(quorumKubeclientset, quorumEdgenetclientset, roleRequestTest.Spec.Email)
+	util.OK(t, err)
+	util.Equals(t, 0, len(pending))
+
+	pending, err = PendingForApprover(quorumKubeclientset, quorumEdgenetclientset, "first.approver@edge-net.org")
+	util.OK(t, err)
+	util.Equals(t, 0, len(pending))
+	pending, err = PendingForApprover(quorumKubeclientset, quorumEdgenetclientset, "second.approver@edge-net.org")
+	util.OK(t, err)
+	util.Equals(t, 1, len(pending))
+
+	util.OK(t, Approve(quorumKubeclientset, quorumEdgenetclientset, roleRequestTest.GetNamespace(), roleRequestTest.GetName(), "second.approver@edge-net.org"))
+	roleRequest = process()
+	util.Equals(t, registrationv1alpha1.StatusBound, roleRequest.Status.State)
+	util.Equals(t, []string{"first.approver@edge-net.org", "second.approver@edge-net.org"}, roleRequest.Status.Approvals)
+	roleBinding, err := quorumKubeclientset.RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.Spec.RoleRef.Name, metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, roleRequestTest.Spec.Email, roleBinding.Subjects[0].Name)
+}
+
+func TestHistory(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, lets the test walk the request through its states
+	historyKubeclientset := testclient.NewSimpleClientset()
+	historyEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	controller, err := NewController(historyKubeclientset,
+		historyEdgenetclientset,
+		informers.NewSharedInformerFactory(historyEdgenetclientset, 0).Registration().V1alpha1().RoleRequests(),
+		multitenancy.DefaultTenantLabelKeys,
+		NeverAutoApprove{},
+		0,
+		0,
+		nil,
+		nil,
+		nil,
+		0,
+		false)
+	util.OK(t, err)
+	controller.recorder = record.NewFakeRecorder(100)
+
+	historyKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "cluster-uid"}}, metav1.CreateOptions{})
+	historyEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName(), Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()}}}
+	historyKubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	historyKubeclientset.RbacV1().ClusterRoles().Create(context.TODO(), &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: g.roleRequestObj.Spec.RoleRef.Name}}, metav1.CreateOptions{})
+
+	roleRequestTest := g.roleRequestObj.DeepCopy()
+	roleRequestTest.SetName("role-request-history-test")
+	_, err = historyEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	// process runs the controller once on the role request, and returns it
+	var process = func() *registrationv1alpha1.RoleRequest {
+		roleRequest, err := historyEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		controller.processRoleRequest(roleRequest.DeepCopy())
+		roleRequest, err = historyEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		return roleRequest
+	}
+
+	roleRequest := process()
+	util.Equals(t, registrationv1alpha1.StatusPending, roleRequest.Status.State)
+	// Staying in the same state does not add to the history
+	roleRequest = process()
+	util.Equals(t, 1, len(roleRequest.Status.History))
+
+	roleRequest.Spec.Approved = true
+	_, err = historyEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Update(context.TODO(), roleRequest, metav1.UpdateOptions{})
+	util.OK(t, err)
+	process()
+	roleRequest = process()
+	util.Equals(t, registrationv1alpha1.StatusBound, roleRequest.Status.State)
+
+	expected := []registrationv1alpha1.Transition{
+		{State: registrationv1alpha1.StatusPending, Message: messagePending},
+		{State: registrationv1alpha1.StatusApproved, Message: messageRoleApproved},
+		{State: registrationv1alpha1.StatusBound, Message: messageRoleBound},
+	}
+	util.Equals(t, len(expected), len(roleRequest.Status.History))
+	for i, transition := range roleRequest.Status.History {
+		util.Equals(t, expected[i].State, transition.State)
+		util.Equals(t, expected[i].Message, transition.Message)
+		util.Equals(t, false, transition.Timestamp.IsZero())
+		if i > 0 {
+			util.Equals(t, false, transition.Timestamp.Before(&roleRequest.Status.History[i-1].Timestamp))
+		}
+	}
+
+	t.Run("capped", func(t *testing.T) {
+		roleRequest := roleRequestTest.DeepCopy()
+		for i := 0; i < 2*maxHistory; i++ {
+			roleRequest.Status.State = fmt.Sprintf("State %d", i)
+			recordTransition(roleRequest)
+		}
+		util.Equals(t, maxHistory, len(roleRequest.Status.History))
+		util.Equals(t, fmt.Sprintf("State %d", maxHistory), roleRequest.Status.History[0].State)
+		util.Equals(t, fmt.Sprintf("State %d", 2*maxHistory-1), roleRequest.Status.History[maxHistory-1].State)
+	})
+}
+
+func TestRoleInOtherNamespace(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, checks the requested Role on a single pass
+	roleKubeclientset := testclient.NewSimpleClientset()
+	roleEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	controller, err := NewController(roleKubeclientset,
+		roleEdgenetclientset,
+		informers.NewSharedInformerFactory(roleEdgenetclientset, 0).Registration().V1alpha1().RoleRequests(),
+		multitenancy.DefaultTenantLabelKeys,
+		NeverAutoApprove{},
+		0,
+		0,
+		nil,
+		nil,
+		nil,
+		0,
+		false)
+	util.OK(t, err)
+	recorder := record.NewFakeRecorder(100)
+	controller.recorder = recorder
+
+	roleKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "cluster-uid"}}, metav1.CreateOptions{})
+	roleEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName(), Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()}}}
+	roleKubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	// A namespace of the same tenant, and one of another tenant
+	tenantSubNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: map[string]string{"edge-net.io/kind": "sub", "edge-net.io/tenant": g.tenantObj.GetName()}}}
+	roleKubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantSubNamespace, metav1.CreateOptions{})
+	roleKubeclientset.RbacV1().Roles("other").Create(context.TODO(), &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "other-role", Namespace: "other"}}, metav1.CreateOptions{})
+	foreignNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "foreign", Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": "foreign"}}}
+	roleKubeclientset.CoreV1().Namespaces().Create(context.TODO(), foreignNamespace, metav1.CreateOptions{})
+	roleKubeclientset.RbacV1().Roles("foreign").Create(context.TODO(), &rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "foreign-role", Namespace: "foreign"}}, metav1.CreateOptions{})
+
+	cases := map[string]struct {
+		roleName string
+		expected string
+	}{
+		"other namespace": {"other-role", fmt.Sprintf(messageRoleInOtherNS, "other-role", g.tenantObj.GetName(), "other")},
+		"other tenant":    {"foreign-role", fmt.Sprintf(messageRoleNotInNS, "foreign-role", g.tenantObj.GetName())},
+		"nowhere":         {"missing-role", fmt.Sprintf(messageRoleNotInNS, "missing-role", g.tenantObj.GetName())},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			roleRequestTest := g.roleRequestObj.DeepCopy()
+			roleRequestTest.SetName(tc.roleName)
+			roleRequestTest.Spec.RoleRef = registrationv1alpha1.RoleRefSpec{Kind: "Role", Name: tc.roleName}
+			_, err := roleEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+			util.OK(t, err)
+
+			controller.processRoleRequest(roleRequestTest.DeepCopy())
+			roleRequest, err := roleEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+			util.OK(t, err)
+			util.Equals(t, registrationv1alpha1.StatusFailed, roleRequest.Status.State)
+			util.Equals(t, tc.expected, roleRequest.Status.Message)
+			util.Equals(t, fmt.Sprintf("%s %s %s", corev1.EventTypeWarning, failureFound, tc.expected), <-recorder.Events)
+		})
+	}
+
+	t.Run("lookup failure", func(t *testing.T) {
+		roleRequestTest := g.roleRequestObj.DeepCopy()
+		roleRequestTest.SetName("unreachable-role")
+		roleRequestTest.Spec.RoleRef = registrationv1alpha1.RoleRefSpec{Kind: "Role", Name: "unreachable-role"}
+		_, err := roleEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+		util.OK(t, err)
+		roleKubeclientset.PrependReactor("get", "roles", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("roles unavailable")
+		})
+
+		// The request is retried rather than failed
+		err = controller.processRoleRequest(roleRequestTest.DeepCopy())
+		util.Equals(t, true, err != nil)
+		roleRequest, err := roleEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, "", roleRequest.Status.State)
+		util.Equals(t, 0, len(recorder.Events))
+	})
+}
+
+func TestPropagation(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+	// A dedicated controller, which is not started, on a hub cluster of its own with two member clusters
+	hubKubeclientset := testclient.NewSimpleClientset()
+	hubEdgenetclientset := edgenettestclient.NewSimpleClientset()
+	memberClientsets := map[string]kubernetes.Interface{"member-a": testclient.NewSimpleClientset(), "member-b": testclient.NewSimpleClientset()}
+	controller, err := NewController(hubKubeclientset,
+		hubEdgenetclientset,
+		informers.NewSharedInformerFactory(hubEdgenetclientset, 0).Registration().V1alpha1().RoleRequests(),
+		multitenancy.DefaultTenantLabelKeys,
+		cohortApprovalPolicy{},
+		0,
+		0,
+		nil,
+		nil,
+		memberClientsets,
+		0,
+		false)
+	util.OK(t, err)
+	controller.recorder = record.NewFakeRecorder(100)
+
+	hubKubeclientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "cluster-uid"}}, metav1.CreateOptions{})
+	hubEdgenetclientset.CoreV1alpha1().Tenants().Create(context.TODO(), g.tenantObj.DeepCopy(), metav1.CreateOptions{})
+	tenantCoreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: g.tenantObj.GetName(), Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": g.tenantObj.GetName()}}}
+	hubKubeclientset.CoreV1().Namespaces().Create(context.TODO(), tenantCoreNamespace, metav1.CreateOptions{})
+	hubKubeclientset.RbacV1().ClusterRoles().Create(context.TODO(), &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: g.roleRequestObj.Spec.RoleRef.Name}}, metav1.CreateOptions{})
+	// Someone already holds the requested role in one of the member clusters
+	existingBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: g.roleRequestObj.Spec.RoleRef.Name, Namespace: g.tenantObj.GetName()},
+		Subjects: []rbacv1.Subject{{Kind: "User", Name: "jane.doe@edge-net.org", APIGroup: "rbac.authorization.k8s.io"}},
+		RoleRef:  rbacv1.RoleRef{Kind: "ClusterRole", Name: g.roleRequestObj.Spec.RoleRef.Name}}
+	_, err = memberClientsets["member-b"].RbacV1().RoleBindings(g.tenantObj.GetName()).Create(context.TODO(), existingBinding, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	roleRequestTest := g.roleRequestObj.DeepCopy()
+	roleRequestTest.SetName("role-request-propagation-test")
+	roleRequestTest.Spec.Approved = true
+	roleRequest, err := hubEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Create(context.TODO(), roleRequestTest, metav1.CreateOptions{})
+	util.OK(t, err)
+	for i := 0; i < 5 && roleRequest.Status.State != registrationv1alpha1.StatusBound; i++ {
+		controller.processRoleRequest(roleRequest.DeepCopy())
+		roleRequest, err = hubEdgenetclientset.RegistrationV1alpha1().RoleRequests(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.GetName(), metav1.GetOptions{})
+		util.OK(t, err)
+	}
+	util.Equals(t, registrationv1alpha1.StatusBound, roleRequest.Status.State)
+	util.Equals(t, map[string]string{"member-a": registrationv1alpha1.StatusBound, "member-b": registrationv1alpha1.StatusBound}, roleRequest.Status.Propagation)
+
+	for cluster, expected := range map[string][]string{"member-a": {roleRequestTest.Spec.Email}, "member-b": {"jane.doe@edge-net.org", roleRequestTest.Spec.Email}} {
+		t.Run(cluster, func(t *testing.T) {
+			roleBinding, err := memberClientsets[cluster].RbacV1().RoleBindings(roleRequestTest.GetNamespace()).Get(context.TODO(), roleRequestTest.Spec.RoleRef.Name, metav1.GetOptions{})
+			util.OK(t, err)
+			subjects := []string{}
+			for _, subject := range roleBinding.Subjects {
+				subjects = append(subjects, subject.Name)
+			}
+			util.Equals(t, expected, subjects)
+			util.Equals(t, roleRequestTest.Spec.RoleRef.Name, roleBinding.RoleRef.Name)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2022 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rolerequest
+
+import (
+	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+)
+
+// autoApprovalReasonAnnotation records on a role request why the approval policy approved it
+const autoApprovalReasonAnnotation = "edge-net.io/auto-approval-reason"
+
+// ApprovalPolicy decides whether a pending role request is approved without waiting for an approver.
+// Deployments plug in their own logic, such as approving requests from a trusted email domain.
+type ApprovalPolicy interface {
+	// ShouldAutoApprove returns whether the role request is approved, and the reason for the decision
+	ShouldAutoApprove(roleRequest *registrationv1alpha1.RoleRequest) (bool, string)
+}
+
+// NeverAutoApprove is the default approval policy, which leaves every role request to an approver
+type NeverAutoApprove struct{}
+
+// ShouldAutoApprove never approves the role request
+func (NeverAutoApprove) ShouldAutoApprove(roleRequest *registrationv1alpha1.RoleRequest) (bool, string) {
+	return false, ""
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rolerequest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+)
+
+// propagateBinding binds the subject of the role request to the requested role in each member cluster where it is not
+// bound yet, and records the outcome per cluster in the status. It reports whether the status changed, and whether the
+// binding is in place in all member clusters.
+func (c *Controller) propagateBinding(roleRequestCopy *registrationv1alpha1.RoleRequest) (bool, bool) {
+	changed, propagated := false, true
+	for _, cluster := range c.memberClusters() {
+		if roleRequestCopy.Status.Propagation[cluster] == registrationv1alpha1.StatusBound {
+			continue
+		}
+		state := registrationv1alpha1.StatusBound
+		if err := bindSubject(c.memberClientsets[cluster], roleRequestCopy); err != nil {
+			klog.Infoln(err)
+			c.recorder.Event(roleRequestCopy, corev1.EventTypeWarning, failurePropagation, fmt.Sprintf(messagePropagationFailed, cluster))
+			state = err.Error()
+			propagated = false
+		}
+		if roleRequestCopy.Status.Propagation[cluster] != state {
+			if roleRequestCopy.Status.Propagation == nil {
+				roleRequestCopy.Status.Propagation = make(map[string]string)
+			}
+			roleRequestCopy.Status.Propagation[cluster] = state
+			changed = true
+		}
+	}
+	return changed, propagated
+}
+
+// memberClusters returns the names of the member clusters, sorted
+func (c *Controller) memberClusters() []string {
+	clusters := make([]string, 0, len(c.memberClientsets))
+	for cluster := range c.memberClientsets {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+// bindSubject binds the subject of the role request to the requested role through the given clientset, creating the
+// binding if it does not exist yet.
+func bindSubject(kubeclientset kubernetes.Interface, roleRequestCopy *registrationv1alpha1.RoleRequest) error {
+	subject := rbacv1.Subject{Kind: "User", Name: roleRequestCopy.GetSubjectName(), APIGroup: "rbac.authorization.k8s.io"}
+	requestedBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: roleRequestCopy.Spec.RoleRef.Name, Namespace: roleRequestCopy.GetNamespace()},
+		Subjects: []rbacv1.Subject{subject}, RoleRef: rbacv1.RoleRef{Kind: roleRequestCopy.Spec.RoleRef.Kind, Name: roleRequestCopy.Spec.RoleRef.Name}}
+	requestedBinding.SetLabels(map[string]string{"edge-net.io/generated": "true"})
+	if _, err := kubeclientset.RbacV1().RoleBindings(requestedBinding.GetNamespace()).Create(context.TODO(), requestedBinding, metav1.CreateOptions{}); err == nil || !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		roleBinding, err := kubeclientset.RbacV1().RoleBindings(requestedBinding.GetNamespace()).Get(context.TODO(), requestedBinding.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		for _, subjectRow := range roleBinding.Subjects {
+			if subjectRow.Kind == "User" && roleRequestCopy.IsSubject(subjectRow.Name) {
+				return nil
+			}
+		}
+		roleBindingCopy := roleBinding.DeepCopy()
+		roleBindingCopy.Subjects = append(roleBindingCopy.Subjects, subject)
+		_, err = kubeclientset.RbacV1().RoleBindings(roleBindingCopy.GetNamespace()).Update(context.TODO(), roleBindingCopy, metav1.UpdateOptions{})
+		return err
+	})
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rolerequest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+	multitenancy "github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Verbs of the planned actions
+const (
+	VerbCreate = "create"
+	VerbUpdate = "update"
+	VerbDelete = "delete"
+)
+
+// PlannedAction is a change to the cluster that the controller would make
+type PlannedAction struct {
+	// Verb is create, update, or delete
+	Verb string
+	Kind string
+	// Cluster is the member cluster the action is taken in, empty for this cluster
+	Cluster   string
+	Namespace string
+	Name      string
+	// Subjects are the subjects of a role binding once the action is taken
+	Subjects []rbacv1.Subject
+}
+
+// PlannedNotification is a notification that the notifier would send on a status change
+type PlannedNotification struct {
+	// Purpose is the kind of notification, such as role-request-approved
+	Purpose string
+	// Recipients are the email addresses notified, empty when the notification goes to the approvers of the namespace
+	Recipients []string
+}
+
+// SimulationResult lists what the controller would do to converge a role request
+type SimulationResult struct {
+	// State and Message are the status the role request would end up with
+	State   string
+	Message string
+	// AutoApproved tells whether the approval policy would approve the request, and AutoApprovalReason why
+	AutoApproved       bool
+	AutoApprovalReason string
+	Actions            []PlannedAction
+	Notifications      []PlannedNotification
+	// History is the history of the role request once the transitions above are recorded
+	History []registrationv1alpha1.Transition
+}
+
+// Simulate runs the decision logic of the controller on the role request in read-only mode, and returns the
+// bindings it would create, the notifications that would be sent, and the state the request would end up with.
+// It walks through the states as far as the request goes without an approver stepping in.
+func (c *Controller) Simulate(roleRequest *registrationv1alpha1.RoleRequest) (SimulationResult, error) {
+	roleRequestCopy := roleRequest.DeepCopy()
+	result := SimulationResult{State: roleRequestCopy.Status.State, Message: roleRequestCopy.Status.Message, History: roleRequestCopy.Status.History}
+	// transition moves the role request to the state as the status update of the controller would
+	transition := func(state, message string) {
+		roleRequestCopy.Status.State, roleRequestCopy.Status.Message = state, message
+		recordTransition(roleRequestCopy)
+		result.State, result.Message, result.History = state, message, roleRequestCopy.Status.History
+	}
+	if roleRequestCopy.Status.Expiry != nil && time.Until(roleRequestCopy.Status.Expiry.Time) <= 0 {
+		if roleRequestCopy.Spec.TemporaryAccess != nil && roleRequestCopy.Status.State == registrationv1alpha1.StatusBound {
+			actions, err := c.planRevocation(roleRequestCopy)
+			if err != nil {
+				return result, err
+			}
+			result.Actions = append(result.Actions, actions...)
+		}
+		result.Actions = append(result.Actions, PlannedAction{Verb: VerbDelete, Kind: "RoleRequest", Namespace: roleRequestCopy.GetNamespace(), Name: roleRequestCopy.GetName()})
+		return result, nil
+	}
+
+	multitenancyManager := multitenancy.NewManager(c.readKubeclientset, c.readEdgenetclientset)
+	permitted, _, namespaceLabels := multitenancyManager.EligibilityCheck(roleRequestCopy.GetNamespace())
+	if !permitted {
+		result.Actions = append(result.Actions, PlannedAction{Verb: VerbDelete, Kind: "RoleRequest", Namespace: roleRequestCopy.GetNamespace(), Name: roleRequestCopy.GetName()})
+		return result, nil
+	}
+	if message, missing, err := c.requestedRoleMissing(roleRequestCopy, namespaceLabels["edge-net.io/tenant"]); err != nil {
+		return result, err
+	} else if missing {
+		transition(registrationv1alpha1.StatusFailed, message)
+		return result, nil
+	}
+
+	state := roleRequestCopy.Status.State
+	if state == registrationv1alpha1.StatusFailed && roleRequestCopy.Status.Message != messageOwnershipFailure {
+		// A failed request is not started over
+		return result, nil
+	}
+	if state != registrationv1alpha1.StatusBound && state != registrationv1alpha1.StatusApproved && state != registrationv1alpha1.StatusPending {
+		actions, err := c.planRequestOwnership(roleRequestCopy)
+		if err != nil {
+			return result, err
+		}
+		result.Actions = append(result.Actions, actions...)
+		result.Notifications = append(result.Notifications, PlannedNotification{Purpose: "role-request-made"})
+		state = registrationv1alpha1.StatusPending
+		transition(state, messagePending)
+	}
+	if state == registrationv1alpha1.StatusPending {
+		message := messageRoleApproved
+		approved := roleRequestCopy.Spec.Approved
+		if requiredApprovals := roleRequestCopy.GetRequiredApprovals(); requiredApprovals > 1 {
+			approvals := c.countApprovals(roleRequestCopy)
+			approved = approvals >= requiredApprovals
+			message = fmt.Sprintf(messageApprovals, approvals, requiredApprovals)
+		} else if !approved {
+			if autoApproved, reason := c.approvalPolicy.ShouldAutoApprove(roleRequestCopy.DeepCopy()); autoApproved {
+				result.AutoApproved, result.AutoApprovalReason = true, reason
+				result.Actions = append(result.Actions, PlannedAction{Verb: VerbUpdate, Kind: "RoleRequest", Namespace: roleRequestCopy.GetNamespace(), Name: roleRequestCopy.GetName()})
+				approved = true
+				message = fmt.Sprintf(messageRoleAutoApproved, reason)
+			}
+		}
+		if !approved {
+			if roleRequestCopy.GetRequiredApprovals() > 1 {
+				transition(state, message)
+			}
+			return result, nil
+		}
+		state = registrationv1alpha1.StatusApproved
+		transition(state, message)
+	}
+	if state == registrationv1alpha1.StatusApproved {
+		if message, withdrawn, err := c.selectiveDeploymentWithdrawn(roleRequestCopy, namespaceLabels); err != nil {
+			return result, err
+		} else if withdrawn {
+			transition(registrationv1alpha1.StatusFailed, message)
+			return result, nil
+		}
+		action, err := planRoleBinding(c.readKubeclientset, roleRequestCopy)
+		if err != nil {
+			return result, err
+		}
+		if action != nil {
+			result.Actions = append(result.Actions, *action)
+		}
+		result.Notifications = append(result.Notifications, PlannedNotification{Purpose: "role-request-approved", Recipients: []string{roleRequestCopy.Spec.Email}})
+		state = registrationv1alpha1.StatusBound
+		transition(state, messageRoleBound)
+	}
+	if state == registrationv1alpha1.StatusBound {
+		// The binding goes to the member clusters it has not been propagated to yet
+		for _, cluster := range c.memberClusters() {
+			if roleRequestCopy.Status.Propagation[cluster] == registrationv1alpha1.StatusBound {
+				continue
+			}
+			action, err := planRoleBinding(c.memberClientsets[cluster], roleRequestCopy)
+			if err != nil {
+				return result, fmt.Errorf("member cluster %s: %w", cluster, err)
+			}
+			if action != nil {
+				action.Cluster = cluster
+				result.Actions = append(result.Actions, *action)
+			}
+		}
+	}
+	return result, nil
+}
+
+// planRequestOwnership returns the role and the role binding that would let the requester manage the request
+func (c *Controller) planRequestOwnership(roleRequestCopy *registrationv1alpha1.RoleRequest) ([]PlannedAction, error) {
+	objectName := fmt.Sprintf("edgenet:%s:%s", "rolerequest", roleRequestCopy.GetName())
+	actions := []PlannedAction{}
+	if _, err := c.readKubeclientset.RbacV1().Roles(roleRequestCopy.GetNamespace()).Get(context.TODO(), objectName, metav1.GetOptions{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		actions = append(actions, PlannedAction{Verb: VerbCreate, Kind: "Role", Namespace: roleRequestCopy.GetNamespace(), Name: objectName})
+	}
+	if _, err := c.readKubeclientset.RbacV1().RoleBindings(roleRequestCopy.GetNamespace()).Get(context.TODO(), objectName, metav1.GetOptions{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		subjects := []rbacv1.Subject{{Kind: "User", Name: roleRequestCopy.GetSubjectName(), APIGroup: "rbac.authorization.k8s.io"}}
+		actions = append(actions, PlannedAction{Verb: VerbCreate, Kind: "RoleBinding", Namespace: roleRequestCopy.GetNamespace(), Name: objectName, Subjects: subjects})
+	}
+	return actions, nil
+}
+
+// planRoleBinding returns how the role binding of the requested role would be changed on approval through the given
+// clientset, or nil if the subject already holds the role
+func planRoleBinding(kubeclientset kubernetes.Interface, roleRequestCopy *registrationv1alpha1.RoleRequest) (*PlannedAction, error) {
+	subject := rbacv1.Subject{Kind: "User", Name: roleRequestCopy.GetSubjectName(), APIGroup: "rbac.authorization.k8s.io"}
+	roleBinding, err := kubeclientset.RbacV1().RoleBindings(roleRequestCopy.GetNamespace()).Get(context.TODO(), roleRequestCopy.Spec.RoleRef.Name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		return &PlannedAction{Verb: VerbCreate, Kind: "RoleBinding", Namespace: roleRequestCopy.GetNamespace(), Name: roleRequestCopy.Spec.RoleRef.Name, Subjects: []rbacv1.Subject{subject}}, nil
+	}
+	for _, subjectRow := range roleBinding.Subjects {
+		if subjectRow.Kind == "User" && roleRequestCopy.IsSubject(subjectRow.Name) {
+			return nil, nil
+		}
+	}
+	subjects := append(append([]rbacv1.Subject{}, roleBinding.Subjects...), subject)
+	return &PlannedAction{Verb: VerbUpdate, Kind: "RoleBinding", Namespace: roleBinding.GetNamespace(), Name: roleBinding.GetName(), Subjects: subjects}, nil
+}
+
+// planRevocation returns how the role bindings of a temporary grant would be changed once the grant expires, in this
+// cluster and in the member clusters
+func (c *Controller) planRevocation(roleRequestCopy *registrationv1alpha1.RoleRequest) ([]PlannedAction, error) {
+	actions := []PlannedAction{}
+	if action, err := planUnbinding(c.readKubeclientset, roleRequestCopy); err != nil {
+		return nil, err
+	} else if action != nil {
+		actions = append(actions, *action)
+	}
+	for _, cluster := range c.memberClusters() {
+		action, err := planUnbinding(c.memberClientsets[cluster], roleRequestCopy)
+		if err != nil {
+			return nil, fmt.Errorf("member cluster %s: %w", cluster, err)
+		}
+		if action != nil {
+			action.Cluster = cluster
+			actions = append(actions, *action)
+		}
+	}
+	return actions, nil
+}
+
+// planUnbinding returns how the role binding of the requested role would be changed through the given clientset to
+// unbind the subject, or nil if the subject no longer holds the role
+func planUnbinding(kubeclientset kubernetes.Interface, roleRequestCopy *registrationv1alpha1.RoleRequest) (*PlannedAction, error) {
+	roleBinding, err := kubeclientset.RbacV1().RoleBindings(roleRequestCopy.GetNamespace()).Get(context.TODO(), roleRequestCopy.Spec.RoleRef.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	subjects := []rbacv1.Subject{}
+	for _, subjectRow := range roleBinding.Subjects {
+		if subjectRow.Kind == "User" && roleRequestCopy.IsSubject(subjectRow.Name) {
+			continue
+		}
+		subjects = append(subjects, subjectRow)
+	}
+	if len(subjects) == len(roleBinding.Subjects) {
+		return nil, nil
+	}
+	if len(subjects) == 0 && roleBinding.GetLabels()["edge-net.io/generated"] == "true" {
+		return &PlannedAction{Verb: VerbDelete, Kind: "RoleBinding", Namespace: roleBinding.GetNamespace(), Name: roleBinding.GetName()}, nil
+	}
+	return &PlannedAction{Verb: VerbUpdate, Kind: "RoleBinding", Namespace: roleBinding.GetNamespace(), Name: roleBinding.GetName(), Subjects: subjects}, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package deadline bounds the time the controllers spend on a single reconcile. The API calls made with a bound
+// config time out at the deadline, so that a sync blocked on a hung call returns and is requeued rather than pinning
+// a worker. It is off unless a deadline is set.
+package deadline
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// ErrExceeded is wrapped by the error returned for a sync that does not complete before the deadline
+var ErrExceeded = errors.New("reconcile deadline exceeded")
+
+var timeout int64
+
+// Flag defines the reconcile-deadline flag, which defaults to the RECONCILE_DEADLINE environment variable
+func Flag() *time.Duration {
+	var defaultDeadline time.Duration
+	if d, err := time.ParseDuration(os.Getenv("RECONCILE_DEADLINE")); err == nil {
+		defaultDeadline = d
+	}
+	return flag.Duration("reconcile-deadline", defaultDeadline, "Time a reconcile and each of its API calls are given to complete, after which the call fails and the reconcile is requeued, zero disables it")
+}
+
+// Timeout returns the deadline of a reconcile, zero meaning there is none
+func Timeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&timeout))
+}
+
+// Set sets the deadline of a reconcile, zero or less disables it
+func Set(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	atomic.StoreInt64(&timeout, int64(d))
+}
+
+// Bound makes the requests sent with the config time out at the deadline of a reconcile, as set when they are sent.
+// Watches, which are meant to last, are left unbound.
+func Bound(config *rest.Config) {
+	config.Wrap(func(delegate http.RoundTripper) http.RoundTripper {
+		return &boundRoundTripper{delegate: delegate}
+	})
+}
+
+type boundRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func (rt *boundRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	d := Timeout()
+	if d == 0 || req.URL.Query().Get("watch") == "true" {
+		return rt.delegate.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), d)
+	resp, err := rt.delegate.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The body is read after the round trip returns, so the context is released once it is closed
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// Sync calls the sync handler with the key, and returns an error wrapping ErrExceeded if the handler takes longer
+// than the deadline, so that the key is requeued with a backoff. The handler always runs to completion before Sync
+// returns, which leaves the key to a single worker at a time; it is the bound API calls that keep it from hanging.
+func Sync(key string, syncHandler func(key string) error) error {
+	d := Timeout()
+	start := time.Now()
+	err := syncHandler(key)
+	if d == 0 || time.Since(start) <= d {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w after %s: %s", ErrExceeded, d, err)
+	}
+	return fmt.Errorf("%w after %s", ErrExceeded, d)
+}
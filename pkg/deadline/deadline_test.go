@@ -0,0 +1,74 @@
+package deadline
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestSync(t *testing.T) {
+	defer Set(0)
+	finished := false
+	slow := func(key string) error {
+		time.Sleep(100 * time.Millisecond)
+		finished = true
+		return nil
+	}
+	failed := func(key string) error {
+		return errors.New(key)
+	}
+
+	Set(-time.Second)
+	util.Equals(t, time.Duration(0), Timeout())
+	util.Equals(t, "edgenet", Sync("edgenet", failed).Error())
+	util.OK(t, Sync("edgenet", slow))
+
+	Set(50 * time.Millisecond)
+	util.Equals(t, "edgenet", Sync("edgenet", failed).Error())
+	finished = false
+	err := Sync("edgenet", slow)
+	util.Equals(t, true, errors.Is(err, ErrExceeded))
+	// The handler is not abandoned
+	util.Equals(t, true, finished)
+}
+
+func TestBound(t *testing.T) {
+	defer Set(0)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("watch") == "true" {
+			// A watch outlasts the deadline
+			time.Sleep(200 * time.Millisecond)
+		} else {
+			<-release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"kind":"NamespaceList","apiVersion":"v1","items":[]}`))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := &rest.Config{Host: server.URL}
+	Bound(config)
+	kubeclientset, err := kubernetes.NewForConfig(config)
+	util.OK(t, err)
+
+	Set(100 * time.Millisecond)
+	start := time.Now()
+	_, err = kubeclientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	util.Equals(t, true, err != nil)
+	util.Equals(t, true, time.Since(start) < time.Second)
+
+	watcher, err := kubeclientset.CoreV1().Namespaces().Watch(context.TODO(), metav1.ListOptions{})
+	util.OK(t, err)
+	watcher.Stop()
+}
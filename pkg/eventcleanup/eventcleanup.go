@@ -0,0 +1,91 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eventcleanup prunes the events a controller recorded once they are older than a TTL, so that the events
+// of the short-lived objects it manages do not pile up. It is off unless a TTL is set.
+package eventcleanup
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+// Interval is the interval at which the events are pruned
+var Interval = 10 * time.Minute
+
+// Start prunes the events the component recorded in the namespace that are older than the TTL, at each interval
+// until stopCh is closed. An empty namespace prunes the events in all namespaces, and a zero TTL disables it.
+func Start(kubeclientset kubernetes.Interface, namespace, component string, ttl time.Duration, stopCh <-chan struct{}) {
+	if ttl <= 0 {
+		return
+	}
+	go wait.Until(func() {
+		pruned, err := Prune(kubeclientset, namespace, component, ttl, time.Now())
+		if err != nil {
+			klog.Infoln(err)
+		}
+		if pruned > 0 {
+			klog.Infof("Pruned %d events of %s older than %s", pruned, component, ttl)
+		}
+	}, Interval, stopCh)
+}
+
+// Prune deletes the events the component recorded in the namespace whose last occurrence is older than the TTL at
+// the given time, and returns how many are deleted. A failing deletion does not stop the others, and the first error
+// is returned at the end. The events are filtered by component on the API server, so that the other events in the
+// namespace are not listed.
+func Prune(kubeclientset kubernetes.Interface, namespace, component string, ttl time.Duration, now time.Time) (int, error) {
+	listOptions := metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("source", component).String()}
+	eventRaw, err := kubeclientset.CoreV1().Events(namespace).List(context.TODO(), listOptions)
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	var firstErr error
+	for _, eventRow := range eventRaw.Items {
+		if eventRow.Source.Component != component || now.Sub(lastOccurrence(eventRow)) <= ttl {
+			continue
+		}
+		if err := kubeclientset.CoreV1().Events(eventRow.GetNamespace()).Delete(context.TODO(), eventRow.GetName(), metav1.DeleteOptions{}); err != nil {
+			if !errors.IsNotFound(err) && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		pruned++
+	}
+	return pruned, firstErr
+}
+
+// lastOccurrence returns the time the event last occurred, falling back on its creation time
+func lastOccurrence(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.GetCreationTimestamp().Time
+	}
+}
@@ -0,0 +1,91 @@
+package eventcleanup
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+	newEvent := func(name, namespace, component string, age time.Duration) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:    metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Source:        corev1.EventSource{Component: component},
+			LastTimestamp: metav1.NewTime(now.Add(-age)),
+		}
+	}
+	microEvent := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: "old-micro", Namespace: "edgenet"},
+		Source:     corev1.EventSource{Component: "tenant-controller"},
+		EventTime:  metav1.NewMicroTime(now.Add(-48 * time.Hour)),
+	}
+	kubeclientset := testclient.NewSimpleClientset(
+		newEvent("old", "edgenet", "tenant-controller", 48*time.Hour),
+		newEvent("old-elsewhere", "lab", "tenant-controller", 25*time.Hour),
+		newEvent("recent", "edgenet", "tenant-controller", time.Hour),
+		newEvent("old-foreign", "edgenet", "kubelet", 48*time.Hour),
+		microEvent,
+	)
+
+	cases := map[string]struct {
+		namespace string
+		pruned    int
+		remaining []string
+	}{
+		"namespace":      {"edgenet", 2, []string{"old-elsewhere", "old-foreign", "recent"}},
+		"all namespaces": {"", 1, []string{"old-foreign", "recent"}},
+	}
+	for _, k := range []string{"namespace", "all namespaces"} {
+		tc := cases[k]
+		t.Run(k, func(t *testing.T) {
+			kubeclientset.ClearActions()
+			pruned, err := Prune(kubeclientset, tc.namespace, "tenant-controller", 24*time.Hour, now)
+			util.OK(t, err)
+			util.Equals(t, tc.pruned, pruned)
+			// The API server only lists the events of the component
+			for _, action := range kubeclientset.Actions() {
+				if listAction, ok := action.(k8stesting.ListAction); ok {
+					util.Equals(t, "source=tenant-controller", listAction.GetListRestrictions().Fields.String())
+				}
+			}
+			eventRaw, err := kubeclientset.CoreV1().Events(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+			util.OK(t, err)
+			var remaining []string
+			for _, eventRow := range eventRaw.Items {
+				remaining = append(remaining, eventRow.GetName())
+			}
+			sort.Strings(remaining)
+			util.Equals(t, tc.remaining, remaining)
+		})
+	}
+}
+
+func TestStartDisabled(t *testing.T) {
+	event := &corev1.Event{
+		ObjectMeta:    metav1.ObjectMeta{Name: "old", Namespace: "edgenet"},
+		Source:        corev1.EventSource{Component: "tenant-controller"},
+		LastTimestamp: metav1.NewTime(time.Now().Add(-48 * time.Hour)),
+	}
+	kubeclientset := testclient.NewSimpleClientset(event)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	Start(kubeclientset, "", "tenant-controller", 0, stopCh)
+	time.Sleep(50 * time.Millisecond)
+	_, err := kubeclientset.CoreV1().Events("edgenet").Get(context.TODO(), "old", metav1.GetOptions{})
+	util.OK(t, err)
+
+	Start(kubeclientset, "", "tenant-controller", time.Hour, stopCh)
+	time.Sleep(50 * time.Millisecond)
+	_, err = kubeclientset.CoreV1().Events("edgenet").Get(context.TODO(), "old", metav1.GetOptions{})
+	util.Equals(t, true, err != nil)
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package eventsink streams the events recorded by the controllers to a sink, as JSON lines or over a channel,
+// in addition to the Kubernetes events, so that they can be ingested by a SIEM. It is off unless a sink is set.
+package eventsink
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+// Event is the structured form of a recorded event
+type Event struct {
+	Time      time.Time `json:"time"`
+	Component string    `json:"component"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+}
+
+var (
+	mutex   sync.Mutex
+	writer  io.Writer
+	channel chan<- Event
+)
+
+// Start sets the sink from its target, which is either stdout or the path of a file the events are appended to.
+// An empty target leaves the sink off.
+func Start(target string) error {
+	switch target = strings.TrimSpace(target); target {
+	case "":
+		return nil
+	case "stdout":
+		SetWriter(os.Stdout)
+		return nil
+	default:
+		file, err := os.OpenFile(target, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("event sink %s cannot be opened: %w", target, err)
+		}
+		SetWriter(file)
+		return nil
+	}
+}
+
+// SetWriter streams the events to the writer as JSON lines, nil turns it off
+func SetWriter(w io.Writer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	writer = w
+}
+
+// SetChannel streams the events to the channel, nil turns it off. The events are dropped while the channel is full,
+// so that a slow reader does not hold up the controller.
+func SetChannel(ch chan<- Event) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	channel = ch
+}
+
+// Watch streams the events of the broadcaster to the sink
+func Watch(eventBroadcaster record.EventBroadcaster) {
+	eventBroadcaster.StartEventWatcher(emit)
+}
+
+// emit writes the event to the sink, if any
+func emit(event *corev1.Event) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if writer == nil && channel == nil {
+		return
+	}
+	structuredEvent := Event{
+		Time:      event.LastTimestamp.Time,
+		Component: event.Source.Component,
+		Type:      event.Type,
+		Reason:    event.Reason,
+		Message:   event.Message,
+		Kind:      event.InvolvedObject.Kind,
+		Namespace: event.InvolvedObject.Namespace,
+		Name:      event.InvolvedObject.Name,
+	}
+	if writer != nil {
+		line, err := json.Marshal(structuredEvent)
+		if err == nil {
+			_, err = writer.Write(append(line, '\n'))
+		}
+		if err != nil {
+			klog.Infoln(err)
+		}
+	}
+	if channel != nil {
+		select {
+		case channel <- structuredEvent:
+		default:
+		}
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package expiry lists the objects expiring across the cluster, such as role requests, subnamespaces, and the claims
+// of tenant resource quotas, so that operators can plan their renewals.
+package expiry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ExpiringItem is an object, or a part of it such as a claim of a tenant resource quota, that expires
+type ExpiringItem struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	// Item names the part of the object that expires, such as claim/initial or claim/initial/cpu for a tenant
+	// resource quota, and is empty when the whole object expires
+	Item   string    `json:"item,omitempty"`
+	Expiry time.Time `json:"expiry"`
+}
+
+// ListExpiring returns the items expiring from now on within the given window, sorted by expiry. The items already
+// expired are left out.
+func ListExpiring(edgenetclientset clientset.Interface, within time.Duration) ([]ExpiringItem, error) {
+	return listExpiring(edgenetclientset, time.Now(), within)
+}
+
+// listExpiring returns the items expiring within the window starting at now
+func listExpiring(edgenetclientset clientset.Interface, now time.Time, within time.Duration) ([]ExpiringItem, error) {
+	items := []ExpiringItem{}
+	add := func(kind, namespace, name, item string, expiry *metav1.Time) {
+		if expiry == nil || expiry.Time.Before(now) || expiry.Time.After(now.Add(within)) {
+			return
+		}
+		items = append(items, ExpiringItem{Kind: kind, Namespace: namespace, Name: name, Item: item, Expiry: expiry.Time})
+	}
+
+	roleRequestRaw, err := edgenetclientset.RegistrationV1alpha1().RoleRequests(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, roleRequestRow := range roleRequestRaw.Items {
+		add("RoleRequest", roleRequestRow.GetNamespace(), roleRequestRow.GetName(), "", roleRequestRow.Status.Expiry)
+	}
+	clusterRoleRequestRaw, err := edgenetclientset.RegistrationV1alpha1().ClusterRoleRequests().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, clusterRoleRequestRow := range clusterRoleRequestRaw.Items {
+		add("ClusterRoleRequest", "", clusterRoleRequestRow.GetName(), "", clusterRoleRequestRow.Status.Expiry)
+	}
+	tenantRequestRaw, err := edgenetclientset.RegistrationV1alpha1().TenantRequests().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, tenantRequestRow := range tenantRequestRaw.Items {
+		add("TenantRequest", "", tenantRequestRow.GetName(), "", tenantRequestRow.Status.Expiry)
+	}
+	subnamespaceRaw, err := edgenetclientset.CoreV1alpha1().SubNamespaces(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, subnamespaceRow := range subnamespaceRaw.Items {
+		add("SubNamespace", subnamespaceRow.GetNamespace(), subnamespaceRow.GetName(), "", subnamespaceRow.Spec.Expiry)
+	}
+	tenantResourceQuotaRaw, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, tenantResourceQuotaRow := range tenantResourceQuotaRaw.Items {
+		for prefix, resourceTunings := range map[string]map[string]corev1alpha1.ResourceTuning{"claim": tenantResourceQuotaRow.Spec.Claim, "drop": tenantResourceQuotaRow.Spec.Drop} {
+			for key, resourceTuning := range resourceTunings {
+				item := fmt.Sprintf("%s/%s", prefix, key)
+				add("TenantResourceQuota", "", tenantResourceQuotaRow.GetName(), item, resourceTuning.Expiry)
+				for resourceName, resourceExpiry := range resourceTuning.ResourceExpiry {
+					resourceExpiry := resourceExpiry
+					add("TenantResourceQuota", "", tenantResourceQuotaRow.GetName(), fmt.Sprintf("%s/%s", item, resourceName), &resourceExpiry)
+				}
+			}
+		}
+	}
+	sliceRaw, err := edgenetclientset.CoreV1alpha1().Slices().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, sliceRow := range sliceRaw.Items {
+		add("Slice", "", sliceRow.GetName(), "", sliceRow.Status.Expiry)
+	}
+	sliceClaimRaw, err := edgenetclientset.CoreV1alpha1().SliceClaims(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, sliceClaimRow := range sliceClaimRaw.Items {
+		add("SliceClaim", sliceClaimRow.GetNamespace(), sliceClaimRow.GetName(), "", sliceClaimRow.Spec.SliceExpiry)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].Expiry.Equal(items[j].Expiry) {
+			return items[i].Expiry.Before(items[j].Expiry)
+		}
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		if items[i].Namespace != items[j].Namespace {
+			return items[i].Namespace < items[j].Namespace
+		}
+		if items[i].Name != items[j].Name {
+			return items[i].Name < items[j].Name
+		}
+		return items[i].Item < items[j].Item
+	})
+	return items, nil
+}
@@ -0,0 +1,80 @@
+package expiry
+
+import (
+	"testing"
+	"time"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	registrationv1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/registration/v1alpha1"
+	edgenettestclient "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/fake"
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestListExpiring(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) *metav1.Time {
+		expiry := metav1.NewTime(now.Add(offset))
+		return &expiry
+	}
+
+	roleRequestSoon := &registrationv1alpha1.RoleRequest{ObjectMeta: metav1.ObjectMeta{Name: "soon", Namespace: "edgenet"}}
+	roleRequestSoon.Status.Expiry = at(2 * time.Hour)
+	roleRequestLater := &registrationv1alpha1.RoleRequest{ObjectMeta: metav1.ObjectMeta{Name: "later", Namespace: "edgenet"}}
+	roleRequestLater.Status.Expiry = at(48 * time.Hour)
+	roleRequestExpired := &registrationv1alpha1.RoleRequest{ObjectMeta: metav1.ObjectMeta{Name: "expired", Namespace: "edgenet"}}
+	roleRequestExpired.Status.Expiry = at(-time.Hour)
+	roleRequestNever := &registrationv1alpha1.RoleRequest{ObjectMeta: metav1.ObjectMeta{Name: "never", Namespace: "edgenet"}}
+
+	subnamespace := &corev1alpha1.SubNamespace{ObjectMeta: metav1.ObjectMeta{Name: "workspace", Namespace: "edgenet"}}
+	subnamespace.Spec.Expiry = at(time.Hour)
+
+	tenantResourceQuota := &corev1alpha1.TenantResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: "edgenet"}}
+	tenantResourceQuota.Spec.Claim = map[string]corev1alpha1.ResourceTuning{
+		"initial": {},
+		"extra": {
+			Expiry:         at(24 * time.Hour),
+			ResourceExpiry: map[corev1.ResourceName]metav1.Time{corev1.ResourceCPU: *at(3 * time.Hour), corev1.ResourceMemory: *at(72 * time.Hour)},
+		},
+	}
+	tenantResourceQuota.Spec.Drop = map[string]corev1alpha1.ResourceTuning{"penalty": {Expiry: at(30 * time.Minute)}}
+
+	sliceClaim := &corev1alpha1.SliceClaim{ObjectMeta: metav1.ObjectMeta{Name: "slice", Namespace: "edgenet"}}
+	sliceClaim.Spec.SliceExpiry = at(25 * time.Hour)
+
+	edgenetclientset := edgenettestclient.NewSimpleClientset(roleRequestSoon, roleRequestLater, roleRequestExpired, roleRequestNever, subnamespace, tenantResourceQuota, sliceClaim)
+
+	cases := map[string]struct {
+		within   time.Duration
+		expected []ExpiringItem
+	}{
+		"none": {0, []ExpiringItem{}},
+		"an hour": {time.Hour, []ExpiringItem{
+			{Kind: "TenantResourceQuota", Name: "edgenet", Item: "drop/penalty", Expiry: now.Add(30 * time.Minute)},
+			{Kind: "SubNamespace", Namespace: "edgenet", Name: "workspace", Expiry: now.Add(time.Hour)},
+		}},
+		"a day": {24 * time.Hour, []ExpiringItem{
+			{Kind: "TenantResourceQuota", Name: "edgenet", Item: "drop/penalty", Expiry: now.Add(30 * time.Minute)},
+			{Kind: "SubNamespace", Namespace: "edgenet", Name: "workspace", Expiry: now.Add(time.Hour)},
+			{Kind: "RoleRequest", Namespace: "edgenet", Name: "soon", Expiry: now.Add(2 * time.Hour)},
+			{Kind: "TenantResourceQuota", Name: "edgenet", Item: "claim/extra/cpu", Expiry: now.Add(3 * time.Hour)},
+			{Kind: "TenantResourceQuota", Name: "edgenet", Item: "claim/extra", Expiry: now.Add(24 * time.Hour)},
+		}},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			items, err := listExpiring(edgenetclientset, now, tc.within)
+			util.OK(t, err)
+			util.Equals(t, len(tc.expected), len(items))
+			for i := range tc.expected {
+				util.Equals(t, tc.expected[i].Kind, items[i].Kind)
+				util.Equals(t, tc.expected[i].Namespace, items[i].Namespace)
+				util.Equals(t, tc.expected[i].Name, items[i].Name)
+				util.Equals(t, tc.expected[i].Item, items[i].Item)
+				util.Equals(t, true, tc.expected[i].Expiry.Equal(items[i].Expiry))
+			}
+		})
+	}
+}
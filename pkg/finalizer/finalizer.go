@@ -0,0 +1,75 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package finalizer holds the finalizers of the EdgeNet controllers, and the helpers to add and remove them.
+// Finalizers are namespaced by the resource they guard, so that they do not collide with those of other
+// operators running in the cluster.
+package finalizer
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Domain is the domain under which the finalizers of the EdgeNet controllers are namespaced
+const Domain = "edge-net.io"
+
+// TenantDeletionProtection holds back the deletion of a tenant for as long as its deletion protection is on
+const TenantDeletionProtection = "tenant." + Domain + "/deletion-protection"
+
+// Name returns the finalizer of the resource for the given purpose, e.g. tenant.edge-net.io/deletion-protection
+func Name(resource, purpose string) string {
+	return fmt.Sprintf("%s.%s/%s", resource, Domain, purpose)
+}
+
+// Has tells whether the object has the finalizer
+func Has(object metav1.Object, finalizer string) bool {
+	for _, existing := range object.GetFinalizers() {
+		if existing == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds the finalizer to the object unless it is already there, and reports whether the object changed
+func Add(object metav1.Object, finalizer string) bool {
+	if Has(object, finalizer) {
+		return false
+	}
+	object.SetFinalizers(append(object.GetFinalizers(), finalizer))
+	return true
+}
+
+// Remove removes every occurrence of the finalizer from the object, and reports whether the object changed
+func Remove(object metav1.Object, finalizer string) bool {
+	finalizers := object.GetFinalizers()
+	remaining := make([]string, 0, len(finalizers))
+	for _, existing := range finalizers {
+		if existing != finalizer {
+			remaining = append(remaining, existing)
+		}
+	}
+	if len(remaining) == len(finalizers) {
+		return false
+	}
+	if len(remaining) == 0 {
+		remaining = nil
+	}
+	object.SetFinalizers(remaining)
+	return true
+}
@@ -0,0 +1,42 @@
+package finalizer
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+)
+
+func TestName(t *testing.T) {
+	util.Equals(t, "tenant.edge-net.io/deletion-protection", TenantDeletionProtection)
+	util.Equals(t, TenantDeletionProtection, Name("tenant", "deletion-protection"))
+	util.Equals(t, "tenant.edge-net.io/protection", Name("tenant", "protection"))
+}
+
+func TestAddRemove(t *testing.T) {
+	subnamespace := &corev1alpha1.SubNamespace{}
+	subnamespace.SetFinalizers([]string{"kubernetes"})
+
+	util.Equals(t, false, Has(subnamespace, TenantDeletionProtection))
+	util.Equals(t, true, Add(subnamespace, TenantDeletionProtection))
+	util.Equals(t, true, Has(subnamespace, TenantDeletionProtection))
+	// Adding twice leaves a single finalizer
+	util.Equals(t, false, Add(subnamespace, TenantDeletionProtection))
+	util.Equals(t, []string{"kubernetes", TenantDeletionProtection}, subnamespace.GetFinalizers())
+
+	util.Equals(t, true, Remove(subnamespace, TenantDeletionProtection))
+	util.Equals(t, false, Has(subnamespace, TenantDeletionProtection))
+	// Removing twice is a no-op, and the finalizers of others are kept
+	util.Equals(t, false, Remove(subnamespace, TenantDeletionProtection))
+	util.Equals(t, []string{"kubernetes"}, subnamespace.GetFinalizers())
+
+	util.Equals(t, true, Remove(subnamespace, "kubernetes"))
+	util.Equals(t, []string(nil), subnamespace.GetFinalizers())
+
+	t.Run("duplicates", func(t *testing.T) {
+		cleanup := Name("subnamespace", "cleanup")
+		subnamespace.SetFinalizers([]string{TenantDeletionProtection, cleanup, TenantDeletionProtection})
+		util.Equals(t, true, Remove(subnamespace, TenantDeletionProtection))
+		util.Equals(t, []string{cleanup}, subnamespace.GetFinalizers())
+	})
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package maintenance lets operators pause the reconciliation of the controllers during cluster maintenance,
+// while the controllers keep running.
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/EdgeNet-project/edgenet/pkg/deadline"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+)
+
+// PausedKey is the key of the maintenance config map that pauses the reconciliation when set to true
+const PausedKey = "paused"
+
+// RequeueDelay is how long the work items are put back in the queue while the reconciliation is paused
+var RequeueDelay = 10 * time.Second
+
+// PollInterval is the interval at which the maintenance config map is read
+var PollInterval = 10 * time.Second
+
+var paused int32
+
+// Paused reports whether the reconciliation is paused
+func Paused() bool {
+	return atomic.LoadInt32(&paused) == 1
+}
+
+// SetPaused pauses or resumes the reconciliation
+func SetPaused(pause bool) {
+	value := int32(0)
+	if pause {
+		value = 1
+	}
+	if previous := atomic.SwapInt32(&paused, value); previous != value {
+		if pause {
+			klog.Infoln("Reconciliation paused for maintenance, objects are not mutated until it is resumed")
+		} else {
+			klog.Infoln("Reconciliation resumed")
+		}
+	}
+}
+
+// WhenResumed runs the function right away, or once the reconciliation is resumed if it is paused. It is meant for
+// the work done outside of the work queues, such as the cleanup after a deleted object that cannot be requeued.
+func WhenResumed(f func()) {
+	if !Paused() {
+		f()
+		return
+	}
+	go func() {
+		for Paused() {
+			time.Sleep(RequeueDelay)
+		}
+		f()
+	}()
+}
+
+// Process syncs the key with the sync handler within the reconcile deadline, and forgets it once it is synced.
+// Nothing is reconciled during maintenance, the key is put back in the queue to be processed once resumed. A key
+// that fails to sync is requeued with a backoff, and the error is returned for the controller to report.
+func Process(queue workqueue.RateLimitingInterface, key string, syncHandler func(key string) error) (bool, error) {
+	if Paused() {
+		queue.AddAfter(key, RequeueDelay)
+		return false, nil
+	}
+	if err := deadline.Sync(key, syncHandler); err != nil {
+		queue.AddRateLimited(key)
+		return false, err
+	}
+	queue.Forget(key)
+	return true, nil
+}
+
+// Start sets the initial state of the reconciliation, and follows the maintenance config map if one is given as
+// namespace/name. The state is left as it is while the config map does not exist or cannot be read.
+func Start(kubeclientset kubernetes.Interface, pause bool, configMap string, stopCh <-chan struct{}) error {
+	SetPaused(pause)
+	if configMap == "" {
+		return nil
+	}
+	namespace, name, err := splitConfigMap(configMap)
+	if err != nil {
+		return err
+	}
+	go wait.Until(func() { sync(kubeclientset, namespace, name) }, PollInterval, stopCh)
+	return nil
+}
+
+// sync pauses or resumes the reconciliation according to the maintenance config map
+func sync(kubeclientset kubernetes.Interface, namespace, name string) {
+	configMap, err := kubeclientset.CoreV1().ConfigMaps(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			klog.Infoln(err)
+		}
+		return
+	}
+	value, elementExists := configMap.Data[PausedKey]
+	if !elementExists {
+		SetPaused(false)
+		return
+	}
+	pause, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		klog.Infof("Invalid %s value in the maintenance config map %s/%s: %s", PausedKey, namespace, name, value)
+		return
+	}
+	SetPaused(pause)
+}
+
+func splitConfigMap(configMap string) (string, string, error) {
+	parts := strings.Split(configMap, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("maintenance config map must be given as namespace/name, got %q", configMap)
+	}
+	return parts[0], parts[1], nil
+}
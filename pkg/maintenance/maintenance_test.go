@@ -0,0 +1,126 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/workqueue"
+)
+
+func TestSync(t *testing.T) {
+	kubeclientset := testclient.NewSimpleClientset()
+	defer SetPaused(false)
+
+	SetPaused(true)
+	util.Equals(t, true, Paused())
+	// The state is kept while the config map does not exist
+	sync(kubeclientset, "edgenet", "maintenance")
+	util.Equals(t, true, Paused())
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "maintenance", Namespace: "edgenet"}, Data: map[string]string{PausedKey: "false"}}
+	_, err := kubeclientset.CoreV1().ConfigMaps("edgenet").Create(context.TODO(), configMap, metav1.CreateOptions{})
+	util.OK(t, err)
+	cases := map[string]struct {
+		data     map[string]string
+		expected bool
+	}{
+		"paused":        {map[string]string{PausedKey: "true"}, true},
+		"invalid value": {map[string]string{PausedKey: "soon"}, true},
+		"resumed":       {map[string]string{PausedKey: " false "}, false},
+		"no key":        {map[string]string{}, false},
+	}
+	for _, k := range []string{"paused", "invalid value", "resumed", "no key"} {
+		tc := cases[k]
+		t.Run(k, func(t *testing.T) {
+			configMap.Data = tc.data
+			_, err := kubeclientset.CoreV1().ConfigMaps("edgenet").Update(context.TODO(), configMap, metav1.UpdateOptions{})
+			util.OK(t, err)
+			sync(kubeclientset, "edgenet", "maintenance")
+			util.Equals(t, tc.expected, Paused())
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	kubeclientset := testclient.NewSimpleClientset()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	defer SetPaused(false)
+
+	util.OK(t, Start(kubeclientset, true, "", stopCh))
+	util.Equals(t, true, Paused())
+	util.Equals(t, true, Start(kubeclientset, false, "maintenance", stopCh) != nil)
+	util.Equals(t, true, Start(kubeclientset, false, "edgenet/", stopCh) != nil)
+	util.OK(t, Start(kubeclientset, false, "edgenet/maintenance", stopCh))
+	util.Equals(t, false, Paused())
+}
+
+func TestWhenResumed(t *testing.T) {
+	defer func(delay time.Duration) { RequeueDelay = delay }(RequeueDelay)
+	defer SetPaused(false)
+	RequeueDelay = 10 * time.Millisecond
+
+	done := make(chan struct{}, 2)
+	WhenResumed(func() { done <- struct{}{} })
+	util.Equals(t, 1, len(done))
+	<-done
+
+	SetPaused(true)
+	WhenResumed(func() { done <- struct{}{} })
+	time.Sleep(50 * time.Millisecond)
+	util.Equals(t, 0, len(done))
+	SetPaused(false)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("expected the function to run once resumed")
+	}
+}
+
+func TestProcess(t *testing.T) {
+	defer func(delay time.Duration) { RequeueDelay = delay }(RequeueDelay)
+	defer SetPaused(false)
+	RequeueDelay = 10 * time.Millisecond
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	calls := 0
+	failing := true
+	syncHandler := func(key string) error {
+		calls++
+		if failing {
+			return errors.New(key)
+		}
+		return nil
+	}
+
+	// The key is put back in the queue without being synced during maintenance
+	SetPaused(true)
+	synced, err := Process(queue, "edgenet", syncHandler)
+	util.OK(t, err)
+	util.Equals(t, false, synced)
+	util.Equals(t, 0, calls)
+	key, _ := queue.Get()
+	util.Equals(t, "edgenet", key)
+	queue.Done(key)
+
+	SetPaused(false)
+	synced, err = Process(queue, "edgenet", syncHandler)
+	util.Equals(t, "edgenet", err.Error())
+	util.Equals(t, false, synced)
+	util.Equals(t, 1, queue.NumRequeues("edgenet"))
+
+	failing = false
+	synced, err = Process(queue, "edgenet", syncHandler)
+	util.OK(t, err)
+	util.Equals(t, true, synced)
+	util.Equals(t, 2, calls)
+	util.Equals(t, 0, queue.NumRequeues("edgenet"))
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exposes the gauges of the controllers in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GaugeVec is a set of gauges sharing a name, partitioned by the values of its labels
+type GaugeVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.RWMutex
+	values map[string]gauge
+}
+
+type gauge struct {
+	labelValues []string
+	value       float64
+}
+
+// NewGaugeVec returns a gauge vector with the given name, help text, and label names
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return &GaugeVec{name: name, help: help, labelNames: labelNames, values: make(map[string]gauge)}
+}
+
+// Set sets the value of the gauge with the given label values, which are in the order of the label names
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	if len(labelValues) != len(g.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", g.name, len(g.labelNames), len(labelValues)))
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[strings.Join(labelValues, "\xff")] = gauge{labelValues: append([]string(nil), labelValues...), value: value}
+}
+
+// Get returns the value of the gauge with the given label values, and whether the gauge is set
+func (g *GaugeVec) Get(labelValues ...string) (float64, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	gauge, elementExists := g.values[strings.Join(labelValues, "\xff")]
+	return gauge.value, elementExists
+}
+
+// DeleteMatching removes the gauges whose label has the given value
+func (g *GaugeVec) DeleteMatching(labelName, labelValue string) {
+	index := -1
+	for i, name := range g.labelNames {
+		if name == labelName {
+			index = i
+		}
+	}
+	if index == -1 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for key, gauge := range g.values {
+		if gauge.labelValues[index] == labelValue {
+			delete(g.values, key)
+		}
+	}
+}
+
+// write writes the gauges in the Prometheus text format, sorted by label values
+func (g *GaugeVec) write(w io.Writer) error {
+	g.mu.RLock()
+	gauges := make([]gauge, 0, len(g.values))
+	for _, gauge := range g.values {
+		gauges = append(gauges, gauge)
+	}
+	g.mu.RUnlock()
+	sort.Slice(gauges, func(i, j int) bool {
+		return strings.Join(gauges[i].labelValues, "\xff") < strings.Join(gauges[j].labelValues, "\xff")
+	})
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, escape(g.help, false), g.name); err != nil {
+		return err
+	}
+	for _, gauge := range gauges {
+		labels := make([]string, len(g.labelNames))
+		for i, name := range g.labelNames {
+			labels[i] = fmt.Sprintf("%s=\"%s\"", name, escape(gauge.labelValues[i], true))
+		}
+		if _, err := fmt.Fprintf(w, "%s{%s} %s\n", g.name, strings.Join(labels, ","), strconv.FormatFloat(gauge.value, 'g', -1, 64)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escape escapes the backslashes and line feeds, and the double quotes of label values
+func escape(value string, quote bool) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	if quote {
+		value = strings.ReplaceAll(value, `"`, `\"`)
+	}
+	return value
+}
+
+// Registry holds the gauge vectors to expose
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]*GaugeVec
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]*GaugeVec)}
+}
+
+// DefaultRegistry is the registry the controllers register their gauges with
+var DefaultRegistry = NewRegistry()
+
+// Register adds the gauge vectors to the registry, it fails if a name is already registered
+func (r *Registry) Register(collectors ...*GaugeVec) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, collector := range collectors {
+		if _, elementExists := r.collectors[collector.name]; elementExists {
+			return fmt.Errorf("metrics: %s is already registered", collector.name)
+		}
+	}
+	for _, collector := range collectors {
+		r.collectors[collector.name] = collector
+	}
+	return nil
+}
+
+// MustRegister registers the gauge vectors with the default registry, and panics if it fails
+func MustRegister(collectors ...*GaugeVec) {
+	if err := DefaultRegistry.Register(collectors...); err != nil {
+		panic(err)
+	}
+}
+
+// ServeHTTP writes the registered gauges in the Prometheus text format, sorted by name
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.RLock()
+	collectors := make([]*GaugeVec, 0, len(r.collectors))
+	for _, collector := range r.collectors {
+		collectors = append(collectors, collector)
+	}
+	r.mu.RUnlock()
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name < collectors[j].name })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, collector := range collectors {
+		if err := collector.write(w); err != nil {
+			return
+		}
+	}
+}
+
+// Handler returns the HTTP handler exposing the gauges of the default registry
+func Handler() http.Handler {
+	return DefaultRegistry
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	gaugeVec := NewGaugeVec("edgenet_test", "Test gauge", "tenant", "resource")
+	util.OK(t, registry.Register(gaugeVec))
+	util.Equals(t, true, registry.Register(NewGaugeVec("edgenet_test", "Duplicate", "tenant")) != nil)
+
+	gaugeVec.Set(2, "edgenet", "memory")
+	gaugeVec.Set(0.5, "edgenet", "cpu")
+	gaugeVec.Set(1, "lip6", "cpu")
+	gaugeVec.Set(3, "edge\"net", "cpu")
+	value, exists := gaugeVec.Get("edgenet", "cpu")
+	util.Equals(t, true, exists)
+	util.Equals(t, 0.5, value)
+
+	gaugeVec.DeleteMatching("tenant", "lip6")
+	_, exists = gaugeVec.Get("lip6", "cpu")
+	util.Equals(t, false, exists)
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(recorder.Body)
+	util.OK(t, err)
+	expected := "# HELP edgenet_test Test gauge\n" +
+		"# TYPE edgenet_test gauge\n" +
+		"edgenet_test{tenant=\"edge\\\"net\",resource=\"cpu\"} 3\n" +
+		"edgenet_test{tenant=\"edgenet\",resource=\"cpu\"} 0.5\n" +
+		"edgenet_test{tenant=\"edgenet\",resource=\"memory\"} 2\n"
+	util.Equals(t, expected, string(body))
+}
@@ -26,19 +26,38 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog"
 )
 
 var labels = map[string]string{"edge-net.io/generated": "true"}
 
-// GrantObjectOwnership configures permission for the object owner
+// GrantObjectOwnership configures permission for the object owner. Creating or updating the cluster role
+// and its binding is retried with backoff on transient API errors, and the error is returned only after
+// the retries are exhausted.
 func (m *Manager) GrantObjectOwnership(apiGroup, resource, resourceName, subject string, ownerReferences []metav1.OwnerReference) error {
-	clusterRole, err := m.createObjectSpecificClusterRole(apiGroup, resource, resourceName, "owner", []string{"get", "update", "patch", "delete"}, ownerReferences)
+	return m.GrantObjectOwnershipWithContext(context.TODO(), apiGroup, resource, resourceName, subject, ownerReferences)
+}
+
+// GrantObjectOwnershipWithContext is GrantObjectOwnership passing the context through to the API calls.
+// The retries stop as soon as the context is done, and the error of the context is returned.
+func (m *Manager) GrantObjectOwnershipWithContext(ctx context.Context, apiGroup, resource, resourceName, subject string, ownerReferences []metav1.OwnerReference) error {
+	retriable := func(err error) bool {
+		return ctx.Err() == nil && IsTransient(err)
+	}
+	var clusterRole string
+	err := retry.OnError(retry.DefaultBackoff, retriable, func() (err error) {
+		clusterRole, err = m.createObjectSpecificClusterRole(ctx, apiGroup, resource, resourceName, "owner", []string{"get", "update", "patch", "delete"}, ownerReferences)
+		return err
+	})
 	if err != nil && !k8serrors.IsAlreadyExists(err) {
 		klog.Infof("Couldn't create owner cluster role %s: %s", subject, err)
 		return err
 	}
-	if err := m.createObjectSpecificClusterRoleBinding(clusterRole, subject, ownerReferences); err != nil && !k8serrors.IsAlreadyExists(err) {
+	err = retry.OnError(retry.DefaultBackoff, retriable, func() error {
+		return m.createObjectSpecificClusterRoleBinding(ctx, clusterRole, subject, ownerReferences)
+	})
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
 		klog.Infof("Couldn't create cluster role binding %s: %s", subject, err)
 		return err
 	}
@@ -47,6 +66,11 @@ func (m *Manager) GrantObjectOwnership(apiGroup, resource, resourceName, subject
 
 // CreateClusterRoles generate a cluster role for tenant owners, admins, and collaborators
 func (m *Manager) CreateClusterRoles() error {
+	return m.CreateClusterRolesWithContext(context.TODO())
+}
+
+// CreateClusterRolesWithContext is CreateClusterRoles passing the context through to the API calls
+func (m *Manager) CreateClusterRolesWithContext(ctx context.Context) error {
 	policyRule := []rbacv1.PolicyRule{{APIGroups: []string{"core.edgenet.io"}, Resources: []string{"subnamespaces"}, Verbs: []string{"*"}},
 		{APIGroups: []string{"core.edgenet.io"}, Resources: []string{"subnamespaces/status"}, Verbs: []string{"get", "list", "watch"}},
 		{APIGroups: []string{"apps.edgenet.io"}, Resources: []string{"selectivedeployments"}, Verbs: []string{"*"}},
@@ -60,14 +84,17 @@ func (m *Manager) CreateClusterRoles() error {
 		{APIGroups: []string{""}, Resources: []string{"events", "controllerrevisions"}, Verbs: []string{"get", "list", "watch"}}}
 	ownerRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: corev1alpha1.TenantOwnerClusterRoleName}, Rules: policyRule}
 	ownerRole.SetLabels(labels)
-	_, err := m.kubeclientset.RbacV1().ClusterRoles().Create(context.TODO(), ownerRole, metav1.CreateOptions{})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := m.kubeclientset.RbacV1().ClusterRoles().Create(ctx, ownerRole, metav1.CreateOptions{})
 	if err != nil {
 		log.Printf("Couldn't create tenant owner cluster role: %s", err)
 		if k8serrors.IsAlreadyExists(err) {
-			currentClusterRole, err := m.kubeclientset.RbacV1().ClusterRoles().Get(context.TODO(), ownerRole.GetName(), metav1.GetOptions{})
+			currentClusterRole, err := m.kubeclientset.RbacV1().ClusterRoles().Get(ctx, ownerRole.GetName(), metav1.GetOptions{})
 			if err == nil {
 				currentClusterRole.Rules = policyRule
-				_, err = m.kubeclientset.RbacV1().ClusterRoles().Update(context.TODO(), currentClusterRole, metav1.UpdateOptions{})
+				_, err = m.kubeclientset.RbacV1().ClusterRoles().Update(ctx, currentClusterRole, metav1.UpdateOptions{})
 				if err == nil {
 					log.Println("Tenant owner cluster role updated")
 				} else {
@@ -78,14 +105,17 @@ func (m *Manager) CreateClusterRoles() error {
 	}
 	adminRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: corev1alpha1.TenantAdminClusterRoleName}, Rules: policyRule}
 	adminRole.SetLabels(labels)
-	_, err = m.kubeclientset.RbacV1().ClusterRoles().Create(context.TODO(), adminRole, metav1.CreateOptions{})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err = m.kubeclientset.RbacV1().ClusterRoles().Create(ctx, adminRole, metav1.CreateOptions{})
 	if err != nil {
 		log.Printf("Couldn't create tenant admin cluster role: %s", err)
 		if k8serrors.IsAlreadyExists(err) {
-			currentClusterRole, err := m.kubeclientset.RbacV1().ClusterRoles().Get(context.TODO(), adminRole.GetName(), metav1.GetOptions{})
+			currentClusterRole, err := m.kubeclientset.RbacV1().ClusterRoles().Get(ctx, adminRole.GetName(), metav1.GetOptions{})
 			if err == nil {
 				currentClusterRole.Rules = policyRule
-				_, err = m.kubeclientset.RbacV1().ClusterRoles().Update(context.TODO(), currentClusterRole, metav1.UpdateOptions{})
+				_, err = m.kubeclientset.RbacV1().ClusterRoles().Update(ctx, currentClusterRole, metav1.UpdateOptions{})
 				if err == nil {
 					log.Println("Tenant admin cluster role updated")
 				} else {
@@ -104,14 +134,17 @@ func (m *Manager) CreateClusterRoles() error {
 		{APIGroups: []string{""}, Resources: []string{"events", "controllerrevisions"}, Verbs: []string{"get", "list", "watch"}}}
 	collaboratorRole := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: corev1alpha1.TenantCollaboratorClusterRoleName}, Rules: policyRule}
 	collaboratorRole.SetLabels(labels)
-	_, err = m.kubeclientset.RbacV1().ClusterRoles().Create(context.TODO(), collaboratorRole, metav1.CreateOptions{})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err = m.kubeclientset.RbacV1().ClusterRoles().Create(ctx, collaboratorRole, metav1.CreateOptions{})
 	if err != nil {
 		log.Printf("Couldn't create tenant collaborator cluster role: %s", err)
 		if k8serrors.IsAlreadyExists(err) {
-			currentClusterRole, err := m.kubeclientset.RbacV1().ClusterRoles().Get(context.TODO(), collaboratorRole.GetName(), metav1.GetOptions{})
+			currentClusterRole, err := m.kubeclientset.RbacV1().ClusterRoles().Get(ctx, collaboratorRole.GetName(), metav1.GetOptions{})
 			if err == nil {
 				currentClusterRole.Rules = policyRule
-				_, err = m.kubeclientset.RbacV1().ClusterRoles().Update(context.TODO(), currentClusterRole, metav1.UpdateOptions{})
+				_, err = m.kubeclientset.RbacV1().ClusterRoles().Update(ctx, currentClusterRole, metav1.UpdateOptions{})
 				if err == nil {
 					log.Println("Tenant collaborator cluster role updated")
 					return err
@@ -124,7 +157,7 @@ func (m *Manager) CreateClusterRoles() error {
 }
 
 // CreateObjectSpecificClusterRole generates a object specific cluster role to allow the user access
-func (m *Manager) createObjectSpecificClusterRole(apiGroup, resource, resourceName, name string, verbs []string, ownerReferences []metav1.OwnerReference) (string, error) {
+func (m *Manager) createObjectSpecificClusterRole(ctx context.Context, apiGroup, resource, resourceName, name string, verbs []string, ownerReferences []metav1.OwnerReference) (string, error) {
 	objectName := fmt.Sprintf("edgenet:%s:%s-%s", resource, resourceName, name)
 	policyRule := []rbacv1.PolicyRule{{APIGroups: []string{apiGroup}, Resources: []string{resource}, ResourceNames: []string{resourceName}, Verbs: verbs},
 		{APIGroups: []string{apiGroup}, Resources: []string{fmt.Sprintf("%s/status", resource)}, ResourceNames: []string{resourceName}, Verbs: []string{"get", "list", "watch"}},
@@ -132,17 +165,18 @@ func (m *Manager) createObjectSpecificClusterRole(apiGroup, resource, resourceNa
 	role := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: objectName, OwnerReferences: ownerReferences},
 		Rules: policyRule}
 
-	_, err := m.kubeclientset.RbacV1().ClusterRoles().Create(context.TODO(), role, metav1.CreateOptions{})
+	if err := ctx.Err(); err != nil {
+		return objectName, err
+	}
+	_, err := m.kubeclientset.RbacV1().ClusterRoles().Create(ctx, role, metav1.CreateOptions{})
 	if err != nil {
 		log.Printf("Couldn't create %s cluster role: %s", objectName, err)
 		if k8serrors.IsAlreadyExists(err) {
-			currentRole, err := m.kubeclientset.RbacV1().ClusterRoles().Get(context.TODO(), role.GetName(), metav1.GetOptions{})
-			if err == nil {
+			var currentRole *rbacv1.ClusterRole
+			if currentRole, err = m.kubeclientset.RbacV1().ClusterRoles().Get(ctx, role.GetName(), metav1.GetOptions{}); err == nil {
 				currentRole.Rules = policyRule
-				_, err = m.kubeclientset.RbacV1().ClusterRoles().Update(context.TODO(), currentRole, metav1.UpdateOptions{})
-				if err == nil {
+				if _, err = m.kubeclientset.RbacV1().ClusterRoles().Update(ctx, currentRole, metav1.UpdateOptions{}); err == nil {
 					log.Printf("Updated: %s cluster role updated", objectName)
-					return objectName, err
 				}
 			}
 		}
@@ -151,27 +185,156 @@ func (m *Manager) createObjectSpecificClusterRole(apiGroup, resource, resourceNa
 }
 
 // CreateObjectSpecificClusterRoleBinding links the cluster role up with the user
-func (m *Manager) createObjectSpecificClusterRoleBinding(roleName, email string, ownerReferences []metav1.OwnerReference) error {
+func (m *Manager) createObjectSpecificClusterRoleBinding(ctx context.Context, roleName, email string, ownerReferences []metav1.OwnerReference) error {
 	roleRef := rbacv1.RoleRef{Kind: "ClusterRole", Name: roleName}
 	rbSubjects := []rbacv1.Subject{{Kind: "User", Name: email, APIGroup: "rbac.authorization.k8s.io"}}
 	roleBind := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: roleName},
 		Subjects: rbSubjects, RoleRef: roleRef}
 	roleBind.ObjectMeta.OwnerReferences = ownerReferences
 	roleBind.SetLabels(labels)
-	_, err := m.kubeclientset.RbacV1().ClusterRoleBindings().Create(context.TODO(), roleBind, metav1.CreateOptions{})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := m.kubeclientset.RbacV1().ClusterRoleBindings().Create(ctx, roleBind, metav1.CreateOptions{})
 	if err != nil {
 		log.Printf("Couldn't create %s cluster role binding: %s", roleName, err)
 		if k8serrors.IsAlreadyExists(err) {
-			currentRoleBind, err := m.kubeclientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), roleName, metav1.GetOptions{})
-			if err == nil {
+			var currentRoleBind *rbacv1.ClusterRoleBinding
+			if currentRoleBind, err = m.kubeclientset.RbacV1().ClusterRoleBindings().Get(ctx, roleName, metav1.GetOptions{}); err == nil {
 				currentRoleBind.Subjects = []rbacv1.Subject{{Kind: "User", Name: email, APIGroup: "rbac.authorization.k8s.io"}}
 				currentRoleBind.SetLabels(labels)
-				if _, err = m.kubeclientset.RbacV1().ClusterRoleBindings().Update(context.TODO(), currentRoleBind, metav1.UpdateOptions{}); err == nil {
+				if _, err = m.kubeclientset.RbacV1().ClusterRoleBindings().Update(ctx, currentRoleBind, metav1.UpdateOptions{}); err == nil {
 					log.Printf("Updated: %s cluster role binding updated", roleName)
-					return err
 				}
 			}
 		}
 	}
 	return err
 }
+
+// RevokeUser removes the user from the role bindings in the namespaces of the tenant and from the cluster role
+// bindings that belong to the tenant, deleting the bindings left without any subject. It returns the number of
+// bindings the user is removed from. A failing binding does not stop the others from being revoked, and the first
+// error is returned at the end.
+func (m *Manager) RevokeUser(tenantNamespace, email string) (int, error) {
+	return m.RevokeUserWithContext(context.TODO(), tenantNamespace, email)
+}
+
+// RevokeUserWithContext is RevokeUser passing the context through to the API calls. Once the context is done,
+// the remaining bindings are left as they are and the error of the context is returned.
+func (m *Manager) RevokeUserWithContext(ctx context.Context, tenantNamespace, email string) (int, error) {
+	removed := 0
+	var firstErr error
+	record := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	namespaces := []string{tenantNamespace}
+	if namespaceRaw, err := m.kubeclientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/tenant=%s", tenantNamespace)}); err == nil {
+		for _, namespaceRow := range namespaceRaw.Items {
+			if namespaceRow.GetName() != tenantNamespace {
+				namespaces = append(namespaces, namespaceRow.GetName())
+			}
+		}
+	} else {
+		record(err)
+	}
+	for _, namespace := range namespaces {
+		if err := ctx.Err(); err != nil {
+			record(err)
+			return removed, firstErr
+		}
+		roleBindingRaw, err := m.kubeclientset.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			record(err)
+			continue
+		}
+		for _, roleBindingRow := range roleBindingRaw.Items {
+			subjects, revoked := withoutUser(roleBindingRow.Subjects, email)
+			if !revoked {
+				continue
+			}
+			if len(subjects) == 0 {
+				err = m.kubeclientset.RbacV1().RoleBindings(namespace).Delete(ctx, roleBindingRow.GetName(), metav1.DeleteOptions{})
+			} else {
+				roleBindingCopy := roleBindingRow.DeepCopy()
+				roleBindingCopy.Subjects = subjects
+				_, err = m.kubeclientset.RbacV1().RoleBindings(namespace).Update(ctx, roleBindingCopy, metav1.UpdateOptions{})
+			}
+			if err != nil && !k8serrors.IsNotFound(err) {
+				record(err)
+				continue
+			}
+			removed++
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		record(err)
+		return removed, firstErr
+	}
+	clusterRoleBindingRaw, err := m.kubeclientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		record(err)
+		return removed, firstErr
+	}
+	for _, clusterRoleBindingRow := range clusterRoleBindingRaw.Items {
+		if !belongsToTenant(clusterRoleBindingRow.ObjectMeta, tenantNamespace) {
+			continue
+		}
+		subjects, revoked := withoutUser(clusterRoleBindingRow.Subjects, email)
+		if !revoked {
+			continue
+		}
+		if len(subjects) == 0 {
+			err = m.kubeclientset.RbacV1().ClusterRoleBindings().Delete(ctx, clusterRoleBindingRow.GetName(), metav1.DeleteOptions{})
+		} else {
+			clusterRoleBindingCopy := clusterRoleBindingRow.DeepCopy()
+			clusterRoleBindingCopy.Subjects = subjects
+			_, err = m.kubeclientset.RbacV1().ClusterRoleBindings().Update(ctx, clusterRoleBindingCopy, metav1.UpdateOptions{})
+		}
+		if err != nil && !k8serrors.IsNotFound(err) {
+			record(err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		klog.Infof("Access of %s revoked from %d bindings of tenant %s", email, removed, tenantNamespace)
+	}
+	return removed, firstErr
+}
+
+// withoutUser returns the subjects except the user, and whether the user was among them
+func withoutUser(subjects []rbacv1.Subject, email string) ([]rbacv1.Subject, bool) {
+	remaining := []rbacv1.Subject{}
+	for _, subject := range subjects {
+		if subject.Kind == "User" && subject.Name == email {
+			continue
+		}
+		remaining = append(remaining, subject)
+	}
+	return remaining, len(remaining) != len(subjects)
+}
+
+// belongsToTenant tells whether a cluster-scoped object is generated for the tenant, either labeled with
+// the tenant or owned by the tenant object
+func belongsToTenant(objectMeta metav1.ObjectMeta, tenant string) bool {
+	if objectMeta.GetLabels()["edge-net.io/tenant"] == tenant {
+		return true
+	}
+	for _, ownerReference := range objectMeta.GetOwnerReferences() {
+		if ownerReference.Kind == "Tenant" && ownerReference.Name == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTransient reports whether the API error is likely to go away on its own, so the request is worth retrying
+func IsTransient(err error) bool {
+	return k8serrors.IsServerTimeout(err) || k8serrors.IsTimeout(err) || k8serrors.IsTooManyRequests(err) ||
+		k8serrors.IsInternalError(err) || k8serrors.IsServiceUnavailable(err) || k8serrors.IsConflict(err)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"strings"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultTenantLabelKeys lists the tenant labels copied to generated objects unless configured otherwise
+var DefaultTenantLabelKeys = []string{"edge-net.io/cost-center"}
+
+// TenantLabels returns the labels to put on every object generated for a tenant, so that cost-accounting
+// tools can attribute them. These are the tenant name and UID, plus the tenant's own labels whose keys
+// are listed in labelKeys, e.g. its cost center. Keys the tenant does not have are left out.
+func TenantLabels(tenant *corev1alpha1.Tenant, labelKeys []string) map[string]string {
+	labels := map[string]string{"edge-net.io/tenant": tenant.GetName(), "edge-net.io/tenant-uid": string(tenant.GetUID())}
+	for _, key := range labelKeys {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		if value, ok := tenant.GetLabels()[key]; ok {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// ClusterLabels returns the label attributing an object generated in a namespace to the cluster, as found in the
+// labels of the namespace, so that objects can be told apart across a federation. It is empty if the namespace
+// does not carry the cluster UID.
+func ClusterLabels(namespaceLabels map[string]string) map[string]string {
+	if clusterUID := namespaceLabels["edge-net.io/cluster-uid"]; clusterUID != "" {
+		return map[string]string{"edge-net.io/cluster-uid": clusterUID}
+	}
+	return map[string]string{}
+}
+
+// StampLabels adds the labels to the object, keeping the other labels it has
+func StampLabels(obj metav1.Object, labels map[string]string) {
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = make(map[string]string, len(labels))
+	}
+	for key, value := range labels {
+		objLabels[key] = value
+	}
+	obj.SetLabels(objLabels)
+}
@@ -0,0 +1,46 @@
+package multitenancy
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTenantLabels(t *testing.T) {
+	tenant := &corev1alpha1.Tenant{ObjectMeta: metav1.ObjectMeta{Name: "edgenet", UID: "edgenet-uid",
+		Labels: map[string]string{"edge-net.io/cost-center": "lip6", "team": "network"}}}
+
+	cases := map[string]struct {
+		labelKeys []string
+		expected  map[string]string
+	}{
+		"default": {DefaultTenantLabelKeys, map[string]string{"edge-net.io/tenant": "edgenet", "edge-net.io/tenant-uid": "edgenet-uid", "edge-net.io/cost-center": "lip6"}},
+		"none":    {nil, map[string]string{"edge-net.io/tenant": "edgenet", "edge-net.io/tenant-uid": "edgenet-uid"}},
+		"missing": {[]string{"project", " team ", ""}, map[string]string{"edge-net.io/tenant": "edgenet", "edge-net.io/tenant-uid": "edgenet-uid", "team": "network"}},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			util.Equals(t, tc.expected, TenantLabels(tenant, tc.labelKeys))
+		})
+	}
+}
+
+func TestClusterLabels(t *testing.T) {
+	util.Equals(t, map[string]string{"edge-net.io/cluster-uid": "cluster-uid"}, ClusterLabels(map[string]string{"edge-net.io/kind": "core", "edge-net.io/cluster-uid": "cluster-uid"}))
+	util.Equals(t, map[string]string{}, ClusterLabels(map[string]string{"edge-net.io/kind": "core"}))
+	util.Equals(t, map[string]string{}, ClusterLabels(nil))
+}
+
+func TestStampLabels(t *testing.T) {
+	namespace := &corev1.Namespace{}
+	StampLabels(namespace, map[string]string{"edge-net.io/tenant": "edgenet"})
+	util.Equals(t, map[string]string{"edge-net.io/tenant": "edgenet"}, namespace.GetLabels())
+
+	namespace.SetLabels(map[string]string{"edge-net.io/kind": "sub", "edge-net.io/tenant": "other"})
+	StampLabels(namespace, map[string]string{"edge-net.io/tenant": "edgenet", "edge-net.io/cost-center": "lip6"})
+	util.Equals(t, map[string]string{"edge-net.io/kind": "sub", "edge-net.io/tenant": "edgenet", "edge-net.io/cost-center": "lip6"}, namespace.GetLabels())
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import "sync"
+
+// keyedLocks holds a read-write mutex per key, which is dropped once no caller holds or waits for it, so that the
+// locks of deleted tenants and namespaces do not pile up
+type keyedLocks struct {
+	mutex sync.Mutex
+	locks map[string]*keyedLock
+}
+
+// keyedLock is the mutex of a key along with the number of callers holding or waiting for it
+type keyedLock struct {
+	sync.RWMutex
+	references int
+}
+
+// acquire returns the lock of the key, creating it if needed, and counts the caller in until release
+func (k *keyedLocks) acquire(key string) *keyedLock {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	lock, exists := k.locks[key]
+	if !exists {
+		lock = new(keyedLock)
+		k.locks[key] = lock
+	}
+	lock.references++
+	return lock
+}
+
+// release counts the caller out of the lock of the key, which is dropped with the last one
+func (k *keyedLocks) release(key string) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if lock, exists := k.locks[key]; exists {
+		if lock.references--; lock.references == 0 {
+			delete(k.locks, key)
+		}
+	}
+}
+
+// len returns the number of locks held or waited for
+func (k *keyedLocks) len() int {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	return len(k.locks)
+}
+
+// tenantLocks holds a read-write mutex per tenant name
+var tenantLocks keyedLocks
+
+// namespaceLocks holds a mutex per namespace name
+var namespaceLocks keyedLocks
+
+// LockTenant acquires the lock of the tenant and returns the function that releases it. The controllers
+// take it around the read-modify-write of the tenant's resource quotas so that subnamespace partitioning
+// and tenant resource quota reconciliation running in the same process do not overwrite each other. The
+// lock does not reach across processes, where the controllers retry their quota updates on conflict.
+func LockTenant(tenant string) func() {
+	lock := tenantLocks.acquire(tenant)
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		tenantLocks.release(tenant)
+	}
+}
+
+// LockNamespaces shares the lock of the tenant, so that it is only held off by LockTenant, and acquires
+// the locks of the namespaces in turn. It returns the function that releases them all. The namespaces
+// must be given from the root of the tenant down, such as a parent before its child, so that callers
+// locking overlapping namespaces of the tree cannot deadlock. Reconciles touching the quota of the same
+// namespace are serialized this way, while those of unrelated namespaces proceed in parallel. As with
+// LockTenant, the locks only serialize the reconciles of this process.
+func LockNamespaces(tenant string, namespaces ...string) func() {
+	tenantLock := tenantLocks.acquire(tenant)
+	tenantLock.RLock()
+	unlocks := []func(){func() {
+		tenantLock.RUnlock()
+		tenantLocks.release(tenant)
+	}}
+	locked := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		if namespace == "" || locked[namespace] {
+			continue
+		}
+		locked[namespace] = true
+		namespaceLock := namespaceLocks.acquire(namespace)
+		namespaceLock.Lock()
+		namespace := namespace
+		unlocks = append(unlocks, func() {
+			namespaceLock.Unlock()
+			namespaceLocks.release(namespace)
+		})
+	}
+	return func() {
+		for i := len(unlocks) - 1; i >= 0; i-- {
+			unlocks[i]()
+		}
+	}
+}
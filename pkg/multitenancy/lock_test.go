@@ -0,0 +1,98 @@
+package multitenancy
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+)
+
+func TestLockTenant(t *testing.T) {
+	// Read-modify-write of a shared counter loses no update under the tenant lock
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := LockTenant("edgenet")
+			defer unlock()
+			value := counter
+			time.Sleep(time.Millisecond)
+			counter = value + 1
+		}()
+	}
+	wg.Wait()
+	util.Equals(t, 50, counter)
+
+	// The lock of another tenant is independent
+	unlock := LockTenant("edgenet")
+	acquired := make(chan bool)
+	go func() {
+		LockTenant("lip6")()
+		acquired <- true
+	}()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Error("lock of another tenant is blocked")
+	}
+	unlock()
+}
+
+func TestLockNamespaces(t *testing.T) {
+	// Read-modify-write of a shared counter loses no update under the lock of the same parent
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			unlock := LockNamespaces("edgenet", "edgenet", fmt.Sprintf("edgenet-child-%d", i))
+			defer unlock()
+			value := counter
+			time.Sleep(time.Millisecond)
+			counter = value + 1
+		}(i)
+	}
+	wg.Wait()
+	util.Equals(t, 50, counter)
+
+	var acquires = func(lock func() func()) bool {
+		acquired := make(chan bool)
+		go func() {
+			lock()()
+			acquired <- true
+		}()
+		select {
+		case <-acquired:
+			return true
+		case <-time.After(250 * time.Millisecond):
+			return false
+		}
+	}
+
+	unlock := LockNamespaces("edgenet", "edgenet", "edgenet-child", "edgenet-child")
+	// Unrelated namespaces of the tenant proceed in parallel
+	util.Equals(t, true, acquires(func() func() { return LockNamespaces("edgenet", "edgenet-other") }))
+	// The tenant resource quota reconciliation waits for the namespaces to be released
+	tenantLocked := make(chan bool)
+	go func() {
+		LockTenant("edgenet")()
+		tenantLocked <- true
+	}()
+	select {
+	case <-tenantLocked:
+		t.Error("tenant lock acquired while a namespace is locked")
+	case <-time.After(250 * time.Millisecond):
+	}
+	unlock()
+	<-tenantLocked
+	util.Equals(t, true, acquires(func() func() { return LockNamespaces("edgenet", "edgenet-child") }))
+
+	// The locks are dropped once released
+	util.Equals(t, 0, tenantLocks.len())
+	util.Equals(t, 0, namespaceLocks.len())
+}
@@ -28,11 +28,14 @@ import (
 	"github.com/EdgeNet-project/edgenet/pkg/util"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	testclient "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 type TestGroup struct {
@@ -145,13 +148,13 @@ func TestCreateObjectSpecificClusterRole(t *testing.T) {
 	}
 	for k, tc := range cases {
 		t.Run(k, func(t *testing.T) {
-			g.multitenancyManager.createObjectSpecificClusterRole(tc.apiGroup, tc.resource, tc.resourceName, "name", tc.verbs, []metav1.OwnerReference{})
+			g.multitenancyManager.createObjectSpecificClusterRole(context.TODO(), tc.apiGroup, tc.resource, tc.resourceName, "name", tc.verbs, []metav1.OwnerReference{})
 			clusterRole, err := g.client.RbacV1().ClusterRoles().Get(context.TODO(), tc.expected, metav1.GetOptions{})
 			util.OK(t, err)
 			if err == nil {
 				util.Equals(t, tc.verbs, clusterRole.Rules[0].Verbs)
 			}
-			_, err = g.multitenancyManager.createObjectSpecificClusterRole(tc.apiGroup, tc.resource, tc.resourceName, "name", tc.verbs, []metav1.OwnerReference{})
+			_, err = g.multitenancyManager.createObjectSpecificClusterRole(context.TODO(), tc.apiGroup, tc.resource, tc.resourceName, "name", tc.verbs, []metav1.OwnerReference{})
 			util.OK(t, err)
 		})
 	}
@@ -168,16 +171,96 @@ func TestCreateObjectSpecificClusterRole(t *testing.T) {
 		}
 		for k, tc := range cases {
 			t.Run(k, func(t *testing.T) {
-				g.multitenancyManager.createObjectSpecificClusterRoleBinding(tc.roleName, tc.email, []metav1.OwnerReference{})
+				g.multitenancyManager.createObjectSpecificClusterRoleBinding(context.TODO(), tc.roleName, tc.email, []metav1.OwnerReference{})
 				_, err := g.client.RbacV1().ClusterRoleBindings().Get(context.TODO(), tc.roleName, metav1.GetOptions{})
 				util.OK(t, err)
-				err = g.multitenancyManager.createObjectSpecificClusterRoleBinding(tc.roleName, tc.email, []metav1.OwnerReference{})
+				err = g.multitenancyManager.createObjectSpecificClusterRoleBinding(context.TODO(), tc.roleName, tc.email, []metav1.OwnerReference{})
 				util.OK(t, err)
 			})
 		}
 	})
 }
 
+func TestGrantObjectOwnershipRetry(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	failures := map[string]int{"clusterroles": 1, "clusterrolebindings": 1}
+	attempts := map[string]int{}
+	failOnce := func(action k8stesting.Action) (bool, runtime.Object, error) {
+		resource := action.GetResource().Resource
+		attempts[resource]++
+		if failures[resource] > 0 {
+			failures[resource]--
+			return true, nil, errors.NewInternalError(fmt.Errorf("etcd leader changed"))
+		}
+		return false, nil, nil
+	}
+	client := g.client.(*testclient.Clientset)
+	client.PrependReactor("create", "clusterroles", failOnce)
+	client.PrependReactor("create", "clusterrolebindings", failOnce)
+
+	err := g.multitenancyManager.GrantObjectOwnership("core.edgenet.io", "tenants", g.tenant.GetName(), g.tenant.Spec.Contact.Email, []metav1.OwnerReference{})
+	util.OK(t, err)
+	util.Equals(t, 2, attempts["clusterroles"])
+	util.Equals(t, 2, attempts["clusterrolebindings"])
+	name := fmt.Sprintf("edgenet:tenants:%s-owner", g.tenant.GetName())
+	_, err = g.client.RbacV1().ClusterRoles().Get(context.TODO(), name, metav1.GetOptions{})
+	util.OK(t, err)
+	clusterRoleBinding, err := g.client.RbacV1().ClusterRoleBindings().Get(context.TODO(), name, metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, g.tenant.Spec.Contact.Email, clusterRoleBinding.Subjects[0].Name)
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		failures["clusterrolebindings"] = 10
+		attempts["clusterrolebindings"] = 0
+		err := g.multitenancyManager.GrantObjectOwnership("core.edgenet.io", "tenants", "lip6", g.tenant.Spec.Contact.Email, []metav1.OwnerReference{})
+		util.Equals(t, true, errors.IsInternalError(err))
+		util.Equals(t, 4, attempts["clusterrolebindings"])
+	})
+}
+
+func TestCanceledContext(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := g.multitenancyManager.GrantObjectOwnershipWithContext(ctx, "core.edgenet.io", "tenants", "canceled", g.tenant.Spec.Contact.Email, []metav1.OwnerReference{})
+	util.Equals(t, context.Canceled, err)
+	_, err = g.client.RbacV1().ClusterRoles().Get(context.TODO(), "edgenet:tenants:canceled-owner", metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+
+	util.Equals(t, context.Canceled, g.multitenancyManager.CreateClusterRolesWithContext(ctx))
+	_, err = g.client.RbacV1().ClusterRoles().Get(context.TODO(), corev1alpha1.TenantOwnerClusterRoleName, metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: "canceled", Namespace: "edgenet"},
+		Subjects: []rbacv1.Subject{{Kind: "User", Name: "canceled@edge-net.org", APIGroup: "rbac.authorization.k8s.io"}}}
+	_, err = g.client.RbacV1().RoleBindings(roleBinding.GetNamespace()).Create(context.TODO(), roleBinding, metav1.CreateOptions{})
+	util.OK(t, err)
+	removed, err := g.multitenancyManager.RevokeUserWithContext(ctx, "edgenet", "canceled@edge-net.org")
+	util.Equals(t, context.Canceled, err)
+	util.Equals(t, 0, removed)
+	_, err = g.client.RbacV1().RoleBindings(roleBinding.GetNamespace()).Get(context.TODO(), roleBinding.GetName(), metav1.GetOptions{})
+	util.OK(t, err)
+
+	t.Run("canceled while retrying", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		attempts := 0
+		client := g.client.(*testclient.Clientset)
+		client.PrependReactor("create", "clusterroles", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			attempts++
+			cancel()
+			return true, nil, errors.NewInternalError(fmt.Errorf("etcd leader changed"))
+		})
+		err := g.multitenancyManager.GrantObjectOwnershipWithContext(ctx, "core.edgenet.io", "tenants", "canceled", g.tenant.Spec.Contact.Email, []metav1.OwnerReference{})
+		util.Equals(t, true, errors.IsInternalError(err))
+		util.Equals(t, 1, attempts)
+	})
+}
+
 func TestApplyTenantResourceQuota(t *testing.T) {
 	g := TestGroup{}
 	g.Init()
@@ -196,3 +279,63 @@ func TestApplyTenantResourceQuota(t *testing.T) {
 	_, err = g.multitenancyManager.edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), g.tenantResourceQuotaObj.GetName(), metav1.GetOptions{})
 	util.OK(t, err)
 }
+
+func TestRevokeUser(t *testing.T) {
+	g := TestGroup{}
+	g.Init()
+
+	email := "compromised@edge-net.org"
+	user := rbacv1.Subject{Kind: "User", Name: email, APIGroup: "rbac.authorization.k8s.io"}
+	colleague := rbacv1.Subject{Kind: "User", Name: g.tenant.Spec.Contact.Email, APIGroup: "rbac.authorization.k8s.io"}
+	for name, tenant := range map[string]string{"edgenet": "edgenet", "edgenet-lab": "edgenet", "lip6": "lip6"} {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"edge-net.io/tenant": tenant}}}
+		if _, err := g.client.CoreV1().Namespaces().Create(context.TODO(), namespace, metav1.CreateOptions{}); errors.IsAlreadyExists(err) {
+			_, err = g.client.CoreV1().Namespaces().Update(context.TODO(), namespace, metav1.UpdateOptions{})
+			util.OK(t, err)
+		}
+	}
+	roleBindings := []*rbacv1.RoleBinding{
+		{ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "edgenet"}, Subjects: []rbacv1.Subject{user}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "collaborators", Namespace: "edgenet-lab"}, Subjects: []rbacv1.Subject{user, colleague}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "admins", Namespace: "edgenet-lab"}, Subjects: []rbacv1.Subject{colleague}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "collaborators", Namespace: "lip6"}, Subjects: []rbacv1.Subject{user}},
+	}
+	for _, roleBinding := range roleBindings {
+		_, err := g.client.RbacV1().RoleBindings(roleBinding.GetNamespace()).Create(context.TODO(), roleBinding, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+	clusterRoleBindings := []*rbacv1.ClusterRoleBinding{
+		{ObjectMeta: metav1.ObjectMeta{Name: "edgenet:tenants:edgenet-owner", OwnerReferences: []metav1.OwnerReference{g.tenant.MakeOwnerReference()}}, Subjects: []rbacv1.Subject{user}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "edgenet:tenants:lip6-owner", Labels: map[string]string{"edge-net.io/tenant": "lip6"}}, Subjects: []rbacv1.Subject{user}},
+	}
+	for _, clusterRoleBinding := range clusterRoleBindings {
+		_, err := g.client.RbacV1().ClusterRoleBindings().Create(context.TODO(), clusterRoleBinding, metav1.CreateOptions{})
+		util.OK(t, err)
+	}
+
+	removed, err := g.multitenancyManager.RevokeUser("edgenet", email)
+	util.OK(t, err)
+	util.Equals(t, 3, removed)
+
+	_, err = g.client.RbacV1().RoleBindings("edgenet").Get(context.TODO(), "owner", metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+	roleBinding, err := g.client.RbacV1().RoleBindings("edgenet-lab").Get(context.TODO(), "collaborators", metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, []rbacv1.Subject{colleague}, roleBinding.Subjects)
+	roleBinding, err = g.client.RbacV1().RoleBindings("edgenet-lab").Get(context.TODO(), "admins", metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, []rbacv1.Subject{colleague}, roleBinding.Subjects)
+	_, err = g.client.RbacV1().ClusterRoleBindings().Get(context.TODO(), "edgenet:tenants:edgenet-owner", metav1.GetOptions{})
+	util.Equals(t, true, errors.IsNotFound(err))
+
+	// The bindings of other tenants are left untouched
+	roleBinding, err = g.client.RbacV1().RoleBindings("lip6").Get(context.TODO(), "collaborators", metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, []rbacv1.Subject{user}, roleBinding.Subjects)
+	_, err = g.client.RbacV1().ClusterRoleBindings().Get(context.TODO(), "edgenet:tenants:lip6-owner", metav1.GetOptions{})
+	util.OK(t, err)
+
+	removed, err = g.multitenancyManager.RevokeUser("edgenet", email)
+	util.OK(t, err)
+	util.Equals(t, 0, removed)
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NormalizeShortName converts a tenant short name into a DNS label, so that it can be used in labels and
+// object names. Letters are lower-cased and stripped of their accents, and any other character is replaced
+// by a hyphen, e.g. "Sorbonne Université" becomes "sorbonne-universite". A name that has nothing left
+// after the conversion is rejected.
+func NormalizeShortName(shortName string) (string, error) {
+	var builder strings.Builder
+	for _, r := range norm.NFKD.String(strings.ToLower(strings.TrimSpace(shortName))) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Drop the combining marks left by the decomposition of accented letters
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune('-')
+		}
+	}
+	normalized := builder.String()
+	for strings.Contains(normalized, "--") {
+		normalized = strings.ReplaceAll(normalized, "--", "-")
+	}
+	if len(normalized) > validation.DNS1123LabelMaxLength {
+		normalized = normalized[:validation.DNS1123LabelMaxLength]
+	}
+	normalized = strings.Trim(normalized, "-")
+	if errs := validation.IsDNS1123Label(normalized); len(errs) > 0 {
+		return "", fmt.Errorf("short name %q cannot be converted to a DNS label: %s", shortName, strings.Join(errs, ", "))
+	}
+	return normalized, nil
+}
@@ -0,0 +1,38 @@
+package multitenancy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+)
+
+func TestNormalizeShortName(t *testing.T) {
+	cases := map[string]struct {
+		shortName string
+		expected  string
+		fails     bool
+	}{
+		"already valid":       {"edgenet", "edgenet", false},
+		"uppercase":           {"EdgeNet", "edgenet", false},
+		"spaces":              {"  Edge   Net Lab ", "edge-net-lab", false},
+		"accented letters":    {"Sorbonne Université", "sorbonne-universite", false},
+		"punctuation":         {"LIP6_(CNRS)", "lip6-cnrs", false},
+		"non-latin script":    {"東京 Lab", "lab", false},
+		"only non-latin":      {"東京大学", "", true},
+		"empty":               {"", "", true},
+		"too long":            {strings.Repeat("a", 70), strings.Repeat("a", 63), false},
+		"too long at hyphens": {strings.Repeat("a", 62) + " b", strings.Repeat("a", 62), false},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			normalized, err := NormalizeShortName(tc.shortName)
+			if tc.fails {
+				util.NotEquals(t, nil, err)
+				return
+			}
+			util.OK(t, err)
+			util.Equals(t, tc.expected, normalized)
+		})
+	}
+}
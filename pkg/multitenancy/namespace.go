@@ -21,11 +21,16 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 )
 
+// DefaultPriorityClassAnnotation names, on the core namespace of a tenant, the priority class its workloads inherit
+const DefaultPriorityClassAnnotation = "edge-net.io/default-priority-class"
+
 // MakeOwnerReferenceForNamespace creates an owner reference for the given namespace.
 func MakeOwnerReferenceForNamespace(namespace *corev1.Namespace) metav1.OwnerReference {
 	// The section below makes namespace the owner
@@ -67,3 +72,27 @@ func (m *Manager) EligibilityCheck(objNamespace string) (bool, *corev1.Namespace
 	}
 	return true, namespace, namespaceLabels
 }
+
+// DefaultPriorityClass returns the priority class that the workloads in the namespace inherit from the core namespace
+// of their tenant, or nil if the namespace belongs to no tenant or the tenant has no priority class.
+func DefaultPriorityClass(kubeclientset kubernetes.Interface, objNamespace string) (*schedulingv1.PriorityClass, error) {
+	namespace, err := kubeclientset.CoreV1().Namespaces().Get(context.TODO(), objNamespace, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	tenant, elementExists := namespace.GetLabels()["edge-net.io/tenant"]
+	if !elementExists {
+		return nil, nil
+	}
+	// The core namespace has the same name as the tenant, and holds the annotation the tenant controller reconciles
+	if tenant = strings.ToLower(tenant); tenant != namespace.GetName() {
+		if namespace, err = kubeclientset.CoreV1().Namespaces().Get(context.TODO(), tenant, metav1.GetOptions{}); err != nil {
+			return nil, err
+		}
+	}
+	priorityClassName, elementExists := namespace.GetAnnotations()[DefaultPriorityClassAnnotation]
+	if !elementExists {
+		return nil, nil
+	}
+	return kubeclientset.SchedulingV1().PriorityClasses().Get(context.TODO(), priorityClassName, metav1.GetOptions{})
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/EdgeNet-project/edgenet/pkg/util"
 
 	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestMakeOwnerReferenceForNamespace(t *testing.T) {
@@ -48,3 +50,35 @@ func TestMakeOwnerReferenceForNamespace(t *testing.T) {
 		util.Equals(t, tc.expected, result)
 	}
 }
+
+func TestDefaultPriorityClass(t *testing.T) {
+	kubeclientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "edgenet", Labels: map[string]string{"edge-net.io/tenant": "edgenet"},
+			Annotations: map[string]string{DefaultPriorityClassAnnotation: "tenant-edgenet"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "edgenet-sub", Labels: map[string]string{"edge-net.io/tenant": "edgenet"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "lip6", Labels: map[string]string{"edge-net.io/tenant": "lip6"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "tenant-edgenet"}, Value: 5000},
+	)
+
+	cases := map[string]struct {
+		namespace string
+		expected  string
+	}{
+		"core namespace":        {"edgenet", "tenant-edgenet"},
+		"subsidiary namespace":  {"edgenet-sub", "tenant-edgenet"},
+		"without priority":      {"lip6", ""},
+		"outside of the tenant": {"default", ""},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			priorityClass, err := DefaultPriorityClass(kubeclientset, tc.namespace)
+			util.OK(t, err)
+			var name string
+			if priorityClass != nil {
+				name = priorityClass.GetName()
+			}
+			util.Equals(t, tc.expected, name)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+/*
+Copyright 2022 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/inf.v0"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// RoundingPolicy determines how a quantity that does not divide evenly into the smallest unit of its
+// resource is rounded. The unit is a millicore for CPU and a byte for byte-based resources.
+type RoundingPolicy string
+
+const (
+	// RoundFloor rounds toward zero, e.g. "0.1Gi" of memory becomes 107374182 bytes.
+	RoundFloor RoundingPolicy = "floor"
+	// RoundCeil rounds away from zero, e.g. "0.1Gi" of memory becomes 107374183 bytes.
+	RoundCeil RoundingPolicy = "ceil"
+	// RoundNearest rounds to the nearest unit, and halves away from zero.
+	RoundNearest RoundingPolicy = "nearest"
+)
+
+// ParseRoundingPolicy returns the rounding policy of the given name. An empty name defaults to RoundFloor.
+func ParseRoundingPolicy(value string) (RoundingPolicy, error) {
+	switch policy := RoundingPolicy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case "":
+		return RoundFloor, nil
+	case RoundFloor, RoundCeil, RoundNearest:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown rounding policy %q: must be one of %s, %s, or %s", value, RoundFloor, RoundCeil, RoundNearest)
+}
+
+func (p RoundingPolicy) rounder() inf.Rounder {
+	switch p {
+	case RoundCeil:
+		return inf.RoundCeil
+	case RoundNearest:
+		return inf.RoundHalfUp
+	}
+	return inf.RoundFloor
+}
+
+// ParseResourceQuantity parses the raw value of the given resource and returns it in canonical form.
+// CPU accepts both cores and millicores, e.g. "6" or "6000m", and memory accepts both SI and binary
+// units, e.g. "6G" or "6Gi".
+func ParseResourceQuantity(name corev1.ResourceName, value string) (resource.Quantity, error) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("invalid quantity %q for resource %s: %s", value, name, err)
+	}
+	return NormalizeResourceQuantity(name, quantity)
+}
+
+// NormalizeResourceQuantity converts a quantity to the canonical form of its resource. CPU is expressed
+// in decimal units and byte-based resources in binary units. Quantities whose unit is ambiguous for the
+// resource, such as "6m" of memory or "2Ki" of CPU, are rejected, as are fractions of extended resources.
+func NormalizeResourceQuantity(name corev1.ResourceName, quantity resource.Quantity) (resource.Quantity, error) {
+	if quantity.Sign() < 0 {
+		return resource.Quantity{}, fmt.Errorf("invalid quantity %q for resource %s: must not be negative", quantity.String(), name)
+	}
+	switch {
+	case isCPUResource(name):
+		if quantity.Format == resource.BinarySI {
+			return resource.Quantity{}, fmt.Errorf("ambiguous quantity %q for resource %s: use cores (e.g. \"6\") or millicores (e.g. \"6000m\")", quantity.String(), name)
+		}
+		if quantity.ScaledValue(resource.Nano)%1000000 != 0 {
+			return resource.Quantity{}, fmt.Errorf("ambiguous quantity %q for resource %s: precision finer than 1m is not allowed", quantity.String(), name)
+		}
+		return *resource.NewMilliQuantity(quantity.MilliValue(), resource.DecimalSI), nil
+	case isByteResource(name):
+		if quantity.MilliValue()%1000 != 0 {
+			return resource.Quantity{}, fmt.Errorf("ambiguous quantity %q for resource %s: fractional bytes are not allowed, did you mean \"M\" or \"Mi\"", quantity.String(), name)
+		}
+		return *resource.NewQuantity(quantity.Value(), resource.BinarySI), nil
+	case isExtendedResource(name):
+		if quantity.MilliValue()%1000 != 0 {
+			return resource.Quantity{}, fmt.Errorf("invalid quantity %q for resource %s: extended resources must be whole numbers", quantity.String(), name)
+		}
+	}
+	return quantity.DeepCopy(), nil
+}
+
+// QuotaResourceName returns the name under which the resource is limited in a resource quota. Extended
+// resources, such as "nvidia.com/gpu", can only be limited on requests, hence "requests.nvidia.com/gpu".
+func QuotaResourceName(name corev1.ResourceName) corev1.ResourceName {
+	if isExtendedResource(name) && !strings.HasPrefix(string(name), corev1.DefaultResourceRequestsPrefix) {
+		return corev1.ResourceName(corev1.DefaultResourceRequestsPrefix + string(name))
+	}
+	return name
+}
+
+// QuotaResourceList returns the resource list with each resource named as in a resource quota. A resource
+// listed both with and without the requests prefix, e.g. "nvidia.com/gpu" and "requests.nvidia.com/gpu",
+// is rejected.
+func QuotaResourceList(resourceList map[corev1.ResourceName]resource.Quantity) (map[corev1.ResourceName]resource.Quantity, error) {
+	if resourceList == nil {
+		return nil, nil
+	}
+	quotaResourceList := make(map[corev1.ResourceName]resource.Quantity, len(resourceList))
+	for name, quantity := range resourceList {
+		quotaName := QuotaResourceName(name)
+		if _, elementExists := quotaResourceList[quotaName]; elementExists {
+			return nil, fmt.Errorf("resource %s is listed more than once", quotaName)
+		}
+		quotaResourceList[quotaName] = quantity
+	}
+	return quotaResourceList, nil
+}
+
+// NormalizeResourceList applies NormalizeResourceQuantity to each element of the resource list.
+func NormalizeResourceList(resourceList map[corev1.ResourceName]resource.Quantity) (map[corev1.ResourceName]resource.Quantity, error) {
+	if resourceList == nil {
+		return nil, nil
+	}
+	normalizedResourceList := make(map[corev1.ResourceName]resource.Quantity, len(resourceList))
+	for name, quantity := range resourceList {
+		normalizedQuantity, err := NormalizeResourceQuantity(name, quantity)
+		if err != nil {
+			return nil, err
+		}
+		normalizedResourceList[name] = normalizedQuantity
+	}
+	return normalizedResourceList, nil
+}
+
+// ParseResourceList parses a comma-separated list of resources and their quantities, such as "cpu=100m,memory=64Mi",
+// each quantity being parsed with ParseResourceQuantity. An empty value results in an empty list.
+func ParseResourceList(value string) (map[corev1.ResourceName]resource.Quantity, error) {
+	resourceList := make(map[corev1.ResourceName]resource.Quantity)
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element == "" {
+			continue
+		}
+		pair := strings.SplitN(element, "=", 2)
+		if len(pair) != 2 || strings.TrimSpace(pair[0]) == "" {
+			return nil, fmt.Errorf("invalid resource %q: must be of the form name=quantity", element)
+		}
+		name := corev1.ResourceName(strings.TrimSpace(pair[0]))
+		if _, elementExists := resourceList[name]; elementExists {
+			return nil, fmt.Errorf("resource %s is listed more than once", name)
+		}
+		quantity, err := ParseResourceQuantity(name, strings.TrimSpace(pair[1]))
+		if err != nil {
+			return nil, err
+		}
+		resourceList[name] = quantity
+	}
+	return resourceList, nil
+}
+
+// ParseResourceNames parses a comma-separated list of resource names, such as "pods,services", named as in a resource
+// quota. An empty value results in an empty list.
+func ParseResourceNames(value string) []corev1.ResourceName {
+	var resourceNames []corev1.ResourceName
+	for _, element := range strings.Split(value, ",") {
+		if element = strings.TrimSpace(element); element == "" {
+			continue
+		}
+		resourceNames = append(resourceNames, QuotaResourceName(corev1.ResourceName(element)))
+	}
+	return resourceNames
+}
+
+// RoundResourceQuantity rounds the quantity to the smallest unit of its resource according to the policy
+// and returns it in canonical form. A nonzero quantity below that unit, such as "6m" of memory, is still
+// rejected as ambiguous rather than being rounded to zero or one byte.
+func RoundResourceQuantity(name corev1.ResourceName, quantity resource.Quantity, policy RoundingPolicy) (resource.Quantity, error) {
+	var scale inf.Scale
+	var unitName string
+	switch {
+	case isCPUResource(name):
+		if quantity.Format == resource.BinarySI {
+			return NormalizeResourceQuantity(name, quantity)
+		}
+		scale, unitName = 3, "1m"
+	case isByteResource(name):
+		scale, unitName = 0, "1 byte"
+	default:
+		return NormalizeResourceQuantity(name, quantity)
+	}
+	if quantity.Sign() < 0 {
+		return NormalizeResourceQuantity(name, quantity)
+	}
+	unit := inf.NewDec(1, scale)
+	decimal := quantity.AsDec()
+	if decimal.Sign() > 0 && decimal.Cmp(unit) < 0 {
+		return resource.Quantity{}, fmt.Errorf("ambiguous quantity %q for resource %s: it is smaller than %s", quantity.String(), name, unitName)
+	}
+	rounded := new(inf.Dec).Round(decimal, scale, policy.rounder())
+	if scale == 3 {
+		return NormalizeResourceQuantity(name, *resource.NewMilliQuantity(rounded.UnscaledBig().Int64(), resource.DecimalSI))
+	}
+	return NormalizeResourceQuantity(name, *resource.NewQuantity(rounded.UnscaledBig().Int64(), resource.BinarySI))
+}
+
+// RoundResourceList applies RoundResourceQuantity to each element of the resource list.
+func RoundResourceList(resourceList map[corev1.ResourceName]resource.Quantity, policy RoundingPolicy) (map[corev1.ResourceName]resource.Quantity, error) {
+	if resourceList == nil {
+		return nil, nil
+	}
+	roundedResourceList := make(map[corev1.ResourceName]resource.Quantity, len(resourceList))
+	for name, quantity := range resourceList {
+		roundedQuantity, err := RoundResourceQuantity(name, quantity, policy)
+		if err != nil {
+			return nil, err
+		}
+		roundedResourceList[name] = roundedQuantity
+	}
+	return roundedResourceList, nil
+}
+
+func isCPUResource(name corev1.ResourceName) bool {
+	return name == corev1.ResourceCPU || name == corev1.ResourceRequestsCPU || name == corev1.ResourceLimitsCPU
+}
+
+func isByteResource(name corev1.ResourceName) bool {
+	switch name {
+	case corev1.ResourceMemory, corev1.ResourceRequestsMemory, corev1.ResourceLimitsMemory,
+		corev1.ResourceStorage, corev1.ResourceRequestsStorage,
+		corev1.ResourceEphemeralStorage, corev1.ResourceRequestsEphemeralStorage, corev1.ResourceLimitsEphemeralStorage:
+		return true
+	}
+	return strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix) ||
+		strings.HasPrefix(string(name), corev1.ResourceRequestsHugePagesPrefix)
+}
+
+// isExtendedResource reports whether the resource is an extended resource, such as "nvidia.com/gpu",
+// with or without the requests prefix.
+func isExtendedResource(name corev1.ResourceName) bool {
+	trimmed := strings.TrimPrefix(string(name), corev1.DefaultResourceRequestsPrefix)
+	return strings.Contains(trimmed, "/") && !strings.HasPrefix(trimmed, corev1.ResourceDefaultNamespacePrefix)
+}
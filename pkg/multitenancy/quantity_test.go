@@ -0,0 +1,182 @@
+package multitenancy
+
+import (
+	"testing"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseResourceQuantity(t *testing.T) {
+	cases := map[string]struct {
+		name     corev1.ResourceName
+		value    string
+		expected string
+		fails    bool
+	}{
+		"cpu in cores":               {corev1.ResourceCPU, "6", "6", false},
+		"cpu in millicores":          {corev1.ResourceCPU, "6000m", "6", false},
+		"cpu fraction":               {corev1.ResourceCPU, "0.5", "500m", false},
+		"cpu with binary suffix":     {corev1.ResourceCPU, "2Ki", "", true},
+		"cpu finer than millicore":   {corev1.ResourceCPU, "100u", "", true},
+		"memory in binary units":     {corev1.ResourceMemory, "6Gi", "6Gi", false},
+		"memory in SI units":         {corev1.ResourceMemory, "6G", "5859375Ki", false},
+		"memory in bytes":            {corev1.ResourceMemory, "1024", "1Ki", false},
+		"memory in millibytes":       {corev1.ResourceMemory, "6m", "", true},
+		"storage in SI units":        {corev1.ResourceEphemeralStorage, "1M", "1000000", false},
+		"hugepages in binary units":  {corev1.ResourceName("hugepages-2Mi"), "2Mi", "2Mi", false},
+		"negative memory":            {corev1.ResourceMemory, "-1Gi", "", true},
+		"malformed quantity":         {corev1.ResourceMemory, "6 Gi", "", true},
+		"other resources unaffected": {corev1.ResourcePods, "100", "100", false},
+		"extended resource":          {corev1.ResourceName("nvidia.com/gpu"), "2", "2", false},
+		"extended resource fraction": {corev1.ResourceName("requests.nvidia.com/gpu"), "500m", "", true},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			quantity, err := ParseResourceQuantity(tc.name, tc.value)
+			if tc.fails {
+				util.NotEquals(t, nil, err)
+				return
+			}
+			util.OK(t, err)
+			util.Equals(t, tc.expected, quantity.String())
+		})
+	}
+}
+
+func TestNormalizeResourceList(t *testing.T) {
+	binary := map[corev1.ResourceName]resource.Quantity{
+		corev1.ResourceCPU:    resource.MustParse("6000m"),
+		corev1.ResourceMemory: resource.MustParse("6Gi"),
+	}
+	mixed := map[corev1.ResourceName]resource.Quantity{
+		corev1.ResourceCPU:    resource.MustParse("6"),
+		corev1.ResourceMemory: resource.MustParse("6442450944"),
+	}
+	normalizedBinary, err := NormalizeResourceList(binary)
+	util.OK(t, err)
+	normalizedMixed, err := NormalizeResourceList(mixed)
+	util.OK(t, err)
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		binaryQuantity := normalizedBinary[name]
+		mixedQuantity := normalizedMixed[name]
+		util.Equals(t, binaryQuantity.String(), mixedQuantity.String())
+	}
+
+	_, err = NormalizeResourceList(map[corev1.ResourceName]resource.Quantity{corev1.ResourceMemory: resource.MustParse("6m")})
+	util.NotEquals(t, nil, err)
+
+	normalizedNil, err := NormalizeResourceList(nil)
+	util.OK(t, err)
+	util.Equals(t, true, normalizedNil == nil)
+}
+
+func TestRoundResourceQuantity(t *testing.T) {
+	cases := map[string]struct {
+		name     corev1.ResourceName
+		value    string
+		policy   RoundingPolicy
+		expected string
+		fails    bool
+	}{
+		"cpu floor":                  {corev1.ResourceCPU, "0.3335", RoundFloor, "333m", false},
+		"cpu ceil":                   {corev1.ResourceCPU, "0.3331", RoundCeil, "334m", false},
+		"cpu nearest down":           {corev1.ResourceCPU, "0.3334", RoundNearest, "333m", false},
+		"cpu nearest half":           {corev1.ResourceCPU, "0.3335", RoundNearest, "334m", false},
+		"cpu even":                   {corev1.ResourceCPU, "6000m", RoundCeil, "6", false},
+		"memory floor":               {corev1.ResourceMemory, "0.1Gi", RoundFloor, "107374182", false},
+		"memory ceil":                {corev1.ResourceMemory, "0.1Gi", RoundCeil, "107374183", false},
+		"memory nearest":             {corev1.ResourceMemory, "1.1Ki", RoundNearest, "1126", false},
+		"memory even":                {corev1.ResourceMemory, "6Gi", RoundFloor, "6Gi", false},
+		"cpu below a millicore":      {corev1.ResourceCPU, "100u", RoundCeil, "", true},
+		"memory below a byte":        {corev1.ResourceMemory, "6m", RoundCeil, "", true},
+		"cpu with binary suffix":     {corev1.ResourceCPU, "2Ki", RoundFloor, "", true},
+		"negative memory":            {corev1.ResourceMemory, "-1.5Gi", RoundFloor, "", true},
+		"other resources unaffected": {corev1.ResourcePods, "100", RoundCeil, "100", false},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			quantity, err := RoundResourceQuantity(tc.name, resource.MustParse(tc.value), tc.policy)
+			if tc.fails {
+				util.NotEquals(t, nil, err)
+				return
+			}
+			util.OK(t, err)
+			util.Equals(t, tc.expected, quantity.String())
+		})
+	}
+}
+
+func TestQuotaResourceName(t *testing.T) {
+	cases := map[string]struct {
+		name     corev1.ResourceName
+		expected corev1.ResourceName
+	}{
+		"cpu":                        {corev1.ResourceCPU, corev1.ResourceCPU},
+		"requests memory":            {corev1.ResourceRequestsMemory, corev1.ResourceRequestsMemory},
+		"hugepages":                  {corev1.ResourceName("hugepages-2Mi"), corev1.ResourceName("hugepages-2Mi")},
+		"kubernetes.io resource":     {corev1.ResourceName("kubernetes.io/batch"), corev1.ResourceName("kubernetes.io/batch")},
+		"extended resource":          {corev1.ResourceName("nvidia.com/gpu"), corev1.ResourceName("requests.nvidia.com/gpu")},
+		"prefixed extended resource": {corev1.ResourceName("requests.nvidia.com/gpu"), corev1.ResourceName("requests.nvidia.com/gpu")},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			util.Equals(t, tc.expected, QuotaResourceName(tc.name))
+		})
+	}
+}
+
+func TestQuotaResourceList(t *testing.T) {
+	quotaResourceList, err := QuotaResourceList(map[corev1.ResourceName]resource.Quantity{
+		corev1.ResourceCPU: resource.MustParse("2"),
+		"example.com/fpga": resource.MustParse("1"),
+	})
+	util.OK(t, err)
+	util.Equals(t, 2, len(quotaResourceList))
+	fpgaQuantity := quotaResourceList["requests.example.com/fpga"]
+	util.Equals(t, "1", fpgaQuantity.String())
+
+	_, err = QuotaResourceList(map[corev1.ResourceName]resource.Quantity{
+		"nvidia.com/gpu":          resource.MustParse("1"),
+		"requests.nvidia.com/gpu": resource.MustParse("2"),
+	})
+	util.NotEquals(t, nil, err)
+
+	quotaResourceList, err = QuotaResourceList(nil)
+	util.OK(t, err)
+	util.Equals(t, true, quotaResourceList == nil)
+}
+
+func TestParseRoundingPolicy(t *testing.T) {
+	policy, err := ParseRoundingPolicy("")
+	util.OK(t, err)
+	util.Equals(t, RoundFloor, policy)
+	policy, err = ParseRoundingPolicy(" Nearest ")
+	util.OK(t, err)
+	util.Equals(t, RoundNearest, policy)
+	_, err = ParseRoundingPolicy("truncate")
+	util.NotEquals(t, nil, err)
+}
+
+func TestParseResourceList(t *testing.T) {
+	resourceList, err := ParseResourceList(" cpu=0.1, memory=64Mi,")
+	util.OK(t, err)
+	util.Equals(t, 2, len(resourceList))
+	cpu, memory := resourceList[corev1.ResourceCPU], resourceList[corev1.ResourceMemory]
+	util.Equals(t, "100m", cpu.String())
+	util.Equals(t, "64Mi", memory.String())
+	resourceList, err = ParseResourceList("")
+	util.OK(t, err)
+	util.Equals(t, 0, len(resourceList))
+	for _, value := range []string{"cpu", "=1", "cpu=1,cpu=2", "memory=6m"} {
+		_, err := ParseResourceList(value)
+		util.NotEquals(t, nil, err)
+	}
+}
+
+func TestParseResourceNames(t *testing.T) {
+	util.Equals(t, []corev1.ResourceName{corev1.ResourcePods, "requests.nvidia.com/gpu"}, ParseResourceNames(" pods, nvidia.com/gpu,"))
+	util.Equals(t, 0, len(ParseResourceNames("")))
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2022 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// QuotaNames are the names of the resource quotas holding the share of a tenant in its core namespace
+// and the share of a subnamespace in its child namespace.
+type QuotaNames struct {
+	Core string
+	Sub  string
+}
+
+// DefaultQuotaNames are the resource quota names used unless configured otherwise
+var DefaultQuotaNames = QuotaNames{Core: "core-quota", Sub: "sub-quota"}
+
+// ForKind returns the name of the resource quota in a namespace of the given kind, core or sub.
+func (q QuotaNames) ForKind(kind string) string {
+	if strings.ToLower(kind) == "core" {
+		return q.Core
+	}
+	return q.Sub
+}
+
+// QuotaExemptLabel marks, when set to true, a namespace of a tenant whose quota is not accounted against the tenant
+// quota, such as a monitoring namespace
+const QuotaExemptLabel = "edge-net.io/quota-exempt"
+
+// IsQuotaExempt tells whether the labels of a namespace exempt it from quota accounting
+func IsQuotaExempt(namespaceLabels map[string]string) bool {
+	return namespaceLabels[QuotaExemptLabel] == "true"
+}
+
+// IsSubNamespaceQuotaExempt tells whether the child namespace of the subnamespace is labeled as exempt from quota
+// accounting, in which case its allocation is not debited from the parent. The label is set on the namespace rather
+// than on the subnamespace, so that it stays out of the hands of the tenant.
+func IsSubNamespaceQuotaExempt(kubeclientset kubernetes.Interface, subnamespace corev1alpha1.SubNamespace) bool {
+	if subnamespace.Status.Child == nil {
+		return false
+	}
+	childNamespace, err := kubeclientset.CoreV1().Namespaces().Get(context.TODO(), *subnamespace.Status.Child, metav1.GetOptions{})
+	return err == nil && IsQuotaExempt(childNamespace.GetLabels())
+}
+
+// ChildOnlyResources lists the resources that only limit the child namespace of a subnamespace, such as pods, which
+// are not debited from the quota of its parent so that a child can be capped on them without taking from the budget
+// of its parent. The subnamespace and tenant resource quota controllers must be given the same list.
+type ChildOnlyResources []corev1.ResourceName
+
+// Contains tells whether the resource, named as in a resource quota or in an allocation, only limits the child
+func (r ChildOnlyResources) Contains(key corev1.ResourceName) bool {
+	for _, resourceName := range r {
+		if QuotaResourceName(resourceName) == QuotaResourceName(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// AggregateQuota sums the net resources of the tenant resource quotas granted to a tenant, that is, its claims minus
+// its drops.
+func AggregateQuota(tenantResourceQuotas ...corev1alpha1.TenantResourceQuota) map[corev1.ResourceName]resource.Quantity {
+	aggregatedQuota := make(map[corev1.ResourceName]resource.Quantity)
+	for _, tenantResourceQuota := range tenantResourceQuotas {
+		for key, value := range tenantResourceQuota.Fetch() {
+			quantity := aggregatedQuota[key]
+			quantity.Add(value)
+			aggregatedQuota[key] = quantity
+		}
+	}
+	return aggregatedQuota
+}
+
+// TenantQuota returns the effective quota of the tenant, which aggregates the tenant resource quota named after the
+// tenant and the additional ones labeled for it. It fails if the tenant has no tenant resource quota of its own.
+func TenantQuota(edgenetclientset clientset.Interface, tenant string) (map[corev1.ResourceName]resource.Quantity, error) {
+	tenantResourceQuota, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().Get(context.TODO(), tenant, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	tenantResourceQuotas := []corev1alpha1.TenantResourceQuota{*tenantResourceQuota}
+	tenantResourceQuotaRaw, err := edgenetclientset.CoreV1alpha1().TenantResourceQuotas().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/tenant=%s", tenant)})
+	if err != nil {
+		return nil, err
+	}
+	for _, tenantResourceQuotaRow := range tenantResourceQuotaRaw.Items {
+		if tenantResourceQuotaRow.GetName() != tenant {
+			tenantResourceQuotas = append(tenantResourceQuotas, tenantResourceQuotaRow)
+		}
+	}
+	return AggregateQuota(tenantResourceQuotas...), nil
+}
+
+// TenantQuotaUsage sums up the usage in the resource quotas of the tenant's namespaces, leaving out those exempt from
+// quota accounting.
+func TenantQuotaUsage(kubeclientset kubernetes.Interface, tenant string) map[corev1.ResourceName]resource.Quantity {
+	usedResourceList := make(map[corev1.ResourceName]resource.Quantity)
+	if namespaceRaw, err := kubeclientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/tenant=%s", tenant)}); err == nil {
+		for _, namespaceRow := range namespaceRaw.Items {
+			if IsQuotaExempt(namespaceRow.GetLabels()) {
+				continue
+			}
+			resourceQuotaRaw, err := kubeclientset.CoreV1().ResourceQuotas(namespaceRow.GetName()).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				continue
+			}
+			for _, resourceQuotaRow := range resourceQuotaRaw.Items {
+				for key, value := range resourceQuotaRow.Status.Used {
+					used := usedResourceList[key]
+					used.Add(value)
+					usedResourceList[key] = used
+				}
+			}
+		}
+	}
+	return usedResourceList
+}
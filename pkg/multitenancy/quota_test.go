@@ -0,0 +1,46 @@
+package multitenancy
+
+import (
+	"context"
+	"testing"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestQuotaNamesForKind(t *testing.T) {
+	quotaNames := QuotaNames{Core: "tenant-share", Sub: "workspace-share"}
+	util.Equals(t, "tenant-share", quotaNames.ForKind("core"))
+	util.Equals(t, "workspace-share", quotaNames.ForKind("sub"))
+	util.Equals(t, "core-quota", DefaultQuotaNames.ForKind("core"))
+	util.Equals(t, "sub-quota", DefaultQuotaNames.ForKind("sub"))
+}
+
+func TestIsSubNamespaceQuotaExempt(t *testing.T) {
+	kubeclientset := fake.NewSimpleClientset()
+	exempt := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "monitoring", Labels: map[string]string{QuotaExemptLabel: "true"}}}
+	accounted := corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "workspace"}}
+	_, err := kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &exempt, metav1.CreateOptions{})
+	util.OK(t, err)
+	_, err = kubeclientset.CoreV1().Namespaces().Create(context.TODO(), &accounted, metav1.CreateOptions{})
+	util.OK(t, err)
+
+	cases := map[string]struct {
+		child    *string
+		expected bool
+	}{
+		"exempt":    {&exempt.Name, true},
+		"accounted": {&accounted.Name, false},
+		"missing":   {nil, false},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			subnamespace := corev1alpha1.SubNamespace{Status: corev1alpha1.SubNamespaceStatus{Child: tc.child}}
+			util.Equals(t, tc.expected, IsSubNamespaceQuotaExempt(kubeclientset, subnamespace))
+		})
+	}
+}
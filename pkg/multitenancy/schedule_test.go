@@ -0,0 +1,52 @@
+package multitenancy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+)
+
+func TestParseSchedule(t *testing.T) {
+	valid := []string{"* * * * *", "0 2 * * *", "*/15 0-6,22-23 1 1-12/2 1-5", "@daily", "@every 90s", " 30 4 * * 0 "}
+	for _, expression := range valid {
+		_, err := ParseSchedule(expression)
+		util.OK(t, err)
+	}
+	invalid := []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 7", "5-1 * * * *", "*/0 * * * *", "a * * * *", "@often", "@every 1ms", "@every soon"}
+	for _, expression := range invalid {
+		_, err := ParseSchedule(expression)
+		util.NotEquals(t, nil, err)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// Friday
+	now := time.Date(2022, time.July, 15, 13, 42, 10, 0, time.UTC)
+	cases := map[string]struct {
+		expression string
+		expected   time.Time
+	}{
+		"every minute":         {"* * * * *", time.Date(2022, time.July, 15, 13, 43, 0, 0, time.UTC)},
+		"nightly":              {"0 2 * * *", time.Date(2022, time.July, 16, 2, 0, 0, 0, time.UTC)},
+		"steps":                {"*/20 * * * *", time.Date(2022, time.July, 15, 14, 0, 0, 0, time.UTC)},
+		"offset steps":         {"5/20 * * * *", time.Date(2022, time.July, 15, 13, 45, 0, 0, time.UTC)},
+		"weekdays":             {"0 9 * * 1-5", time.Date(2022, time.July, 18, 9, 0, 0, 0, time.UTC)},
+		"monthly":              {"@monthly", time.Date(2022, time.August, 1, 0, 0, 0, 0, time.UTC)},
+		"yearly":               {"@yearly", time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		"day of month or week": {"0 0 20 * 0", time.Date(2022, time.July, 17, 0, 0, 0, 0, time.UTC)},
+		"leap day":             {"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		"fixed interval":       {"@every 2s", now.Add(2 * time.Second)},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			schedule, err := ParseSchedule(tc.expression)
+			util.OK(t, err)
+			util.Equals(t, tc.expected, schedule.Next(now))
+		})
+	}
+
+	schedule, err := ParseSchedule("0 0 31 2 *")
+	util.OK(t, err)
+	util.Equals(t, true, schedule.Next(now).IsZero())
+}
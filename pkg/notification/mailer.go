@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
@@ -52,13 +53,15 @@ func (c *Content) email(purpose string) error {
 		klog.Infoln(err)
 		return err
 	}
-	var htmlBody bytes.Buffer
 	pathTemplate := "./email"
 	if flag.Lookup("template-path") != nil {
 		pathTemplate = flag.Lookup("template-path").Value.(flag.Getter).Get().(string)
 	}
-	t, _ := template.ParseFiles(fmt.Sprintf("%s/%s.html", pathTemplate, purpose))
-	t.Execute(&htmlBody, c)
+	htmlBody, err := c.render(pathTemplate, purpose)
+	if err != nil {
+		klog.Infoln(err)
+		return err
+	}
 	// || c.TenantRequest != nil
 	if len(c.Recipient) == 0 {
 		c.Recipient = append(c.Recipient, smtpInfo.To)
@@ -80,6 +83,41 @@ func (c *Content) email(purpose string) error {
 	return err
 }
 
+// render executes the email template of the purpose, in the language of the content's locale if
+// there is a translation of it, and in English otherwise
+func (c *Content) render(pathTemplate, purpose string) (*bytes.Buffer, error) {
+	t, err := template.ParseFiles(getTemplateFile(pathTemplate, purpose, c.Locale))
+	if err != nil {
+		return nil, err
+	}
+	var htmlBody bytes.Buffer
+	if err := t.Execute(&htmlBody, c); err != nil {
+		return nil, err
+	}
+	return &htmlBody, nil
+}
+
+// getTemplateFile looks up the template in the directory of the locale, e.g. "fr-ca", then in that
+// of its language, e.g. "fr", and falls back to the default, English template
+func getTemplateFile(pathTemplate, purpose, locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	language, _, _ := strings.Cut(locale, "-")
+	for _, dir := range []string{locale, language} {
+		if dir == "" {
+			continue
+		}
+		if file := fmt.Sprintf("%s/%s/%s.html", pathTemplate, dir, purpose); fileExists(file) {
+			return file
+		}
+	}
+	return fmt.Sprintf("%s/%s.html", pathTemplate, purpose)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
 func getSMTPInformation() (*smtpServer, error) {
 	// The code below inits the SMTP configuration for sending emails
 	// The path of the yaml config file of smtp server
@@ -2,11 +2,15 @@ package notification
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/EdgeNet-project/edgenet/pkg/util"
 	"github.com/sirupsen/logrus"
 
 	"k8s.io/klog"
@@ -23,6 +27,60 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+func TestRenderLocalizedTemplate(t *testing.T) {
+	pathTemplate := t.TempDir()
+	util.OK(t, os.Mkdir(filepath.Join(pathTemplate, "fr"), 0755))
+	util.OK(t, os.Mkdir(filepath.Join(pathTemplate, "pt-br"), 0755))
+	util.OK(t, ioutil.WriteFile(filepath.Join(pathTemplate, "greeting.html"), []byte("Dear {{.FirstName}}"), 0644))
+	util.OK(t, ioutil.WriteFile(filepath.Join(pathTemplate, "fr", "greeting.html"), []byte("Bonjour {{.FirstName}}"), 0644))
+	util.OK(t, ioutil.WriteFile(filepath.Join(pathTemplate, "pt-br", "greeting.html"), []byte("Prezado {{.FirstName}}"), 0644))
+
+	cases := map[string]struct {
+		locale   string
+		expected string
+	}{
+		"default":            {"", "Dear John"},
+		"french":             {"fr", "Bonjour John"},
+		"regional french":    {"fr_CA", "Bonjour John"},
+		"regional portugese": {"pt-BR", "Prezado John"},
+		"portugese":          {"pt", "Dear John"},
+		"untranslated":       {"de", "Dear John"},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			content := &Content{FirstName: "John", Locale: tc.locale}
+			htmlBody, err := content.render(pathTemplate, "greeting")
+			util.OK(t, err)
+			util.Equals(t, tc.expected, htmlBody.String())
+		})
+	}
+
+	content := &Content{Locale: "fr"}
+	_, err := content.render(pathTemplate, "missing")
+	util.NotEquals(t, nil, err)
+}
+
+func TestRenderFrenchRoleRequestTemplates(t *testing.T) {
+	content := new(Content)
+	content.Init("Jean", "Dupont", "jean.dupont@edge-net.org", "[EdgeNet] Role request approved", "cluster-uid", []string{"jean.dupont@edge-net.org"})
+	content.RoleRequest = &RoleRequest{Name: "jeandupont", Namespace: "edgenet"}
+	for _, purpose := range []string{"role-request-approved", "role-request-made"} {
+		content.Locale = "fr"
+		htmlBody, err := content.render("../../assets/templates/email", purpose)
+		util.OK(t, err)
+		util.Equals(t, true, strings.Contains(htmlBody.String(), "Cordialement"))
+		util.Equals(t, true, strings.Contains(htmlBody.String(), "edgenet"))
+
+		content.Locale = ""
+		htmlBody, err = content.render("../../assets/templates/email", purpose)
+		util.OK(t, err)
+		util.Equals(t, true, strings.Contains(htmlBody.String(), "Sincerely"))
+	}
+	htmlBody, err := content.render("../../assets/templates/email", "role-request-approved")
+	util.OK(t, err)
+	util.Equals(t, true, strings.Contains(htmlBody.String(), fmt.Sprintf("Dear %s %s", content.FirstName, content.LastName)))
+}
+
 /*func TestNotification(t *testing.T) {
 	var smtpServer smtpServer
 	// The code below inits the SMTP configuration for sending emails
@@ -24,9 +24,13 @@ type Content struct {
 	LastName           string
 	Subject            string
 	Recipient          []string
+	Locale             string
 	RoleRequest        *RoleRequest
 	TenantRequest      *TenantRequest
 	ClusterRoleRequest *ClusterRoleRequest
+	QuotaAlert         *QuotaAlert
+	ClaimExpiry        *ClaimExpiry
+	ReconcileStuck     *ReconcileStuck
 }
 
 // RoleRequest is the structure for the role request
@@ -45,6 +49,37 @@ type TenantRequest struct {
 	Tenant string
 }
 
+// QuotaAlert is the structure for the alert on the quota utilization of a tenant
+type QuotaAlert struct {
+	Tenant    string
+	Resource  string
+	Threshold int
+	Used      string
+	Allocated string
+}
+
+// ClaimExpiry is the structure for the notice of the claims of a tenant about to expire
+type ClaimExpiry struct {
+	Tenant    string
+	Resources []ExpiringResource
+}
+
+// ExpiringResource is a resource of a claim along with its expiration date
+type ExpiringResource struct {
+	Claim    string
+	Resource string
+	Quantity string
+	Expiry   string
+}
+
+// ReconcileStuck is the structure for the alert on an object that repeatedly fails to sync
+type ReconcileStuck struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Failures  int
+}
+
 // Init is the function to initialize info for the notification content
 func (c *Content) Init(firstname, lastname, email, subject, clusterUID string, recipient []string) {
 	c.Cluster = clusterUID
@@ -59,7 +94,8 @@ func (c *Content) Init(firstname, lastname, email, subject, clusterUID string, r
 func (c *Content) SendNotification(purpose string) error {
 	var err error
 	err = c.email(purpose)
-	if c.RoleRequest == nil {
+	// Quota alerts and expiry notices are for tenant owners only, so they are not relayed to the cluster admins on Slack
+	if c.RoleRequest == nil && c.QuotaAlert == nil && c.ClaimExpiry == nil {
 		err = c.slack(purpose)
 	}
 	return err
@@ -93,6 +93,8 @@ func (c *Content) getRequestInformation() string {
 		return fmt.Sprintf("Name: %s, Namespace: %s", c.RoleRequest.Name, c.RoleRequest.Namespace)
 	} else if c.TenantRequest != nil {
 		return fmt.Sprintf("Name: %s", c.TenantRequest.Tenant)
+	} else if c.ReconcileStuck != nil {
+		return fmt.Sprintf("Kind: %s, Name: %s, Namespace: %s, Failures: %d", c.ReconcileStuck.Kind, c.ReconcileStuck.Name, c.ReconcileStuck.Namespace, c.ReconcileStuck.Failures)
 	} else {
 		return fmt.Sprintf("Name: %s", c.ClusterRoleRequest.Name)
 	}
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package permission applies the cluster roles and cluster role bindings that operators declare in a policy file,
+// so that the permissions of the authorities can be kept as code next to those generated by the controllers.
+package permission
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// Policy is the document of a policy file, listing the cluster roles and cluster role bindings to apply
+type Policy struct {
+	ClusterRoles        []rbacv1.ClusterRole        `json:"clusterRoles,omitempty"`
+	ClusterRoleBindings []rbacv1.ClusterRoleBinding `json:"clusterRoleBindings,omitempty"`
+}
+
+// ApplyPolicyFile parses the YAML policy document at the path and creates the cluster roles and cluster role
+// bindings it declares, or updates them to match the document if they exist already. Nothing is applied unless
+// the whole document is valid.
+func ApplyPolicyFile(clientset kubernetes.Interface, path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("policy file %s cannot be read: %w", path, err)
+	}
+	policy, err := ParsePolicy(content)
+	if err != nil {
+		return fmt.Errorf("policy file %s is invalid: %w", path, err)
+	}
+	for _, clusterRole := range policy.ClusterRoles {
+		if err := applyClusterRole(clientset, clusterRole.DeepCopy()); err != nil {
+			return err
+		}
+	}
+	for _, clusterRoleBinding := range policy.ClusterRoleBindings {
+		if err := applyClusterRoleBinding(clientset, clusterRoleBinding.DeepCopy()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParsePolicy parses a YAML policy document, rejecting unknown fields, and validates its structure
+func ParsePolicy(content []byte) (*Policy, error) {
+	policy := new(Policy)
+	if err := yaml.UnmarshalStrict(content, policy); err != nil {
+		return nil, err
+	}
+	if problems := policy.validate(); len(problems) != 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return policy, nil
+}
+
+// validate returns a message for each problem in the structure of the policy
+func (p *Policy) validate() []string {
+	var problems []string
+	clusterRoleNames := make(map[string]bool)
+	for i, clusterRole := range p.ClusterRoles {
+		name := clusterRole.GetName()
+		switch {
+		case name == "":
+			problems = append(problems, fmt.Sprintf("cluster role %d has no name", i))
+		case clusterRoleNames[name]:
+			problems = append(problems, fmt.Sprintf("cluster role %s is declared more than once", name))
+		}
+		clusterRoleNames[name] = true
+		for j, rule := range clusterRole.Rules {
+			if len(rule.Verbs) == 0 {
+				problems = append(problems, fmt.Sprintf("rule %d of cluster role %s has no verbs", j, name))
+			}
+			if len(rule.Resources) == 0 && len(rule.NonResourceURLs) == 0 {
+				problems = append(problems, fmt.Sprintf("rule %d of cluster role %s has neither resources nor non-resource URLs", j, name))
+			}
+		}
+	}
+	clusterRoleBindingNames := make(map[string]bool)
+	for i, clusterRoleBinding := range p.ClusterRoleBindings {
+		name := clusterRoleBinding.GetName()
+		switch {
+		case name == "":
+			problems = append(problems, fmt.Sprintf("cluster role binding %d has no name", i))
+		case clusterRoleBindingNames[name]:
+			problems = append(problems, fmt.Sprintf("cluster role binding %s is declared more than once", name))
+		}
+		clusterRoleBindingNames[name] = true
+		if roleRef := clusterRoleBinding.RoleRef; roleRef.Kind != "ClusterRole" || roleRef.APIGroup != rbacv1.GroupName || roleRef.Name == "" {
+			problems = append(problems, fmt.Sprintf("cluster role binding %s must refer to a cluster role by name in the %s group", name, rbacv1.GroupName))
+		}
+		if len(clusterRoleBinding.Subjects) == 0 {
+			problems = append(problems, fmt.Sprintf("cluster role binding %s has no subjects", name))
+		}
+		for j, subject := range clusterRoleBinding.Subjects {
+			switch {
+			case subject.Name == "":
+				problems = append(problems, fmt.Sprintf("subject %d of cluster role binding %s has no name", j, name))
+			case subject.Kind == rbacv1.ServiceAccountKind && subject.Namespace == "":
+				problems = append(problems, fmt.Sprintf("service account %s bound by cluster role binding %s has no namespace", subject.Name, name))
+			case subject.Kind != rbacv1.UserKind && subject.Kind != rbacv1.GroupKind && subject.Kind != rbacv1.ServiceAccountKind:
+				problems = append(problems, fmt.Sprintf("subject %s of cluster role binding %s has unknown kind %q", subject.Name, name, subject.Kind))
+			}
+		}
+	}
+	return problems
+}
+
+// applyClusterRole creates the cluster role, or updates the existing one to match it
+func applyClusterRole(clientset kubernetes.Interface, clusterRole *rbacv1.ClusterRole) error {
+	_, err := clientset.RbacV1().ClusterRoles().Create(context.TODO(), clusterRole, metav1.CreateOptions{})
+	if !errors.IsAlreadyExists(err) {
+		return err
+	}
+	currentClusterRole, err := clientset.RbacV1().ClusterRoles().Get(context.TODO(), clusterRole.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(currentClusterRole.Rules, clusterRole.Rules) && reflect.DeepEqual(currentClusterRole.AggregationRule, clusterRole.AggregationRule) && hasLabels(currentClusterRole.GetLabels(), clusterRole.GetLabels()) {
+		return nil
+	}
+	currentClusterRole.Rules = clusterRole.Rules
+	currentClusterRole.AggregationRule = clusterRole.AggregationRule
+	currentClusterRole.SetLabels(withLabels(currentClusterRole.GetLabels(), clusterRole.GetLabels()))
+	_, err = clientset.RbacV1().ClusterRoles().Update(context.TODO(), currentClusterRole, metav1.UpdateOptions{})
+	return err
+}
+
+// applyClusterRoleBinding creates the cluster role binding, or updates the existing one to match it. As the role a
+// binding refers to cannot be changed, a binding referring to another role is re-created.
+func applyClusterRoleBinding(clientset kubernetes.Interface, clusterRoleBinding *rbacv1.ClusterRoleBinding) error {
+	_, err := clientset.RbacV1().ClusterRoleBindings().Create(context.TODO(), clusterRoleBinding, metav1.CreateOptions{})
+	if !errors.IsAlreadyExists(err) {
+		return err
+	}
+	currentClusterRoleBinding, err := clientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), clusterRoleBinding.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if currentClusterRoleBinding.RoleRef != clusterRoleBinding.RoleRef {
+		if err := clientset.RbacV1().ClusterRoleBindings().Delete(context.TODO(), clusterRoleBinding.GetName(), metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		_, err = clientset.RbacV1().ClusterRoleBindings().Create(context.TODO(), clusterRoleBinding, metav1.CreateOptions{})
+		return err
+	}
+	if reflect.DeepEqual(currentClusterRoleBinding.Subjects, clusterRoleBinding.Subjects) && hasLabels(currentClusterRoleBinding.GetLabels(), clusterRoleBinding.GetLabels()) {
+		return nil
+	}
+	currentClusterRoleBinding.Subjects = clusterRoleBinding.Subjects
+	currentClusterRoleBinding.SetLabels(withLabels(currentClusterRoleBinding.GetLabels(), clusterRoleBinding.GetLabels()))
+	_, err = clientset.RbacV1().ClusterRoleBindings().Update(context.TODO(), currentClusterRoleBinding, metav1.UpdateOptions{})
+	return err
+}
+
+// hasLabels tells whether the labels include the declared ones
+func hasLabels(labels, declaredLabels map[string]string) bool {
+	for key, value := range declaredLabels {
+		if currentValue, elementExists := labels[key]; !elementExists || currentValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// withLabels returns the labels with the declared ones set, leaving the others as they are
+func withLabels(labels, declaredLabels map[string]string) map[string]string {
+	if labels == nil && len(declaredLabels) != 0 {
+		labels = make(map[string]string, len(declaredLabels))
+	}
+	for key, value := range declaredLabels {
+		labels[key] = value
+	}
+	return labels
+}
@@ -0,0 +1,95 @@
+package permission
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+)
+
+const policy = `
+clusterRoles:
+- metadata:
+    name: edgenet:authority-auditor
+    labels:
+      edge-net.io/policy: authorities
+  rules:
+  - apiGroups: ["core.edgenet.io"]
+    resources: ["tenants", "tenantresourcequotas"]
+    verbs: ["get", "list", "watch"]
+clusterRoleBindings:
+- metadata:
+    name: edgenet:authority-auditor
+  roleRef:
+    apiGroup: rbac.authorization.k8s.io
+    kind: ClusterRole
+    name: edgenet:authority-auditor
+  subjects:
+  - apiGroup: rbac.authorization.k8s.io
+    kind: User
+    name: auditor@edge-net.org
+`
+
+func TestApplyPolicyFile(t *testing.T) {
+	kubeclientset := testclient.NewSimpleClientset()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	util.OK(t, ioutil.WriteFile(path, []byte(policy), 0644))
+
+	// Applying the same file again leaves the objects as they are
+	for i := 0; i < 2; i++ {
+		util.OK(t, ApplyPolicyFile(kubeclientset, path))
+		clusterRole, err := kubeclientset.RbacV1().ClusterRoles().Get(context.TODO(), "edgenet:authority-auditor", metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, "authorities", clusterRole.GetLabels()["edge-net.io/policy"])
+		util.Equals(t, []string{"tenants", "tenantresourcequotas"}, clusterRole.Rules[0].Resources)
+		clusterRoleBinding, err := kubeclientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), "edgenet:authority-auditor", metav1.GetOptions{})
+		util.OK(t, err)
+		util.Equals(t, "edgenet:authority-auditor", clusterRoleBinding.RoleRef.Name)
+		util.Equals(t, []rbacv1.Subject{{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: "auditor@edge-net.org"}}, clusterRoleBinding.Subjects)
+	}
+
+	// The objects are brought back in line with the file
+	clusterRoleBinding, err := kubeclientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), "edgenet:authority-auditor", metav1.GetOptions{})
+	util.OK(t, err)
+	clusterRoleBinding.Subjects = append(clusterRoleBinding.Subjects, rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: "intruder@edge-net.org"})
+	_, err = kubeclientset.RbacV1().ClusterRoleBindings().Update(context.TODO(), clusterRoleBinding, metav1.UpdateOptions{})
+	util.OK(t, err)
+	util.OK(t, ApplyPolicyFile(kubeclientset, path))
+	clusterRoleBinding, err = kubeclientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), "edgenet:authority-auditor", metav1.GetOptions{})
+	util.OK(t, err)
+	util.Equals(t, 1, len(clusterRoleBinding.Subjects))
+
+	util.Equals(t, true, ApplyPolicyFile(kubeclientset, filepath.Join(t.TempDir(), "missing.yaml")) != nil)
+}
+
+func TestParsePolicy(t *testing.T) {
+	cases := map[string]struct {
+		policy   string
+		expected string
+	}{
+		"valid":         {policy, ""},
+		"unknown field": {"roles: []", "unknown field"},
+		"no name":       {"clusterRoles:\n- rules:\n  - resources: [pods]\n    verbs: [get]", "cluster role 0 has no name"},
+		"no verbs":      {"clusterRoles:\n- metadata:\n    name: viewer\n  rules:\n  - resources: [pods]", "rule 0 of cluster role viewer has no verbs"},
+		"role ref":      {"clusterRoleBindings:\n- metadata:\n    name: viewer\n  roleRef:\n    kind: Role\n    name: viewer\n  subjects:\n  - kind: User\n    name: viewer", "must refer to a cluster role"},
+		"no subjects":   {"clusterRoleBindings:\n- metadata:\n    name: viewer\n  roleRef:\n    apiGroup: rbac.authorization.k8s.io\n    kind: ClusterRole\n    name: viewer", "cluster role binding viewer has no subjects"},
+		"subject kind":  {"clusterRoleBindings:\n- metadata:\n    name: viewer\n  roleRef:\n    apiGroup: rbac.authorization.k8s.io\n    kind: ClusterRole\n    name: viewer\n  subjects:\n  - kind: Robot\n    name: viewer", "unknown kind \"Robot\""},
+	}
+	for k, tc := range cases {
+		t.Run(k, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tc.policy))
+			if tc.expected == "" {
+				util.OK(t, err)
+				return
+			}
+			util.Equals(t, true, err != nil && strings.Contains(err.Error(), tc.expected))
+		})
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2022 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	clientset "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned"
+	"github.com/EdgeNet-project/edgenet/pkg/multitenancy"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// AccessGraph is a snapshot of who can access what within a tenant, together with the quota the tenant holds.
+type AccessGraph struct {
+	Tenant              string                                    `json:"tenant"`
+	FullName            string                                    `json:"fullname,omitempty"`
+	Contact             string                                    `json:"contact,omitempty"`
+	State               string                                    `json:"state,omitempty"`
+	Namespaces          []NamespaceNode                           `json:"namespaces"`
+	ClusterRoleBindings []Binding                                 `json:"clusterrolebindings"`
+	Quota               map[corev1.ResourceName]resource.Quantity `json:"quota,omitempty"`
+}
+
+// NamespaceNode describes a namespace of the tenant, the subnamespaces created in it, and the bindings granting access to it.
+type NamespaceNode struct {
+	Name           string                         `json:"name"`
+	Kind           string                         `json:"kind"`
+	Parent         string                         `json:"parent,omitempty"`
+	SubNamespaces  []SubNamespaceNode             `json:"subnamespaces,omitempty"`
+	RoleBindings   []Binding                      `json:"rolebindings,omitempty"`
+	ResourceQuotas map[string]corev1.ResourceList `json:"resourcequotas,omitempty"`
+}
+
+// SubNamespaceNode describes a subnamespace object and the child namespace it points to.
+type SubNamespaceNode struct {
+	Name  string `json:"name"`
+	Mode  string `json:"mode"`
+	Child string `json:"child,omitempty"`
+	State string `json:"state,omitempty"`
+}
+
+// Binding describes a role binding or a cluster role binding.
+type Binding struct {
+	Name     string           `json:"name"`
+	RoleRef  rbacv1.RoleRef   `json:"roleref"`
+	Subjects []rbacv1.Subject `json:"subjects"`
+}
+
+// TenantAccessGraph assembles the namespaces, subnamespaces, bindings, and quota of a tenant
+// into a single document and returns it in JSON.
+func TenantAccessGraph(kubeclientset kubernetes.Interface, edgenetclientset clientset.Interface, tenant string) ([]byte, error) {
+	graph, err := buildAccessGraph(kubeclientset, edgenetclientset, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(graph, "", "  ")
+}
+
+// TenantAccessGraphYAML is TenantAccessGraph returning the document in YAML.
+func TenantAccessGraphYAML(kubeclientset kubernetes.Interface, edgenetclientset clientset.Interface, tenant string) ([]byte, error) {
+	graph, err := buildAccessGraph(kubeclientset, edgenetclientset, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(graph)
+}
+
+func buildAccessGraph(kubeclientset kubernetes.Interface, edgenetclientset clientset.Interface, tenant string) (*AccessGraph, error) {
+	tenantObj, err := edgenetclientset.CoreV1alpha1().Tenants().Get(context.TODO(), tenant, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	graph := &AccessGraph{
+		Tenant:              tenantObj.GetName(),
+		FullName:            tenantObj.Spec.FullName,
+		Contact:             tenantObj.Spec.Contact.Email,
+		State:               tenantObj.Status.State,
+		Namespaces:          []NamespaceNode{},
+		ClusterRoleBindings: []Binding{},
+	}
+
+	namespaceRaw, err := kubeclientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/tenant=%s", tenant)})
+	if err != nil {
+		return nil, err
+	}
+	for _, namespaceRow := range namespaceRaw.Items {
+		node, err := buildNamespaceNode(kubeclientset, edgenetclientset, namespaceRow)
+		if err != nil {
+			return nil, err
+		}
+		graph.Namespaces = append(graph.Namespaces, node)
+	}
+	sort.Slice(graph.Namespaces, func(i, j int) bool { return graph.Namespaces[i].Name < graph.Namespaces[j].Name })
+
+	// The cluster role bindings of the tenant are the owner binding, known by name, and those labeled with the tenant
+	ownerBindingName := fmt.Sprintf("edgenet:tenants:%s-owner", tenant)
+	if ownerBinding, err := kubeclientset.RbacV1().ClusterRoleBindings().Get(context.TODO(), ownerBindingName, metav1.GetOptions{}); err == nil {
+		graph.ClusterRoleBindings = append(graph.ClusterRoleBindings, Binding{Name: ownerBinding.GetName(), RoleRef: ownerBinding.RoleRef, Subjects: ownerBinding.Subjects})
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	clusterRoleBindingRaw, err := kubeclientset.RbacV1().ClusterRoleBindings().List(context.TODO(), metav1.ListOptions{LabelSelector: fmt.Sprintf("edge-net.io/tenant=%s", tenant)})
+	if err != nil {
+		return nil, err
+	}
+	for _, clusterRoleBindingRow := range clusterRoleBindingRaw.Items {
+		if clusterRoleBindingRow.GetName() != ownerBindingName {
+			graph.ClusterRoleBindings = append(graph.ClusterRoleBindings, Binding{Name: clusterRoleBindingRow.GetName(), RoleRef: clusterRoleBindingRow.RoleRef, Subjects: clusterRoleBindingRow.Subjects})
+		}
+	}
+	sort.Slice(graph.ClusterRoleBindings, func(i, j int) bool { return graph.ClusterRoleBindings[i].Name < graph.ClusterRoleBindings[j].Name })
+
+	tenantQuota, err := multitenancy.TenantQuota(edgenetclientset, tenant)
+	if err == nil {
+		graph.Quota = tenantQuota
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func buildNamespaceNode(kubeclientset kubernetes.Interface, edgenetclientset clientset.Interface, namespace corev1.Namespace) (NamespaceNode, error) {
+	node := NamespaceNode{
+		Name:   namespace.GetName(),
+		Kind:   namespace.GetLabels()["edge-net.io/kind"],
+		Parent: namespace.GetLabels()["edge-net.io/parent-namespace"],
+	}
+
+	subnamespaceRaw, err := edgenetclientset.CoreV1alpha1().SubNamespaces(namespace.GetName()).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return node, err
+	}
+	for _, subnamespaceRow := range subnamespaceRaw.Items {
+		subnamespaceNode := SubNamespaceNode{
+			Name:  subnamespaceRow.GetName(),
+			Mode:  subnamespaceRow.GetMode(),
+			State: subnamespaceRow.Status.State,
+		}
+		if subnamespaceRow.Status.Child != nil {
+			subnamespaceNode.Child = *subnamespaceRow.Status.Child
+		}
+		node.SubNamespaces = append(node.SubNamespaces, subnamespaceNode)
+	}
+
+	roleBindingRaw, err := kubeclientset.RbacV1().RoleBindings(namespace.GetName()).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return node, err
+	}
+	for _, roleBindingRow := range roleBindingRaw.Items {
+		node.RoleBindings = append(node.RoleBindings, Binding{Name: roleBindingRow.GetName(), RoleRef: roleBindingRow.RoleRef, Subjects: roleBindingRow.Subjects})
+	}
+
+	resourceQuotaRaw, err := kubeclientset.CoreV1().ResourceQuotas(namespace.GetName()).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return node, err
+	}
+	for _, resourceQuotaRow := range resourceQuotaRaw.Items {
+		if node.ResourceQuotas == nil {
+			node.ResourceQuotas = make(map[string]corev1.ResourceList)
+		}
+		node.ResourceQuotas[resourceQuotaRow.GetName()] = resourceQuotaRow.Spec.Hard
+	}
+	return node, nil
+}
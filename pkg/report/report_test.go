@@ -0,0 +1,109 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1alpha1 "github.com/EdgeNet-project/edgenet/pkg/apis/core/v1alpha1"
+	edgenettestclient "github.com/EdgeNet-project/edgenet/pkg/generated/clientset/versioned/fake"
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	testclient "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func TestTenantAccessGraph(t *testing.T) {
+	child := "edgenet-lab-1a2b3c"
+	tenantObj := &corev1alpha1.Tenant{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgenet"},
+		Spec: corev1alpha1.TenantSpec{
+			FullName: "EdgeNet",
+			Contact:  corev1alpha1.Contact{Email: "john.doe@edge-net.org"},
+		},
+		Status: corev1alpha1.TenantStatus{State: corev1alpha1.StatusEstablished},
+	}
+	tenantResourceQuotaObj := &corev1alpha1.TenantResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgenet"},
+		Spec: corev1alpha1.TenantResourceQuotaSpec{
+			Claim: map[string]corev1alpha1.ResourceTuning{
+				"initial": {ResourceList: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("8")}},
+			},
+		},
+	}
+	subnamespaceObj := &corev1alpha1.SubNamespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "lab", Namespace: "edgenet"},
+		Spec:       corev1alpha1.SubNamespaceSpec{Workspace: &corev1alpha1.Workspace{}},
+		Status:     corev1alpha1.SubNamespaceStatus{State: corev1alpha1.StatusSubnamespaceCreated, Child: &child},
+	}
+	coreNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "edgenet", Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": "edgenet"}}}
+	childNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: child, Labels: map[string]string{"edge-net.io/kind": "sub", "edge-net.io/tenant": "edgenet", "edge-net.io/parent-namespace": "edgenet"}}}
+	otherNamespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: map[string]string{"edge-net.io/kind": "core", "edge-net.io/tenant": "other"}}}
+	subjects := []rbacv1.Subject{{Kind: "User", Name: "john.doe@edge-net.org", APIGroup: "rbac.authorization.k8s.io"}}
+	ownerRoleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgenet:tenant-owner", Namespace: "edgenet"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: corev1alpha1.TenantOwnerClusterRoleName},
+		Subjects:   subjects,
+	}
+	workspaceRoleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgenet:workspace:owner", Namespace: child},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: corev1alpha1.TenantOwnerClusterRoleName},
+		Subjects:   subjects,
+	}
+	otherRoleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgenet:tenant-owner", Namespace: "other"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: corev1alpha1.TenantOwnerClusterRoleName},
+		Subjects:   subjects,
+	}
+	ownerClusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgenet:tenants:edgenet-owner"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "edgenet:tenants:edgenet-owner"},
+		Subjects:   subjects,
+	}
+	labeledClusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgenet:tenants:edgenet-admin", Labels: map[string]string{"edge-net.io/tenant": "edgenet"}},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "edgenet:tenants:edgenet-admin"},
+		Subjects:   subjects,
+	}
+	otherClusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "edgenet:tenants:other-owner"},
+		RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "edgenet:tenants:other-owner"},
+		Subjects:   subjects,
+	}
+	kubeclientset := testclient.NewSimpleClientset(coreNamespace, childNamespace, otherNamespace, ownerRoleBinding, workspaceRoleBinding, otherRoleBinding, ownerClusterRoleBinding, labeledClusterRoleBinding, otherClusterRoleBinding)
+	edgenetclientset := edgenettestclient.NewSimpleClientset(tenantObj, tenantResourceQuotaObj, subnamespaceObj)
+
+	raw, err := TenantAccessGraph(kubeclientset, edgenetclientset, "edgenet")
+	util.OK(t, err)
+	graph := new(AccessGraph)
+	util.OK(t, json.Unmarshal(raw, graph))
+
+	util.Equals(t, "edgenet", graph.Tenant)
+	util.Equals(t, corev1alpha1.StatusEstablished, graph.State)
+	util.Equals(t, 2, len(graph.Namespaces))
+	util.Equals(t, "edgenet", graph.Namespaces[0].Name)
+	util.Equals(t, "core", graph.Namespaces[0].Kind)
+	util.Equals(t, []SubNamespaceNode{{Name: "lab", Mode: "workspace", Child: child, State: corev1alpha1.StatusSubnamespaceCreated}}, graph.Namespaces[0].SubNamespaces)
+	util.Equals(t, []Binding{{Name: ownerRoleBinding.GetName(), RoleRef: ownerRoleBinding.RoleRef, Subjects: subjects}}, graph.Namespaces[0].RoleBindings)
+	util.Equals(t, child, graph.Namespaces[1].Name)
+	util.Equals(t, "edgenet", graph.Namespaces[1].Parent)
+	util.Equals(t, []Binding{{Name: workspaceRoleBinding.GetName(), RoleRef: workspaceRoleBinding.RoleRef, Subjects: subjects}}, graph.Namespaces[1].RoleBindings)
+	util.Equals(t, []Binding{
+		{Name: labeledClusterRoleBinding.GetName(), RoleRef: labeledClusterRoleBinding.RoleRef, Subjects: subjects},
+		{Name: ownerClusterRoleBinding.GetName(), RoleRef: ownerClusterRoleBinding.RoleRef, Subjects: subjects},
+	}, graph.ClusterRoleBindings)
+	cpu := graph.Quota[corev1.ResourceCPU]
+	util.Equals(t, "8", cpu.String())
+
+	raw, err = TenantAccessGraphYAML(kubeclientset, edgenetclientset, "edgenet")
+	util.OK(t, err)
+	yamlGraph := new(AccessGraph)
+	util.OK(t, yaml.Unmarshal(raw, yamlGraph))
+	util.Equals(t, graph, yamlGraph)
+
+	_, err = TenantAccessGraph(kubeclientset, edgenetclientset, "missing")
+	util.NotEquals(t, nil, err)
+}
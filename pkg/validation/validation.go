@@ -0,0 +1,98 @@
+/*
+Copyright 2021 Contributors to the EdgeNet project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validation checks the arguments the controllers are created with, so that a missing clientset or informer
+// is reported when the controller is created rather than by a panic once it runs.
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ArgumentError is an invalid argument given to the constructor of a controller
+type ArgumentError struct {
+	// Controller is the name of the controller, e.g. sliceclaim-controller
+	Controller string
+	// Argument is the name of the invalid argument
+	Argument string
+	// Reason tells why the argument is invalid
+	Reason string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("%s: %s %s", e.Controller, e.Argument, e.Reason)
+}
+
+// Errors are all the invalid arguments given to the constructor of a controller
+type Errors []*ArgumentError
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, argumentError := range e {
+		messages = append(messages, argumentError.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validator collects the invalid arguments of a controller
+type Validator struct {
+	controller string
+	errors     Errors
+}
+
+// NewValidator returns a validator for the arguments of the named controller
+func NewValidator(controller string) *Validator {
+	return &Validator{controller: controller}
+}
+
+// NotNil records an error if the argument is nil, including a nil pointer held by an interface
+func (v *Validator) NotNil(argument string, value interface{}) {
+	if isNil(value) {
+		v.errors = append(v.errors, &ArgumentError{Controller: v.controller, Argument: argument, Reason: "must not be nil"})
+	}
+}
+
+// OneOf records an error if the argument is none of the allowed values, which are compared case-insensitively
+func (v *Validator) OneOf(argument, value string, allowed ...string) {
+	for _, allowedValue := range allowed {
+		if strings.EqualFold(value, allowedValue) {
+			return
+		}
+	}
+	reason := fmt.Sprintf("must be one of %s, got %q", strings.Join(allowed, ", "), value)
+	v.errors = append(v.errors, &ArgumentError{Controller: v.controller, Argument: argument, Reason: reason})
+}
+
+// Err returns the invalid arguments as Errors, or nil if all the arguments are valid
+func (v *Validator) Err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return v.errors
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	switch reflectValue := reflect.ValueOf(value); reflectValue.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return reflectValue.IsNil()
+	}
+	return false
+}
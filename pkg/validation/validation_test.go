@@ -0,0 +1,34 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/EdgeNet-project/edgenet/pkg/util"
+
+	"k8s.io/client-go/kubernetes"
+	testclient "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidator(t *testing.T) {
+	validator := NewValidator("test-controller")
+	validator.NotNil("kubeclientset", testclient.NewSimpleClientset())
+	validator.OneOf("provisioning", "dynamic", "Dynamic", "Manual")
+	util.OK(t, validator.Err())
+
+	var kubeclientset *testclient.Clientset
+	var kubeinterface kubernetes.Interface
+	validator.NotNil("kubeclientset", kubeclientset)
+	validator.NotNil("kubeinterface", kubeinterface)
+	validator.OneOf("provisioning", "Automatic", "Dynamic", "Manual")
+	err := validator.Err()
+	util.Equals(t, "test-controller: kubeclientset must not be nil; "+
+		"test-controller: kubeinterface must not be nil; "+
+		"test-controller: provisioning must be one of Dynamic, Manual, got \"Automatic\"", err.Error())
+
+	var validationErrors Errors
+	util.Equals(t, true, errors.As(err, &validationErrors))
+	util.Equals(t, 3, len(validationErrors))
+	util.Equals(t, "provisioning", validationErrors[2].Argument)
+	util.Equals(t, "test-controller", validationErrors[2].Controller)
+}